@@ -0,0 +1,147 @@
+// Package apierror รูปแบบ Error ของ HTTP API ตาม RFC 7807 (application/problem+json)
+// Handler และ Middleware ส่ง error ผ่าน c.Error แล้ว middleware.Errors เป็นผู้เขียน Response
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType Media Type ของ Problem Details (RFC 7807)
+const ContentType = "application/problem+json"
+
+// typePrefix นำหน้า Code เพื่อให้ type เป็น URI ตาม RFC 7807
+const typePrefix = "urn:worm:problem:"
+
+// Code รหัส Error ที่คงที่ Client ใช้ตัดสินใจได้โดยไม่ต้องอ่านข้อความ
+const (
+	CodeBadRequest   = "bad_request"
+	CodeValidation   = "validation_failed"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeInternal     = "internal_error"
+
+	// การยืนยันตัวตนและบัญชี
+	CodeInvalidCredentials     = "invalid_credentials"
+	CodeAccountDisabled        = "account_disabled"
+	CodePasswordLoginDisabled  = "password_login_disabled"
+	CodePasswordChangeRequired = "password_change_required"
+
+	// 2FA
+	CodeTwoFactorRequired       = "two_factor_required"
+	CodeInvalidTwoFactorCode    = "invalid_two_factor_code"
+	CodeTwoFactorSetupRequired  = "two_factor_setup_required"
+	CodeTwoFactorAlreadyEnabled = "two_factor_already_enabled"
+	CodeTwoFactorNotEnabled     = "two_factor_not_enabled"
+	CodeTwoFactorNotEnrolled    = "two_factor_not_enrolled"
+	CodeTwoFactorMandatory      = "two_factor_mandatory"
+
+	// องค์กร
+	CodeNoOrganization = "no_organization"
+
+	// Sensor
+	CodeReadingOutOfRange = "reading_out_of_range"
+
+	// Rate Limit (429)
+	CodeRateLimited   = "rate_limited"
+	CodeQuotaExceeded = "quota_exceeded"
+)
+
+// --- 1. Response Models ---
+
+// FieldError ปัญหาของ Field หนึ่งใน Request (ชื่อตาม JSON)
+type FieldError struct {
+	Field   string `json:"field" example:"new_password"`
+	Message string `json:"message" example:"must be at least 8 characters"`
+}
+
+// Problem รูปแบบ Error ที่ทุก Endpoint คืน (Content-Type: application/problem+json)
+type Problem struct {
+	Type     string `json:"type" example:"urn:worm:problem:validation_failed"`
+	Title    string `json:"title" example:"Bad Request"`
+	Status   int    `json:"status" example:"400"`
+	Detail   string `json:"detail,omitempty" example:"Request body is invalid"`
+	Instance string `json:"instance,omitempty" example:"/api/register"`
+	// Code รหัสคงที่สำหรับโปรแกรม (ดูรายการใน apierror)
+	Code      string       `json:"code" example:"validation_failed"`
+	RequestID string       `json:"request_id,omitempty" example:"3f0c9a52-4a0e-4d4b-9d7e-2f3c1b7a9e10"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// --- 2. Error ---
+
+// Error error ที่รู้ว่าจะตอบ Client อย่างไร
+// Err คือสาเหตุภายใน (เช่น Error จากฐานข้อมูล) เขียนลง Log เท่านั้น ไม่ส่งให้ Client
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	Fields []FieldError
+	Err    error
+}
+
+// New สร้าง Error ที่จะตอบด้วย status และ code
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// Internal Error ที่ไม่คาดคิด Client เห็นเพียงข้อความกลางๆ
+func Internal(err error) *Error {
+	return &Error{
+		Status: http.StatusInternalServerError,
+		Code:   CodeInternal,
+		Detail: "An unexpected error occurred",
+		Err:    err,
+	}
+}
+
+// WithField เพิ่มรายละเอียดว่า Field ไหนมีปัญหา
+func (e *Error) WithField(field, message string) *Error {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Detail)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Problem แปลงเป็น Response Body
+func (e *Error) Problem() Problem {
+	return Problem{
+		Type:   typePrefix + e.Code,
+		Title:  http.StatusText(e.Status),
+		Status: e.Status,
+		Detail: e.Detail,
+		Code:   e.Code,
+		Errors: e.Fields,
+	}
+}
+
+// --- 3. Gin Helpers ---
+
+// Abort หยุด Chain ของ Middleware แล้วให้ middleware.Errors ตอบ err
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Respond เขียน Problem ของ err ลง Response ทันที
+func Respond(c *gin.Context, err error) {
+	p := From(err).Problem()
+	p.Instance = c.Request.URL.Path
+	p.RequestID = c.GetString("request_id")
+
+	c.Header("Content-Type", ContentType)
+	c.JSON(p.Status, p)
+}
@@ -0,0 +1,222 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"worm/repository"
+	"worm/services"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// sentinel Error ของ services / repository ที่รู้ว่าจะตอบ Client อย่างไร
+// (ข้อความของ Error เหล่านี้ปลอดภัยที่จะส่งให้ Client)
+type sentinel struct {
+	err    error
+	status int
+	code   string
+	// Field ที่เกี่ยวข้อง ("" = ไม่ระบุ)
+	field string
+}
+
+var sentinels = []sentinel{
+	{services.ErrUserNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{services.ErrInvalidUsername, http.StatusBadRequest, CodeValidation, "username"},
+	{services.ErrInvalidRole, http.StatusBadRequest, CodeValidation, "role"},
+	{services.ErrIncorrectPassword, http.StatusUnauthorized, CodeInvalidCredentials, ""},
+	{services.ErrSamePassword, http.StatusBadRequest, CodeValidation, "new_password"},
+	{services.ErrPasswordLoginDisabled, http.StatusForbidden, CodePasswordLoginDisabled, ""},
+	{services.ErrUserDisabled, http.StatusForbidden, CodeAccountDisabled, ""},
+	{services.ErrInvalidAPIKey, http.StatusUnauthorized, CodeUnauthorized, ""},
+
+	{services.ErrTwoFactorRequired, http.StatusUnauthorized, CodeTwoFactorRequired, "otp_code"},
+	{services.ErrInvalidTwoFactorCode, http.StatusUnauthorized, CodeInvalidTwoFactorCode, ""},
+	{services.ErrTwoFactorAlreadyEnabled, http.StatusConflict, CodeTwoFactorAlreadyEnabled, ""},
+	{services.ErrTwoFactorNotEnabled, http.StatusBadRequest, CodeTwoFactorNotEnabled, ""},
+	{services.ErrTwoFactorNotEnrolled, http.StatusBadRequest, CodeTwoFactorNotEnrolled, ""},
+	{services.ErrTwoFactorMandatory, http.StatusForbidden, CodeTwoFactorMandatory, ""},
+
+	{services.ErrOrganizationNotFound, http.StatusNotFound, CodeNotFound, "organization_id"},
+	{services.ErrInvalidOrganizationName, http.StatusBadRequest, CodeValidation, "name"},
+	{services.ErrOrganizationRequired, http.StatusBadRequest, CodeValidation, "organization_id"},
+	{services.ErrSuperadminOrganization, http.StatusBadRequest, CodeValidation, "organization_id"},
+	{services.ErrSuperadminOnly, http.StatusForbidden, CodeForbidden, ""},
+	{services.ErrNoOrganization, http.StatusForbidden, CodeNoOrganization, ""},
+
+	{services.ErrLocationNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{services.ErrInvalidLocationName, http.StatusBadRequest, CodeValidation, "name"},
+	{services.ErrInvalidLocationKind, http.StatusBadRequest, CodeValidation, "kind"},
+	{services.ErrLocationParent, http.StatusBadRequest, CodeValidation, "parent_id"},
+	{services.ErrLocationCycle, http.StatusBadRequest, CodeValidation, "parent_id"},
+	{services.ErrLocationHasChildren, http.StatusConflict, CodeConflict, ""},
+	{services.ErrLocationInUse, http.StatusConflict, CodeConflict, ""},
+	{services.ErrNotABin, http.StatusBadRequest, CodeValidation, "location_id"},
+	{services.ErrOrganizationMismatch, http.StatusBadRequest, CodeValidation, "organization_id"},
+	{services.ErrDeviceNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{services.ErrInvalidAssignmentTime, http.StatusBadRequest, CodeValidation, "started_at"},
+	{services.ErrInvalidTimeRange, http.StatusBadRequest, CodeValidation, "from"},
+	{services.ErrInvalidInterval, http.StatusBadRequest, CodeValidation, "interval"},
+
+	{services.ErrActuatorNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{services.ErrInvalidActuatorName, http.StatusBadRequest, CodeValidation, "name"},
+	{services.ErrInvalidActuatorKind, http.StatusBadRequest, CodeValidation, "kind"},
+	{services.ErrInvalidChannel, http.StatusBadRequest, CodeValidation, "channel"},
+	{services.ErrActuatorInUse, http.StatusConflict, CodeConflict, ""},
+	{services.ErrInvalidCommand, http.StatusBadRequest, CodeValidation, "action"},
+	{services.ErrInvalidDutyCycle, http.StatusBadRequest, CodeValidation, "duty_cycle"},
+	{services.ErrInvalidDuration, http.StatusBadRequest, CodeValidation, "duration_seconds"},
+	{services.ErrCommandNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{services.ErrCommandNotDelivered, http.StatusConflict, CodeConflict, ""},
+	{services.ErrInvalidOverride, http.StatusBadRequest, CodeValidation, "duration_seconds"},
+
+	{services.ErrRuleNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{services.ErrInvalidRuleName, http.StatusBadRequest, CodeValidation, "name"},
+	{services.ErrInvalidRuleMode, http.StatusBadRequest, CodeValidation, "mode"},
+	{services.ErrInvalidRuleCondition, http.StatusBadRequest, CodeValidation, ""},
+	{services.ErrInvalidRuleTiming, http.StatusBadRequest, CodeValidation, ""},
+	{services.ErrUsedByRule, http.StatusConflict, CodeConflict, ""},
+
+	{services.ErrScheduleNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{services.ErrInvalidScheduleName, http.StatusBadRequest, CodeValidation, "name"},
+	{services.ErrInvalidCron, http.StatusBadRequest, CodeValidation, "cron"},
+	{services.ErrInvalidTimeZone, http.StatusBadRequest, CodeValidation, "time_zone"},
+	{services.ErrInvalidJob, http.StatusBadRequest, CodeValidation, "job"},
+	{services.ErrScheduleActuatorRequired, http.StatusBadRequest, CodeValidation, "actuator_id"},
+	{services.ErrInvalidRetention, http.StatusBadRequest, CodeValidation, "retention_days"},
+	{services.ErrUsedBySchedule, http.StatusConflict, CodeConflict, ""},
+
+	{services.ErrEventNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{services.ErrInvalidEventKind, http.StatusBadRequest, CodeValidation, "kind"},
+	{services.ErrInvalidEventFields, http.StatusBadRequest, CodeValidation, ""},
+	{services.ErrInvalidOccurredAt, http.StatusBadRequest, CodeValidation, "occurred_at"},
+	{services.ErrInvalidNotes, http.StatusBadRequest, CodeValidation, "notes"},
+	{services.ErrEventNotABin, http.StatusBadRequest, CodeValidation, ""},
+	{services.ErrLocationHasEvents, http.StatusConflict, CodeConflict, ""},
+	{services.ErrTimelineRange, http.StatusBadRequest, CodeValidation, "from"},
+
+	{services.ErrCalibrationNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{services.ErrInvalidCalibrationMetric, http.StatusBadRequest, CodeValidation, "metric"},
+	{services.ErrInvalidCalibrationMethod, http.StatusBadRequest, CodeValidation, "method"},
+	{services.ErrInvalidCalibration, http.StatusBadRequest, CodeValidation, ""},
+	{services.ErrInvalidValidity, http.StatusBadRequest, CodeValidation, "valid_until"},
+	{services.ErrCalibrationOverlap, http.StatusConflict, CodeConflict, ""},
+
+	{services.ErrReadingOutOfRange, http.StatusBadRequest, CodeReadingOutOfRange, ""},
+	{services.ErrOIDCUsernameTaken, http.StatusConflict, CodeConflict, "username"},
+
+	{repository.ErrNotFound, http.StatusNotFound, CodeNotFound, ""},
+	{repository.ErrTenantMismatch, http.StatusForbidden, CodeForbidden, ""},
+	{repository.ErrNoOrganization, http.StatusBadRequest, CodeValidation, "organization_id"},
+}
+
+// From แปลง error ใดๆ เป็น *Error
+// *Error ใช้ตามที่ระบุ, Error จากการ Bind Request = 400, ค่าซ้ำ = 409 พร้อมชื่อ Field,
+// sentinel ที่รู้จัก = ตามตาราง, นอกนั้น = 500
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	if isBindError(err) {
+		return Validation(err)
+	}
+	var conflict *repository.ConflictError
+	if errors.As(err, &conflict) {
+		e := New(http.StatusConflict, CodeConflict, sentence(conflict.Error()))
+		e.Err = err
+		if conflict.Field != "" {
+			e.WithField(conflict.Field, "already exists")
+		}
+		return e
+	}
+	for _, s := range sentinels {
+		if errors.Is(err, s.err) {
+			e := New(s.status, s.code, sentence(err.Error()))
+			if s.field != "" {
+				e.WithField(s.field, err.Error())
+			}
+			return e
+		}
+	}
+	return Internal(err)
+}
+
+// Validation แปลง Error จาก c.ShouldBindJSON เป็น 400 พร้อมรายละเอียดราย Field
+// (ไม่ส่งข้อความดิบจาก Validator / encoding/json ให้ Client)
+func Validation(err error) *Error {
+	e := New(http.StatusBadRequest, CodeValidation, "Request body is invalid")
+
+	var verrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &verrs):
+		for _, fe := range verrs {
+			e.WithField(fe.Field(), validationMessage(fe))
+		}
+	case errors.As(err, &typeErr):
+		e.WithField(typeErr.Field, "must be of type "+typeErr.Type.String())
+	case errors.Is(err, io.EOF):
+		e.Detail = "Request body is empty"
+	default:
+		e.Code = CodeBadRequest
+		e.Detail = "Request body is not valid JSON"
+	}
+	e.Err = err
+	return e
+}
+
+// UseJSONFieldNames ให้ Validator รายงานชื่อ Field ตาม Tag json (new_password แทน NewPassword)
+// เรียกครั้งเดียวตอนสร้าง Router
+func UseJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+}
+
+// --- Internal Logic ---
+
+func isBindError(err error) bool {
+	var verrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &verrs) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fe.Param())
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	}
+	return fmt.Sprintf("failed the '%s' rule", fe.Tag())
+}
+
+// sentence ขึ้นต้นข้อความด้วยตัวพิมพ์ใหญ่ (ข้อความของ sentinel เป็นตัวเล็กตามธรรมเนียม Go)
+func sentence(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,198 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"worm/repository"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error ของ services ที่ไม่ได้ตอบ Client ผ่าน From (มี Handler จัดการเอง หรือไม่ถึง HTTP)
+var unmapped = map[string]string{
+	"ErrObserverFailed": "sensor_controller ตอบ 200 เพราะค่าถูกบันทึกแล้ว",
+	"ErrAlertQueueFull": "เกิดใน Worker ไม่ถึง Response",
+}
+
+// ทุก services.Err* ต้องอยู่ใน sentinels (หรือ unmapped) ไม่งั้นจะกลายเป็น 500 เงียบๆ
+func TestEveryServiceErrorIsMapped(t *testing.T) {
+	declared := exportedErrors(t, "../services")
+	mapped := map[string]bool{}
+	for _, name := range selectorsIn(t, "mapping.go", "sentinels", "services") {
+		mapped[name] = true
+	}
+	for _, name := range declared {
+		if _, skip := unmapped[name]; skip {
+			if mapped[name] {
+				t.Errorf("services.%s is in sentinels and unmapped, remove it from unmapped", name)
+			}
+			continue
+		}
+		if !mapped[name] {
+			t.Errorf("services.%s is not in sentinels and would be answered with 500", name)
+		}
+	}
+	if len(declared) < 50 {
+		t.Fatalf("found only %d services.Err* declarations, is the parser looking in the right place?", len(declared))
+	}
+}
+
+// ทุกแถวของ sentinels ได้ status / code ของตัวเองแม้ถูก Wrap (ไม่ถูกแถวก่อนหน้าบัง)
+func TestSentinelsMapToTheirStatus(t *testing.T) {
+	for _, s := range sentinels {
+		e := From(fmt.Errorf("context: %w", s.err))
+		if e.Status != s.status || e.Code != s.code {
+			t.Errorf("%v: got %d %s, want %d %s", s.err, e.Status, e.Code, s.status, s.code)
+		}
+		if e.Status >= http.StatusInternalServerError {
+			t.Errorf("%v: sentinel mapped to %d", s.err, e.Status)
+		}
+		if s.field != "" && (len(e.Fields) != 1 || e.Fields[0].Field != s.field) {
+			t.Errorf("%v: fields %+v, want %s", s.err, e.Fields, s.field)
+		}
+	}
+}
+
+func TestFrom(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		field  string
+	}{
+		{"service not found", services.ErrUserNotFound, http.StatusNotFound, CodeNotFound, ""},
+		{"service validation", services.ErrInvalidCron, http.StatusBadRequest, CodeValidation, "cron"},
+		{"service conflict", services.ErrUsedBySchedule, http.StatusConflict, CodeConflict, ""},
+		{"wrong password", services.ErrIncorrectPassword, http.StatusUnauthorized, CodeInvalidCredentials, ""},
+		{"out of range", services.ErrReadingOutOfRange, http.StatusBadRequest, CodeReadingOutOfRange, ""},
+		{"repository not found", repository.ErrNotFound, http.StatusNotFound, CodeNotFound, ""},
+		{"other tenant", repository.ErrTenantMismatch, http.StatusForbidden, CodeForbidden, ""},
+		{"unique field", &repository.ConflictError{Field: "username"}, http.StatusConflict, CodeConflict, "username"},
+		{"unique", &repository.ConflictError{}, http.StatusConflict, CodeConflict, ""},
+		{"bad json", &json.SyntaxError{}, http.StatusBadRequest, CodeBadRequest, ""},
+		{"api error", New(http.StatusTooManyRequests, CodeRateLimited, "slow down"), http.StatusTooManyRequests, CodeRateLimited, ""},
+		{"unknown", errors.New("disk on fire"), http.StatusInternalServerError, CodeInternal, ""},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			e := From(c.err)
+			if e.Status != c.status || e.Code != c.code {
+				t.Fatalf("got %d %s, want %d %s", e.Status, e.Code, c.status, c.code)
+			}
+			if c.field != "" && (len(e.Fields) != 1 || e.Fields[0].Field != c.field) {
+				t.Errorf("fields %+v, want %s", e.Fields, c.field)
+			}
+		})
+	}
+
+	// ข้อความภายในของ 500 ไม่ถึง Client
+	if e := From(errors.New("disk on fire")); strings.Contains(e.Detail, "disk") {
+		t.Errorf("internal detail leaked: %q", e.Detail)
+	}
+}
+
+func TestRespondWritesProblemJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, c := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("load: %w", services.ErrScheduleNotFound), http.StatusNotFound, CodeNotFound},
+		{services.ErrInvalidCron, http.StatusBadRequest, CodeValidation},
+		{errors.New("boom"), http.StatusInternalServerError, CodeInternal},
+	} {
+		w := httptest.NewRecorder()
+		c2, _ := gin.CreateTestContext(w)
+		c2.Request = httptest.NewRequest(http.MethodGet, "/api/schedules/7", nil)
+		c2.Set("request_id", "req-1")
+		Respond(c2, c.err)
+
+		if got := w.Header().Get("Content-Type"); got != ContentType {
+			t.Errorf("%v: Content-Type %q, want %q", c.err, got, ContentType)
+		}
+		var p Problem
+		body, _ := io.ReadAll(w.Body)
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Fatalf("%v: decode: %v", c.err, err)
+		}
+		if w.Code != c.status || p.Status != c.status || p.Code != c.code || p.Type != typePrefix+c.code {
+			t.Errorf("%v: %d %s", c.err, w.Code, body)
+		}
+		if p.Instance != "/api/schedules/7" || p.RequestID != "req-1" {
+			t.Errorf("%v: instance %q, request_id %q", c.err, p.Instance, p.RequestID)
+		}
+	}
+}
+
+// --- Helpers ---
+
+// exportedErrors ชื่อ var Err* ระดับ Package ใน dir (ไม่รวมไฟล์ทดสอบ)
+func exportedErrors(t *testing.T, dir string) []string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatalf("parse %s: %v", path, err)
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					if strings.HasPrefix(name.Name, "Err") {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectorsIn ชื่อ pkg.X ทั้งหมดที่อยู่ในค่าของ var name ใน file
+func selectorsIn(t *testing.T, file, name, pkg string) []string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		t.Fatalf("parse %s: %v", file, err)
+	}
+	obj := f.Scope.Lookup(name)
+	if obj == nil {
+		t.Fatalf("%s not found in %s", name, file)
+	}
+	var names []string
+	ast.Inspect(obj.Decl.(ast.Node), func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == pkg {
+				names = append(names, sel.Sel.Name)
+			}
+		}
+		return true
+	})
+	return names
+}
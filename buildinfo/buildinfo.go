@@ -0,0 +1,42 @@
+// Package buildinfo เวอร์ชันของ Binary ใส่ตอน Build ด้วย ldflags เช่น
+//
+//	go build -ldflags "-X worm/buildinfo.Version=v1.2.0 -X worm/buildinfo.Commit=$(git rev-parse --short HEAD) -X worm/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// ถ้าไม่ได้ใส่ Commit / BuildTime จะใช้ข้อมูล VCS ที่ Go ฝังไว้ให้ (ถ้ามี)
+package buildinfo
+
+import "runtime/debug"
+
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info ข้อมูลที่ /version คืน
+type Info struct {
+	Version   string `json:"version" example:"v1.2.0"`
+	Commit    string `json:"commit" example:"8bf7b36"`
+	BuildTime string `json:"build_time" example:"2026-10-19T06:00:00Z"`
+	GoVersion string `json:"go_version" example:"go1.22.5"`
+}
+
+// Get คืนข้อมูลเวอร์ชันของ Binary ที่รันอยู่
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = bi.GoVersion
+	for _, s := range bi.Settings {
+		switch {
+		case s.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = s.Value
+		case s.Key == "vcs.time" && info.BuildTime == "":
+			info.BuildTime = s.Value
+		}
+	}
+	return info
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"worm/config"
+	"worm/migrations"
+	"worm/repository"
+	"worm/services"
+
+	"gorm.io/gorm"
+)
+
+// --- Helpers ที่คำสั่งย่อยใช้ร่วมกัน ---
+
+// subcommand สร้าง FlagSet ที่มี -config และ Usage ของคำสั่งนั้น
+func subcommand(name, usage string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	configFile := fs.String("config", "", "path to a YAML or TOML config file (default: $CONFIG_FILE)")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fmt.Fprintln(os.Stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	return fs, configFile
+}
+
+// openStore โหลด Config และเปิดฐานข้อมูลที่ Migrate แล้ว
+// ไม่พิมพ์อะไรลง stdout (คำสั่งอย่าง `sensor export` ใช้ stdout ส่งข้อมูล)
+func openStore(configFile string) (*config.Config, *gorm.DB, *repository.Store) {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := config.OpenDB(cfg.Database.DSN)
+	if err != nil {
+		log.Fatal("Failed to connect to database: ", err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := migrator.Check(); err != nil {
+		log.Fatal(err)
+	}
+	return cfg, db, repository.NewStore(db)
+}
+
+// cliContext Context ของคำสั่ง CLI (ผู้ที่รัน CLI บนเครื่องได้เห็นข้อมูลทุกองค์กร)
+func cliContext() context.Context {
+	return repository.AllTenants(context.Background())
+}
+
+// organizationID หา ID ขององค์กรจากชื่อใน Flag -org ("" = nil ไม่ระบุ)
+func organizationID(ctx context.Context, store *repository.Store, name string) (*uint, error) {
+	if name == "" {
+		return nil, nil
+	}
+	org, err := services.NewOrganizationService(store.Organizations).FindByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("organization %q: %w", name, err)
+	}
+	return &org.ID, nil
+}
+
+// usageError แสดง Usage แล้วออกด้วย Code 2
+func usageError(fs *flag.FlagSet, format string, args ...interface{}) {
+	if format != "" {
+		fmt.Fprintf(os.Stderr, format+"\n\n", args...)
+	}
+	fs.Usage()
+	os.Exit(2)
+}
@@ -0,0 +1,503 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"worm/utils"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Config ค่าตั้งค่าทั้งหมดของระบบ
+// ลำดับความสำคัญ (ตัวหลังทับตัวหน้า): ค่าเริ่มต้น -> ไฟล์ YAML/TOML -> .env -> Environment Variables
+type Config struct {
+	// development | production
+	Env string `yaml:"env" toml:"env" env:"APP_ENV"`
+
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	Admin    AdminConfig    `yaml:"admin" toml:"admin"`
+	CORS     CORSConfig     `yaml:"cors" toml:"cors"`
+	OIDC     OIDCConfig     `yaml:"oidc" toml:"oidc"`
+	Metrics  MetricsConfig  `yaml:"metrics" toml:"metrics"`
+	Log      LogConfig      `yaml:"log" toml:"log"`
+	Actuator ActuatorConfig `yaml:"actuator" toml:"actuator"`
+
+	Automation AutomationConfig `yaml:"automation" toml:"automation"`
+	Schedule   ScheduleConfig   `yaml:"schedule" toml:"schedule"`
+	Anomaly    AnomalyConfig    `yaml:"anomaly" toml:"anomaly"`
+
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+}
+
+// ServerConfig ค่าของ HTTP Server
+type ServerConfig struct {
+	Port string `yaml:"port" toml:"port" env:"PORT"`
+
+	// เวลาสูงสุดในการอ่าน Request (รวม Body) / เขียน Response / Keep-Alive ที่ว่างอยู่
+	ReadTimeout  Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout  Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// เวลาที่รอ Request ที่ค้างอยู่ให้เสร็จเมื่อได้รับ SIGTERM ก่อนตัดทิ้ง
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	// IP / CIDR ของ Reverse Proxy ที่เชื่อ X-Forwarded-For ได้ ว่าง = ไม่เชื่อใคร (ใช้ IP ที่ต่อเข้ามาตรงๆ)
+	// ถ้าเชื่อทุกที่ Client จะปลอม IP เพื่อหนี Rate Limit ของ /login และปลอม client_ip ใน Access Log ได้
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
+}
+
+// DatabaseConfig ค่าการเชื่อมต่อฐานข้อมูล
+// DSN เลือก Driver จาก Scheme: postgres://... หรือ sqlite://worm.db (sqlite://:memory: สำหรับทดสอบ)
+type DatabaseConfig struct {
+	DSN string `yaml:"dsn" toml:"dsn" env:"DB_DSN"`
+}
+
+// AuthConfig ค่าเกี่ยวกับรหัสผ่านและ 2FA
+type AuthConfig struct {
+	BcryptCost      int    `yaml:"bcrypt_cost" toml:"bcrypt_cost" env:"BCRYPT_COST"`
+	RequireAdmin2FA bool   `yaml:"require_admin_2fa" toml:"require_admin_2fa" env:"REQUIRE_ADMIN_2FA"`
+	TOTPIssuer      string `yaml:"totp_issuer" toml:"totp_issuer" env:"TOTP_ISSUER"`
+}
+
+// AdminConfig ค่าสำหรับสร้าง Admin คนแรก (ใช้เฉพาะตอนที่ยังไม่มี Admin)
+type AdminConfig struct {
+	Username string `yaml:"username" toml:"username" env:"ADMIN_USERNAME"`
+	Password string `yaml:"password" toml:"password" env:"ADMIN_PASSWORD"`
+}
+
+// CORSConfig ค่า CORS ของ Browser Client
+type CORSConfig struct {
+	// Origin ที่อนุญาต เช่น "https://farm.example.com" หรือ "https://*.example.com" (Subdomain ใดก็ได้)
+	// ว่างใน Production = ไม่อนุญาต Cross-Origin เลย, ว่างใน Development = "*"
+	AllowOrigins     []string `yaml:"allow_origins" toml:"allow_origins" env:"CORS_ALLOW_ORIGINS"`
+	AllowMethods     []string `yaml:"allow_methods" toml:"allow_methods" env:"CORS_ALLOW_METHODS"`
+	AllowHeaders     []string `yaml:"allow_headers" toml:"allow_headers" env:"CORS_ALLOW_HEADERS"`
+	ExposeHeaders    []string `yaml:"expose_headers" toml:"expose_headers" env:"CORS_EXPOSE_HEADERS"`
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           Duration `yaml:"max_age" toml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// OIDCConfig ค่า SSO (ปิดอยู่ถ้าไม่ได้กำหนด Issuer)
+type OIDCConfig struct {
+	Issuer       string   `yaml:"issuer" toml:"issuer" env:"OIDC_ISSUER"`
+	ClientID     string   `yaml:"client_id" toml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string   `yaml:"client_secret" toml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	GroupsClaim  string   `yaml:"groups_claim" toml:"groups_claim" env:"OIDC_GROUPS_CLAIM"`
+	AdminGroups  []string `yaml:"admin_groups" toml:"admin_groups" env:"OIDC_ADMIN_GROUPS"`
+	UserGroups   []string `yaml:"user_groups" toml:"user_groups" env:"OIDC_USER_GROUPS"`
+	// ชื่อองค์กรที่บัญชี SSO ใหม่สังกัด (ค่าเริ่มต้นคือองค์กร Default ที่ Migration สร้างไว้)
+	Organization string `yaml:"organization" toml:"organization" env:"OIDC_ORGANIZATION"`
+}
+
+// MetricsConfig ค่าของ GET /metrics (Prometheus)
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"METRICS_ENABLED"`
+	// ถ้ากำหนด: Prometheus ต้องส่ง Authorization: Bearer <token> (บังคับใน Production เพราะมีค่าของทุกองค์กร)
+	Token string `yaml:"token" toml:"token" env:"METRICS_TOKEN"`
+}
+
+// LogConfig รูปแบบของ Log (log/slog)
+type LogConfig struct {
+	// debug | info | warn | error
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	// json | text
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+// ActuatorConfig อายุของคำสั่งถึง Actuator
+type ActuatorConfig struct {
+	// Device ต้อง Poll คำสั่งภายในเวลานี้ ไม่งั้นคำสั่งหมดอายุ (ไม่อยากให้พัดลมเปิดตามคำสั่งเมื่อชั่วโมงก่อน)
+	CommandTTL Duration `yaml:"command_ttl" toml:"command_ttl" env:"ACTUATOR_COMMAND_TTL"`
+	// Device ต้องยืนยันคำสั่งภายในเวลานี้หลัง Poll ไม่งั้นถือว่าล้มเหลว
+	AckTimeout Duration `yaml:"ack_timeout" toml:"ack_timeout" env:"ACTUATOR_ACK_TIMEOUT"`
+}
+
+// AutomationConfig การประเมิน Automation Rule
+type AutomationConfig struct {
+	// ประเมินทุก Rule ทุกช่วงเวลานี้ (นอกเหนือจากตอนที่มีค่า Sensor ใหม่) เพื่อให้ Rule ทำงานแม้ค่าไม่เปลี่ยน
+	Interval Duration `yaml:"interval" toml:"interval" env:"AUTOMATION_INTERVAL"`
+	// ใช้ค่าเฉลี่ยของค่า Sensor ในช่วงเวลานี้ล่าสุด (ไม่มีค่าในช่วงนี้ = เงื่อนไขไม่เป็นจริง)
+	ReadingWindow Duration `yaml:"reading_window" toml:"reading_window" env:"AUTOMATION_READING_WINDOW"`
+}
+
+// ScheduleConfig การทำงานของ Scheduler
+type ScheduleConfig struct {
+	// ตรวจหา Schedule ที่ถึงเวลาทุกช่วงเวลานี้ (Cron ละเอียดระดับนาที)
+	Interval Duration `yaml:"interval" toml:"interval" env:"SCHEDULE_INTERVAL"`
+	// รอบที่เลยเวลานานกว่านี้ (เช่น Server ปิดอยู่) จะถูกข้ามและบันทึกเป็น skipped แทนการทำช้า
+	MisfireGrace Duration `yaml:"misfire_grace" toml:"misfire_grace" env:"SCHEDULE_MISFIRE_GRACE"`
+}
+
+// AnomalyConfig การตรวจค่าผิดปกติตอนรับค่าจาก Sensor (เทียบค่าดิบกับค่าล่าสุดของ Device เดียวกัน)
+type AnomalyConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"ANOMALY_ENABLED"`
+	// จำนวนค่าล่าสุดที่ใช้คำนวณค่าเฉลี่ย / ส่วนเบี่ยงเบนมาตรฐาน (z-score) และจำนวนขั้นต่ำก่อนเริ่มตรวจ
+	Window     int `yaml:"window" toml:"window" env:"ANOMALY_WINDOW"`
+	MinSamples int `yaml:"min_samples" toml:"min_samples" env:"ANOMALY_MIN_SAMPLES"`
+	// ไม่ใช้ค่าที่เก่ากว่านี้ (Device ที่หยุดส่งไปนานเริ่มนับใหม่)
+	MaxAge Duration `yaml:"max_age" toml:"max_age" env:"ANOMALY_MAX_AGE"`
+	// ห่างจากค่าเฉลี่ยเกินกี่เท่าของส่วนเบี่ยงเบนมาตรฐาน
+	ZScore float64 `yaml:"z_score" toml:"z_score" env:"ANOMALY_Z_SCORE"`
+	// เปลี่ยนจากค่าก่อนหน้าเร็วกว่านี้ต่อนาที (°C / %RH) 0 = ไม่ตรวจ
+	MaxTemperatureRate float64 `yaml:"max_temperature_rate" toml:"max_temperature_rate" env:"ANOMALY_MAX_TEMPERATURE_RATE"`
+	MaxHumidityRate    float64 `yaml:"max_humidity_rate" toml:"max_humidity_rate" env:"ANOMALY_MAX_HUMIDITY_RATE"`
+	// ค่าเดิมซ้ำติดกันเท่านี้ค่า (รวมค่าใหม่) ถือว่า Sensor ค้าง 0 = ไม่ตรวจ
+	StuckCount int `yaml:"stuck_count" toml:"stuck_count" env:"ANOMALY_STUCK_COUNT"`
+	// ส่งค่าที่ผิดปกติ (POST JSON) ไปที่ URL นี้ เช่น Alertmanager / Chat Webhook ว่าง = ไม่ส่ง
+	// ส่งจาก Worker เบื้องหลัง (Ingest ไม่รอ) AlertTimeout ใช้ต่อการส่งแต่ละครั้ง
+	AlertWebhook string   `yaml:"alert_webhook" toml:"alert_webhook" env:"ANOMALY_ALERT_WEBHOOK"`
+	AlertTimeout Duration `yaml:"alert_timeout" toml:"alert_timeout" env:"ANOMALY_ALERT_TIMEOUT"`
+}
+
+// RateLimitConfig จำกัดจำนวน Request แบบ Token Bucket แยกตามกลุ่ม Route
+// *_per_minute = อัตราเติม Token (0 = ไม่จำกัดกลุ่มนั้น), *_burst = จำนวนที่ยิงติดกันได้ก่อนถูกจำกัด
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	// memory = นับแยกแต่ละ Instance | database = นับรวมทุก Instance (ใช้เมื่อรันหลายตัวหลัง Load Balancer)
+	Backend string `yaml:"backend" toml:"backend" env:"RATE_LIMIT_BACKEND"`
+
+	// POST /login ต่อ IP ของ Client (กันการเดารหัสผ่าน)
+	LoginPerMinute int `yaml:"login_per_minute" toml:"login_per_minute" env:"RATE_LIMIT_LOGIN_PER_MINUTE"`
+	LoginBurst     int `yaml:"login_burst" toml:"login_burst" env:"RATE_LIMIT_LOGIN_BURST"`
+	// /api/* ต่อ API Key
+	APIPerMinute int `yaml:"api_per_minute" toml:"api_per_minute" env:"RATE_LIMIT_API_PER_MINUTE"`
+	APIBurst     int `yaml:"api_burst" toml:"api_burst" env:"RATE_LIMIT_API_BURST"`
+	// POST /api/sensor ต่อ Device (X-Device-ID หรือเจ้าของ API Key)
+	IngestPerMinute int `yaml:"ingest_per_minute" toml:"ingest_per_minute" env:"RATE_LIMIT_INGEST_PER_MINUTE"`
+	IngestBurst     int `yaml:"ingest_burst" toml:"ingest_burst" env:"RATE_LIMIT_INGEST_BURST"`
+
+	// จำนวนค่า Sensor สูงสุดต่อ Device ต่อวัน (นับตามวัน UTC) 0 = ไม่จำกัด
+	DailyDeviceQuota int `yaml:"daily_device_quota" toml:"daily_device_quota" env:"RATE_LIMIT_DAILY_DEVICE_QUOTA"`
+}
+
+// Default ค่าเริ่มต้นที่ปลอดภัยพอสำหรับ Development
+func Default() *Config {
+	return &Config{
+		Env: "development",
+		Server: ServerConfig{
+			Port:            "8080",
+			ReadTimeout:     Duration(15 * time.Second),
+			WriteTimeout:    Duration(30 * time.Second),
+			IdleTimeout:     Duration(2 * time.Minute),
+			ShutdownTimeout: Duration(20 * time.Second),
+		},
+		Auth: AuthConfig{
+			BcryptCost: 14,
+			TOTPIssuer: "Worm",
+		},
+		Admin: AdminConfig{Username: "admin"},
+		CORS: CORSConfig{
+			AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowHeaders:  []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-KEY", "X-Request-ID", "X-Device-ID"},
+			ExposeHeaders: []string{"Content-Length", "X-Request-ID", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Quota-Limit", "X-Quota-Remaining", "X-Quota-Reset"},
+			MaxAge:        Duration(12 * time.Hour),
+		},
+		OIDC:    OIDCConfig{GroupsClaim: "groups", Organization: "Default"},
+		Metrics: MetricsConfig{Enabled: true},
+		Log:     LogConfig{Level: "info", Format: "json"},
+		// Device ทั่วไป Poll ทุก 5-30 วินาที
+		Actuator:   ActuatorConfig{CommandTTL: Duration(5 * time.Minute), AckTimeout: Duration(time.Minute)},
+		Automation: AutomationConfig{Interval: Duration(30 * time.Second), ReadingWindow: Duration(5 * time.Minute)},
+		Schedule:   ScheduleConfig{Interval: Duration(15 * time.Second), MisfireGrace: Duration(5 * time.Minute)},
+		// อุณหภูมิใน Bin เปลี่ยนช้า ค่าเริ่มต้นจึงจับเฉพาะค่าที่ผิดชัดเจน
+		Anomaly: AnomalyConfig{
+			Enabled:            true,
+			Window:             30,
+			MinSamples:         10,
+			MaxAge:             Duration(6 * time.Hour),
+			ZScore:             4,
+			MaxTemperatureRate: 2,
+			MaxHumidityRate:    10,
+			StuckCount:         30,
+			AlertTimeout:       Duration(5 * time.Second),
+		},
+		// Sensor ปกติส่งทุก 1-5 นาที ค่าเริ่มต้นจึงเผื่อไว้มากแต่ยังหยุด Firmware ที่วนลูปได้
+		RateLimit: RateLimitConfig{
+			Enabled:         true,
+			Backend:         "memory",
+			LoginPerMinute:  10,
+			LoginBurst:      5,
+			APIPerMinute:    600,
+			APIBurst:        100,
+			IngestPerMinute: 60,
+			IngestBurst:     10,
+		},
+	}
+}
+
+// IsProduction รันอยู่ใน Production หรือไม่
+func (c *Config) IsProduction() bool {
+	return c.Env == "production"
+}
+
+// Load โหลด Config จากทุกแหล่งแล้วตรวจสอบความถูกต้อง
+// path = ไฟล์ .yaml/.yml/.toml (ว่าง = ใช้ CONFIG_FILE หรือไม่ใช้ไฟล์)
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	// .env ไม่บังคับว่าต้องมี (บน Render ใช้ Env จริง)
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read .env: %w", err)
+	}
+	lookup := func(key string) (string, bool) {
+		if v, ok := os.LookupEnv(key); ok {
+			return v, true
+		}
+		v, ok := dotenv[key]
+		return v, ok
+	}
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), lookup); err != nil {
+		return nil, err
+	}
+
+	// Development เปิดกว้างไว้ก่อนถ้าไม่ได้กำหนด ส่วน Production ต้องระบุ Origin เอง
+	if !cfg.IsProduction() && len(cfg.CORS.AllowOrigins) == 0 {
+		cfg.CORS.AllowOrigins = []string{"*"}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate ตรวจค่าทั้งหมด และรวม Error ทุกตัวไว้ในครั้งเดียว
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Env != "development" && c.Env != "production" {
+		add("env: must be \"development\" or \"production\", got %q (APP_ENV)", c.Env)
+	}
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		add("server.port: must be a number between 1 and 65535, got %q (PORT)", c.Server.Port)
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 {
+		add("server: read_timeout, write_timeout, idle_timeout and shutdown_timeout must be positive")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			add("server.trusted_proxies: %q is not an IP address or CIDR (SERVER_TRUSTED_PROXIES)", proxy)
+		}
+	}
+	if c.Database.DSN == "" {
+		add("database.dsn: is required (DB_DSN)")
+	} else if _, err := DriverName(c.Database.DSN); err != nil {
+		add("database.dsn: %v (DB_DSN)", err)
+	}
+	if c.Auth.BcryptCost < bcrypt.MinCost || c.Auth.BcryptCost > bcrypt.MaxCost {
+		add("auth.bcrypt_cost: must be between %d and %d, got %d (BCRYPT_COST)", bcrypt.MinCost, bcrypt.MaxCost, c.Auth.BcryptCost)
+	}
+	if c.IsProduction() && c.Auth.BcryptCost < bcrypt.DefaultCost {
+		add("auth.bcrypt_cost: must be at least %d in production", bcrypt.DefaultCost)
+	}
+	if c.Admin.Username == "" {
+		add("admin.username: is required (ADMIN_USERNAME)")
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		add("log.level: must be debug, info, warn or error, got %q (LOG_LEVEL)", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		add("log.format: must be \"json\" or \"text\", got %q (LOG_FORMAT)", c.Log.Format)
+	}
+	if c.Actuator.CommandTTL <= 0 || c.Actuator.AckTimeout <= 0 {
+		add("actuator: command_ttl and ack_timeout must be positive (ACTUATOR_COMMAND_TTL, ACTUATOR_ACK_TIMEOUT)")
+	}
+	if c.Automation.Interval <= 0 || c.Automation.ReadingWindow <= 0 {
+		add("automation: interval and reading_window must be positive (AUTOMATION_INTERVAL, AUTOMATION_READING_WINDOW)")
+	}
+	if c.Schedule.Interval <= 0 || c.Schedule.MisfireGrace <= 0 {
+		add("schedule: interval and misfire_grace must be positive (SCHEDULE_INTERVAL, SCHEDULE_MISFIRE_GRACE)")
+	}
+	if a := c.Anomaly; a.Window < 2 || a.MinSamples < 2 || a.MinSamples > a.Window {
+		add("anomaly: window must be at least 2 and min_samples between 2 and window (ANOMALY_WINDOW, ANOMALY_MIN_SAMPLES)")
+	}
+	if a := c.Anomaly; a.MaxAge <= 0 || a.ZScore <= 0 || a.AlertTimeout <= 0 {
+		add("anomaly: max_age, z_score and alert_timeout must be positive (ANOMALY_MAX_AGE, ANOMALY_Z_SCORE, ANOMALY_ALERT_TIMEOUT)")
+	}
+	if a := c.Anomaly; a.MaxTemperatureRate < 0 || a.MaxHumidityRate < 0 || a.StuckCount < 0 || a.StuckCount == 1 {
+		add("anomaly: max_temperature_rate and max_humidity_rate must not be negative and stuck_count must be 0 or at least 2")
+	}
+	if w := c.Anomaly.AlertWebhook; w != "" {
+		if u, err := url.Parse(w); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("anomaly.alert_webhook: must be an http or https URL (ANOMALY_ALERT_WEBHOOK)")
+		}
+	}
+	errs = append(errs, c.CORS.validate()...)
+	errs = append(errs, c.RateLimit.validate()...)
+	if c.OIDC.Issuer != "" && (c.OIDC.ClientID == "" || c.OIDC.RedirectURL == "") {
+		add("oidc: client_id and redirect_url are required when issuer is set (OIDC_CLIENT_ID, OIDC_REDIRECT_URL)")
+	}
+	if c.OIDC.Issuer != "" && strings.TrimSpace(c.OIDC.Organization) == "" {
+		add("oidc: organization is required when issuer is set (OIDC_ORGANIZATION)")
+	}
+
+	// /metrics มีค่า Sensor ของทุกองค์กร (แยกด้วย Label organization) จึงเปิดโดยไม่มี Token ไม่ได้ใน Production
+	if c.IsProduction() && c.Metrics.Enabled && c.Metrics.Token == "" {
+		add("metrics.token: is required in production because /metrics exposes the readings of every organization (METRICS_TOKEN, or set METRICS_ENABLED=false)")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validate ตรวจ Origin ทีละตัว และไม่ยอมให้ใช้ "*" คู่กับ Credentials
+func (c CORSConfig) validate() []error {
+	var errs []error
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			if len(c.AllowOrigins) > 1 {
+				errs = append(errs, errors.New("cors.allow_origins: \"*\" cannot be combined with other origins"))
+			}
+			if c.AllowCredentials {
+				errs = append(errs, errors.New("cors: allow_origins \"*\" with allow_credentials is insecure, list the allowed origins explicitly"))
+			}
+			continue
+		}
+
+		scheme, host, ok := strings.Cut(origin, "://")
+		valid := ok && (scheme == "http" || scheme == "https") && host != "" && !strings.Contains(host, "/")
+		// Wildcard ได้เฉพาะ Subdomain ซ้ายสุด เช่น https://*.example.com
+		if n := strings.Count(host, "*"); n > 0 {
+			valid = valid && n == 1 && strings.HasPrefix(host, "*.") && strings.Count(host, ".") >= 2
+		}
+		if !valid {
+			errs = append(errs, fmt.Errorf("cors.allow_origins: invalid origin %q (expected scheme://host[:port] or scheme://*.domain)", origin))
+		}
+	}
+	return errs
+}
+
+// validate ตรวจ Backend และค่าของแต่ละกลุ่ม
+func (c RateLimitConfig) validate() []error {
+	var errs []error
+	if c.Backend != "memory" && c.Backend != "database" {
+		errs = append(errs, fmt.Errorf("rate_limit.backend: must be \"memory\" or \"database\", got %q (RATE_LIMIT_BACKEND)", c.Backend))
+	}
+	groups := []struct {
+		name             string
+		perMinute, burst int
+	}{
+		{"login", c.LoginPerMinute, c.LoginBurst},
+		{"api", c.APIPerMinute, c.APIBurst},
+		{"ingest", c.IngestPerMinute, c.IngestBurst},
+	}
+	for _, g := range groups {
+		if g.perMinute < 0 {
+			errs = append(errs, fmt.Errorf("rate_limit.%s_per_minute: must not be negative, got %d", g.name, g.perMinute))
+		}
+		if g.perMinute > 0 && g.burst < 1 {
+			errs = append(errs, fmt.Errorf("rate_limit.%s_burst: must be at least 1 when %s_per_minute is set, got %d", g.name, g.name, g.burst))
+		}
+	}
+	if c.DailyDeviceQuota < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit.daily_device_quota: must not be negative, got %d (RATE_LIMIT_DAILY_DEVICE_QUOTA)", c.DailyDeviceQuota))
+	}
+	return errs
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config file %s: unsupported format (use .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv เขียนทับ Field ที่มี Tag `env` ด้วยค่าจาก Environment (ไล่เข้าไปใน Struct ย่อย)
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := t.Field(i).Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		raw, ok := lookup(key)
+		if !ok {
+			continue
+		}
+
+		// ชนิดที่อ่านจาก String เองได้ (เช่น Duration) ใช้วิธีเดียวกับ YAML / TOML
+		if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			if err := u.UnmarshalText([]byte(raw)); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(raw)
+		case reflect.Int:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				return fmt.Errorf("%s: must be an integer, got %q", key, raw)
+			}
+			field.SetInt(int64(n))
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return fmt.Errorf("%s: must be a number, got %q", key, raw)
+			}
+			field.SetFloat(f)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				return fmt.Errorf("%s: must be true or false, got %q", key, raw)
+			}
+			field.SetBool(b)
+		case reflect.Slice:
+			field.Set(reflect.ValueOf(utils.SplitList(raw)))
+		}
+	}
+	return nil
+}
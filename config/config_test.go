@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig เขียนไฟล์ Config ชั่วคราวแล้วคืน Path
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+// wantDurations ค่าที่ไฟล์ในแต่ละ Test ตั้งไว้
+func wantDurations(t *testing.T, cfg *Config) {
+	t.Helper()
+	checks := []struct {
+		name      string
+		got, want Duration
+	}{
+		{"cors.max_age", cfg.CORS.MaxAge, Duration(90 * time.Minute)},
+		{"actuator.command_ttl", cfg.Actuator.CommandTTL, Duration(2 * time.Minute)},
+		{"automation.interval", cfg.Automation.Interval, Duration(45 * time.Second)},
+		{"schedule.misfire_grace", cfg.Schedule.MisfireGrace, Duration(10 * time.Minute)},
+		{"anomaly.alert_timeout", cfg.Anomaly.AlertTimeout, Duration(1500 * time.Millisecond)},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestLoadDurationsFromTOML(t *testing.T) {
+	t.Setenv("DB_DSN", "sqlite://:memory:")
+	path := writeConfig(t, "worm.toml", `
+[cors]
+max_age = "1h30m"
+
+[actuator]
+command_ttl = "2m"
+
+[automation]
+interval = "45s"
+
+[schedule]
+misfire_grace = "10m"
+
+[anomaly]
+alert_timeout = "1.5s"
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	wantDurations(t, cfg)
+}
+
+func TestLoadDurationsFromYAML(t *testing.T) {
+	t.Setenv("DB_DSN", "sqlite://:memory:")
+	path := writeConfig(t, "worm.yaml", `
+cors:
+  max_age: 1h30m
+actuator:
+  command_ttl: 2m
+automation:
+  interval: 45s
+schedule:
+  misfire_grace: 10m
+anomaly:
+  alert_timeout: 1.5s
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	wantDurations(t, cfg)
+}
+
+func TestLoadDurationsFromEnv(t *testing.T) {
+	t.Setenv("DB_DSN", "sqlite://:memory:")
+	t.Setenv("CORS_MAX_AGE", "1h30m")
+	t.Setenv("ACTUATOR_COMMAND_TTL", "2m")
+	t.Setenv("AUTOMATION_INTERVAL", "45s")
+	t.Setenv("SCHEDULE_MISFIRE_GRACE", "10m")
+	t.Setenv("ANOMALY_ALERT_TIMEOUT", "1.5s")
+	cfg, err := Load(writeConfig(t, "worm.toml", ""))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	wantDurations(t, cfg)
+}
+
+func TestLoadServerTimeoutsFromTOML(t *testing.T) {
+	t.Setenv("DB_DSN", "sqlite://:memory:")
+	t.Setenv("SERVER_SHUTDOWN_TIMEOUT", "40s")
+	path := writeConfig(t, "worm.toml", `
+[server]
+port = "9090"
+read_timeout = "15s"
+write_timeout = "1m"
+idle_timeout = "5m"
+trusted_proxies = ["10.0.0.1"]
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := ServerConfig{
+		Port:            "9090",
+		ReadTimeout:     Duration(15 * time.Second),
+		WriteTimeout:    Duration(time.Minute),
+		IdleTimeout:     Duration(5 * time.Minute),
+		ShutdownTimeout: Duration(40 * time.Second),
+		TrustedProxies:  []string{"10.0.0.1"},
+	}
+	if !reflect.DeepEqual(cfg.Server, want) {
+		t.Fatalf("server: got %+v, want %+v", cfg.Server, want)
+	}
+}
+
+func TestLoadRejectsInvalidDuration(t *testing.T) {
+	t.Setenv("DB_DSN", "sqlite://:memory:")
+	if _, err := Load(writeConfig(t, "worm.toml", "[cors]\nmax_age = \"twelve hours\"\n")); err == nil {
+		t.Fatal("toml: want an error for an invalid duration")
+	}
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("CORS_MAX_AGE", "12")
+	if _, err := Load(""); err == nil {
+		t.Fatal("env: want an error for a duration without a unit")
+	}
+}
+
+func TestProductionRequiresMetricsToken(t *testing.T) {
+	cfg := Default()
+	cfg.Env = "production"
+	cfg.Database.DSN = "postgres://worm@db/worm"
+	cfg.CORS.AllowOrigins = []string{"https://farm.example.com"}
+
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "metrics.token") {
+		t.Fatalf("production without a metrics token: got %v, want a metrics.token error", err)
+	}
+	cfg.Metrics.Token = "scrape-secret"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("production with a metrics token: %v", err)
+	}
+	cfg.Metrics = MetricsConfig{Enabled: false}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("production with metrics disabled: %v", err)
+	}
+}
+
+func TestValidateTrustedProxies(t *testing.T) {
+	cfg := Default()
+	cfg.Server.TrustedProxies = []string{"10.0.0.1", "172.16.0.0/12", "::1", "proxy.local"}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `"proxy.local"`) {
+		t.Fatalf("want an error for the host name, got %v", err)
+	}
+	if strings.Contains(err.Error(), "10.0.0.1") || strings.Contains(err.Error(), "172.16.0.0/12") {
+		t.Fatalf("valid proxies rejected: %v", err)
+	}
+}
@@ -1,32 +1,121 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"os"
-	"worm/models"
+	"log/slog"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-func ConnectDB() *gorm.DB {
-	// ลองโหลด .env (ถ้ามี) แต่ถ้าไม่มี (บน Render) ก็ข้ามไป
-	_ = godotenv.Load()
+// drivers เลือก Driver จาก Scheme ของ DSN เพิ่ม Backend ใหม่ได้ที่นี่ที่เดียว
+// (ต้องมีโฟลเดอร์ migrations/<ชื่อ Dialector> ด้วย)
+var drivers = map[string]func(dsn string) gorm.Dialector{
+	"postgres":   postgres.Open,
+	"postgresql": postgres.Open,
+	"sqlite":     openSQLite,
+}
 
-	dsn := os.Getenv("DB_DSN")
-	if dsn == "" {
-		log.Fatal("DB_DSN is not set in environment variables")
+// DriverName คืนชื่อ Driver ของ DSN
+// รองรับ postgres://..., postgresql://..., sqlite://path/to/file.db, sqlite://:memory:
+// และรูปแบบ key=value เดิมของ Postgres (host=... user=...)
+func DriverName(dsn string) (string, error) {
+	scheme, _, ok := strings.Cut(dsn, "://")
+	if !ok {
+		if strings.Contains(dsn, "=") {
+			return "postgres", nil
+		}
+		return "", fmt.Errorf("cannot detect database driver from DSN (use postgres:// or sqlite://)")
 	}
+	if _, known := drivers[scheme]; !known {
+		return "", fmt.Errorf("unsupported database driver %q", scheme)
+	}
+	return scheme, nil
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// ConnectDB เชื่อมต่อฐานข้อมูลตาม database.dsn (ตรวจแล้วใน Config.Validate)
+func ConnectDB(cfg *Config) *gorm.DB {
+	db, err := OpenDB(cfg.Database.DSN)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	fmt.Println("Database Connected & Migrated!")
-	db.AutoMigrate(&models.User{}, &models.SensorData{})
+	// Schema จัดการด้วย package migrations (คำสั่ง `migrate`) ไม่ใช้ AutoMigrate แล้ว
+	slog.Info("database connected", "driver", db.Dialector.Name())
 
 	return db
-}
\ No newline at end of file
+}
+
+// CloseDB ปิด Connection Pool (เรียกหลัง Server หยุดรับ Request แล้ว)
+func CloseDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// PingDB ตรวจว่ายังติดต่อฐานข้อมูลได้ (ใช้กับ /readyz)
+func PingDB(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// OpenDB เปิดการเชื่อมต่อด้วย Driver ที่ตรงกับ DSN
+func OpenDB(dsn string) (*gorm.DB, error) {
+	name, err := DriverName(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(drivers[name](dsn), &gorm.Config{
+		// "ไม่พบข้อมูล" เป็นเรื่องปกติ (เช่น Login ผิดชื่อ) ไม่ต้อง Log
+		// ParameterizedQueries: ไม่ใส่ค่าจริงลงใน SQL ที่ Log (กัน API Key / Hash หลุด)
+		Logger: logger.NewSlogLogger(slog.Default(), logger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+			ParameterizedQueries:      true,
+		}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "sqlite" {
+		// SQLite เขียนได้ทีละ Connection เดียว และ :memory: แยกฐานข้อมูลตาม Connection
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
+	return db, nil
+}
+
+// openSQLite แปลง sqlite://path เป็น DSN ของ Driver และเปิด Foreign Key / Busy Timeout
+// ใช้ Driver ที่เขียนด้วย Go ล้วน (modernc.org/sqlite) Build แบบ CGO_ENABLED=0 ได้ เช่น Cross-compile ไป Raspberry Pi
+func openSQLite(dsn string) gorm.Dialector {
+	path := strings.TrimPrefix(dsn, "sqlite://")
+	params := "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	if strings.Contains(path, "?") {
+		path += "&" + params
+	} else {
+		path += "?" + params
+	}
+	if strings.HasPrefix(path, ":memory:") {
+		path = "file:" + path
+	}
+	return sqlite.Open(path)
+}
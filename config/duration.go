@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Duration ช่วงเวลาใน Config เขียนแบบ time.ParseDuration เช่น "30s", "12h"
+// ใช้แทน time.Duration เพราะ go-toml อ่าน String เป็น time.Duration ไม่ได้ (YAML / TOML / Env จึงเขียนเหมือนกัน)
+type Duration time.Duration
+
+// UnmarshalText อ่านจาก String เช่น "5m" (encoding.TextUnmarshaler ใช้ทั้ง YAML, TOML และ Env)
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("must be a duration such as 30s or 12h, got %q", text)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText เขียนกลับเป็นรูปแบบเดียวกับที่อ่าน
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// String เช่น "12h0m0s"
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
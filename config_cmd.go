@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"worm/config"
+)
+
+const configUsage = `usage: worm config check [-config file]
+
+Loads the configuration (defaults, file, .env, environment), validates it
+and exits with status 1 if anything is wrong. Nothing is written to the database.
+`
+
+// runConfig คำสั่ง `worm config check`
+func runConfig(args []string) {
+	fs, configFile := subcommand("config", configUsage)
+	if len(args) == 0 || args[0] != "check" {
+		usageError(fs, "")
+	}
+	fs.Parse(args[1:])
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	driver, _ := config.DriverName(cfg.Database.DSN)
+	fmt.Printf("Configuration OK (env: %s, database: %s, port: %s)\n", cfg.Env, driver, cfg.Server.Port)
+}
@@ -0,0 +1,354 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"worm/apierror"
+	"worm/models"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Request Models ---
+
+// CreateActuatorRequest แบบฟอร์มเพิ่ม Actuator
+type CreateActuatorRequest struct {
+	// Device ที่ควบคุม Relay นี้ (ดู GET /api/devices)
+	DeviceID uint `json:"device_id" example:"1" binding:"required"`
+	// 1-100 ตัวอักษร ไม่ซ้ำภายใน Device
+	Name string `json:"name" example:"Mister 1" binding:"required"`
+	// ตั้งเองได้ เช่น fan, mister, heater
+	Kind string `json:"kind" example:"mister" binding:"required"`
+	// ช่อง Relay บน Device (0-255) ไม่ซ้ำภายใน Device
+	Channel int `json:"channel" example:"0"`
+}
+
+// UpdateActuatorRequest แบบฟอร์มแก้ไข Actuator (ส่งเฉพาะค่าที่ต้องการแก้)
+type UpdateActuatorRequest struct {
+	Name    *string `json:"name" example:"Mister 1"`
+	Kind    *string `json:"kind" example:"mister"`
+	Channel *int    `json:"channel" example:"1"`
+}
+
+// CommandRequest แบบฟอร์มสั่ง Actuator
+type CommandRequest struct {
+	// on | off | duty
+	Action string `json:"action" example:"on" binding:"required"`
+	// % ของเวลาที่เปิดในแต่ละรอบ 1-100 (เฉพาะ duty)
+	DutyCycle *int `json:"duty_cycle" example:"25"`
+	// ทำกี่วินาทีแล้วกลับเป็นปิด 1-86400 (ไม่ส่ง = จนกว่าจะมีคำสั่งใหม่, ใช้กับ off ไม่ได้)
+	DurationSeconds *int `json:"duration_seconds" example:"120"`
+}
+
+// OverrideRequest แบบฟอร์มเปิด / ปิด Manual Override
+type OverrideRequest struct {
+	// true = Automation Rule / Schedule ไม่สั่ง Actuator นี้
+	Enabled bool `json:"enabled" example:"true"`
+	// Override นานกี่วินาที 1-604800 (ไม่ส่ง = จนกว่าจะปิดเอง)
+	DurationSeconds *int `json:"duration_seconds" example:"3600"`
+}
+
+// AcknowledgeRequest แบบฟอร์มที่ Device รายงานผลของคำสั่ง
+type AcknowledgeRequest struct {
+	// acknowledged = ทำตามแล้ว | failed = ทำไม่ได้
+	Status string `json:"status" example:"acknowledged" binding:"required,oneof=acknowledged failed"`
+	// เหตุผลเมื่อ failed
+	Error string `json:"error" example:"relay did not switch"`
+}
+
+// --- 2. Handlers ---
+
+// GetAllActuatorsHandler ดู Actuator ทั้งหมด
+// @Summary      ดูรายการ Actuator
+// @Description  Actuator ขององค์กร (Superadmin เห็นทุกองค์กร) พร้อมคำสั่งล่าสุดที่ Device ยืนยันแล้ว (state)
+// @Tags         Actuator
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array} models.Actuator
+// @Failure      401  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /actuators [get]
+func (h *Handler) GetAllActuatorsHandler(c *gin.Context) {
+	actuators, err := h.Actuators.GetAllActuators(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"actuators": actuators})
+}
+
+// CreateActuatorHandler เพิ่ม Actuator
+// @Summary      เพิ่ม Actuator (Admin Only)
+// @Description  ผูก Relay (พัดลม เครื่องพ่นหมอก ฮีตเตอร์) กับช่องของ Device
+// @Tags         Actuator
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body CreateActuatorRequest true "ข้อมูล Actuator"
+// @Success      200  {object} models.Actuator
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem "ไม่พบ Device"
+// @Failure      409  {object} apierror.Problem "ชื่อหรือช่องซ้ำภายใน Device"
+// @Failure      500  {object} apierror.Problem
+// @Router       /actuators [post]
+func (h *Handler) CreateActuatorHandler(c *gin.Context) {
+	var req CreateActuatorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	actuator, err := h.Actuators.CreateActuator(c.Request.Context(), services.ActuatorInput{
+		DeviceID: req.DeviceID,
+		Name:     req.Name,
+		Kind:     req.Kind,
+		Channel:  req.Channel,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, actuator)
+}
+
+// UpdateActuatorHandler แก้ไข Actuator
+// @Summary      แก้ไข Actuator (Admin Only)
+// @Description  เปลี่ยนชื่อ ชนิด หรือช่อง Relay (คำสั่งที่สั่งไปแล้วยังใช้ช่องเดิม)
+// @Tags         Actuator
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path   int                    true  "Actuator ID"
+// @Param        request body   UpdateActuatorRequest  true  "ข้อมูลที่ต้องการแก้"
+// @Success      200     {object} models.Actuator
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      403     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      409     {object} apierror.Problem "ชื่อหรือช่องซ้ำภายใน Device"
+// @Failure      500     {object} apierror.Problem
+// @Router       /actuators/{id} [put]
+func (h *Handler) UpdateActuatorHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrActuatorNotFound)
+		return
+	}
+
+	var req UpdateActuatorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	actuator, err := h.Actuators.UpdateActuator(c.Request.Context(), uint(id), services.ActuatorUpdate{
+		Name:    req.Name,
+		Kind:    req.Kind,
+		Channel: req.Channel,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, actuator)
+}
+
+// DeleteActuatorHandler ลบ Actuator
+// @Summary      ลบ Actuator (Admin Only)
+// @Description  ลบได้เฉพาะ Actuator ที่ยังไม่เคยได้รับคำสั่ง (เก็บประวัติคำสั่งไว้)
+// @Tags         Actuator
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Actuator ID"
+// @Success      200  {object} map[string]string
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      409  {object} apierror.Problem "มีประวัติคำสั่งแล้ว หรือมี Automation Rule / Schedule ใช้อยู่"
+// @Failure      500  {object} apierror.Problem
+// @Router       /actuators/{id} [delete]
+func (h *Handler) DeleteActuatorHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrActuatorNotFound)
+		return
+	}
+
+	actuator, err := h.Actuators.DeleteActuator(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Actuator " + actuator.Name + " deleted"})
+}
+
+// SetOverrideHandler เปิด / ปิด Manual Override
+// @Summary      เปิด / ปิด Manual Override
+// @Description  ระหว่าง Override Automation Rule และ Schedule จะไม่สั่ง Actuator นี้ (บันทึกใน Log / ประวัติว่าข้าม) แต่ยังสั่งเองได้
+// @Tags         Actuator
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path   int              true  "Actuator ID"
+// @Param        request body   OverrideRequest  true  "โหมด Override"
+// @Success      200     {object} models.Actuator
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      500     {object} apierror.Problem
+// @Router       /actuators/{id}/override [put]
+func (h *Handler) SetOverrideHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrActuatorNotFound)
+		return
+	}
+
+	var req OverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	actuator, err := h.Actuators.SetOverride(c.Request.Context(), uint(id), req.Enabled, req.DurationSeconds)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, actuator)
+}
+
+// SendCommandHandler สั่ง Actuator
+// @Summary      สั่ง Actuator
+// @Description  เข้าคิวคำสั่ง on / off / duty (duty_cycle %) พร้อมระยะเวลา แล้วรอ Device มา Poll
+// @Description  คำสั่งที่ Device ไม่ Poll ภายใน actuator.command_ttl จะเป็น expired
+// @Tags         Actuator
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path   int             true  "Actuator ID"
+// @Param        request body   CommandRequest  true  "คำสั่ง"
+// @Success      200     {object} models.ActuatorCommand
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      500     {object} apierror.Problem
+// @Router       /actuators/{id}/commands [post]
+func (h *Handler) SendCommandHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrActuatorNotFound)
+		return
+	}
+
+	var req CommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	user := currentUser(c)
+	cmd, err := h.Actuators.SendCommand(c.Request.Context(), uint(id), services.CommandInput{
+		Action:          req.Action,
+		DutyCycle:       req.DutyCycle,
+		DurationSeconds: req.DurationSeconds,
+	}, models.CommandSourceUser, &user.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, cmd)
+}
+
+// GetCommandHistoryHandler ประวัติคำสั่งของ Actuator
+// @Summary      ดูประวัติคำสั่งของ Actuator
+// @Description  ทุกคำสั่งพร้อมสถานะ (queued, delivered, acknowledged, failed, expired) เรียงจากล่าสุด
+// @Tags         Actuator
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Actuator ID"
+// @Success      200  {array} models.ActuatorCommand
+// @Failure      401  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /actuators/{id}/commands [get]
+func (h *Handler) GetCommandHistoryHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrActuatorNotFound)
+		return
+	}
+
+	cmds, err := h.Actuators.CommandHistory(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"commands": cmds})
+}
+
+// PollCommandsHandler Device ดึงคำสั่งที่รออยู่
+// @Summary      Device ดึงคำสั่ง (Poll)
+// @Description  คืนคำสั่งที่รออยู่ของ Device (X-Device-ID) เรียงตามลำดับที่สั่ง แต่ละคำสั่งถูกส่งครั้งเดียว
+// @Description  Device ต้องยืนยันผลผ่าน /device/commands/{id}/ack ภายใน actuator.ack_timeout
+// @Tags         Actuator
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Device-ID header string false "รหัส Device (ไม่ส่ง = ใช้ชื่อเจ้าของ API Key)"
+// @Success      200  {array} models.ActuatorCommand
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem "บัญชีไม่สังกัดองค์กร (Superadmin)"
+// @Failure      500  {object} apierror.Problem
+// @Router       /device/commands [get]
+func (h *Handler) PollCommandsHandler(c *gin.Context) {
+	cmds, err := h.Actuators.PollCommands(c.Request.Context(), c.GetString("device"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"commands": cmds})
+}
+
+// AcknowledgeCommandHandler Device รายงานผลของคำสั่ง
+// @Summary      Device ยืนยันคำสั่ง
+// @Description  รายงานว่าทำตามคำสั่งแล้ว (acknowledged) หรือทำไม่ได้ (failed) ส่งผลเดิมซ้ำได้
+// @Tags         Actuator
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Device-ID header string false "รหัส Device (ไม่ส่ง = ใช้ชื่อเจ้าของ API Key)"
+// @Param        id      path   int                 true  "Command ID"
+// @Param        request body   AcknowledgeRequest  true  "ผลของคำสั่ง"
+// @Success      200     {object} models.ActuatorCommand
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      403     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem "ไม่พบคำสั่งของ Device นี้"
+// @Failure      409     {object} apierror.Problem "คำสั่งไม่ได้รอการยืนยัน (หมดเวลาแล้ว หรือรายงานผลไปแล้ว)"
+// @Failure      500     {object} apierror.Problem
+// @Router       /device/commands/{id}/ack [post]
+func (h *Handler) AcknowledgeCommandHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrCommandNotFound)
+		return
+	}
+
+	var req AcknowledgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	cmd, err := h.Actuators.AcknowledgeCommand(c.Request.Context(), c.GetString("device"), uint(id),
+		req.Status == models.CommandAcknowledged, req.Error)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, cmd)
+}
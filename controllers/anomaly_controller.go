@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"worm/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Handlers ---
+
+// GetAnomaliesHandler ดูค่าที่ผิดปกติ
+// @Summary      ดูค่า Sensor ที่ผิดปกติ
+// @Description  ค่าที่ตรวจพบความผิดปกติตอนบันทึก (anomalies) ล่าสุดก่อน ไม่เกิน 1000 ค่า
+// @Description  zscore = ห่างจากค่าเฉลี่ยของค่าล่าสุดมาก, spike = เปลี่ยนเร็วผิดปกติ, stuck = ค่าเดิมซ้ำติดกันนาน (ดู anomaly ใน Config)
+// @Tags         Sensor
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        device_id  query  int     false  "Device ID ไม่ส่ง = ทุก Device"
+// @Param        from       query  string  false  "เริ่ม (RFC 3339) ไม่ส่ง = 24 ชั่วโมงก่อน to"
+// @Param        to         query  string  false  "สิ้นสุด (RFC 3339, ไม่รวม) ไม่ส่ง = ตอนนี้"
+// @Success      200  {object} object{data=[]models.SensorData}
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /anomalies [get]
+func (h *Handler) GetAnomaliesHandler(c *gin.Context) {
+	var deviceID uint64
+	if s := c.Query("device_id"); s != "" {
+		var err error
+		if deviceID, err = strconv.ParseUint(s, 10, 64); err != nil || deviceID == 0 {
+			_ = c.Error(apierror.New(http.StatusBadRequest, apierror.CodeValidation, "Query parameter is invalid").
+				WithField("device_id", "must be a positive integer"))
+			return
+		}
+	}
+	from, err := timeQuery(c, "from")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	to, err := timeQuery(c, "to")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	data, err := h.Anomalies.Anomalies(c.Request.Context(), uint(deviceID), from, to)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Handlers ---
+
+// GetAuditLogsHandler ดู Audit Log
+// @Summary      ดู Audit Log (Admin Only)
+// @Description  แสดงประวัติการกระทำสำคัญของ Admin เรียงจากล่าสุด
+// @Description  Admin ขององค์กรเห็นเฉพาะรายการขององค์กรตัวเอง ส่วนรายการระดับระบบเห็นเฉพาะ Superadmin
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array} models.AuditLog
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /audit [get]
+func (h *Handler) GetAuditLogsHandler(c *gin.Context) {
+	logs, err := h.Auth.AuditLogs(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"logs": logs})
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"worm/apierror"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Request Models ---
+
+// LoginRequest โมเดลสำหรับ Login
+type LoginRequest struct {
+	Username string `json:"username" example:"admin" binding:"required"`
+	Password string `json:"password" example:"pass1234" binding:"required"`
+	// ขั้นที่ 2 (เฉพาะบัญชีที่เปิด 2FA): รหัสจากแอป Authenticator หรือ Recovery Code
+	OTPCode string `json:"otp_code" example:"123456"`
+}
+
+// --- 2. Handlers ---
+
+// LoginHandler เข้าสู่ระบบด้วย Username/Password
+// @Summary      เข้าสู่ระบบ (Login)
+// @Description  รับ Username/Password (และ otp_code ถ้าเปิด 2FA) เพื่อรับ API Key
+// @Description  Username ที่ไม่มีอยู่และรหัสผ่านผิดตอบ 401 invalid_credentials เหมือนกัน รหัส 2FA แต่ละรหัสใช้ได้ครั้งเดียว
+// @Description  API Key ที่ได้คือ Key ถาวรของบัญชี (ไม่ใช่ Session ที่หมดอายุ) 2FA ป้องกันเฉพาะขั้นตอน Login:
+// @Description  ผู้ที่ได้ Key ไปแล้วใช้ API ได้โดยไม่ต้องผ่าน 2FA อีก ถ้า Key หลุดให้ออก Key ใหม่ด้วย `worm key revoke <username>`
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body LoginRequest true "ข้อมูล Login"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /login [post]
+func (h *Handler) LoginHandler(c *gin.Context) {
+	var req LoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	user, err := h.Auth.Login(c.Request.Context(), req.Username, req.Password, req.OTPCode)
+	if reason := loginFailureReason(err); reason != "" {
+		h.Metrics.AuthFailures.Inc(reason)
+	}
+	if errors.Is(err, services.ErrUserNotFound) || errors.Is(err, services.ErrIncorrectPassword) {
+		// ตอบเหมือนกันทั้งสองกรณี (401 ไม่ใช่ 404) ไม่ให้ไล่หา Username ที่มีอยู่ได้ (แยกกันเฉพาะใน Metrics)
+		err = apierror.New(http.StatusUnauthorized, apierror.CodeInvalidCredentials, "Invalid username or password")
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Login successful",
+		"username": user.Username,
+		"role":     user.Role,
+		"api_key":  user.APIKey,
+		// ต้องเรียก /api/me/password ก่อนใช้ API อื่น
+		"must_change_password": user.MustChangePassword,
+		// Admin ที่ยังไม่เปิด 2FA ทั้งที่ระบบบังคับ -> ต้องไปที่ /api/2fa/enroll ก่อน
+		"two_factor_setup_required": h.Auth.TwoFactorSetupRequired(user),
+	})
+}
+
+// --- 3. Internal Logic ---
+
+// loginFailureReason Label ของ worm_auth_failures_total ("" = ไม่นับ)
+func loginFailureReason(err error) string {
+	switch {
+	case err == nil, errors.Is(err, services.ErrTwoFactorRequired):
+		// ขอรหัส 2FA เป็นขั้นตอนปกติ ไม่ใช่การล็อกอินผิด
+		return ""
+	case errors.Is(err, services.ErrUserNotFound):
+		return "user_not_found"
+	case errors.Is(err, services.ErrIncorrectPassword):
+		return "incorrect_password"
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		return "invalid_two_factor_code"
+	case errors.Is(err, services.ErrUserDisabled):
+		return "account_disabled"
+	case errors.Is(err, services.ErrPasswordLoginDisabled):
+		return "password_login_disabled"
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"worm/config"
+	"worm/controllers"
+	"worm/metrics"
+	"worm/middleware"
+	"worm/models"
+	"worm/repository"
+	"worm/repository/memory"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginFailuresDoNotRevealUsernames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := memory.NewStore()
+	users := services.NewUserService(store, bcrypt.MinCost)
+	if _, err := users.CreateUser(repository.AllTenants(context.Background()), "root", "correct-horse", models.RoleSuperadmin, nil); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	h := &controllers.Handler{
+		Auth:    services.NewAuthService(store, config.AuthConfig{BcryptCost: bcrypt.MinCost}),
+		Metrics: metrics.New(),
+	}
+	r := gin.New()
+	r.Use(middleware.Errors())
+	r.POST("/login", h.LoginHandler)
+
+	login := func(username, password string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		body := `{"username":"` + username + `","password":"` + password + `"}`
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body)))
+		var problem map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("decode %s: %v", w.Body.String(), err)
+		}
+		// request_id / instance ต่างกันทุก Request อยู่แล้ว
+		delete(problem, "request_id")
+		return w.Code, problem
+	}
+
+	unknownStatus, unknown := login("nobody", "correct-horse")
+	wrongStatus, wrong := login("root", "wrong-password")
+	if unknownStatus != http.StatusUnauthorized || wrongStatus != http.StatusUnauthorized {
+		t.Fatalf("status: unknown user %d, wrong password %d, want 401 for both", unknownStatus, wrongStatus)
+	}
+	a, _ := json.Marshal(unknown)
+	b, _ := json.Marshal(wrong)
+	if string(a) != string(b) {
+		t.Fatalf("responses differ:\nunknown user:   %s\nwrong password: %s", a, b)
+	}
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"worm/apierror"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Request Models ---
+
+// CreateRuleRequest แบบฟอร์มสร้าง Automation Rule
+// ตัวอย่าง: ความชื้นใน Bin 4 ต่ำกว่า 65 % นาน 10 นาที → เปิดเครื่องพ่นหมอก 2 นาที แล้วเว้น 15 นาที
+type CreateRuleRequest struct {
+	// 1-100 ตัวอักษร
+	Name string `json:"name" example:"Bin 4 humidity" binding:"required"`
+	// active | dry_run (บันทึกว่าจะสั่ง แต่ไม่สั่งจริง) | disabled ไม่ส่ง = dry_run
+	Mode string `json:"mode" example:"dry_run"`
+	// ใช้ค่าเฉลี่ยของทุก Device ใต้ Location นี้ (รวมทุกชั้น)
+	LocationID uint `json:"location_id" example:"4" binding:"required"`
+	// temperature | humidity
+	Metric string `json:"metric" example:"humidity" binding:"required"`
+	// below | above
+	Operator  string  `json:"operator" example:"below" binding:"required"`
+	Threshold float64 `json:"threshold" example:"65"`
+	// เมื่อเงื่อนไขเป็นจริงแล้ว ค่าต้องเลย Threshold ไปอีกเท่านี้จึงกลับเป็นเท็จ (>= 0)
+	Hysteresis float64 `json:"hysteresis" example:"2"`
+	// เงื่อนไขต้องเป็นจริงต่อเนื่องกี่วินาที (0-86400)
+	ForSeconds int `json:"for_seconds" example:"600"`
+	// เว้นกี่วินาทีระหว่างการทำงานแต่ละครั้ง (60-604800)
+	CooldownSeconds int `json:"cooldown_seconds" example:"900" binding:"required"`
+	// Actuator ที่จะสั่ง (องค์กรเดียวกับ Location)
+	ActuatorID uint `json:"actuator_id" example:"1" binding:"required"`
+	// คำสั่ง (เหมือน POST /api/actuators/{id}/commands)
+	Command CommandRequest `json:"command"`
+}
+
+// UpdateRuleRequest แบบฟอร์มแก้ไข Automation Rule (ส่งเฉพาะค่าที่ต้องการแก้)
+type UpdateRuleRequest struct {
+	Name            *string  `json:"name" example:"Bin 4 humidity"`
+	Mode            *string  `json:"mode" example:"active"`
+	LocationID      *uint    `json:"location_id" example:"4"`
+	Metric          *string  `json:"metric" example:"humidity"`
+	Operator        *string  `json:"operator" example:"below"`
+	Threshold       *float64 `json:"threshold" example:"65"`
+	Hysteresis      *float64 `json:"hysteresis" example:"2"`
+	ForSeconds      *int     `json:"for_seconds" example:"600"`
+	CooldownSeconds *int     `json:"cooldown_seconds" example:"900"`
+	ActuatorID      *uint    `json:"actuator_id" example:"1"`
+	// แทนคำสั่งเดิมทั้งชุด
+	Command *CommandRequest `json:"command"`
+}
+
+// --- 2. Handlers ---
+
+// GetAllRulesHandler ดู Automation Rule ทั้งหมด
+// @Summary      ดูรายการ Automation Rule
+// @Description  Rule ขององค์กร (Superadmin เห็นทุกองค์กร) พร้อมสถานะจากการประเมินล่าสุด
+// @Tags         Automation
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array} models.AutomationRule
+// @Failure      401  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /automation/rules [get]
+func (h *Handler) GetAllRulesHandler(c *gin.Context) {
+	rules, err := h.Automation.GetAllRules(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// CreateRuleHandler สร้าง Automation Rule
+// @Summary      สร้าง Automation Rule (Admin Only)
+// @Description  สั่ง Actuator เมื่อค่าเฉลี่ยของ Location (ช่วง automation.reading_window ล่าสุด) เข้าเงื่อนไขต่อเนื่อง for_seconds
+// @Description  ประเมินทุกครั้งที่มีค่าใหม่จาก Device ใต้ Location และทุก automation.interval
+// @Description  Rule ใหม่เป็น dry_run ถ้าไม่ระบุ mode: ดูใน Log ว่าจะสั่งอะไรเมื่อไรก่อนเปิดใช้จริง
+// @Tags         Automation
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body CreateRuleRequest true "ข้อมูล Rule"
+// @Success      200  {object} models.AutomationRule
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem "ไม่พบ Location หรือ Actuator"
+// @Failure      500  {object} apierror.Problem
+// @Router       /automation/rules [post]
+func (h *Handler) CreateRuleHandler(c *gin.Context) {
+	var req CreateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	rule, err := h.Automation.CreateRule(c.Request.Context(), services.RuleInput{
+		Name:            req.Name,
+		Mode:            req.Mode,
+		LocationID:      req.LocationID,
+		Metric:          req.Metric,
+		Operator:        req.Operator,
+		Threshold:       req.Threshold,
+		Hysteresis:      req.Hysteresis,
+		ForSeconds:      req.ForSeconds,
+		CooldownSeconds: req.CooldownSeconds,
+		ActuatorID:      req.ActuatorID,
+		Command:         commandInput(req.Command),
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRuleHandler แก้ไข Automation Rule
+// @Summary      แก้ไข Automation Rule (Admin Only)
+// @Description  แก้ค่าตั้งหรือเปลี่ยน mode แล้วเริ่มนับเงื่อนไขใหม่ (Cooldown จากการทำงานครั้งล่าสุดยังมีผล)
+// @Tags         Automation
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path   int                true  "Rule ID"
+// @Param        request body   UpdateRuleRequest  true  "ข้อมูลที่ต้องการแก้"
+// @Success      200     {object} models.AutomationRule
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      403     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      500     {object} apierror.Problem
+// @Router       /automation/rules/{id} [put]
+func (h *Handler) UpdateRuleHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrRuleNotFound)
+		return
+	}
+
+	var req UpdateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	upd := services.RuleUpdate{
+		Name:            req.Name,
+		Mode:            req.Mode,
+		LocationID:      req.LocationID,
+		Metric:          req.Metric,
+		Operator:        req.Operator,
+		Threshold:       req.Threshold,
+		Hysteresis:      req.Hysteresis,
+		ForSeconds:      req.ForSeconds,
+		CooldownSeconds: req.CooldownSeconds,
+		ActuatorID:      req.ActuatorID,
+	}
+	if req.Command != nil {
+		cmd := commandInput(*req.Command)
+		upd.Command = &cmd
+	}
+	rule, err := h.Automation.UpdateRule(c.Request.Context(), uint(id), upd)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRuleHandler ลบ Automation Rule
+// @Summary      ลบ Automation Rule (Admin Only)
+// @Description  ลบ Rule พร้อม Log ของ Rule (คำสั่งที่ Rule เคยสั่งยังอยู่ในประวัติของ Actuator)
+// @Tags         Automation
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Rule ID"
+// @Success      200  {object} map[string]string
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /automation/rules/{id} [delete]
+func (h *Handler) DeleteRuleHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrRuleNotFound)
+		return
+	}
+
+	rule, err := h.Automation.DeleteRule(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Rule " + rule.Name + " deleted"})
+}
+
+// GetRuleLogHandler Log การทำงานของ Rule
+// @Summary      ดู Log ของ Automation Rule
+// @Description  200 รายการล่าสุด: fired (สั่งแล้ว), dry_run (จะสั่ง), override (ข้ามเพราะ Manual Override), failed
+// @Tags         Automation
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Rule ID"
+// @Success      200  {array} models.AutomationLog
+// @Failure      401  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /automation/rules/{id}/log [get]
+func (h *Handler) GetRuleLogHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrRuleNotFound)
+		return
+	}
+
+	entries, err := h.Automation.RuleLog(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"log": entries})
+}
+
+// --- 3. Helpers ---
+
+func commandInput(req CommandRequest) services.CommandInput {
+	return services.CommandInput{
+		Action:          req.Action,
+		DutyCycle:       req.DutyCycle,
+		DurationSeconds: req.DurationSeconds,
+	}
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"worm/apierror"
+	"worm/models"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Request Models ---
+
+// CreateCalibrationRequest แบบฟอร์มเพิ่ม Calibration (ส่งเฉพาะค่าของ method นั้น)
+//   - offset: ค่าจริง = ค่าดิบ + offset
+//   - linear: ค่าจริง = ค่าดิบ × gain + offset (offset ไม่บังคับ)
+//   - table: ประมาณเส้นตรงระหว่าง points (2-20 จุด เรียงตาม raw)
+type CreateCalibrationRequest struct {
+	// temperature | humidity
+	Metric string `json:"metric" example:"temperature" binding:"required"`
+	// offset | linear | table
+	Method string `json:"method" example:"offset" binding:"required"`
+	// -50 ถึง 50
+	Offset *float64 `json:"offset" example:"-1.5"`
+	// 0.5 ถึง 2
+	Gain   *float64                  `json:"gain"`
+	Points []models.CalibrationPoint `json:"points"`
+	// ไม่ส่ง = ตอนนี้ (ย้อนหลังได้ แล้วเรียก recompute)
+	ValidFrom *time.Time `json:"valid_from" example:"2026-10-01T00:00:00Z"`
+	// ไม่ส่ง = ไม่สิ้นสุด
+	ValidUntil *time.Time `json:"valid_until"`
+	// ไม่เกิน 1000 ตัวอักษร
+	Notes string `json:"notes" example:"checked against a reference thermometer"`
+}
+
+// RecomputeRequest ช่วงเวลาที่ต้องการแก้ค่าใหม่
+type RecomputeRequest struct {
+	From time.Time `json:"from" example:"2026-10-01T00:00:00Z" binding:"required"`
+	// ไม่ส่ง = ตอนนี้
+	To *time.Time `json:"to"`
+}
+
+// --- 2. Handlers ---
+
+// GetCalibrationsHandler ดู Calibration ของ Device
+// @Summary      ดู Calibration ของ Device
+// @Description  ทุก Calibration ของ Device (รวมที่สิ้นสุดแล้ว) เรียงตาม metric และ valid_from
+// @Tags         Calibration
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Device ID"
+// @Success      200  {array} models.Calibration
+// @Failure      401  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /devices/{id}/calibrations [get]
+func (h *Handler) GetCalibrationsHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrDeviceNotFound)
+		return
+	}
+
+	calibrations, err := h.Calibrations.Calibrations(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"calibrations": calibrations})
+}
+
+// CreateCalibrationHandler เพิ่ม Calibration ให้ Device
+// @Summary      เพิ่ม Calibration (Admin Only)
+// @Description  ค่าที่ Device ส่งมาในช่วง [valid_from, valid_until) ถูกแก้ตอนบันทึก ค่าดิบเก็บไว้ใน raw_temp / raw_humidity
+// @Description  Calibration เดิมของ Metric เดียวกันที่ยังไม่สิ้นสุดจะสิ้นสุดที่ valid_from ของตัวใหม่ ช่วงอื่นห้ามซ้อนกัน
+// @Description  ค่าที่บันทึกไปแล้วไม่เปลี่ยน ใช้ POST /api/devices/{id}/calibrations/recompute
+// @Tags         Calibration
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path   int                       true  "Device ID"
+// @Param        request body   CreateCalibrationRequest  true  "ข้อมูล Calibration"
+// @Success      200     {object} models.Calibration
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      403     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      409     {object} apierror.Problem "ช่วงเวลาซ้อนกับ Calibration อื่น"
+// @Failure      500     {object} apierror.Problem
+// @Router       /devices/{id}/calibrations [post]
+func (h *Handler) CreateCalibrationHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrDeviceNotFound)
+		return
+	}
+
+	var req CreateCalibrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	calibration, err := h.Calibrations.CreateCalibration(c.Request.Context(), uint(id), services.CalibrationInput{
+		Metric:     req.Metric,
+		Method:     req.Method,
+		Offset:     req.Offset,
+		Gain:       req.Gain,
+		Points:     req.Points,
+		ValidFrom:  req.ValidFrom,
+		ValidUntil: req.ValidUntil,
+		Notes:      req.Notes,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, calibration)
+}
+
+// DeleteCalibrationHandler ลบ Calibration
+// @Summary      ลบ Calibration (Admin Only)
+// @Description  ค่าที่บันทึกไปแล้วไม่เปลี่ยน ใช้ recompute เพื่อกลับไปใช้ค่าดิบ
+// @Tags         Calibration
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Calibration ID"
+// @Success      200  {object} map[string]string
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /calibrations/{id} [delete]
+func (h *Handler) DeleteCalibrationHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrCalibrationNotFound)
+		return
+	}
+
+	calibration, err := h.Calibrations.DeleteCalibration(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Calibration " + strconv.FormatUint(uint64(calibration.ID), 10) + " deleted"})
+}
+
+// RecomputeCalibrationHandler แก้ค่าย้อนหลังตาม Calibration ปัจจุบัน
+// @Summary      คำนวณค่าย้อนหลังใหม่ (Admin Only)
+// @Description  แก้ temp / humidity ของค่าที่ Device บันทึกในช่วง [from, to) ใหม่จากค่าดิบตาม Calibration ที่ใช้ได้ ณ เวลาที่บันทึก
+// @Description  ใช้หลังเพิ่ม Calibration ย้อนหลังหรือลบ Calibration (Automation Rule ไม่ถูกประเมินซ้ำ)
+// @Tags         Calibration
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path   int               true  "Device ID"
+// @Param        request body   RecomputeRequest  true  "ช่วงเวลา"
+// @Success      200     {object} map[string]int "updated: จำนวนค่าที่เปลี่ยน"
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      403     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      500     {object} apierror.Problem
+// @Router       /devices/{id}/calibrations/recompute [post]
+func (h *Handler) RecomputeCalibrationHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrDeviceNotFound)
+		return
+	}
+
+	var req RecomputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	n, err := h.Calibrations.Recompute(c.Request.Context(), uint(id), req.From, req.To)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": n})
+}
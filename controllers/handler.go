@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"worm/config"
+	"worm/metrics"
+	"worm/models"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler รวม Service ที่ทุก Handler ใช้ สร้างครั้งเดียวใน server.NewRouter
+// Handler ไม่เรียกฐานข้อมูลเอง (ทดสอบได้ด้วย repository/memory)
+type Handler struct {
+	Users         *services.UserService
+	Auth          *services.AuthService
+	Organizations *services.OrganizationService
+	Sensors       *services.SensorService
+	Locations     *services.LocationService
+	Actuators     *services.ActuatorService
+	Automation    *services.AutomationService
+	Schedules     *services.ScheduleService
+	Husbandry     *services.HusbandryService
+	Calibrations  *services.CalibrationService
+	Anomalies     *services.AnomalyService
+	Metrics       *metrics.Metrics
+	// nil = ปิด SSO
+	OIDC   *OIDCAuth
+	Config *config.Config
+	// รายการที่ /readyz ตรวจ
+	ReadyChecks []ReadyCheck
+}
+
+// currentUser User ที่ผ่าน middleware.RequireAPIKey แล้ว
+func currentUser(c *gin.Context) *models.User {
+	return c.MustGet("user").(*models.User)
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"worm/buildinfo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// เวลาสูงสุดที่ /readyz รอการตรวจทั้งหมด (ต้องสั้นกว่า Timeout ของ Load Balancer)
+const readyTimeout = 2 * time.Second
+
+// ReadyCheck สิ่งที่ /readyz ตรวจ เช่น ฐานข้อมูล (Check คืน nil = พร้อม)
+type ReadyCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// --- 1. Response Models ---
+
+// ReadyResponse ผลการตรวจความพร้อม (ok หรือข้อความ Error ของแต่ละรายการ)
+type ReadyResponse struct {
+	Status string            `json:"status" example:"ready"`
+	Checks map[string]string `json:"checks"`
+}
+
+// --- 2. Handlers ---
+
+// HealthHandler Process ยังทำงานอยู่
+// @Summary      Liveness Probe
+// @Description  คืน 200 เสมอถ้า Process ยังตอบได้ (ไม่ตรวจฐานข้อมูล)
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object} map[string]string
+// @Router       /healthz [get]
+func (h *Handler) HealthHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadyHandler พร้อมรับ Request หรือไม่
+// @Summary      Readiness Probe
+// @Description  ตรวจการเชื่อมต่อฐานข้อมูล, Migration และ Background Worker คืน 503 ถ้ามีรายการใดไม่พร้อม
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object} ReadyResponse
+// @Failure      503  {object} ReadyResponse
+// @Router       /readyz [get]
+func (h *Handler) ReadyHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyTimeout)
+	defer cancel()
+
+	resp := ReadyResponse{Status: "ready", Checks: map[string]string{}}
+	status := http.StatusOK
+	for _, rc := range h.ReadyChecks {
+		if err := rc.Check(ctx); err != nil {
+			resp.Checks[rc.Name] = err.Error()
+			resp.Status = "not ready"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		resp.Checks[rc.Name] = "ok"
+	}
+	c.JSON(status, resp)
+}
+
+// VersionHandler เวอร์ชันของ Server
+// @Summary      เวอร์ชันของ Server
+// @Description  Version, Commit และเวลา Build (ใส่ตอน Build ด้วย ldflags)
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object} buildinfo.Info
+// @Router       /version [get]
+func (h *Handler) VersionHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, buildinfo.Get())
+}
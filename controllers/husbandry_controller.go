@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"worm/apierror"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Request Models ---
+
+// RecordEventRequest แบบฟอร์มบันทึกการดูแล Bin (ส่งเฉพาะค่าของ kind นั้น)
+//   - feeding: feed_type, weight_kg
+//   - bedding: bedding_material, weight_kg (ไม่บังคับ)
+//   - moisture: water_liters
+//   - harvest: castings_kg, worms_kg (ไม่บังคับ)
+//   - population: worm_count
+type RecordEventRequest struct {
+	// feeding | bedding | moisture | harvest | population
+	Kind string `json:"kind" example:"feeding" binding:"required"`
+	// ไม่ส่ง = ตอนนี้ (ห้ามอยู่ในอนาคต)
+	OccurredAt *time.Time `json:"occurred_at" example:"2026-10-19T08:00:00Z"`
+	// ไม่เกิน 1000 ตัวอักษร
+	Notes           string   `json:"notes" example:"coffee grounds from the cafe"`
+	FeedType        string   `json:"feed_type" example:"vegetable scraps"`
+	BeddingMaterial string   `json:"bedding_material" example:"shredded cardboard"`
+	WeightKg        *float64 `json:"weight_kg" example:"2.5"`
+	WaterLiters     *float64 `json:"water_liters" example:"1.5"`
+	CastingsKg      *float64 `json:"castings_kg" example:"12"`
+	WormsKg         *float64 `json:"worms_kg" example:"0.8"`
+	WormCount       *int64   `json:"worm_count" example:"4000"`
+}
+
+// --- 2. Handlers ---
+
+// GetEventsHandler ดูบันทึกการดูแล
+// @Summary      ดูบันทึกการดูแล Bin
+// @Description  บันทึกของ Location นี้และทุก Bin ที่อยู่ใต้ เรียงตาม occurred_at
+// @Tags         Husbandry
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path   int     true   "Location ID"
+// @Param        from  query  string  false  "เริ่ม (RFC 3339) ไม่ส่ง = ไม่จำกัด"
+// @Param        to    query  string  false  "สิ้นสุด (RFC 3339, ไม่รวม) ไม่ส่ง = ไม่จำกัด"
+// @Param        kind  query  string  false  "feeding | bedding | moisture | harvest | population"
+// @Success      200  {array} models.HusbandryEvent
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /locations/{id}/events [get]
+func (h *Handler) GetEventsHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrLocationNotFound)
+		return
+	}
+
+	from, err := timeQuery(c, "from")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	to, err := timeQuery(c, "to")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	events, err := h.Husbandry.Events(c.Request.Context(), uint(id), from, to, c.Query("kind"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// RecordEventHandler บันทึกการดูแล Bin
+// @Summary      บันทึกการดูแล Bin
+// @Description  ให้อาหาร เปลี่ยนวัสดุรองพื้น เติมน้ำ เก็บเกี่ยว หรือประมาณจำนวนไส้เดือน (เฉพาะ Location ชนิด bin)
+// @Description  ส่งได้เฉพาะค่าของ kind นั้น ค่าอื่นต้องว่าง น้ำหนัก / ปริมาณน้ำต้องมากกว่า 0 และไม่เกิน 1000
+// @Tags         Husbandry
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path   int                 true  "Location ID (Bin)"
+// @Param        request body   RecordEventRequest  true  "ข้อมูลการดูแล"
+// @Success      200     {object} models.HusbandryEvent
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      500     {object} apierror.Problem
+// @Router       /locations/{id}/events [post]
+func (h *Handler) RecordEventHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrLocationNotFound)
+		return
+	}
+
+	var req RecordEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	user := currentUser(c)
+	event, err := h.Husbandry.RecordEvent(c.Request.Context(), uint(id), services.EventInput{
+		Kind:            req.Kind,
+		OccurredAt:      req.OccurredAt,
+		Notes:           req.Notes,
+		FeedType:        req.FeedType,
+		BeddingMaterial: req.BeddingMaterial,
+		WeightKg:        req.WeightKg,
+		WaterLiters:     req.WaterLiters,
+		CastingsKg:      req.CastingsKg,
+		WormsKg:         req.WormsKg,
+		WormCount:       req.WormCount,
+	}, &user.ID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, event)
+}
+
+// DeleteEventHandler ลบบันทึกการดูแล
+// @Summary      ลบบันทึกการดูแล (Admin Only)
+// @Description  ใช้แก้บันทึกที่ผิด (บันทึกใหม่แทน)
+// @Tags         Husbandry
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Event ID"
+// @Success      200  {object} map[string]string
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /events/{id} [delete]
+func (h *Handler) DeleteEventHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrEventNotFound)
+		return
+	}
+
+	event, err := h.Husbandry.DeleteEvent(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Event " + strconv.FormatUint(uint64(event.ID), 10) + " deleted"})
+}
+
+// GetTimelineHandler ค่า Sensor และบันทึกการดูแลรวมกันตามเวลา
+// @Summary      ดู Timeline ของ Location
+// @Description  ค่า Sensor ทุกค่า (type reading) และบันทึกการดูแล (type event) ของ Location นี้และทุก Bin ที่อยู่ใต้ เรียงตามเวลา
+// @Description  ใช้ดูว่าการให้อาหาร / เติมน้ำส่งผลต่ออุณหภูมิและความชื้นอย่างไร ช่วงยาวได้ไม่เกิน 7 วัน
+// @Tags         Husbandry
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path   int     true   "Location ID"
+// @Param        from  query  string  false  "เริ่ม (RFC 3339) ไม่ส่ง = 24 ชั่วโมงก่อน to"
+// @Param        to    query  string  false  "สิ้นสุด (RFC 3339, ไม่รวม) ไม่ส่ง = ตอนนี้"
+// @Success      200  {array} models.TimelineEntry
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /locations/{id}/timeline [get]
+func (h *Handler) GetTimelineHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrLocationNotFound)
+		return
+	}
+
+	from, err := timeQuery(c, "from")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	to, err := timeQuery(c, "to")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	entries, err := h.Husbandry.Timeline(c.Request.Context(), uint(id), from, to)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"timeline": entries})
+}
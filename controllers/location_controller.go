@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"worm/apierror"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Request Models ---
+
+// CreateLocationRequest แบบฟอร์มสร้าง Location
+type CreateLocationRequest struct {
+	// 1-100 ตัวอักษร ไม่ซ้ำกับพี่น้อง (ไม่สนตัวพิมพ์)
+	Name string `json:"name" example:"Shed 2" binding:"required"`
+	// ตั้งเองได้ (farm, shed, row, ...) มีเพียง bin ที่ติดตั้ง Device ได้และมีลูกไม่ได้
+	Kind string `json:"kind" example:"shed" binding:"required"`
+	// ไม่ส่ง = ชั้นบนสุด
+	ParentID *uint `json:"parent_id" example:"1"`
+	// Superadmin ต้องระบุเมื่อสร้างชั้นบนสุด (นอกนั้นใช้องค์กรของผู้เรียก / ของ Parent)
+	OrganizationID uint `json:"organization_id" example:"1"`
+}
+
+// UpdateLocationRequest แบบฟอร์มแก้ไข Location (ส่งเฉพาะค่าที่ต้องการแก้)
+type UpdateLocationRequest struct {
+	Name *string `json:"name" example:"Shed 2B"`
+	Kind *string `json:"kind" example:"shed"`
+	// ย้ายไปไว้ใต้ Location นี้ (0 = ย้ายเป็นชั้นบนสุด)
+	ParentID *uint `json:"parent_id" example:"1"`
+}
+
+// AssignDeviceRequest แบบฟอร์มติดตั้ง / ถอด Device
+type AssignDeviceRequest struct {
+	// Bin ที่ติดตั้ง (null = ถอดออก)
+	LocationID *uint `json:"location_id" example:"7"`
+	// เวลาที่ติดตั้งจริง (RFC 3339) ไม่ส่ง = ตอนนี้
+	StartedAt *time.Time `json:"started_at" example:"2026-10-19T08:00:00Z"`
+}
+
+// --- 2. Handlers ---
+
+// GetAllLocationsHandler ดู Location ทั้งหมด
+// @Summary      ดูรายการ Location
+// @Description  Location ทั้งหมดขององค์กร (Superadmin เห็นทุกองค์กร) ประกอบเป็นต้นไม้ได้จาก parent_id
+// @Tags         Location
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array} models.Location
+// @Failure      401  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /locations [get]
+func (h *Handler) GetAllLocationsHandler(c *gin.Context) {
+	locations, err := h.Locations.GetAllLocations(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"locations": locations})
+}
+
+// CreateLocationHandler สร้าง Location
+// @Summary      สร้าง Location (Admin Only)
+// @Description  สร้าง Location ใต้ parent_id เช่น ฟาร์ม → โรงเรือน → แถว → Bin (Bin มี Location ลูกไม่ได้)
+// @Tags         Location
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body CreateLocationRequest true "ข้อมูล Location"
+// @Success      200  {object} models.Location
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem "ไม่พบ Parent"
+// @Failure      409  {object} apierror.Problem "ชื่อซ้ำกับพี่น้อง (ไม่สนตัวพิมพ์)"
+// @Failure      500  {object} apierror.Problem
+// @Router       /locations [post]
+func (h *Handler) CreateLocationHandler(c *gin.Context) {
+	var req CreateLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	location, err := h.Locations.CreateLocation(c.Request.Context(), services.LocationInput{
+		Name:           req.Name,
+		Kind:           req.Kind,
+		ParentID:       req.ParentID,
+		OrganizationID: req.OrganizationID,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, location)
+}
+
+// UpdateLocationHandler แก้ไข Location
+// @Summary      แก้ไข / ย้าย Location (Admin Only)
+// @Description  เปลี่ยนชื่อ ชนิด หรือย้ายไปใต้ Location อื่น (ลูกหลานย้ายตาม) ค่า Sensor ที่บันทึกแล้วไม่เปลี่ยน
+// @Description  Bin ที่เคยติดตั้ง Device เปลี่ยนเป็นชนิดอื่นไม่ได้
+// @Tags         Location
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path   int                    true  "Location ID"
+// @Param        request body   UpdateLocationRequest  true  "ข้อมูลที่ต้องการแก้"
+// @Success      200     {object} models.Location
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      403     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      409     {object} apierror.Problem
+// @Failure      500     {object} apierror.Problem
+// @Router       /locations/{id} [put]
+func (h *Handler) UpdateLocationHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrLocationNotFound)
+		return
+	}
+
+	var req UpdateLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	location, err := h.Locations.UpdateLocation(c.Request.Context(), uint(id), services.LocationUpdate{
+		Name:     req.Name,
+		Kind:     req.Kind,
+		ParentID: req.ParentID,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, location)
+}
+
+// DeleteLocationHandler ลบ Location
+// @Summary      ลบ Location (Admin Only)
+// @Description  ลบได้เฉพาะ Location ที่ไม่มีลูกและไม่เคยติดตั้ง Device (เก็บประวัติของข้อมูลย้อนหลังไว้)
+// @Tags         Location
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Location ID"
+// @Success      200  {object} map[string]string
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      409  {object} apierror.Problem "ยังมี Location ลูก เคยติดตั้ง Device หรือมี Automation Rule ใช้อยู่"
+// @Failure      500  {object} apierror.Problem
+// @Router       /locations/{id} [delete]
+func (h *Handler) DeleteLocationHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrLocationNotFound)
+		return
+	}
+
+	location, err := h.Locations.DeleteLocation(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Location " + location.Name + " deleted"})
+}
+
+// GetLocationStatsHandler สรุปค่า Sensor ของ Location
+// @Summary      สรุปค่า Sensor ตาม Location
+// @Description  ค่าเฉลี่ย / ต่ำสุด / สูงสุดของทุก Device ใต้ Location นี้ (ทุกชั้น) แยกตาม Location ลูกด้วย
+// @Description  ค่าแต่ละค่านับให้ Bin ที่ Device ติดตั้งอยู่ ณ เวลาที่บันทึก (ย้าย Device แล้วข้อมูลเก่าไม่ย้ายตาม)
+// @Description  ส่ง interval (เช่น 15m, 1h อย่างน้อย 1m ไม่เกิน 1000 ช่วง) เพื่อรับค่ารายช่วงใน series
+// @Tags         Location
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id        path   int     true   "Location ID"
+// @Param        from      query  string  false  "เริ่ม (RFC 3339) ไม่ส่ง = 24 ชั่วโมงก่อน to"
+// @Param        to        query  string  false  "สิ้นสุด (RFC 3339, ไม่รวม) ไม่ส่ง = ตอนนี้"
+// @Param        interval  query  string  false  "ความยาวแต่ละช่วง เช่น 1h"
+// @Success      200  {object} models.LocationStats
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /locations/{id}/stats [get]
+func (h *Handler) GetLocationStatsHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrLocationNotFound)
+		return
+	}
+
+	from, err := timeQuery(c, "from")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	to, err := timeQuery(c, "to")
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	var interval time.Duration
+	if s := c.Query("interval"); s != "" {
+		if interval, err = time.ParseDuration(s); err != nil {
+			_ = c.Error(apierror.New(http.StatusBadRequest, apierror.CodeValidation, "Query parameter is invalid").
+				WithField("interval", "must be a duration such as 15m or 1h"))
+			return
+		}
+	}
+
+	stats, err := h.Locations.Stats(c.Request.Context(), uint(id), from, to, interval)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// AssignDeviceHandler ติดตั้ง Device ที่ Bin
+// @Summary      ติดตั้ง / ย้าย / ถอด Device (Admin Only)
+// @Description  ปิดการติดตั้งเดิมที่ started_at แล้วเริ่มติดตั้งที่ Bin ใหม่ (location_id null = ถอดออก)
+// @Description  ค่าที่บันทึกก่อน started_at ยังนับให้ Location เดิม
+// @Tags         Location
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path   int                  true  "Device ID"
+// @Param        request body   AssignDeviceRequest  true  "Bin และเวลาที่ติดตั้ง"
+// @Success      200     {object} models.DeviceAssignment "assignment (null เมื่อถอดออก)"
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      403     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      500     {object} apierror.Problem
+// @Router       /devices/{id}/location [put]
+func (h *Handler) AssignDeviceHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrDeviceNotFound)
+		return
+	}
+
+	var req AssignDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	assignment, err := h.Locations.AssignDevice(c.Request.Context(), uint(id), req.LocationID, req.StartedAt)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"assignment": assignment})
+}
+
+// GetDeviceAssignmentsHandler ประวัติการติดตั้งของ Device
+// @Summary      ดูประวัติการติดตั้ง Device
+// @Description  ทุกการติดตั้งของ Device เรียงจากเก่าไปใหม่ (ended_at null = ยังติดตั้งอยู่)
+// @Tags         Location
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Device ID"
+// @Success      200  {array} models.DeviceAssignment
+// @Failure      401  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /devices/{id}/assignments [get]
+func (h *Handler) GetDeviceAssignmentsHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrDeviceNotFound)
+		return
+	}
+
+	assignments, err := h.Locations.DeviceAssignments(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"assignments": assignments})
+}
+
+// --- 3. Helpers ---
+
+// timeQuery อ่าน Query Parameter แบบ RFC 3339 (ไม่ส่ง = nil)
+func timeQuery(c *gin.Context, name string) (*time.Time, error) {
+	s := c.Query(name)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, apierror.New(http.StatusBadRequest, apierror.CodeValidation, "Query parameter is invalid").
+			WithField(name, "must be an RFC 3339 time such as 2026-10-19T08:00:00Z")
+	}
+	return &t, nil
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"worm/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler Metric สำหรับ Prometheus
+// @Summary      Prometheus Metrics
+// @Description  Request / Latency ต่อ Route, การรับค่า Sensor ต่อ Device, ค่าที่ถูกปฏิเสธ, Connection Pool และการยืนยันตัวตนที่ไม่ผ่าน (Text Format)
+// @Description  Metric ของ Device แยกด้วย Label organization (ID ขององค์กร) และ device จึงมีค่าของทุกองค์กร
+// @Description  ชื่อ Device มาจาก Client จึงแยก Series ได้ไม่เกิน 1000 Device ต่อ Metric ที่เกินรวมอยู่ใน device=other
+// @Description  ถ้าตั้ง metrics.token ต้องส่ง Authorization: Bearer <token> (บังคับใน Production)
+// @Tags         Health
+// @Produce      plain
+// @Success      200  {string} string
+// @Failure      401  {object} apierror.Problem
+// @Router       /metrics [get]
+func (h *Handler) MetricsHandler(c *gin.Context) {
+	if token := h.Config.Metrics.Token; token != "" {
+		given := c.GetHeader("Authorization")
+		if subtle.ConstantTimeCompare([]byte(given), []byte("Bearer "+token)) != 1 {
+			_ = c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid metrics token"))
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	h.Metrics.WriteText(c.Writer)
+}
@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"worm/apierror"
+	"worm/config"
+	"worm/utils"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// Cookie เก็บ state / nonce / PKCE verifier ระหว่าง Redirect ไป IdP และกลับมา
+const oidcCookieName = "worm_oidc"
+
+// อายุของ Cookie (วินาที) = เวลาที่ให้ User ล็อกอินที่ IdP ให้เสร็จ
+const oidcCookieMaxAge = 600
+
+// ErrOIDCNotAllowed User ไม่อยู่ในกลุ่มที่อนุญาตให้เข้าระบบ
+var ErrOIDCNotAllowed = errors.New("user is not a member of an allowed group")
+
+// OIDCAuth ตัวเชื่อมต่อ OpenID Connect Provider (สร้างครั้งเดียวตอนเริ่มระบบ)
+type OIDCAuth struct {
+	OAuth2   oauth2.Config
+	Verifier *oidc.IDTokenVerifier
+
+	// ชื่อ Claim ที่เก็บรายชื่อกลุ่ม (ค่าเริ่มต้น "groups")
+	GroupsClaim string
+	// กลุ่มใน IdP ที่จะได้ Role admin
+	AdminGroups []string
+	// ถ้ากำหนด: ต้องอยู่ในกลุ่มใดกลุ่มหนึ่ง (หรือ AdminGroups) ถึงจะเข้าได้
+	UserGroups []string
+	// ชื่อองค์กรของบัญชีที่สร้างอัตโนมัติ (AdminGroups ได้ Role admin ขององค์กรนี้)
+	Organization string
+}
+
+// oidcClaims Claim ที่ใช้จาก ID Token
+type oidcClaims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// --- 1. Setup ---
+
+// NewOIDCAuth โหลด Discovery Document ของ IdP ตาม cfg
+// คืน nil ถ้าไม่ได้ตั้ง oidc.issuer (ปิด SSO)
+func NewOIDCAuth(ctx context.Context, cfg config.OIDCConfig) (*OIDCAuth, error) {
+	if cfg.Issuer == "" {
+		return nil, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	return &OIDCAuth{
+		OAuth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email", "groups"},
+		},
+		Verifier:    provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		GroupsClaim: cfg.GroupsClaim,
+		AdminGroups: cfg.AdminGroups,
+		UserGroups:  cfg.UserGroups,
+
+		Organization: cfg.Organization,
+	}, nil
+}
+
+// --- 2. Handlers ---
+
+// OIDCLoginHandler เริ่ม SSO Login
+// @Summary      เริ่มล็อกอินผ่าน SSO (OpenID Connect)
+// @Description  Redirect ไปหน้า Login ของ Identity Provider (Authorization Code + PKCE)
+// @Tags         Auth
+// @Success      302
+// @Router       /login/oidc [get]
+func (h *Handler) OIDCLoginHandler(c *gin.Context) {
+	oa := h.OIDC
+	state, err := randomToken()
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	secure := strings.HasPrefix(oa.OAuth2.RedirectURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcCookieName, state+"."+nonce+"."+verifier, oidcCookieMaxAge, "/", "", secure, true)
+
+	authURL := oa.OAuth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallbackHandler รับ Code กลับจาก IdP
+// @Summary      Callback จาก SSO
+// @Description  แลก Authorization Code เป็น ID Token, สร้าง User อัตโนมัติเมื่อเข้าครั้งแรก และคืน API Key
+// @Tags         Auth
+// @Produce      json
+// @Param        code   query  string  true  "Authorization Code"
+// @Param        state  query  string  true  "State"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      409  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /login/oidc/callback [get]
+func (h *Handler) OIDCCallbackHandler(c *gin.Context) {
+	oa := h.OIDC
+	cookie, err := c.Cookie(oidcCookieName)
+	// ลบ Cookie ทันที ใช้ได้ครั้งเดียว
+	c.SetCookie(oidcCookieName, "", -1, "/", "", false, true)
+	if err != nil {
+		_ = c.Error(apierror.New(http.StatusBadRequest, apierror.CodeBadRequest, "Login session expired, please start again"))
+		return
+	}
+
+	parts := strings.Split(cookie, ".")
+	if len(parts) != 3 || parts[0] != c.Query("state") {
+		_ = c.Error(apierror.New(http.StatusBadRequest, apierror.CodeBadRequest, "Invalid state"))
+		return
+	}
+	nonce, verifier := parts[1], parts[2]
+
+	if errParam := c.Query("error"); errParam != "" {
+		_ = c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Identity provider error: "+errParam))
+		return
+	}
+
+	ctx := c.Request.Context()
+	token, err := oa.OAuth2.Exchange(ctx, c.Query("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		_ = c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Failed to exchange authorization code"))
+		return
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		_ = c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "No id_token in token response"))
+		return
+	}
+	idToken, err := oa.Verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		_ = c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid ID token"))
+		return
+	}
+	if idToken.Nonce != nonce {
+		_ = c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid nonce"))
+		return
+	}
+
+	var claims oidcClaims
+	var rawClaims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		_ = c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid ID token claims"))
+		return
+	}
+	if err := idToken.Claims(&rawClaims); err != nil {
+		_ = c.Error(apierror.New(http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid ID token claims"))
+		return
+	}
+
+	role, err := oa.RoleForGroups(groupsFromClaims(rawClaims, oa.GroupsClaim))
+	if err != nil {
+		_ = c.Error(apierror.New(http.StatusForbidden, apierror.CodeForbidden, "User is not a member of an allowed group"))
+		return
+	}
+
+	user, err := h.Auth.ProvisionOIDCUser(ctx, claims.Subject, oidcUsername(claims), role, oa.Organization)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Login successful",
+		"username": user.Username,
+		"role":     user.Role,
+		"api_key":  user.APIKey,
+	})
+}
+
+// --- 3. Internal Logic ---
+
+// RoleForGroups แปลงกลุ่มจาก IdP เป็น Role ของระบบ
+func (oa *OIDCAuth) RoleForGroups(groups []string) (string, error) {
+	if intersects(groups, oa.AdminGroups) {
+		return "admin", nil
+	}
+	if len(oa.UserGroups) > 0 && !intersects(groups, oa.UserGroups) {
+		return "", ErrOIDCNotAllowed
+	}
+	return "user", nil
+}
+
+func oidcUsername(claims oidcClaims) string {
+	if claims.PreferredUsername != "" {
+		return claims.PreferredUsername
+	}
+	if claims.Email != "" {
+		return claims.Email
+	}
+	return claims.Subject
+}
+
+// groupsFromClaims รองรับทั้ง Claim แบบ Array และ String คั่นด้วย comma
+func groupsFromClaims(claims map[string]interface{}, name string) []string {
+	switch v := claims[name].(type) {
+	case []interface{}:
+		groups := make([]string, 0, len(v))
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+		return groups
+	case string:
+		return utils.SplitList(v)
+	}
+	return nil
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,350 @@
+package controllers_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"worm/config"
+	"worm/controllers"
+	"worm/metrics"
+	"worm/middleware"
+	"worm/models"
+	"worm/repository"
+	"worm/repository/memory"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const oidcTestClientID = "worm-test"
+
+// mockIssuer OpenID Provider จำลอง: Discovery, JWKS และ Token Endpoint ที่ตรวจ PKCE
+// Code ต้องลงทะเบียนผ่าน authorize ก่อน (แทนการที่ User ล็อกอินที่ IdP)
+type mockIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	codes  map[string]mockGrant
+	tokens int
+}
+
+// mockGrant สิ่งที่ IdP จำไว้ตอนออก Code
+type mockGrant struct {
+	challenge string
+	nonce     string
+	claims    map[string]interface{}
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	m := &mockIssuer{t: t, key: key, codes: map[string]mockGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                m.server.URL,
+			"authorization_endpoint":                m.server.URL + "/authorize",
+			"token_endpoint":                        m.server.URL + "/token",
+			"jwks_uri":                              m.server.URL + "/keys",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize ทำหน้าที่ IdP หลัง User ล็อกอินสำเร็จ: จำ PKCE Challenge / Nonce จาก authURL แล้วคืน Code
+func (m *mockIssuer) authorize(authURL string, claims map[string]interface{}) string {
+	m.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatalf("parse auth url: %v", err)
+	}
+	q := u.Query()
+	if got := u.Scheme + "://" + u.Host + u.Path; got != m.server.URL+"/authorize" {
+		m.t.Fatalf("redirected to %s, want the discovered authorization endpoint", got)
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		m.t.Fatalf("auth url without an S256 PKCE challenge: %s", authURL)
+	}
+	if q.Get("client_id") != oidcTestClientID || q.Get("state") == "" || q.Get("nonce") == "" {
+		m.t.Fatalf("auth url missing client_id / state / nonce: %s", authURL)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code := "code-" + q.Get("state")
+	m.codes[code] = mockGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), claims: claims}
+	return code
+}
+
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	m.tokens++
+	grant, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   m.server.URL,
+		"aud":   oidcTestClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": grant.nonce,
+	}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+	writeJSON(w, map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     m.sign(claims),
+	})
+}
+
+// sign ออก JWT แบบ RS256 ด้วยกุญแจของ Issuer
+func (m *mockIssuer) sign(claims map[string]interface{}) string {
+	m.t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		m.t.Fatalf("marshal claims: %v", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	if err != nil {
+		m.t.Fatalf("sign: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (m *mockIssuer) tokenRequests() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tokens
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// oidcFixture Router ที่มีแค่ Route ของ SSO ต่อกับ mockIssuer และ memory Store
+type oidcFixture struct {
+	issuer *mockIssuer
+	store  *repository.Store
+	router *gin.Engine
+}
+
+func newOIDCFixture(t *testing.T) *oidcFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	issuer := newMockIssuer(t)
+	store := memory.NewStore()
+	if err := store.Organizations.Create(repository.AllTenants(context.Background()), &models.Organization{Name: "Green Farm"}); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+
+	oa, err := controllers.NewOIDCAuth(context.Background(), config.OIDCConfig{
+		Issuer:       issuer.server.URL,
+		ClientID:     oidcTestClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://worm.test/login/oidc/callback",
+		GroupsClaim:  "groups",
+		AdminGroups:  []string{"worm-admins"},
+		Organization: "Green Farm",
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCAuth: %v", err)
+	}
+
+	h := &controllers.Handler{
+		Auth:    services.NewAuthService(store, config.AuthConfig{BcryptCost: bcrypt.MinCost}),
+		Metrics: metrics.New(),
+		OIDC:    oa,
+	}
+	r := gin.New()
+	r.Use(middleware.Errors())
+	r.GET("/login/oidc", h.OIDCLoginHandler)
+	r.GET("/login/oidc/callback", h.OIDCCallbackHandler)
+	return &oidcFixture{issuer: issuer, store: store, router: r}
+}
+
+// start เรียก /login/oidc คืน URL ของ IdP และ Cookie ที่ได้
+func (f *oidcFixture) start(t *testing.T) (string, *http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login/oidc", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login: status %d, want 302", w.Code)
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "worm_oidc" {
+			return w.Header().Get("Location"), cookie
+		}
+	}
+	t.Fatal("login did not set the worm_oidc cookie")
+	return "", nil
+}
+
+// callback เรียก /login/oidc/callback แบบที่ Browser ทำหลัง IdP Redirect กลับมา
+func (f *oidcFixture) callback(t *testing.T, cookie *http.Cookie, state, code string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/login/oidc/callback?"+url.Values{"state": {state}, "code": {code}}.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", w.Body.String(), err)
+	}
+	return w.Code, body
+}
+
+// login ล็อกอินครบรอบด้วย claims ที่ IdP ใส่ใน ID Token
+func (f *oidcFixture) login(t *testing.T, claims map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	authURL, cookie := f.start(t)
+	u, _ := url.Parse(authURL)
+	code := f.issuer.authorize(authURL, claims)
+	return f.callback(t, cookie, u.Query().Get("state"), code)
+}
+
+func TestOIDCDiscovery(t *testing.T) {
+	f := newOIDCFixture(t)
+	authURL, cookie := f.start(t)
+	f.issuer.authorize(authURL, nil)
+
+	// Cookie = state.nonce.verifier และตรงกับที่ส่งไป IdP
+	parts := strings.Split(cookie.Value, ".")
+	u, _ := url.Parse(authURL)
+	if len(parts) != 3 || parts[0] != u.Query().Get("state") || parts[1] != u.Query().Get("nonce") {
+		t.Fatalf("cookie %q does not carry the state and nonce sent to the issuer", cookie.Value)
+	}
+	if !cookie.HttpOnly {
+		t.Fatal("worm_oidc cookie must be HttpOnly")
+	}
+
+	if oa, err := controllers.NewOIDCAuth(context.Background(), config.OIDCConfig{}); oa != nil || err != nil {
+		t.Fatalf("no issuer: got %v, %v, want SSO disabled", oa, err)
+	}
+	if _, err := controllers.NewOIDCAuth(context.Background(), config.OIDCConfig{Issuer: f.issuer.server.URL + "/other"}); err == nil {
+		t.Fatal("issuer without a discovery document: want an error")
+	}
+}
+
+func TestOIDCLoginProvisionsAndLinksUser(t *testing.T) {
+	f := newOIDCFixture(t)
+	ctx := repository.AllTenants(context.Background())
+
+	status, body := f.login(t, map[string]interface{}{"sub": "idp-42", "preferred_username": "somchai", "groups": []string{"staff"}})
+	if status != http.StatusOK {
+		t.Fatalf("first login: status %d %v", status, body)
+	}
+	if body["username"] != "somchai" || body["role"] != "user" || body["api_key"] == "" {
+		t.Fatalf("first login: unexpected body %v", body)
+	}
+	user, err := f.store.Users.FindByOIDCSubject(ctx, "idp-42")
+	if err != nil {
+		t.Fatalf("user not linked to the subject: %v", err)
+	}
+	if !user.PasswordLoginDisabled || user.OrganizationID == nil {
+		t.Fatalf("provisioned user: password login disabled %v, organization %v", user.PasswordLoginDisabled, user.OrganizationID)
+	}
+
+	// ครั้งต่อไปหาจาก Subject (ชื่อใน IdP เปลี่ยนได้) และ Role ตามกลุ่มล่าสุด
+	status, body = f.login(t, map[string]interface{}{"sub": "idp-42", "preferred_username": "somchai.k", "groups": []string{"worm-admins"}})
+	if status != http.StatusOK {
+		t.Fatalf("second login: status %d %v", status, body)
+	}
+	if body["username"] != "somchai" || body["role"] != "admin" || body["api_key"] != user.APIKey {
+		t.Fatalf("second login did not resolve to the linked user: %v", body)
+	}
+
+	// Subject ใหม่ที่ชื่อชนกับบัญชีเดิมต้องไม่ได้บัญชีนั้นไป
+	status, body = f.login(t, map[string]interface{}{"sub": "idp-99", "preferred_username": "somchai"})
+	if status != http.StatusConflict {
+		t.Fatalf("username taken by another subject: status %d %v, want 409", status, body)
+	}
+}
+
+func TestOIDCCallbackRejectsBadState(t *testing.T) {
+	f := newOIDCFixture(t)
+	authURL, cookie := f.start(t)
+	code := f.issuer.authorize(authURL, map[string]interface{}{"sub": "idp-42"})
+
+	if status, body := f.callback(t, cookie, "forged", code); status != http.StatusBadRequest {
+		t.Fatalf("forged state: status %d %v, want 400", status, body)
+	}
+	u, _ := url.Parse(authURL)
+	if status, body := f.callback(t, nil, u.Query().Get("state"), code); status != http.StatusBadRequest {
+		t.Fatalf("missing cookie: status %d %v, want 400", status, body)
+	}
+	// State ไม่ผ่าน = ไม่แลก Code กับ IdP เลย
+	if n := f.issuer.tokenRequests(); n != 0 {
+		t.Fatalf("token endpoint called %d times on a rejected state", n)
+	}
+}
+
+func TestOIDCCallbackRejectsWrongVerifier(t *testing.T) {
+	f := newOIDCFixture(t)
+	authURL, cookie := f.start(t)
+	code := f.issuer.authorize(authURL, map[string]interface{}{"sub": "idp-42"})
+
+	// Cookie ที่ state ถูกแต่ verifier ไม่ใช่ตัวที่สร้าง Challenge
+	parts := strings.Split(cookie.Value, ".")
+	cookie.Value = parts[0] + "." + parts[1] + ".not-the-verifier-not-the-verifier-not-the-verifier"
+	if status, body := f.callback(t, cookie, parts[0], code); status != http.StatusUnauthorized {
+		t.Fatalf("wrong PKCE verifier: status %d %v, want 401", status, body)
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"net/http"
+	"worm/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Request Models ---
+
+// CreateOrganizationRequest แบบฟอร์มสร้างองค์กร
+type CreateOrganizationRequest struct {
+	// 1-100 ตัวอักษร ไม่ซ้ำ (ไม่สนตัวพิมพ์)
+	Name string `json:"name" example:"Green Farm" binding:"required"`
+}
+
+// --- 2. Handlers ---
+
+// CreateOrganizationHandler สร้างองค์กรใหม่
+// @Summary      สร้างองค์กรใหม่ (Superadmin Only)
+// @Description  สร้างองค์กร (Tenant) ใหม่ จากนั้นสร้าง Admin ขององค์กรด้วย /register พร้อม organization_id
+// @Tags         Organization
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body CreateOrganizationRequest true "ข้อมูลองค์กร"
+// @Success      200  {object} models.Organization
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      409  {object} apierror.Problem "ชื่อองค์กรซ้ำ (ไม่สนตัวพิมพ์)"
+// @Failure      500  {object} apierror.Problem
+// @Router       /organizations [post]
+func (h *Handler) CreateOrganizationHandler(c *gin.Context) {
+	var req CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	org, err := h.Organizations.CreateOrganization(c.Request.Context(), req.Name)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if err := h.Auth.RecordAudit(c.Request.Context(), currentUser(c).ID, "organization.create", nil, "created organization "+org.Name); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// GetAllOrganizationsHandler ดูรายชื่อองค์กร
+// @Summary      ดูรายชื่อองค์กร (Admin Only)
+// @Description  Superadmin เห็นทุกองค์กร ส่วน Admin ขององค์กรเห็นเฉพาะองค์กรตัวเอง
+// @Tags         Organization
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array} models.Organization
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /organizations [get]
+func (h *Handler) GetAllOrganizationsHandler(c *gin.Context) {
+	orgs, err := h.Organizations.GetAllOrganizations(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"organizations": orgs})
+}
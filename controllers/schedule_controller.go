@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"worm/apierror"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Request Models ---
+
+// CreateScheduleRequest แบบฟอร์มสร้าง Schedule
+type CreateScheduleRequest struct {
+	// 1-100 ตัวอักษร
+	Name string `json:"name" example:"Morning ventilation" binding:"required"`
+	// Cron 5 ช่อง (minute hour day-of-month month day-of-week) หรือ @daily, @hourly, ...
+	Cron string `json:"cron" example:"0 6 * * *" binding:"required"`
+	// IANA Time Zone ที่ใช้อ่าน Cron (ไม่ส่ง = UTC)
+	TimeZone string `json:"time_zone" example:"Asia/Bangkok"`
+	// ไม่ส่ง = เปิด
+	Enabled *bool `json:"enabled" example:"true"`
+	// actuator_command | prune_sensor_data
+	Job string `json:"job" example:"actuator_command" binding:"required"`
+	// เฉพาะ actuator_command
+	ActuatorID *uint           `json:"actuator_id" example:"1"`
+	Command    *CommandRequest `json:"command"`
+	// เฉพาะ prune_sensor_data: ลบค่า Sensor ที่เก่ากว่ากี่วัน (1-3650)
+	RetentionDays *int `json:"retention_days" example:"90"`
+	// Superadmin ต้องระบุสำหรับ prune_sensor_data (นอกนั้นใช้องค์กรของผู้เรียก / ของ Actuator)
+	OrganizationID uint `json:"organization_id" example:"1"`
+}
+
+// UpdateScheduleRequest แบบฟอร์มแก้ไข Schedule (ส่งเฉพาะค่าที่ต้องการแก้)
+type UpdateScheduleRequest struct {
+	Name       *string `json:"name" example:"Morning ventilation"`
+	Cron       *string `json:"cron" example:"30 5 * * *"`
+	TimeZone   *string `json:"time_zone" example:"Asia/Bangkok"`
+	Enabled    *bool   `json:"enabled" example:"false"`
+	Job        *string `json:"job" example:"actuator_command"`
+	ActuatorID *uint   `json:"actuator_id" example:"1"`
+	// แทนคำสั่งเดิมทั้งชุด
+	Command       *CommandRequest `json:"command"`
+	RetentionDays *int            `json:"retention_days" example:"90"`
+}
+
+// --- 2. Handlers ---
+
+// GetAllSchedulesHandler ดู Schedule ทั้งหมด
+// @Summary      ดูรายการ Schedule
+// @Description  Schedule ขององค์กร (Superadmin เห็นทุกองค์กร) พร้อมเวลาทำงานครั้งถัดไป
+// @Tags         Schedule
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array} models.Schedule
+// @Failure      401  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /schedules [get]
+func (h *Handler) GetAllSchedulesHandler(c *gin.Context) {
+	schedules, err := h.Schedules.GetAllSchedules(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules})
+}
+
+// CreateScheduleHandler สร้าง Schedule
+// @Summary      สร้าง Schedule (Admin Only)
+// @Description  ทำงานตาม Cron ใน Time Zone ที่กำหนด เช่น เปิดพัดลมระบายอากาศทุกเช้า 06:00 เวลาไทย
+// @Description  รอบที่เลยเวลาเกิน schedule.misfire_grace (เช่น Server ปิดอยู่) จะถูกข้ามและบันทึกเป็น skipped
+// @Tags         Schedule
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body CreateScheduleRequest true "ข้อมูล Schedule"
+// @Success      200  {object} models.Schedule
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem "ไม่พบ Actuator"
+// @Failure      500  {object} apierror.Problem
+// @Router       /schedules [post]
+func (h *Handler) CreateScheduleHandler(c *gin.Context) {
+	var req CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	in := services.ScheduleInput{
+		Name:           req.Name,
+		Cron:           req.Cron,
+		TimeZone:       req.TimeZone,
+		Enabled:        req.Enabled,
+		Job:            req.Job,
+		ActuatorID:     req.ActuatorID,
+		RetentionDays:  req.RetentionDays,
+		OrganizationID: req.OrganizationID,
+	}
+	if req.Command != nil {
+		in.Command = commandInput(*req.Command)
+	}
+	schedule, err := h.Schedules.CreateSchedule(c.Request.Context(), in)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateScheduleHandler แก้ไข Schedule
+// @Summary      แก้ไข Schedule (Admin Only)
+// @Description  แก้ค่าแล้วคำนวณเวลาทำงานครั้งถัดไปใหม่ (ปิดด้วย enabled: false)
+// @Tags         Schedule
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path   int                    true  "Schedule ID"
+// @Param        request body   UpdateScheduleRequest  true  "ข้อมูลที่ต้องการแก้"
+// @Success      200     {object} models.Schedule
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      403     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      500     {object} apierror.Problem
+// @Router       /schedules/{id} [put]
+func (h *Handler) UpdateScheduleHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrScheduleNotFound)
+		return
+	}
+
+	var req UpdateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	upd := services.ScheduleUpdate{
+		Name:          req.Name,
+		Cron:          req.Cron,
+		TimeZone:      req.TimeZone,
+		Enabled:       req.Enabled,
+		Job:           req.Job,
+		ActuatorID:    req.ActuatorID,
+		RetentionDays: req.RetentionDays,
+	}
+	if req.Command != nil {
+		cmd := commandInput(*req.Command)
+		upd.Command = &cmd
+	}
+	schedule, err := h.Schedules.UpdateSchedule(c.Request.Context(), uint(id), upd)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteScheduleHandler ลบ Schedule
+// @Summary      ลบ Schedule (Admin Only)
+// @Description  ลบ Schedule พร้อมประวัติการทำงาน (คำสั่งที่เคยสั่งยังอยู่ในประวัติของ Actuator)
+// @Tags         Schedule
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Schedule ID"
+// @Success      200  {object} map[string]string
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /schedules/{id} [delete]
+func (h *Handler) DeleteScheduleHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrScheduleNotFound)
+		return
+	}
+
+	schedule, err := h.Schedules.DeleteSchedule(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule " + schedule.Name + " deleted"})
+}
+
+// GetScheduleRunsHandler ประวัติการทำงานของ Schedule
+// @Summary      ดูประวัติการทำงานของ Schedule
+// @Description  200 รายการล่าสุด: succeeded, failed หรือ skipped (เลยเวลา / Actuator อยู่ในโหมด Manual Override)
+// @Tags         Schedule
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path  int  true  "Schedule ID"
+// @Success      200  {array} models.ScheduleRun
+// @Failure      401  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /schedules/{id}/runs [get]
+func (h *Handler) GetScheduleRunsHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrScheduleNotFound)
+		return
+	}
+
+	runs, err := h.Schedules.Runs(c.Request.Context(), uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
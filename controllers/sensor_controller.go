@@ -1,11 +1,14 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
-	"worm/models"
+	"strconv"
+	"time"
+	"worm/apierror"
+	"worm/services"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // --- 1. Request Models (สำหรับ Swagger) ---
@@ -20,60 +23,107 @@ type SensorRequest struct {
 
 // AddSensorHandler บันทึกค่า Sensor
 // @Summary      บันทึกค่าอุณหภูมิและความชื้น
-// @Description  รับค่า temp และ humidity แล้วบันทึกลงฐานข้อมูล
+// @Description  รับค่า temp (-40 ถึง 80) และ humidity (0 ถึง 100) แล้วบันทึกลงฐานข้อมูล
+// @Description  ค่านอกช่วงนี้ไม่ถูกบันทึก ตอบ 400 reading_out_of_range และนับใน worm_readings_rejected_total{reason="out_of_range"}
+// @Description  จำกัดจำนวนต่อ Device (rate_limit.ingest_*) และต่อวัน (rate_limit.daily_device_quota)
+// @Description  ค่าเป็นขององค์กรของเจ้าของ API Key และลงทะเบียน Device ให้อัตโนมัติเมื่อส่งค่าครั้งแรก
+// @Description  ค่าถูกแก้ตาม Calibration ของ Device ก่อนบันทึก (ค่าดิบเก็บไว้ใน raw_temp / raw_humidity)
+// @Description  แล้วคำนวณจุดน้ำค้าง ความชื้นสัมบูรณ์ และ VPD จากค่าที่แก้แล้วเก็บไว้ใน derived
+// @Description  ค่าดิบถูกตรวจเทียบกับค่าล่าสุดของ Device (z-score, spike, stuck) ค่าที่ผิดปกติยังถูกบันทึกพร้อม anomalies (ดู GET /api/anomalies)
 // @Tags         Sensor
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
+// @Param        X-Device-ID header string false "รหัส Device (ไม่ส่ง = ใช้ชื่อเจ้าของ API Key)"
 // @Param        request body SensorRequest true "ข้อมูล Sensor"
 // @Success      200  {object} map[string]string "message: Saved"
-// @Failure      400  {object} map[string]string "error: Bad Request"
+// @Failure      400  {object} apierror.Problem "Body ไม่ถูกต้อง หรือค่านอกช่วง (reading_out_of_range)"
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem "บัญชีไม่สังกัดองค์กร (Superadmin)"
+// @Failure      429  {object} apierror.Problem "เกิน Rate Limit หรือ Quota รายวัน (ดู Retry-After)"
+// @Failure      500  {object} apierror.Problem
 // @Router       /sensor [post]
-func AddSensorHandler(c *gin.Context, db *gorm.DB) {
+func (h *Handler) AddSensorHandler(c *gin.Context) {
+	// X-Device-ID หรือเจ้าของ API Key (ดู middleware.Device)
+	device := c.GetString("device")
+
 	var req SensorRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.Metrics.ReadingsRejected.Inc("invalid_body")
+		_ = c.Error(apierror.Validation(err))
 		return
 	}
 
 	// เรียก Logic ภายใน
-	if err := AddSensorData(db, req.Temperature, req.Humidity); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	data, err := h.Sensors.AddSensorData(c.Request.Context(), device, req.Temperature, req.Humidity)
+	if errors.Is(err, services.ErrReadingOutOfRange) {
+		h.Metrics.ReadingsRejected.Inc("out_of_range")
+		_ = c.Error(err)
+		return
+	}
+	if errors.Is(err, services.ErrNoOrganization) {
+		h.Metrics.ReadingsRejected.Inc("no_organization")
+		_ = c.Error(err)
+		return
+	}
+	if errors.Is(err, services.ErrObserverFailed) {
+		// ค่าถูกบันทึกแล้ว ตอบ 200 ให้ Device ไม่ส่งซ้ำ (Error ยังไปอยู่ใน Access Log)
+		_ = c.Error(err)
+		err = nil
+	}
+	if err != nil {
+		h.Metrics.ReadingsRejected.Inc("storage_error")
+		_ = c.Error(err)
 		return
 	}
 
+	// ชื่อ Device ซ้ำกันได้ข้ามองค์กร จึงแยก Series ด้วย ID ขององค์กร
+	org := strconv.FormatUint(uint64(data.OrganizationID), 10)
+	h.Metrics.ReadingsIngested.Inc(org, device)
+	h.Metrics.LastIngested.Set(float64(time.Now().Unix()), org, device)
+	h.Metrics.Temperature.Set(data.Temperature, org, device)
+	h.Metrics.Humidity.Set(data.Humidity, org, device)
+	for _, a := range data.Anomalies {
+		h.Metrics.Anomalies.Inc(org, device, a.Metric, a.Kind)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Saved"})
 }
 
 // GetAllSensorHandler ดูข้อมูล Sensor ทั้งหมด
 // @Summary      ดูประวัติ Sensor
-// @Description  ดึงข้อมูลอุณหภูมิและความชื้นทั้งหมด
+// @Description  ดึงข้อมูลอุณหภูมิและความชื้นทั้งหมดขององค์กร (Superadmin เห็นทุกองค์กร)
 // @Tags         Sensor
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Success      200  {array} models.SensorData
+// @Failure      401  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
 // @Router       /sensor [get]
-func GetAllSensorHandler(c *gin.Context, db *gorm.DB) {
-	data, err := GetAllSensorData(db)
+func (h *Handler) GetAllSensorHandler(c *gin.Context) {
+	data, err := h.Sensors.GetAllSensorData(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": data})
 }
 
-// --- 3. Internal Logic (Functions เดิมของคุณ) ---
-
-func AddSensorData(db *gorm.DB, temp float64, humidity float64) error {
-	data := models.SensorData{
-		Temperature: temp,
-		Humidity:    humidity,
+// GetAllDevicesHandler ดู Device ทั้งหมด
+// @Summary      ดูรายการ Device
+// @Description  Device ขององค์กรที่เคยส่งค่า (Superadmin เห็นทุกองค์กร) พร้อมเวลาที่ส่งค่าล่าสุด
+// @Tags         Sensor
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {array} models.Device
+// @Failure      401  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /devices [get]
+func (h *Handler) GetAllDevicesHandler(c *gin.Context) {
+	devices, err := h.Sensors.GetAllDevices(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
-	return db.Create(&data).Error
+	c.JSON(http.StatusOK, gin.H{"devices": devices})
 }
-
-func GetAllSensorData(db *gorm.DB) ([]models.SensorData, error) {
-	var data []models.SensorData
-	err := db.Find(&data).Error
-	return data, err
-}
\ No newline at end of file
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"worm/apierror"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// --- 1. Request Models ---
+
+// TwoFactorCodeRequest รหัสจากแอป Authenticator
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" example:"123456" binding:"required"`
+}
+
+// --- 2. Handlers ---
+
+// EnrollTwoFactorHandler เริ่มต้นเปิดใช้ 2FA
+// @Summary      ขอ Secret สำหรับเปิดใช้ 2FA
+// @Description  สร้าง TOTP Secret ใหม่และคืน otpauth:// URI สำหรับสแกนในแอป Authenticator (ยังไม่เปิดใช้จนกว่าจะยืนยันรหัส)
+// @Tags         2FA
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object} map[string]string
+// @Failure      401  {object} apierror.Problem
+// @Failure      409  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /2fa/enroll [post]
+func (h *Handler) EnrollTwoFactorHandler(c *gin.Context) {
+	user := currentUser(c)
+
+	secret, uri, err := h.Auth.EnrollTwoFactor(c.Request.Context(), user)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": uri,
+	})
+}
+
+// ConfirmTwoFactorHandler ยืนยันรหัสเพื่อเปิดใช้ 2FA
+// @Summary      ยืนยันและเปิดใช้ 2FA
+// @Description  ตรวจรหัส 6 หลักจากแอป แล้วเปิดใช้ 2FA พร้อมคืน Recovery Code (แสดงครั้งเดียว)
+// @Tags         2FA
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body TwoFactorCodeRequest true "รหัสจากแอป Authenticator"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      409  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /2fa/confirm [post]
+func (h *Handler) ConfirmTwoFactorHandler(c *gin.Context) {
+	user := currentUser(c)
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	codes, err := h.Auth.ConfirmTwoFactor(c.Request.Context(), user, req.Code)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactorHandler ปิด 2FA ของตัวเอง
+// @Summary      ปิดใช้ 2FA
+// @Description  ปิด 2FA ของบัญชีตัวเอง ต้องยืนยันด้วยรหัสจากแอปหรือ Recovery Code
+// @Tags         2FA
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body TwoFactorCodeRequest true "รหัสจากแอป Authenticator หรือ Recovery Code"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /2fa/disable [post]
+func (h *Handler) DisableTwoFactorHandler(c *gin.Context) {
+	user := currentUser(c)
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
+	}
+
+	err := h.Auth.DisableTwoFactor(c.Request.Context(), user, req.Code)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// ResetTwoFactorHandler Admin รีเซ็ต 2FA ให้ User อื่น
+// @Summary      รีเซ็ต 2FA ของ User (Admin Only)
+// @Description  ลบ TOTP Secret และ Recovery Code ทั้งหมดของ User (กรณีทำมือถือหาย) และบันทึกลง Audit Log
+// @Tags         2FA
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path     int  true  "User ID"
+// @Success      200  {object} map[string]string
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /users/{id}/2fa [delete]
+func (h *Handler) ResetTwoFactorHandler(c *gin.Context) {
+	requester := currentUser(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrUserNotFound)
+		return
+	}
+
+	err = h.Auth.ResetTwoFactor(c.Request.Context(), requester, uint(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset"})
+}
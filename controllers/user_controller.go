@@ -2,21 +2,23 @@ package controllers
 
 import (
 	"net/http"
-	"worm/models"
-	"worm/utils"
+	"strconv"
+	"worm/apierror"
+	"worm/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
 // --- 1. Request Models ---
 
 // RegisterRequest แบบฟอร์มสมัครสมาชิก
 type RegisterRequest struct {
+	// 3-64 ตัว: ตัวอักษร ตัวเลข . _ - @ (ไม่สนตัวพิมพ์ตอนตรวจชื่อซ้ำ)
 	Username string `json:"username" example:"staff01" binding:"required"`
 	Password string `json:"password" example:"pass1234" binding:"required"`
-	Role     string `json:"role" example:"user" binding:"required"` // admin หรือ user
+	Role     string `json:"role" example:"user" binding:"required"` // superadmin, admin หรือ user
+	// องค์กรของ User ใหม่ (ไม่ส่ง = องค์กรของผู้สร้าง, Superadmin ต้องส่งยกเว้นสร้าง Superadmin)
+	OrganizationID *uint `json:"organization_id" example:"1"`
 }
 
 // --- 2. Handlers ---
@@ -24,34 +26,37 @@ type RegisterRequest struct {
 // CreateUserHandler สร้าง User ใหม่
 // @Summary      สร้าง User ใหม่ (Admin Only)
 // @Description  สร้าง User หรือ Admin ใหม่ โดยต้องใช้ Key ของ Admin
+// @Description  Admin ขององค์กรสร้างได้เฉพาะในองค์กรตัวเอง ส่วน Role superadmin สร้างได้เฉพาะ Superadmin
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        request body RegisterRequest true "ข้อมูล User ใหม่"
 // @Success      200  {object} map[string]interface{}
-// @Failure      400  {object} map[string]string
-// @Failure      500  {object} map[string]string
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      404  {object} apierror.Problem "ไม่พบองค์กร"
+// @Failure      409  {object} apierror.Problem "Username ซ้ำ (ไม่สนตัวพิมพ์)"
+// @Failure      500  {object} apierror.Problem
 // @Router       /register [post]
-func CreateUserHandler(c *gin.Context, db *gorm.DB) {
-	// (สมมติว่า Middleware เช็คสิทธิ์ Admin ให้แล้วที่ main.go)
-
+func (h *Handler) CreateUserHandler(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(apierror.Validation(err))
 		return
 	}
 
-	apiKey, err := CreateUser(db, req.Username, req.Password, req.Role)
+	apiKey, err := h.Users.CreateUser(c.Request.Context(), req.Username, req.Password, req.Role, req.OrganizationID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user (username might exist)"})
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "User created",
+		"message":  "User created",
 		"username": req.Username,
-		"api_key": apiKey,
+		"api_key":  apiKey,
 	})
 }
 
@@ -60,11 +65,16 @@ type UpdateUserRequest struct {
 	Username *string `json:"username" example:"new_name"`
 	Password *string `json:"password" example:"new_pass123"`
 	Role     *string `json:"role" example:"admin"`
+	// true = บังคับให้ล็อกอินผ่าน SSO เท่านั้น
+	PasswordLoginDisabled *bool `json:"password_login_disabled" example:"false"`
+	// ย้ายไปองค์กรอื่น (Superadmin เท่านั้น)
+	OrganizationID *uint `json:"organization_id" example:"2"`
 }
 
 // UpdateUserHandler แก้ไขข้อมูล User
 // @Summary      แก้ไขข้อมูล User (Admin Only)
-// @Description  อัปเดต Username, Password หรือ Role (ส่งเฉพาะค่าที่ต้องการแก้)
+// @Description  อัปเดต Username, Password, Role, องค์กร หรือการปิด Password Login (ส่งเฉพาะค่าที่ต้องการแก้)
+// @Description  Admin ขององค์กรแก้ได้เฉพาะ User ในองค์กรตัวเอง (User ขององค์กรอื่นตอบ 404)
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -72,92 +82,97 @@ type UpdateUserRequest struct {
 // @Param        id      path   int                true  "User ID"
 // @Param        request body   UpdateUserRequest  true  "ข้อมูลที่ต้องการแก้"
 // @Success      200     {object} models.User
-// @Failure      400     {object} map[string]string
-// @Failure      404     {object} map[string]string
+// @Failure      400     {object} apierror.Problem
+// @Failure      401     {object} apierror.Problem
+// @Failure      403     {object} apierror.Problem
+// @Failure      404     {object} apierror.Problem
+// @Failure      409     {object} apierror.Problem "Username ซ้ำ (ไม่สนตัวพิมพ์)"
+// @Failure      500     {object} apierror.Problem
 // @Router       /users/{id} [put]
-func UpdateUserHandler(c *gin.Context, db *gorm.DB) {
+func (h *Handler) UpdateUserHandler(c *gin.Context) {
 	// 1. รับ ID
-	id := c.Param("id")
-
-	// 2. หา User เดิม
-	var user models.User
-	if err := db.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(services.ErrUserNotFound)
 		return
 	}
 
-	// 3. รับค่าจาก Body
+	// 2. รับค่าจาก Body
 	var req UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(apierror.Validation(err))
 		return
 	}
 
-	// 4. อัปเดตทีละค่า (ถ้าส่งมา)
-	if req.Username != nil {
-		user.Username = *req.Username
-	}
-	if req.Role != nil {
-		if *req.Role == "admin" || *req.Role == "user" {
-			user.Role = *req.Role
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be 'admin' or 'user'"})
-			return
-		}
+	// 3. อัปเดตเฉพาะค่าที่ส่งมา
+	user, err := h.Users.UpdateUser(c.Request.Context(), uint(id), services.UserUpdate{
+		Username:              req.Username,
+		Password:              req.Password,
+		Role:                  req.Role,
+		PasswordLoginDisabled: req.PasswordLoginDisabled,
+		OrganizationID:        req.OrganizationID,
+	})
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
-	if req.Password != nil {
-		hashed, _ := utils.HashPassword(*req.Password)
-		user.Password = hashed
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangePasswordRequest แบบฟอร์มเปลี่ยนรหัสผ่านของตัวเอง
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" example:"old_pass123" binding:"required"`
+	NewPassword     string `json:"new_password" example:"new_pass123" binding:"required,min=8"`
+}
+
+// ChangePasswordHandler เปลี่ยนรหัสผ่านของตัวเอง
+// @Summary      เปลี่ยนรหัสผ่านของตัวเอง
+// @Description  ต้องใส่รหัสผ่านเดิม ใช้ได้แม้ถูกบังคับให้เปลี่ยนรหัสผ่าน (must_change_password)
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body ChangePasswordRequest true "รหัสผ่านเดิมและรหัสผ่านใหม่"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} apierror.Problem
+// @Failure      401  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
+// @Router       /me/password [post]
+func (h *Handler) ChangePasswordHandler(c *gin.Context) {
+	user := currentUser(c)
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apierror.Validation(err))
+		return
 	}
 
-	// 5. บันทึก
-	if err := db.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Update failed (username might exist)"})
+	err := h.Users.ChangePassword(c.Request.Context(), user, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
 }
 
 // GetAllUsersHandler ดูรายชื่อ User
 // @Summary      ดูรายชื่อ User ทั้งหมด
-// @Description  แสดงรายชื่อ User และ Role ทั้งหมด (Admin Only)
+// @Description  แสดงรายชื่อ User และ Role ทั้งหมด (Admin Only) Admin ขององค์กรเห็นเฉพาะองค์กรตัวเอง
 // @Tags         Auth
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Success      200  {array} models.User
+// @Failure      401  {object} apierror.Problem
+// @Failure      403  {object} apierror.Problem
+// @Failure      500  {object} apierror.Problem
 // @Router       /users [get]
-func GetAllUsersHandler(c *gin.Context, db *gorm.DB) {
-	users, err := GetAllUsers(db)
+func (h *Handler) GetAllUsersHandler(c *gin.Context) {
+	users, err := h.Users.GetAllUsers(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"users": users})
 }
-
-// --- 3. Internal Logic ---
-
-// CreateUser (Logic ล้วน - ตัด Middleware ออกไปเช็คที่ Router แทน)
-func CreateUser(db *gorm.DB, newUsername, newPassword, role string) (string, error) {
-	hashedPassword, _ := utils.HashPassword(newPassword)
-	generatedKey := uuid.New().String()
-
-	newUser := models.User{
-		Username: newUsername,
-		Password: hashedPassword,
-		Role:     role,
-		APIKey:   generatedKey,
-	}
-
-	if err := db.Create(&newUser).Error; err != nil {
-		return "", err
-	}
-	return generatedKey, nil
-}
-
-func GetAllUsers(db *gorm.DB) ([]models.User, error) {
-	var users []models.User
-	result := db.Find(&users)
-	return users, result.Error
-}
\ No newline at end of file
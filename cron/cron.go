@@ -0,0 +1,201 @@
+// Package cron แปลง Cron Expression มาตรฐาน 5 ช่อง (minute hour day-of-month month day-of-week)
+// และหาเวลาทำงานครั้งถัดไปตาม Time Zone
+//
+// รองรับ *, รายการ (1,15), ช่วง (1-5), ขั้น (*/15, 0-30/10), ชื่อเดือน / วัน (jan, mon)
+// และ @yearly, @monthly, @weekly, @daily, @hourly
+// ถ้าจำกัดทั้ง day-of-month และ day-of-week จะทำงานเมื่อตรงอย่างใดอย่างหนึ่ง (เหมือน Vixie cron)
+// ช่องที่ขึ้นต้นด้วย * (เช่น */2) ไม่นับว่าจำกัด: 0 9 */2 * mon = วันคี่ที่เป็นวันจันทร์
+// เวลาที่เกิดซ้ำเมื่อ Daylight Saving ย้อนกลับทำงานครั้งเดียว (ครั้งแรก)
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ค้นหาเวลาถัดไปไม่เกินช่วงนี้ (เช่น 30 ก.พ. ไม่มีวันเกิดขึ้น)
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// field ขอบเขตและชื่อของช่องหนึ่ง
+type field struct {
+	name     string
+	min, max int
+	// ชื่อแทนตัวเลขตั้งแต่ min (nil = ไม่มี)
+	names []string
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	// 7 = อาทิตย์ เหมือน 0
+	{name: "day of week", min: 0, max: 7, names: dayNames},
+}
+
+// Schedule Cron Expression ที่แปลงแล้ว (แต่ละช่องเป็น Bitset ของค่าที่ตรง)
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// ช่องที่ขึ้นต้นด้วย * (ใช้ตัดสินการรวม day-of-month กับ day-of-week)
+	domAny, dowAny bool
+}
+
+// Parse แปลง Cron Expression (ช่องว่างหลายตัวนับเป็นตัวเดียว ไม่สนตัวพิมพ์)
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	if m, ok := macros[expr]; ok {
+		expr = m
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(parts))
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fields[i].name, err)
+		}
+		bits[i] = b
+	}
+	s := &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}
+	// 7 = อาทิตย์
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// Next เวลาแรกที่ตรงหลัง after (ตัดวินาทีทิ้ง) ตามนาฬิกาของ loc
+// ช่วงเวลาที่ถูกข้ามเมื่อเข้า Daylight Saving ไม่ถูกเลือก และเวลาที่เกิดซ้ำเมื่อออกจาก Daylight Saving ถูกเลือกเฉพาะครั้งแรก
+// คืนค่าศูนย์ถ้าไม่มีเวลาที่ตรงภายใน 5 ปี
+func (s *Schedule) Next(after time.Time, loc *time.Location) time.Time {
+	t := after.In(loc).Truncate(time.Minute).Add(time.Minute)
+	end := t.Add(searchLimit)
+	for t.Before(end) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			// บวกเวลาจริงแทน time.Date เพื่อไม่วนซ้ำในชั่วโมงที่ Daylight Saving ย้อนกลับ
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case s.minute&(1<<uint(t.Minute())) == 0 || repeated(t):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// repeated เวลาบนนาฬิกาของ t เคยเกิดขึ้นแล้วก่อน t (ชั่วโมงที่ Daylight Saving ย้อนกลับ เช่น 01:30 EDT แล้ว 01:30 EST)
+func repeated(t time.Time) bool {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return false
+	}
+	_, offset := t.Zone()
+	_, before := start.Add(-time.Second).Zone()
+	back := time.Duration(before-offset) * time.Second
+	return back > 0 && t.Sub(start) < back
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseField แปลงช่องหนึ่งเป็น Bitset
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %q", item)
+			}
+			rng, step = item[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = value(a, f); err != nil {
+				return 0, err
+			}
+			if hi, err = value(b, f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			v, err := value(rng, f)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// 5/15 = ตั้งแต่ 5 ทุก 15
+			if step == 1 {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value แปลงตัวเลขหรือชื่อ และตรวจขอบเขต
+func value(s string, f field) (int, error) {
+	for i, name := range f.names {
+		if s == name {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
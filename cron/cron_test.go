@@ -0,0 +1,143 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+// nextN เวลาทำงาน n ครั้งถัดไปหลัง after
+func nextN(t *testing.T, expr string, after time.Time, loc *time.Location, n int) []time.Time {
+	t.Helper()
+	s, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("parse %q: %v", expr, err)
+	}
+	var out []time.Time
+	for i := 0; i < n; i++ {
+		after = s.Next(after, loc)
+		out = append(out, after)
+	}
+	return out
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestNextAcrossDaylightSaving(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	for _, c := range []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  []string
+	}{
+		// 1 พ.ย. 2026 นาฬิกาย้อนจาก 02:00 EDT เป็น 01:00 EST: 01:30 เกิดสองครั้งแต่ทำงานครั้งเดียว
+		{"fall back daily", "30 1 * * *", time.Date(2026, 10, 31, 12, 0, 0, 0, ny),
+			[]string{"2026-11-01T01:30:00-04:00", "2026-11-02T01:30:00-05:00"}},
+		{"fall back every 30 minutes", "*/30 1 * * *", time.Date(2026, 11, 1, 0, 50, 0, 0, ny),
+			[]string{"2026-11-01T01:00:00-04:00", "2026-11-01T01:30:00-04:00", "2026-11-02T01:00:00-05:00"}},
+		{"hourly through fall back", "0 * * * *", time.Date(2026, 11, 1, 0, 30, 0, 0, ny),
+			[]string{"2026-11-01T01:00:00-04:00", "2026-11-01T02:00:00-05:00", "2026-11-01T03:00:00-05:00"}},
+		// 8 มี.ค. 2026 นาฬิกากระโดดจาก 02:00 EST เป็น 03:00 EDT: 02:30 ไม่มีอยู่จริงจึงข้ามวันนั้น
+		{"spring forward skips the missing time", "30 2 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, ny),
+			[]string{"2026-03-09T02:30:00-04:00", "2026-03-10T02:30:00-04:00"}},
+		{"spring forward keeps the next hour", "30 3 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, ny),
+			[]string{"2026-03-08T03:30:00-04:00", "2026-03-09T03:30:00-04:00"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got := nextN(t, c.expr, c.after, ny, len(c.want))
+			for i, w := range c.want {
+				if got[i].Format(time.RFC3339) != w {
+					t.Fatalf("run %d: got %s, want %s (all: %v)", i, got[i].Format(time.RFC3339), w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestStarPrefixedDayFieldIsUnrestricted(t *testing.T) {
+	// */2 ขึ้นต้นด้วย * จึงรวมกับวันจันทร์แบบ AND (วันคี่ที่เป็นวันจันทร์) ไม่ใช่ OR
+	got := nextN(t, "0 9 */2 * mon", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.UTC, 3)
+	for i, w := range []string{"2026-10-05T09:00:00Z", "2026-10-19T09:00:00Z", "2026-11-09T09:00:00Z"} {
+		if got[i].Format(time.RFC3339) != w {
+			t.Fatalf("run %d: got %s, want %s (all: %v)", i, got[i].Format(time.RFC3339), w, got)
+		}
+	}
+}
+
+func TestParseAndNext(t *testing.T) {
+	// 1 ต.ค. 2026 เป็นวันพฤหัสบดี
+	after := time.Date(2026, 10, 1, 10, 7, 30, 0, time.UTC)
+	for _, c := range []struct {
+		expr string
+		want []string
+	}{
+		{"@hourly", []string{"2026-10-01T11:00:00Z", "2026-10-01T12:00:00Z"}},
+		{"@daily", []string{"2026-10-02T00:00:00Z", "2026-10-03T00:00:00Z"}},
+		{"@weekly", []string{"2026-10-04T00:00:00Z", "2026-10-11T00:00:00Z"}},
+		{"@monthly", []string{"2026-11-01T00:00:00Z", "2026-12-01T00:00:00Z"}},
+		{"@yearly", []string{"2027-01-01T00:00:00Z", "2028-01-01T00:00:00Z"}},
+		// ไม่สนตัวพิมพ์และช่องว่างซ้ำ
+		{"  0   6 * JAN,Oct  MON-fri ", []string{"2026-10-02T06:00:00Z", "2026-10-05T06:00:00Z"}},
+		{"*/15 10 * * *", []string{"2026-10-01T10:15:00Z", "2026-10-01T10:30:00Z", "2026-10-01T10:45:00Z", "2026-10-02T10:00:00Z"}},
+		{"0-30/10 10 * * *", []string{"2026-10-01T10:10:00Z", "2026-10-01T10:20:00Z", "2026-10-01T10:30:00Z", "2026-10-02T10:00:00Z"}},
+		// 5/20 = ตั้งแต่ 5 ทุก 20
+		{"5/20 10 * * *", []string{"2026-10-01T10:25:00Z", "2026-10-01T10:45:00Z", "2026-10-02T10:05:00Z"}},
+		{"0 8-9 * * *", []string{"2026-10-02T08:00:00Z", "2026-10-02T09:00:00Z", "2026-10-03T08:00:00Z"}},
+		{"0 12 1,15 * *", []string{"2026-10-01T12:00:00Z", "2026-10-15T12:00:00Z", "2026-11-01T12:00:00Z"}},
+		// 7 = อาทิตย์ เหมือน 0
+		{"0 0 * * 7", []string{"2026-10-04T00:00:00Z", "2026-10-11T00:00:00Z"}},
+		// จำกัดทั้งสองช่อง = วันที่ 13 หรือวันศุกร์
+		{"0 0 13 * fri", []string{"2026-10-02T00:00:00Z", "2026-10-09T00:00:00Z", "2026-10-13T00:00:00Z", "2026-10-16T00:00:00Z"}},
+		// วันที่ที่ไม่มีในบางเดือน
+		{"0 0 31 * *", []string{"2026-10-31T00:00:00Z", "2026-12-31T00:00:00Z"}},
+		{"0 0 29 2 *", []string{"2028-02-29T00:00:00Z"}},
+	} {
+		t.Run(c.expr, func(t *testing.T) {
+			got := nextN(t, c.expr, after, time.UTC, len(c.want))
+			for i, w := range c.want {
+				if got[i].Format(time.RFC3339) != w {
+					t.Fatalf("run %d: got %s, want %s (all: %v)", i, got[i].Format(time.RFC3339), w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNextUsesTheScheduleTimeZone(t *testing.T) {
+	bangkok := mustLoad(t, "Asia/Bangkok")
+	got := nextN(t, "0 6 * * *", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), bangkok, 1)[0]
+	if want := time.Date(2026, 10, 1, 23, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("got %s, want 06:00 in Bangkok (%s)", got, want)
+	}
+}
+
+func TestNeverMatchingScheduleReturnsZero(t *testing.T) {
+	for _, expr := range []string{"0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+		s, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", expr, err)
+		}
+		if next := s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.UTC); !next.IsZero() {
+			t.Fatalf("%q: got %s, want no run", expr, next)
+		}
+	}
+}
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"", "* * * *", "* * * * * *", "@reboot",
+		"60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "*/x * * * *", "5-1 * * * *", "* * * foo *", "1-2-3 * * * *", "a * * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): want an error", expr)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package derived คำนวณค่าที่ได้จากอุณหภูมิและความชื้นสัมพัทธ์ (จุดน้ำค้าง ความชื้นสัมบูรณ์ VPD)
+//
+// ค่าถูกคำนวณตอนบันทึกแต่ละค่าและเก็บคู่กับ SensorData ตามชื่อของ Formula
+// เพิ่มสูตรใหม่ได้โดยเพิ่ม Formula เข้า Pipeline ที่ส่งให้ Service (ดู Default) ไม่ต้องแก้ Handler หรือตาราง
+package derived
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+)
+
+// namePattern ชื่อของ Formula (ใช้เป็น Key ใน JSON และชื่อ Column ของ CSV)
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Formula สูตรหนึ่งสูตร
+type Formula struct {
+	// ควรมีหน่วยต่อท้าย เช่น dew_point_c
+	Name string
+	// จำนวนทศนิยมที่เก็บ
+	Decimals int
+	// Compute คำนวณจากอุณหภูมิ (°C) และความชื้นสัมพัทธ์ (%) คืน NaN ถ้าคำนวณไม่ได้
+	Compute func(tempC, rh float64) float64
+}
+
+// Pipeline ชุดของ Formula ที่คำนวณทุกครั้งที่บันทึกค่า
+type Pipeline []Formula
+
+// Default จุดน้ำค้าง (°C) ความชื้นสัมบูรณ์ (g/m³) และ Vapour Pressure Deficit (kPa)
+var Default = MustPipeline(DewPoint, AbsoluteHumidity, VPD)
+
+// MustPipeline สร้าง Pipeline และ panic ถ้าชื่อผิดรูปแบบหรือซ้ำ (Formula เป็นค่าคงที่ในโค้ด)
+func MustPipeline(formulas ...Formula) Pipeline {
+	seen := map[string]bool{}
+	for _, f := range formulas {
+		if !namePattern.MatchString(f.Name) || seen[f.Name] {
+			panic(fmt.Sprintf("derived: invalid or duplicate formula name %q", f.Name))
+		}
+		seen[f.Name] = true
+	}
+	return Pipeline(formulas)
+}
+
+// Names ชื่อของทุก Formula ตามลำดับ
+func (p Pipeline) Names() []string {
+	out := make([]string, len(p))
+	for i, f := range p {
+		out[i] = f.Name
+	}
+	return out
+}
+
+// Apply คำนวณทุก Formula (ค่าที่คำนวณไม่ได้ไม่อยู่ในผลลัพธ์) คืน nil ถ้า Pipeline ว่าง
+func (p Pipeline) Apply(tempC, rh float64) map[string]float64 {
+	if len(p) == 0 {
+		return nil
+	}
+	out := make(map[string]float64, len(p))
+	for _, f := range p {
+		v := f.Compute(tempC, rh)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		scale := math.Pow(10, float64(f.Decimals))
+		out[f.Name] = math.Round(v*scale) / scale
+	}
+	return out
+}
+
+// ค่าคงที่ของสูตร Magnus (Alduchov & Eskridge 1996) ใช้ได้ช่วง -40 ถึง 50 °C
+const (
+	magnusA = 17.625
+	magnusB = 243.04
+	// hPa
+	magnusC = 6.1094
+)
+
+// SaturationVapourPressure ความดันไอน้ำอิ่มตัว (hPa) ที่อุณหภูมิ tempC
+func SaturationVapourPressure(tempC float64) float64 {
+	return magnusC * math.Exp(magnusA*tempC/(tempC+magnusB))
+}
+
+// DewPoint อุณหภูมิจุดน้ำค้าง (°C) คำนวณไม่ได้เมื่อความชื้นเป็น 0
+var DewPoint = Formula{
+	Name:     "dew_point_c",
+	Decimals: 2,
+	Compute: func(tempC, rh float64) float64 {
+		if rh <= 0 {
+			return math.NaN()
+		}
+		g := math.Log(rh/100) + magnusA*tempC/(magnusB+tempC)
+		return magnusB * g / (magnusA - g)
+	},
+}
+
+// AbsoluteHumidity ปริมาณไอน้ำในอากาศ (g/m³)
+var AbsoluteHumidity = Formula{
+	Name:     "absolute_humidity_gm3",
+	Decimals: 2,
+	Compute: func(tempC, rh float64) float64 {
+		// ความดันไอน้ำ (hPa) × 100 / (R ของไอน้ำ 461.5 J/(kg·K) × อุณหภูมิ K) × 1000 g/kg
+		e := SaturationVapourPressure(tempC) * rh / 100
+		return e * 100 / (461.5 * (tempC + 273.15)) * 1000
+	},
+}
+
+// VPD ผลต่างระหว่างความดันไอน้ำอิ่มตัวกับที่มีจริง (kPa) ยิ่งสูงยิ่งแห้ง
+var VPD = Formula{
+	Name:     "vpd_kpa",
+	Decimals: 3,
+	Compute: func(tempC, rh float64) float64 {
+		return SaturationVapourPressure(tempC) * (1 - rh/100) / 10
+	},
+}
@@ -0,0 +1,92 @@
+package derived
+
+import (
+	"math"
+	"testing"
+)
+
+// ค่าอ้างอิงจากตาราง Psychrometric (ไม่ใช่ค่าที่สูตรนี้คำนวณได้เอง) จึงเทียบแบบมีค่าคลาดเคลื่อน
+func TestFormulasMatchReferenceValues(t *testing.T) {
+	for _, c := range []struct {
+		tempC, rh        float64
+		dewPoint, absHum float64
+		vpd              float64
+	}{
+		{25, 60, 16.69, 13.8, 1.267},
+		{30, 90, 28.17, 27.3, 0.424},
+		{-10, 50, -18.5, 1.18, 0.143},
+		{20, 100, 20, 17.3, 0},
+		{0, 100, 0, 4.85, 0},
+	} {
+		for _, f := range []struct {
+			formula   Formula
+			want, tol float64
+		}{
+			{DewPoint, c.dewPoint, 0.05},
+			{AbsoluteHumidity, c.absHum, 0.1},
+			{VPD, c.vpd, 0.005},
+		} {
+			if got := f.formula.Compute(c.tempC, c.rh); math.Abs(got-f.want) > f.tol {
+				t.Errorf("%s(%v °C, %v %%) = %.4f, want %v ± %v", f.formula.Name, c.tempC, c.rh, got, f.want, f.tol)
+			}
+		}
+	}
+}
+
+func TestApplyDropsValuesThatCannotBeComputed(t *testing.T) {
+	for _, rh := range []float64{0, -5} {
+		if v := DewPoint.Compute(25, rh); !math.IsNaN(v) {
+			t.Errorf("dew point at %v %% = %v, want NaN", rh, v)
+		}
+	}
+
+	got := Default.Apply(25, 0)
+	if _, ok := got["dew_point_c"]; ok {
+		t.Errorf("Apply kept dew_point_c for 0 %% humidity: %v", got)
+	}
+	if got["absolute_humidity_gm3"] != 0 || got["vpd_kpa"] != 3.162 {
+		t.Errorf("Apply(25, 0) = %v, want absolute_humidity_gm3 0 and vpd_kpa 3.162", got)
+	}
+
+	// ปัดตาม Decimals ของแต่ละ Formula
+	want := map[string]float64{"dew_point_c": 16.7, "absolute_humidity_gm3": 13.79, "vpd_kpa": 1.265}
+	got = Default.Apply(25, 60)
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("Apply(25, 60)[%s] = %v, want %v", name, got[name], v)
+		}
+	}
+	if Pipeline(nil).Apply(25, 60) != nil {
+		t.Error("empty pipeline should return nil")
+	}
+}
+
+func TestMustPipelinePanicsOnBadNames(t *testing.T) {
+	renamed := func(name string) Formula {
+		f := VPD
+		f.Name = name
+		return f
+	}
+	for _, c := range []struct {
+		name     string
+		formulas []Formula
+	}{
+		{"duplicate", []Formula{DewPoint, VPD, renamed(DewPoint.Name)}},
+		{"upper case", []Formula{renamed("VPD")}},
+		{"empty", []Formula{renamed("")}},
+		{"leading digit", []Formula{renamed("1vpd")}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("MustPipeline did not panic")
+				}
+			}()
+			MustPipeline(c.formulas...)
+		})
+	}
+
+	if names := Default.Names(); len(names) != 3 || names[0] != "dew_point_c" || names[2] != "vpd_kpa" {
+		t.Errorf("Default.Names() = %v", names)
+	}
+}
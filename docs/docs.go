@@ -15,14 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/register": {
+        "/2fa/confirm": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "สร้าง User หรือ Admin ใหม่ โดยต้องใช้ Key ของ Admin",
+                "description": "ตรวจรหัส 6 หลักจากแอป แล้วเปิดใช้ 2FA พร้อมคืน Recovery Code (แสดงครั้งเดียว)",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,17 +30,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "2FA"
                 ],
-                "summary": "สร้าง User ใหม่ (Admin Only)",
+                "summary": "ยืนยันและเปิดใช้ 2FA",
                 "parameters": [
                     {
-                        "description": "ข้อมูล User ใหม่",
+                        "description": "รหัสจากแอป Authenticator",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.RegisterRequest"
+                            "$ref": "#/definitions/controllers.TwoFactorCodeRequest"
                         }
                     }
                 ],
@@ -55,82 +55,114 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Problem"
                         }
                     }
                 }
             }
         },
-        "/sensor": {
-            "get": {
+        "/2fa/disable": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "ดึงข้อมูลอุณหภูมิและความชื้นทั้งหมด",
+                "description": "ปิด 2FA ของบัญชีตัวเอง ต้องยืนยันด้วยรหัสจากแอปหรือ Recovery Code",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Sensor"
+                    "2FA"
+                ],
+                "summary": "ปิดใช้ 2FA",
+                "parameters": [
+                    {
+                        "description": "รหัสจากแอป Authenticator หรือ Recovery Code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TwoFactorCodeRequest"
+                        }
+                    }
                 ],
-                "summary": "ดูประวัติ Sensor",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.SensorData"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/2fa/enroll": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "รับค่า temp และ humidity แล้วบันทึกลงฐานข้อมูล",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "สร้าง TOTP Secret ใหม่และคืน otpauth:// URI สำหรับสแกนในแอป Authenticator (ยังไม่เปิดใช้จนกว่าจะยืนยันรหัส)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Sensor"
-                ],
-                "summary": "บันทึกค่าอุณหภูมิและความชื้น",
-                "parameters": [
-                    {
-                        "description": "ข้อมูล Sensor",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/controllers.SensorRequest"
-                        }
-                    }
+                    "2FA"
                 ],
+                "summary": "ขอ Secret สำหรับเปิดใช้ 2FA",
                 "responses": {
                     "200": {
-                        "description": "message: Saved",
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -138,54 +170,148 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "error: Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
                         }
                     }
                 }
             }
         },
-        "/users": {
+        "/actuators": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "แสดงรายชื่อ User และ Role ทั้งหมด (Admin Only)",
+                "description": "Actuator ขององค์กร (Superadmin เห็นทุกองค์กร) พร้อมคำสั่งล่าสุดที่ Device ยืนยันแล้ว (state)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Actuator"
                 ],
-                "summary": "ดูรายชื่อ User ทั้งหมด",
+                "summary": "ดูรายการ Actuator",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.User"
+                                "$ref": "#/definitions/models.Actuator"
                             }
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ผูก Relay (พัดลม เครื่องพ่นหมอก ฮีตเตอร์) กับช่องของ Device",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actuator"
+                ],
+                "summary": "เพิ่ม Actuator (Admin Only)",
+                "parameters": [
+                    {
+                        "description": "ข้อมูล Actuator",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateActuatorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Actuator"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "ไม่พบ Device",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "ชื่อหรือช่องซ้ำภายใน Device",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
                     }
                 }
             }
         },
-        "/users/{id}": {
+        "/actuators/{id}": {
             "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "อัปเดต Username, Password หรือ Role (ส่งเฉพาะค่าที่ต้องการแก้)",
+                "description": "เปลี่ยนชื่อ ชนิด หรือช่อง Relay (คำสั่งที่สั่งไปแล้วยังใช้ช่องเดิม)",
                 "consumes": [
                     "application/json"
                 ],
@@ -193,13 +319,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Actuator"
                 ],
-                "summary": "แก้ไขข้อมูล User (Admin Only)",
+                "summary": "แก้ไข Actuator (Admin Only)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Actuator ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -210,7 +336,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.UpdateUserRequest"
+                            "$ref": "#/definitions/controllers.UpdateActuatorRequest"
                         }
                     }
                 ],
@@ -218,91 +344,4513 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.Actuator"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "ชื่อหรือช่องซ้ำภายใน Device",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ลบได้เฉพาะ Actuator ที่ยังไม่เคยได้รับคำสั่ง (เก็บประวัติคำสั่งไว้)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actuator"
+                ],
+                "summary": "ลบ Actuator (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Actuator ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "มีประวัติคำสั่งแล้ว หรือมี Automation Rule / Schedule ใช้อยู่",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "controllers.RegisterRequest": {
-            "type": "object",
-            "required": [
-                "password",
-                "role",
-                "username"
-            ],
-            "properties": {
-                "password": {
-                    "type": "string",
-                    "example": "pass1234"
-                },
-                "role": {
-                    "description": "admin หรือ user",
-                    "type": "string",
-                    "example": "user"
-                },
-                "username": {
-                    "type": "string",
-                    "example": "staff01"
-                }
-            }
         },
-        "controllers.SensorRequest": {
-            "type": "object",
-            "properties": {
-                "humidity": {
-                    "type": "number",
+        "/actuators/{id}/commands": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ทุกคำสั่งพร้อมสถานะ (queued, delivered, acknowledged, failed, expired) เรียงจากล่าสุด",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actuator"
+                ],
+                "summary": "ดูประวัติคำสั่งของ Actuator",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Actuator ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ActuatorCommand"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "เข้าคิวคำสั่ง on / off / duty (duty_cycle %) พร้อมระยะเวลา แล้วรอ Device มา Poll\nคำสั่งที่ Device ไม่ Poll ภายใน actuator.command_ttl จะเป็น expired",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actuator"
+                ],
+                "summary": "สั่ง Actuator",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Actuator ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "คำสั่ง",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CommandRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActuatorCommand"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/actuators/{id}/override": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ระหว่าง Override Automation Rule และ Schedule จะไม่สั่ง Actuator นี้ (บันทึกใน Log / ประวัติว่าข้าม) แต่ยังสั่งเองได้",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actuator"
+                ],
+                "summary": "เปิด / ปิด Manual Override",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Actuator ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "โหมด Override",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OverrideRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Actuator"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/anomalies": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ค่าที่ตรวจพบความผิดปกติตอนบันทึก (anomalies) ล่าสุดก่อน ไม่เกิน 1000 ค่า\nzscore = ห่างจากค่าเฉลี่ยของค่าล่าสุดมาก, spike = เปลี่ยนเร็วผิดปกติ, stuck = ค่าเดิมซ้ำติดกันนาน (ดู anomaly ใน Config)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sensor"
+                ],
+                "summary": "ดูค่า Sensor ที่ผิดปกติ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID ไม่ส่ง = ทุก Device",
+                        "name": "device_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "เริ่ม (RFC 3339) ไม่ส่ง = 24 ชั่วโมงก่อน to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "สิ้นสุด (RFC 3339, ไม่รวม) ไม่ส่ง = ตอนนี้",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "data": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/models.SensorData"
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/audit": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "แสดงประวัติการกระทำสำคัญของ Admin เรียงจากล่าสุด\nAdmin ขององค์กรเห็นเฉพาะรายการขององค์กรตัวเอง ส่วนรายการระดับระบบเห็นเฉพาะ Superadmin",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "ดู Audit Log (Admin Only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AuditLog"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/automation/rules": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rule ขององค์กร (Superadmin เห็นทุกองค์กร) พร้อมสถานะจากการประเมินล่าสุด",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Automation"
+                ],
+                "summary": "ดูรายการ Automation Rule",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AutomationRule"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "สั่ง Actuator เมื่อค่าเฉลี่ยของ Location (ช่วง automation.reading_window ล่าสุด) เข้าเงื่อนไขต่อเนื่อง for_seconds\nประเมินทุกครั้งที่มีค่าใหม่จาก Device ใต้ Location และทุก automation.interval\nRule ใหม่เป็น dry_run ถ้าไม่ระบุ mode: ดูใน Log ว่าจะสั่งอะไรเมื่อไรก่อนเปิดใช้จริง",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Automation"
+                ],
+                "summary": "สร้าง Automation Rule (Admin Only)",
+                "parameters": [
+                    {
+                        "description": "ข้อมูล Rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AutomationRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "ไม่พบ Location หรือ Actuator",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/automation/rules/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "แก้ค่าตั้งหรือเปลี่ยน mode แล้วเริ่มนับเงื่อนไขใหม่ (Cooldown จากการทำงานครั้งล่าสุดยังมีผล)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Automation"
+                ],
+                "summary": "แก้ไข Automation Rule (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ข้อมูลที่ต้องการแก้",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.UpdateRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AutomationRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ลบ Rule พร้อม Log ของ Rule (คำสั่งที่ Rule เคยสั่งยังอยู่ในประวัติของ Actuator)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Automation"
+                ],
+                "summary": "ลบ Automation Rule (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/automation/rules/{id}/log": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "200 รายการล่าสุด: fired (สั่งแล้ว), dry_run (จะสั่ง), override (ข้ามเพราะ Manual Override), failed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Automation"
+                ],
+                "summary": "ดู Log ของ Automation Rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AutomationLog"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/calibrations/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ค่าที่บันทึกไปแล้วไม่เปลี่ยน ใช้ recompute เพื่อกลับไปใช้ค่าดิบ",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Calibration"
+                ],
+                "summary": "ลบ Calibration (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Calibration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/device/commands": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "คืนคำสั่งที่รออยู่ของ Device (X-Device-ID) เรียงตามลำดับที่สั่ง แต่ละคำสั่งถูกส่งครั้งเดียว\nDevice ต้องยืนยันผลผ่าน /device/commands/{id}/ack ภายใน actuator.ack_timeout",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actuator"
+                ],
+                "summary": "Device ดึงคำสั่ง (Poll)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "รหัส Device (ไม่ส่ง = ใช้ชื่อเจ้าของ API Key)",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ActuatorCommand"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "บัญชีไม่สังกัดองค์กร (Superadmin)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/device/commands/{id}/ack": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "รายงานว่าทำตามคำสั่งแล้ว (acknowledged) หรือทำไม่ได้ (failed) ส่งผลเดิมซ้ำได้",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Actuator"
+                ],
+                "summary": "Device ยืนยันคำสั่ง",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "รหัส Device (ไม่ส่ง = ใช้ชื่อเจ้าของ API Key)",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Command ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ผลของคำสั่ง",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AcknowledgeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActuatorCommand"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "ไม่พบคำสั่งของ Device นี้",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "คำสั่งไม่ได้รอการยืนยัน (หมดเวลาแล้ว หรือรายงานผลไปแล้ว)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Device ขององค์กรที่เคยส่งค่า (Superadmin เห็นทุกองค์กร) พร้อมเวลาที่ส่งค่าล่าสุด",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sensor"
+                ],
+                "summary": "ดูรายการ Device",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Device"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/assignments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ทุกการติดตั้งของ Device เรียงจากเก่าไปใหม่ (ended_at null = ยังติดตั้งอยู่)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Location"
+                ],
+                "summary": "ดูประวัติการติดตั้ง Device",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DeviceAssignment"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/calibrations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ทุก Calibration ของ Device (รวมที่สิ้นสุดแล้ว) เรียงตาม metric และ valid_from",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Calibration"
+                ],
+                "summary": "ดู Calibration ของ Device",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Calibration"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ค่าที่ Device ส่งมาในช่วง [valid_from, valid_until) ถูกแก้ตอนบันทึก ค่าดิบเก็บไว้ใน raw_temp / raw_humidity\nCalibration เดิมของ Metric เดียวกันที่ยังไม่สิ้นสุดจะสิ้นสุดที่ valid_from ของตัวใหม่ ช่วงอื่นห้ามซ้อนกัน\nค่าที่บันทึกไปแล้วไม่เปลี่ยน ใช้ POST /api/devices/{id}/calibrations/recompute",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Calibration"
+                ],
+                "summary": "เพิ่ม Calibration (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ข้อมูล Calibration",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateCalibrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Calibration"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "ช่วงเวลาซ้อนกับ Calibration อื่น",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/calibrations/recompute": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "แก้ temp / humidity ของค่าที่ Device บันทึกในช่วง [from, to) ใหม่จากค่าดิบตาม Calibration ที่ใช้ได้ ณ เวลาที่บันทึก\nใช้หลังเพิ่ม Calibration ย้อนหลังหรือลบ Calibration (Automation Rule ไม่ถูกประเมินซ้ำ)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Calibration"
+                ],
+                "summary": "คำนวณค่าย้อนหลังใหม่ (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ช่วงเวลา",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RecomputeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated: จำนวนค่าที่เปลี่ยน",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/location": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ปิดการติดตั้งเดิมที่ started_at แล้วเริ่มติดตั้งที่ Bin ใหม่ (location_id null = ถอดออก)\nค่าที่บันทึกก่อน started_at ยังนับให้ Location เดิม",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Location"
+                ],
+                "summary": "ติดตั้ง / ย้าย / ถอด Device (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Bin และเวลาที่ติดตั้ง",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AssignDeviceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "assignment (null เมื่อถอดออก)",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeviceAssignment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/events/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ใช้แก้บันทึกที่ผิด (บันทึกใหม่แทน)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Husbandry"
+                ],
+                "summary": "ลบบันทึกการดูแล (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "คืน 200 เสมอถ้า Process ยังตอบได้ (ไม่ตรวจฐานข้อมูล)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Liveness Probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/locations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Location ทั้งหมดขององค์กร (Superadmin เห็นทุกองค์กร) ประกอบเป็นต้นไม้ได้จาก parent_id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Location"
+                ],
+                "summary": "ดูรายการ Location",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Location"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "สร้าง Location ใต้ parent_id เช่น ฟาร์ม → โรงเรือน → แถว → Bin (Bin มี Location ลูกไม่ได้)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Location"
+                ],
+                "summary": "สร้าง Location (Admin Only)",
+                "parameters": [
+                    {
+                        "description": "ข้อมูล Location",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateLocationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "ไม่พบ Parent",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "ชื่อซ้ำกับพี่น้อง (ไม่สนตัวพิมพ์)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "เปลี่ยนชื่อ ชนิด หรือย้ายไปใต้ Location อื่น (ลูกหลานย้ายตาม) ค่า Sensor ที่บันทึกแล้วไม่เปลี่ยน\nBin ที่เคยติดตั้ง Device เปลี่ยนเป็นชนิดอื่นไม่ได้",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Location"
+                ],
+                "summary": "แก้ไข / ย้าย Location (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ข้อมูลที่ต้องการแก้",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.UpdateLocationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ลบได้เฉพาะ Location ที่ไม่มีลูกและไม่เคยติดตั้ง Device (เก็บประวัติของข้อมูลย้อนหลังไว้)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Location"
+                ],
+                "summary": "ลบ Location (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "ยังมี Location ลูก เคยติดตั้ง Device หรือมี Automation Rule ใช้อยู่",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/{id}/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "บันทึกของ Location นี้และทุก Bin ที่อยู่ใต้ เรียงตาม occurred_at",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Husbandry"
+                ],
+                "summary": "ดูบันทึกการดูแล Bin",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "เริ่ม (RFC 3339) ไม่ส่ง = ไม่จำกัด",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "สิ้นสุด (RFC 3339, ไม่รวม) ไม่ส่ง = ไม่จำกัด",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "feeding | bedding | moisture | harvest | population",
+                        "name": "kind",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.HusbandryEvent"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ให้อาหาร เปลี่ยนวัสดุรองพื้น เติมน้ำ เก็บเกี่ยว หรือประมาณจำนวนไส้เดือน (เฉพาะ Location ชนิด bin)\nส่งได้เฉพาะค่าของ kind นั้น ค่าอื่นต้องว่าง น้ำหนัก / ปริมาณน้ำต้องมากกว่า 0 และไม่เกิน 1000",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Husbandry"
+                ],
+                "summary": "บันทึกการดูแล Bin",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID (Bin)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ข้อมูลการดูแล",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RecordEventRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HusbandryEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/{id}/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ค่าเฉลี่ย / ต่ำสุด / สูงสุดของทุก Device ใต้ Location นี้ (ทุกชั้น) แยกตาม Location ลูกด้วย\nค่าแต่ละค่านับให้ Bin ที่ Device ติดตั้งอยู่ ณ เวลาที่บันทึก (ย้าย Device แล้วข้อมูลเก่าไม่ย้ายตาม)\nส่ง interval (เช่น 15m, 1h อย่างน้อย 1m ไม่เกิน 1000 ช่วง) เพื่อรับค่ารายช่วงใน series",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Location"
+                ],
+                "summary": "สรุปค่า Sensor ตาม Location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "เริ่ม (RFC 3339) ไม่ส่ง = 24 ชั่วโมงก่อน to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "สิ้นสุด (RFC 3339, ไม่รวม) ไม่ส่ง = ตอนนี้",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ความยาวแต่ละช่วง เช่น 1h",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LocationStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/locations/{id}/timeline": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ค่า Sensor ทุกค่า (type reading) และบันทึกการดูแล (type event) ของ Location นี้และทุก Bin ที่อยู่ใต้ เรียงตามเวลา\nใช้ดูว่าการให้อาหาร / เติมน้ำส่งผลต่ออุณหภูมิและความชื้นอย่างไร ช่วงยาวได้ไม่เกิน 7 วัน",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Husbandry"
+                ],
+                "summary": "ดู Timeline ของ Location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "เริ่ม (RFC 3339) ไม่ส่ง = 24 ชั่วโมงก่อน to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "สิ้นสุด (RFC 3339, ไม่รวม) ไม่ส่ง = ตอนนี้",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TimelineEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "รับ Username/Password (และ otp_code ถ้าเปิด 2FA) เพื่อรับ API Key\nUsername ที่ไม่มีอยู่และรหัสผ่านผิดตอบ 401 invalid_credentials เหมือนกัน รหัส 2FA แต่ละรหัสใช้ได้ครั้งเดียว\nAPI Key ที่ได้คือ Key ถาวรของบัญชี (ไม่ใช่ Session ที่หมดอายุ) 2FA ป้องกันเฉพาะขั้นตอน Login:\nผู้ที่ได้ Key ไปแล้วใช้ API ได้โดยไม่ต้องผ่าน 2FA อีก ถ้า Key หลุดให้ออก Key ใหม่ด้วย ` + "`" + `worm key revoke \u003cusername\u003e` + "`" + `",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "เข้าสู่ระบบ (Login)",
+                "parameters": [
+                    {
+                        "description": "ข้อมูล Login",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/oidc": {
+            "get": {
+                "description": "Redirect ไปหน้า Login ของ Identity Provider (Authorization Code + PKCE)",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "เริ่มล็อกอินผ่าน SSO (OpenID Connect)",
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    }
+                }
+            }
+        },
+        "/login/oidc/callback": {
+            "get": {
+                "description": "แลก Authorization Code เป็น ID Token, สร้าง User อัตโนมัติเมื่อเข้าครั้งแรก และคืน API Key",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Callback จาก SSO",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/password": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ต้องใส่รหัสผ่านเดิม ใช้ได้แม้ถูกบังคับให้เปลี่ยนรหัสผ่าน (must_change_password)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "เปลี่ยนรหัสผ่านของตัวเอง",
+                "parameters": [
+                    {
+                        "description": "รหัสผ่านเดิมและรหัสผ่านใหม่",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Request / Latency ต่อ Route, การรับค่า Sensor ต่อ Device, ค่าที่ถูกปฏิเสธ, Connection Pool และการยืนยันตัวตนที่ไม่ผ่าน (Text Format)\nMetric ของ Device แยกด้วย Label organization (ID ขององค์กร) และ device จึงมีค่าของทุกองค์กร\nชื่อ Device มาจาก Client จึงแยก Series ได้ไม่เกิน 1000 Device ต่อ Metric ที่เกินรวมอยู่ใน device=other\nถ้าตั้ง metrics.token ต้องส่ง Authorization: Bearer \u003ctoken\u003e (บังคับใน Production)",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Prometheus Metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Superadmin เห็นทุกองค์กร ส่วน Admin ขององค์กรเห็นเฉพาะองค์กรตัวเอง",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "ดูรายชื่อองค์กร (Admin Only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Organization"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "สร้างองค์กร (Tenant) ใหม่ จากนั้นสร้าง Admin ขององค์กรด้วย /register พร้อม organization_id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Organization"
+                ],
+                "summary": "สร้างองค์กรใหม่ (Superadmin Only)",
+                "parameters": [
+                    {
+                        "description": "ข้อมูลองค์กร",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateOrganizationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Organization"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "ชื่อองค์กรซ้ำ (ไม่สนตัวพิมพ์)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "ตรวจการเชื่อมต่อฐานข้อมูล, Migration และ Background Worker คืน 503 ถ้ามีรายการใดไม่พร้อม",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness Probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ReadyResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ReadyResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "สร้าง User หรือ Admin ใหม่ โดยต้องใช้ Key ของ Admin\nAdmin ขององค์กรสร้างได้เฉพาะในองค์กรตัวเอง ส่วน Role superadmin สร้างได้เฉพาะ Superadmin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "สร้าง User ใหม่ (Admin Only)",
+                "parameters": [
+                    {
+                        "description": "ข้อมูล User ใหม่",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "ไม่พบองค์กร",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Username ซ้ำ (ไม่สนตัวพิมพ์)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Schedule ขององค์กร (Superadmin เห็นทุกองค์กร) พร้อมเวลาทำงานครั้งถัดไป",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedule"
+                ],
+                "summary": "ดูรายการ Schedule",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Schedule"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ทำงานตาม Cron ใน Time Zone ที่กำหนด เช่น เปิดพัดลมระบายอากาศทุกเช้า 06:00 เวลาไทย\nรอบที่เลยเวลาเกิน schedule.misfire_grace (เช่น Server ปิดอยู่) จะถูกข้ามและบันทึกเป็น skipped",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedule"
+                ],
+                "summary": "สร้าง Schedule (Admin Only)",
+                "parameters": [
+                    {
+                        "description": "ข้อมูล Schedule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "ไม่พบ Actuator",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "แก้ค่าแล้วคำนวณเวลาทำงานครั้งถัดไปใหม่ (ปิดด้วย enabled: false)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedule"
+                ],
+                "summary": "แก้ไข Schedule (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ข้อมูลที่ต้องการแก้",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.UpdateScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ลบ Schedule พร้อมประวัติการทำงาน (คำสั่งที่เคยสั่งยังอยู่ในประวัติของ Actuator)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedule"
+                ],
+                "summary": "ลบ Schedule (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}/runs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "200 รายการล่าสุด: succeeded, failed หรือ skipped (เลยเวลา / Actuator อยู่ในโหมด Manual Override)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedule"
+                ],
+                "summary": "ดูประวัติการทำงานของ Schedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ScheduleRun"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/sensor": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ดึงข้อมูลอุณหภูมิและความชื้นทั้งหมดขององค์กร (Superadmin เห็นทุกองค์กร)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sensor"
+                ],
+                "summary": "ดูประวัติ Sensor",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SensorData"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "รับค่า temp (-40 ถึง 80) และ humidity (0 ถึง 100) แล้วบันทึกลงฐานข้อมูล\nค่านอกช่วงนี้ไม่ถูกบันทึก ตอบ 400 reading_out_of_range และนับใน worm_readings_rejected_total{reason=\"out_of_range\"}\nจำกัดจำนวนต่อ Device (rate_limit.ingest_*) และต่อวัน (rate_limit.daily_device_quota)\nค่าเป็นขององค์กรของเจ้าของ API Key และลงทะเบียน Device ให้อัตโนมัติเมื่อส่งค่าครั้งแรก\nค่าถูกแก้ตาม Calibration ของ Device ก่อนบันทึก (ค่าดิบเก็บไว้ใน raw_temp / raw_humidity)\nแล้วคำนวณจุดน้ำค้าง ความชื้นสัมบูรณ์ และ VPD จากค่าที่แก้แล้วเก็บไว้ใน derived\nค่าดิบถูกตรวจเทียบกับค่าล่าสุดของ Device (z-score, spike, stuck) ค่าที่ผิดปกติยังถูกบันทึกพร้อม anomalies (ดู GET /api/anomalies)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sensor"
+                ],
+                "summary": "บันทึกค่าอุณหภูมิและความชื้น",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "รหัส Device (ไม่ส่ง = ใช้ชื่อเจ้าของ API Key)",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "ข้อมูล Sensor",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SensorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: Saved",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Body ไม่ถูกต้อง หรือค่านอกช่วง (reading_out_of_range)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "บัญชีไม่สังกัดองค์กร (Superadmin)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "เกิน Rate Limit หรือ Quota รายวัน (ดู Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "แสดงรายชื่อ User และ Role ทั้งหมด (Admin Only) Admin ขององค์กรเห็นเฉพาะองค์กรตัวเอง",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "ดูรายชื่อ User ทั้งหมด",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "อัปเดต Username, Password, Role, องค์กร หรือการปิด Password Login (ส่งเฉพาะค่าที่ต้องการแก้)\nAdmin ขององค์กรแก้ได้เฉพาะ User ในองค์กรตัวเอง (User ขององค์กรอื่นตอบ 404)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "แก้ไขข้อมูล User (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ข้อมูลที่ต้องการแก้",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Username ซ้ำ (ไม่สนตัวพิมพ์)",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/2fa": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "ลบ TOTP Secret และ Recovery Code ทั้งหมดของ User (กรณีทำมือถือหาย) และบันทึกลง Audit Log",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "2FA"
+                ],
+                "summary": "รีเซ็ต 2FA ของ User (Admin Only)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apierror.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Version, Commit และเวลา Build (ใส่ตอน Build ด้วย ldflags)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "เวอร์ชันของ Server",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/buildinfo.Info"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apierror.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "new_password"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be at least 8 characters"
+                }
+            }
+        },
+        "apierror.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code รหัสคงที่สำหรับโปรแกรม (ดูรายการใน apierror)",
+                    "type": "string",
+                    "example": "validation_failed"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "Request body is invalid"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apierror.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/register"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f0c9a52-4a0e-4d4b-9d7e-2f3c1b7a9e10"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Bad Request"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:worm:problem:validation_failed"
+                }
+            }
+        },
+        "buildinfo.Info": {
+            "type": "object",
+            "properties": {
+                "build_time": {
+                    "type": "string",
+                    "example": "2026-10-19T06:00:00Z"
+                },
+                "commit": {
+                    "type": "string",
+                    "example": "8bf7b36"
+                },
+                "go_version": {
+                    "type": "string",
+                    "example": "go1.22.5"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "v1.2.0"
+                }
+            }
+        },
+        "controllers.AcknowledgeRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "error": {
+                    "description": "เหตุผลเมื่อ failed",
+                    "type": "string",
+                    "example": "relay did not switch"
+                },
+                "status": {
+                    "description": "acknowledged = ทำตามแล้ว | failed = ทำไม่ได้",
+                    "type": "string",
+                    "enum": [
+                        "acknowledged",
+                        "failed"
+                    ],
+                    "example": "acknowledged"
+                }
+            }
+        },
+        "controllers.AssignDeviceRequest": {
+            "type": "object",
+            "properties": {
+                "location_id": {
+                    "description": "Bin ที่ติดตั้ง (null = ถอดออก)",
+                    "type": "integer",
+                    "example": 7
+                },
+                "started_at": {
+                    "description": "เวลาที่ติดตั้งจริง (RFC 3339) ไม่ส่ง = ตอนนี้",
+                    "type": "string",
+                    "example": "2026-10-19T08:00:00Z"
+                }
+            }
+        },
+        "controllers.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string",
+                    "example": "old_pass123"
+                },
+                "new_password": {
+                    "type": "string",
+                    "minLength": 8,
+                    "example": "new_pass123"
+                }
+            }
+        },
+        "controllers.CommandRequest": {
+            "type": "object",
+            "required": [
+                "action"
+            ],
+            "properties": {
+                "action": {
+                    "description": "on | off | duty",
+                    "type": "string",
+                    "example": "on"
+                },
+                "duration_seconds": {
+                    "description": "ทำกี่วินาทีแล้วกลับเป็นปิด 1-86400 (ไม่ส่ง = จนกว่าจะมีคำสั่งใหม่, ใช้กับ off ไม่ได้)",
+                    "type": "integer",
+                    "example": 120
+                },
+                "duty_cycle": {
+                    "description": "% ของเวลาที่เปิดในแต่ละรอบ 1-100 (เฉพาะ duty)",
+                    "type": "integer",
+                    "example": 25
+                }
+            }
+        },
+        "controllers.CreateActuatorRequest": {
+            "type": "object",
+            "required": [
+                "device_id",
+                "kind",
+                "name"
+            ],
+            "properties": {
+                "channel": {
+                    "description": "ช่อง Relay บน Device (0-255) ไม่ซ้ำภายใน Device",
+                    "type": "integer",
+                    "example": 0
+                },
+                "device_id": {
+                    "description": "Device ที่ควบคุม Relay นี้ (ดู GET /api/devices)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "kind": {
+                    "description": "ตั้งเองได้ เช่น fan, mister, heater",
+                    "type": "string",
+                    "example": "mister"
+                },
+                "name": {
+                    "description": "1-100 ตัวอักษร ไม่ซ้ำภายใน Device",
+                    "type": "string",
+                    "example": "Mister 1"
+                }
+            }
+        },
+        "controllers.CreateCalibrationRequest": {
+            "type": "object",
+            "required": [
+                "method",
+                "metric"
+            ],
+            "properties": {
+                "gain": {
+                    "description": "0.5 ถึง 2",
+                    "type": "number"
+                },
+                "method": {
+                    "description": "offset | linear | table",
+                    "type": "string",
+                    "example": "offset"
+                },
+                "metric": {
+                    "description": "temperature | humidity",
+                    "type": "string",
+                    "example": "temperature"
+                },
+                "notes": {
+                    "description": "ไม่เกิน 1000 ตัวอักษร",
+                    "type": "string",
+                    "example": "checked against a reference thermometer"
+                },
+                "offset": {
+                    "description": "-50 ถึง 50",
+                    "type": "number",
+                    "example": -1.5
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CalibrationPoint"
+                    }
+                },
+                "valid_from": {
+                    "description": "ไม่ส่ง = ตอนนี้ (ย้อนหลังได้ แล้วเรียก recompute)",
+                    "type": "string",
+                    "example": "2026-10-01T00:00:00Z"
+                },
+                "valid_until": {
+                    "description": "ไม่ส่ง = ไม่สิ้นสุด",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.CreateLocationRequest": {
+            "type": "object",
+            "required": [
+                "kind",
+                "name"
+            ],
+            "properties": {
+                "kind": {
+                    "description": "ตั้งเองได้ (farm, shed, row, ...) มีเพียง bin ที่ติดตั้ง Device ได้และมีลูกไม่ได้",
+                    "type": "string",
+                    "example": "shed"
+                },
+                "name": {
+                    "description": "1-100 ตัวอักษร ไม่ซ้ำกับพี่น้อง (ไม่สนตัวพิมพ์)",
+                    "type": "string",
+                    "example": "Shed 2"
+                },
+                "organization_id": {
+                    "description": "Superadmin ต้องระบุเมื่อสร้างชั้นบนสุด (นอกนั้นใช้องค์กรของผู้เรียก / ของ Parent)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "parent_id": {
+                    "description": "ไม่ส่ง = ชั้นบนสุด",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "controllers.CreateOrganizationRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "description": "1-100 ตัวอักษร ไม่ซ้ำ (ไม่สนตัวพิมพ์)",
+                    "type": "string",
+                    "example": "Green Farm"
+                }
+            }
+        },
+        "controllers.CreateRuleRequest": {
+            "type": "object",
+            "required": [
+                "actuator_id",
+                "cooldown_seconds",
+                "location_id",
+                "metric",
+                "name",
+                "operator"
+            ],
+            "properties": {
+                "actuator_id": {
+                    "description": "Actuator ที่จะสั่ง (องค์กรเดียวกับ Location)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "command": {
+                    "description": "คำสั่ง (เหมือน POST /api/actuators/{id}/commands)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/controllers.CommandRequest"
+                        }
+                    ]
+                },
+                "cooldown_seconds": {
+                    "description": "เว้นกี่วินาทีระหว่างการทำงานแต่ละครั้ง (60-604800)",
+                    "type": "integer",
+                    "example": 900
+                },
+                "for_seconds": {
+                    "description": "เงื่อนไขต้องเป็นจริงต่อเนื่องกี่วินาที (0-86400)",
+                    "type": "integer",
+                    "example": 600
+                },
+                "hysteresis": {
+                    "description": "เมื่อเงื่อนไขเป็นจริงแล้ว ค่าต้องเลย Threshold ไปอีกเท่านี้จึงกลับเป็นเท็จ (\u003e= 0)",
+                    "type": "number",
+                    "example": 2
+                },
+                "location_id": {
+                    "description": "ใช้ค่าเฉลี่ยของทุก Device ใต้ Location นี้ (รวมทุกชั้น)",
+                    "type": "integer",
+                    "example": 4
+                },
+                "metric": {
+                    "description": "temperature | humidity",
+                    "type": "string",
+                    "example": "humidity"
+                },
+                "mode": {
+                    "description": "active | dry_run (บันทึกว่าจะสั่ง แต่ไม่สั่งจริง) | disabled ไม่ส่ง = dry_run",
+                    "type": "string",
+                    "example": "dry_run"
+                },
+                "name": {
+                    "description": "1-100 ตัวอักษร",
+                    "type": "string",
+                    "example": "Bin 4 humidity"
+                },
+                "operator": {
+                    "description": "below | above",
+                    "type": "string",
+                    "example": "below"
+                },
+                "threshold": {
+                    "type": "number",
+                    "example": 65
+                }
+            }
+        },
+        "controllers.CreateScheduleRequest": {
+            "type": "object",
+            "required": [
+                "cron",
+                "job",
+                "name"
+            ],
+            "properties": {
+                "actuator_id": {
+                    "description": "เฉพาะ actuator_command",
+                    "type": "integer",
+                    "example": 1
+                },
+                "command": {
+                    "$ref": "#/definitions/controllers.CommandRequest"
+                },
+                "cron": {
+                    "description": "Cron 5 ช่อง (minute hour day-of-month month day-of-week) หรือ @daily, @hourly, ...",
+                    "type": "string",
+                    "example": "0 6 * * *"
+                },
+                "enabled": {
+                    "description": "ไม่ส่ง = เปิด",
+                    "type": "boolean",
+                    "example": true
+                },
+                "job": {
+                    "description": "actuator_command | prune_sensor_data",
+                    "type": "string",
+                    "example": "actuator_command"
+                },
+                "name": {
+                    "description": "1-100 ตัวอักษร",
+                    "type": "string",
+                    "example": "Morning ventilation"
+                },
+                "organization_id": {
+                    "description": "Superadmin ต้องระบุสำหรับ prune_sensor_data (นอกนั้นใช้องค์กรของผู้เรียก / ของ Actuator)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "retention_days": {
+                    "description": "เฉพาะ prune_sensor_data: ลบค่า Sensor ที่เก่ากว่ากี่วัน (1-3650)",
+                    "type": "integer",
+                    "example": 90
+                },
+                "time_zone": {
+                    "description": "IANA Time Zone ที่ใช้อ่าน Cron (ไม่ส่ง = UTC)",
+                    "type": "string",
+                    "example": "Asia/Bangkok"
+                }
+            }
+        },
+        "controllers.LoginRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "otp_code": {
+                    "description": "ขั้นที่ 2 (เฉพาะบัญชีที่เปิด 2FA): รหัสจากแอป Authenticator หรือ Recovery Code",
+                    "type": "string",
+                    "example": "123456"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "pass1234"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "admin"
+                }
+            }
+        },
+        "controllers.OverrideRequest": {
+            "type": "object",
+            "properties": {
+                "duration_seconds": {
+                    "description": "Override นานกี่วินาที 1-604800 (ไม่ส่ง = จนกว่าจะปิดเอง)",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "enabled": {
+                    "description": "true = Automation Rule / Schedule ไม่สั่ง Actuator นี้",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "controllers.ReadyResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ready"
+                }
+            }
+        },
+        "controllers.RecomputeRequest": {
+            "type": "object",
+            "required": [
+                "from"
+            ],
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "2026-10-01T00:00:00Z"
+                },
+                "to": {
+                    "description": "ไม่ส่ง = ตอนนี้",
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.RecordEventRequest": {
+            "type": "object",
+            "required": [
+                "kind"
+            ],
+            "properties": {
+                "bedding_material": {
+                    "type": "string",
+                    "example": "shredded cardboard"
+                },
+                "castings_kg": {
+                    "type": "number",
+                    "example": 12
+                },
+                "feed_type": {
+                    "type": "string",
+                    "example": "vegetable scraps"
+                },
+                "kind": {
+                    "description": "feeding | bedding | moisture | harvest | population",
+                    "type": "string",
+                    "example": "feeding"
+                },
+                "notes": {
+                    "description": "ไม่เกิน 1000 ตัวอักษร",
+                    "type": "string",
+                    "example": "coffee grounds from the cafe"
+                },
+                "occurred_at": {
+                    "description": "ไม่ส่ง = ตอนนี้ (ห้ามอยู่ในอนาคต)",
+                    "type": "string",
+                    "example": "2026-10-19T08:00:00Z"
+                },
+                "water_liters": {
+                    "type": "number",
+                    "example": 1.5
+                },
+                "weight_kg": {
+                    "type": "number",
+                    "example": 2.5
+                },
+                "worm_count": {
+                    "type": "integer",
+                    "example": 4000
+                },
+                "worms_kg": {
+                    "type": "number",
+                    "example": 0.8
+                }
+            }
+        },
+        "controllers.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "role",
+                "username"
+            ],
+            "properties": {
+                "organization_id": {
+                    "description": "องค์กรของ User ใหม่ (ไม่ส่ง = องค์กรของผู้สร้าง, Superadmin ต้องส่งยกเว้นสร้าง Superadmin)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "password": {
+                    "type": "string",
+                    "example": "pass1234"
+                },
+                "role": {
+                    "description": "superadmin, admin หรือ user",
+                    "type": "string",
+                    "example": "user"
+                },
+                "username": {
+                    "description": "3-64 ตัว: ตัวอักษร ตัวเลข . _ - @ (ไม่สนตัวพิมพ์ตอนตรวจชื่อซ้ำ)",
+                    "type": "string",
+                    "example": "staff01"
+                }
+            }
+        },
+        "controllers.SensorRequest": {
+            "type": "object",
+            "properties": {
+                "humidity": {
+                    "type": "number",
                     "example": 60
                 },
-                "temp": {
+                "temp": {
+                    "type": "number",
+                    "example": 32.5
+                }
+            }
+        },
+        "controllers.TwoFactorCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "controllers.UpdateActuatorRequest": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "mister"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Mister 1"
+                }
+            }
+        },
+        "controllers.UpdateLocationRequest": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "example": "shed"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Shed 2B"
+                },
+                "parent_id": {
+                    "description": "ย้ายไปไว้ใต้ Location นี้ (0 = ย้ายเป็นชั้นบนสุด)",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "controllers.UpdateRuleRequest": {
+            "type": "object",
+            "properties": {
+                "actuator_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "command": {
+                    "description": "แทนคำสั่งเดิมทั้งชุด",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/controllers.CommandRequest"
+                        }
+                    ]
+                },
+                "cooldown_seconds": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "for_seconds": {
+                    "type": "integer",
+                    "example": 600
+                },
+                "hysteresis": {
+                    "type": "number",
+                    "example": 2
+                },
+                "location_id": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "metric": {
+                    "type": "string",
+                    "example": "humidity"
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Bin 4 humidity"
+                },
+                "operator": {
+                    "type": "string",
+                    "example": "below"
+                },
+                "threshold": {
+                    "type": "number",
+                    "example": 65
+                }
+            }
+        },
+        "controllers.UpdateScheduleRequest": {
+            "type": "object",
+            "properties": {
+                "actuator_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "command": {
+                    "description": "แทนคำสั่งเดิมทั้งชุด",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/controllers.CommandRequest"
+                        }
+                    ]
+                },
+                "cron": {
+                    "type": "string",
+                    "example": "30 5 * * *"
+                },
+                "enabled": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "job": {
+                    "type": "string",
+                    "example": "actuator_command"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Morning ventilation"
+                },
+                "retention_days": {
+                    "type": "integer",
+                    "example": 90
+                },
+                "time_zone": {
+                    "type": "string",
+                    "example": "Asia/Bangkok"
+                }
+            }
+        },
+        "controllers.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "organization_id": {
+                    "description": "ย้ายไปองค์กรอื่น (Superadmin เท่านั้น)",
+                    "type": "integer",
+                    "example": 2
+                },
+                "password": {
+                    "type": "string",
+                    "example": "new_pass123"
+                },
+                "password_login_disabled": {
+                    "description": "true = บังคับให้ล็อกอินผ่าน SSO เท่านั้น",
+                    "type": "boolean",
+                    "example": false
+                },
+                "role": {
+                    "type": "string",
+                    "example": "admin"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "new_name"
+                }
+            }
+        },
+        "models.Actuator": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "description": "ช่อง Relay บน Device (ไม่ซ้ำภายใน Device, idx_actuators_device_channel)",
+                    "type": "integer",
+                    "example": 0
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "kind": {
+                    "description": "ตั้งเองได้ เช่น fan, mister, heater",
+                    "type": "string",
+                    "example": "mister"
+                },
+                "manual_override": {
+                    "description": "Manual Override: Automation Rule / Schedule ไม่สั่ง Actuator นี้จนถึง OverrideUntil (null = จนกว่าจะปิด)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "description": "ไม่ซ้ำภายใน Device เดียวกัน (idx_actuators_device_name_lower)",
+                    "type": "string",
+                    "example": "Mister 1"
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "override_until": {
+                    "type": "string"
+                },
+                "state": {
+                    "description": "คำสั่งล่าสุดที่ Device ยืนยันแล้ว (\"\" = ยังไม่เคยได้รับคำสั่ง)",
+                    "type": "string",
+                    "example": "on"
+                },
+                "state_changed_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ActuatorCommand": {
+            "type": "object",
+            "properties": {
+                "acknowledged_at": {
+                    "type": "string"
+                },
+                "action": {
+                    "description": "on | off | duty",
+                    "type": "string",
+                    "example": "on"
+                },
+                "actuator_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "channel": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "description": "คัดลอกจาก Actuator ตอนสั่ง เพื่อให้ Device Poll ได้โดยไม่ต้อง Join",
+                    "type": "integer",
+                    "example": 1
+                },
+                "duration_seconds": {
+                    "description": "ทำตามคำสั่งกี่วินาทีแล้วกลับเป็นปิด (null = จนกว่าจะมีคำสั่งใหม่)",
+                    "type": "integer",
+                    "example": 120
+                },
+                "duty_cycle": {
+                    "description": "1-100 (เฉพาะ duty)",
+                    "type": "integer",
+                    "example": 25
+                },
+                "error": {
+                    "description": "เหตุผลเมื่อ failed (จาก Device หรือจากระบบเมื่อรอการยืนยันนานเกินไป)",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "Device ต้อง Poll ก่อนเวลานี้ ไม่งั้นคำสั่งหมดอายุ (actuators.command_ttl)",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "requested_by": {
+                    "description": "User ที่สั่ง (null = ระบบ)",
+                    "type": "integer",
+                    "example": 2
+                },
+                "source": {
+                    "description": "ผู้สั่ง (user = ผ่าน API)",
+                    "type": "string",
+                    "example": "user"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "queued"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Anomaly": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "description": "zscore | spike | stuck",
+                    "type": "string",
+                    "example": "spike"
+                },
+                "metric": {
+                    "description": "temperature | humidity",
+                    "type": "string",
+                    "example": "temperature"
+                },
+                "score": {
+                    "description": "zscore: z-score | spike: อัตราการเปลี่ยนต่อนาที | stuck: จำนวนค่าที่ซ้ำ",
+                    "type": "number",
+                    "example": 4.5
+                }
+            }
+        },
+        "models.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "2fa.reset"
+                },
+                "actor_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "organization_id": {
+                    "description": "null = การกระทำระดับระบบ (Superadmin / CLI)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "target_id": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.AutomationLog": {
+            "type": "object",
+            "properties": {
+                "command_id": {
+                    "description": "คำสั่งที่ส่ง (เฉพาะ fired)",
+                    "type": "integer",
+                    "example": 10
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string",
+                    "example": "would send on for 120s to Mister 1"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "outcome": {
+                    "description": "fired | dry_run | override | failed",
+                    "type": "string",
+                    "example": "dry_run"
+                },
+                "rule_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "value": {
+                    "description": "ค่าที่ทำให้ Rule ทำงาน",
+                    "type": "number",
+                    "example": 63.5
+                }
+            }
+        },
+        "models.AutomationRule": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "on"
+                },
+                "actuator_id": {
+                    "description": "การกระทำ (เหมือน POST /api/actuators/{id}/commands)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "condition_met": {
+                    "description": "สถานะจากการประเมินล่าสุด (ระบบเป็นผู้แก้)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "condition_since": {
+                    "type": "string"
+                },
+                "cooldown_seconds": {
+                    "description": "เว้นอย่างน้อยกี่วินาทีระหว่างการทำงานแต่ละครั้ง",
+                    "type": "integer",
+                    "example": 900
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "duration_seconds": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "duty_cycle": {
+                    "type": "integer"
+                },
+                "fired_count": {
+                    "description": "จำนวนครั้งที่ทำงาน (ใช้กันสอง Instance ทำงานซ้ำในรอบเดียวกัน)",
+                    "type": "integer",
+                    "example": 3
+                },
+                "for_seconds": {
+                    "description": "เงื่อนไขต้องเป็นจริงต่อเนื่องกี่วินาทีก่อนทำงาน",
+                    "type": "integer",
+                    "example": 600
+                },
+                "hysteresis": {
+                    "description": "เงื่อนไขที่เป็นจริงแล้วจะกลับเป็นเท็จเมื่อค่าเลย Threshold ไปอีก Hysteresis (กันการกระพริบรอบ Threshold)",
+                    "type": "number",
+                    "example": 2
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_evaluated_at": {
+                    "type": "string"
+                },
+                "last_fired_at": {
+                    "type": "string"
+                },
+                "last_value": {
+                    "type": "number",
+                    "example": 63.5
+                },
+                "location_id": {
+                    "description": "เงื่อนไข: ค่าเฉลี่ยของ Metric ทุก Device ใต้ Location ในช่วง automation.reading_window ล่าสุด",
+                    "type": "integer",
+                    "example": 4
+                },
+                "metric": {
+                    "type": "string",
+                    "example": "humidity"
+                },
+                "mode": {
+                    "description": "active | dry_run | disabled",
+                    "type": "string",
+                    "example": "dry_run"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Bin 4 humidity"
+                },
+                "operator": {
+                    "type": "string",
+                    "example": "below"
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "threshold": {
+                    "type": "number",
+                    "example": 65
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Calibration": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "gain": {
+                    "description": "ใช้เฉพาะ linear",
+                    "type": "number",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "method": {
+                    "description": "offset | linear | table",
+                    "type": "string",
+                    "example": "offset"
+                },
+                "metric": {
+                    "description": "temperature | humidity",
+                    "type": "string",
+                    "example": "temperature"
+                },
+                "notes": {
+                    "type": "string",
+                    "example": "checked against a reference thermometer"
+                },
+                "offset": {
+                    "type": "number",
+                    "example": -1.5
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "points": {
+                    "description": "ใช้เฉพาะ table (เรียงตาม Raw)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CalibrationPoint"
+                    }
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CalibrationPoint": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "type": "number",
+                    "example": 25
+                },
+                "raw": {
+                    "type": "number",
+                    "example": 26.4
+                }
+            }
+        },
+        "models.ChildStats": {
+            "type": "object",
+            "properties": {
+                "location": {
+                    "$ref": "#/definitions/models.Location"
+                },
+                "summary": {
+                    "$ref": "#/definitions/models.SensorSummary"
+                }
+            }
+        },
+        "models.Device": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "X-Device-ID หรือชื่อเจ้าของ API Key, Unique ภายในองค์กรแบบไม่สนตัวพิมพ์ (idx_devices_org_name_lower)",
+                    "type": "string",
+                    "example": "bin-01"
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.DeviceAssignment": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "ended_at": {
+                    "description": "null = ยังติดตั้งอยู่ (มีได้รายการเดียวต่อ Device, idx_device_assignments_open)",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "location_id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "started_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HusbandryEvent": {
+            "type": "object",
+            "properties": {
+                "bedding_material": {
+                    "type": "string",
+                    "example": "shredded cardboard"
+                },
+                "castings_kg": {
                     "type": "number",
-                    "example": 32.5
+                    "example": 12
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "feed_type": {
+                    "type": "string",
+                    "example": "vegetable scraps"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "kind": {
+                    "description": "feeding | bedding | moisture | harvest | population",
+                    "type": "string",
+                    "example": "feeding"
+                },
+                "location_id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "notes": {
+                    "type": "string",
+                    "example": "coffee grounds from the cafe"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "recorded_by": {
+                    "description": "User ที่บันทึก",
+                    "type": "integer",
+                    "example": 2
+                },
+                "water_liters": {
+                    "type": "number",
+                    "example": 1.5
+                },
+                "weight_kg": {
+                    "type": "number",
+                    "example": 2.5
+                },
+                "worm_count": {
+                    "type": "integer",
+                    "example": 4000
+                },
+                "worms_kg": {
+                    "type": "number",
+                    "example": 0.8
                 }
             }
         },
-        "controllers.UpdateUserRequest": {
+        "models.Location": {
             "type": "object",
             "properties": {
-                "password": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "kind": {
+                    "description": "ชนิดตั้งชื่อเองได้ (farm, shed, row, ...) ยกเว้น bin ที่มีความหมายพิเศษ",
                     "type": "string",
-                    "example": "new_pass123"
+                    "example": "shed"
                 },
-                "role": {
+                "name": {
+                    "description": "ไม่ซ้ำกับพี่น้องภายใต้ Parent เดียวกัน (idx_locations_parent_name_lower)",
                     "type": "string",
-                    "example": "admin"
+                    "example": "Shed 2"
                 },
-                "username": {
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "parent_id": {
+                    "description": "null = ชั้นบนสุด (เช่นฟาร์ม)",
+                    "type": "integer",
+                    "example": 2
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LocationStats": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "description": "ค่าสรุปของ Location ลูกโดยตรงแต่ละตัว (รวมทุกชั้นใต้ลูกตัวนั้น)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ChildStats"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "location": {
+                    "$ref": "#/definitions/models.Location"
+                },
+                "series": {
+                    "description": "เฉพาะเมื่อขอ interval: ค่าสรุปรายช่วงเวลา (เฉพาะช่วงที่มีข้อมูล)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SensorSummary"
+                    }
+                },
+                "summary": {
+                    "$ref": "#/definitions/models.SensorSummary"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MetricSummary": {
+            "type": "object",
+            "properties": {
+                "avg": {
+                    "type": "number",
+                    "example": 18.2
+                },
+                "max": {
+                    "type": "number",
+                    "example": 20.7
+                },
+                "min": {
+                    "type": "number",
+                    "example": 15.9
+                }
+            }
+        },
+        "models.Organization": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "description": "Unique แบบไม่สนตัวพิมพ์ (idx_organizations_name_lower ใน Migration 0005)",
                     "type": "string",
-                    "example": "new_name"
+                    "example": "Green Farm"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Schedule": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "on"
+                },
+                "actuator_id": {
+                    "description": "เฉพาะ actuator_command (เหมือน POST /api/actuators/{id}/commands)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "cron": {
+                    "description": "Cron 5 ช่อง (minute hour day-of-month month day-of-week) หรือ @daily, @hourly, ...",
+                    "type": "string",
+                    "example": "0 6 * * *"
+                },
+                "duration_seconds": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "duty_cycle": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "job": {
+                    "description": "actuator_command | prune_sensor_data",
+                    "type": "string",
+                    "example": "actuator_command"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Morning ventilation"
+                },
+                "next_run_at": {
+                    "description": "เวลาทำงานครั้งถัดไป (null = ปิดอยู่) เก็บในฐานข้อมูลจึงไม่หายเมื่อ Restart",
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "retention_days": {
+                    "description": "เฉพาะ prune_sensor_data",
+                    "type": "integer"
+                },
+                "run_count": {
+                    "description": "จำนวนครั้งที่ถูกหยิบไปทำ (ใช้กันหลาย Instance ทำรอบเดียวกันซ้ำ)",
+                    "type": "integer",
+                    "example": 12
+                },
+                "time_zone": {
+                    "description": "IANA Time Zone ที่ใช้อ่าน Cron",
+                    "type": "string",
+                    "example": "Asia/Bangkok"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ScheduleRun": {
+            "type": "object",
+            "properties": {
+                "command_id": {
+                    "description": "คำสั่งที่ส่ง (เฉพาะ actuator_command ที่สำเร็จ)",
+                    "type": "integer",
+                    "example": 10
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string",
+                    "example": "sent on for 900s to Fan 1"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "outcome": {
+                    "description": "succeeded | failed | skipped",
+                    "type": "string",
+                    "example": "succeeded"
+                },
+                "schedule_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "scheduled_for": {
+                    "description": "เวลาที่ควรทำตาม Cron",
+                    "type": "string"
                 }
             }
         },
         "models.SensorData": {
             "type": "object",
             "properties": {
+                "anomalies": {
+                    "description": "ค่าผิดปกติที่ตรวจพบตอนบันทึก (เทียบค่าดิบกับค่าล่าสุดของ Device เดียวกัน) ไม่มี = ปกติหรือไม่ได้ตรวจ",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Anomaly"
+                    }
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "derived": {
+                    "description": "ค่าที่คำนวณจาก Temperature / Humidity ตอนบันทึก ตามชื่อของสูตร (ดู package derived)\nnull = ข้อมูลก่อนมีการคำนวณ (คำนวณได้ด้วย POST /api/devices/{id}/calibrations/recompute)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    },
+                    "example": {
+                        "absolute_humidity_gm3": 20.78,
+                        "dew_point_c": 23.73,
+                        "vpd_kpa": 1.954
+                    }
+                },
+                "device_id": {
+                    "description": "null = ข้อมูลก่อนมีการลงทะเบียน Device",
+                    "type": "integer",
+                    "example": 1
+                },
                 "humidity": {
                     "type": "number",
                     "example": 60
@@ -311,35 +4859,129 @@ const docTemplate = `{
                     "description": "ทำเหมือนกัน",
                     "type": "integer"
                 },
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "raw_humidity": {
+                    "type": "number",
+                    "example": 60
+                },
+                "raw_temp": {
+                    "description": "ค่าดิบที่ Device ส่งมา (ใช้คำนวณใหม่เมื่อเพิ่ม Calibration ย้อนหลัง)",
+                    "type": "number",
+                    "example": 34
+                },
                 "temp": {
+                    "description": "ค่าหลังแก้ตาม Calibration (ไม่มี Calibration = เท่าค่าดิบ)",
                     "type": "number",
                     "example": 32.5
                 }
             }
         },
-        "models.User": {
+        "models.SensorSummary": {
+            "type": "object",
+            "properties": {
+                "bucket_start": {
+                    "description": "จุดเริ่มของช่วงเวลา (เฉพาะเมื่อขอแบบแบ่งช่วง)",
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 288
+                },
+                "derived": {
+                    "description": "ค่าสรุปของค่าที่คำนวณตอนบันทึก ตามชื่อของสูตร (นับเฉพาะค่าที่มี)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.MetricSummary"
+                    }
+                },
+                "humidity_avg": {
+                    "type": "number",
+                    "example": 71.3
+                },
+                "humidity_max": {
+                    "type": "number",
+                    "example": 80.5
+                },
+                "humidity_min": {
+                    "type": "number",
+                    "example": 64
+                },
+                "temp_avg": {
+                    "type": "number",
+                    "example": 24.6
+                },
+                "temp_max": {
+                    "type": "number",
+                    "example": 27.9
+                },
+                "temp_min": {
+                    "type": "number",
+                    "example": 21.2
+                }
+            }
+        },
+        "models.TimelineEntry": {
             "type": "object",
             "properties": {
-                "api_key": {
+                "event": {
+                    "$ref": "#/definitions/models.HusbandryEvent"
+                },
+                "reading": {
+                    "$ref": "#/definitions/models.SensorData"
+                },
+                "time": {
                     "type": "string"
                 },
+                "type": {
+                    "description": "reading | event",
+                    "type": "string",
+                    "example": "event"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
                 "created_at": {
                     "type": "string"
                 },
+                "disabled": {
+                    "description": "true = ปิดบัญชี (ใช้ API Key / Login / SSO ไม่ได้) แต่ยังเก็บประวัติไว้",
+                    "type": "boolean"
+                },
                 "id": {
                     "description": "ลบ gorm.Model ทิ้ง แล้วใส่ 3 บรรทัดนี้แทน",
                     "type": "integer",
                     "example": 1
                 },
+                "must_change_password": {
+                    "description": "true = ต้องเปลี่ยนรหัสผ่านก่อนใช้ API อื่น (เช่น Admin คนแรกที่ได้รหัสสุ่ม)",
+                    "type": "boolean"
+                },
+                "organization_id": {
+                    "description": "องค์กรที่ User สังกัด (null = Superadmin ที่ไม่อยู่ในองค์กรใด)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "password_login_disabled": {
+                    "type": "boolean"
+                },
                 "role": {
+                    "description": "superadmin (ทุกองค์กร) | admin (Admin ขององค์กร) | user",
                     "type": "string",
                     "example": "user"
                 },
+                "totp_enabled": {
+                    "type": "boolean"
+                },
                 "updated_at": {
                     "type": "string"
                 },
                 "username": {
-                    "description": "Fields เดิมของคุณ",
+                    "description": "Fields เดิมของคุณ\nUnique แบบไม่สนตัวพิมพ์ (idx_users_username_lower ใน Migration 0003)",
                     "type": "string",
                     "example": "staff01"
                 }
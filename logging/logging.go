@@ -0,0 +1,105 @@
+// Package logging ตั้งค่า log/slog ของระบบ (JSON หรือ Text) และซ่อนค่าที่เป็นความลับก่อนเขียน Log
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// ค่าที่ใช้แทนข้อมูลลับใน Log
+const redacted = "[REDACTED]"
+
+// sensitiveKeys ชื่อ Attribute (ตัวเล็ก) ที่ต้องไม่ปรากฏใน Log
+var sensitiveKeys = map[string]bool{
+	"password":         true,
+	"new_password":     true,
+	"current_password": true,
+	"api_key":          true,
+	"x-api-key":        true,
+	"authorization":    true,
+	"token":            true,
+	"access_token":     true,
+	"refresh_token":    true,
+	"id_token":         true,
+	"secret":           true,
+	"client_secret":    true,
+	"totp_secret":      true,
+	"otp_code":         true,
+	"recovery_codes":   true,
+	"cookie":           true,
+	"set-cookie":       true,
+}
+
+// New สร้าง Logger ตาม level (debug, info, warn, error) และ format (json, text)
+func New(w io.Writer, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       ParseLevel(level),
+		ReplaceAttr: redact,
+	}
+	var handler slog.Handler
+	if format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// ParseLevel แปลงชื่อ Level (ค่าที่ไม่รู้จัก = info)
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// IsSensitive ชื่อ Field นี้เป็นความลับหรือไม่ (ไม่สนตัวพิมพ์)
+func IsSensitive(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// redact แทนค่าของ Attribute ที่เป็นความลับ (รวมถึงที่อยู่ใน Group)
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if IsSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+// --- Request ID ใน Context ---
+
+type requestIDKey struct{}
+
+// WithRequestID เก็บ Request ID ไว้ใน ctx ทุก Log ที่ใช้ ctx นี้จะมี request_id ติดไปด้วย
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID Request ID ใน ctx ("" ถ้าไม่มี)
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler เติม request_id จาก Context ให้ทุก Log (ใช้กับ slog.InfoContext ฯลฯ)
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
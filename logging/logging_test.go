@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSensitiveKeysAreRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "debug", "json")
+
+	logger.With("API_Key", "with-attrs").WithGroup("request").Info("login",
+		"Password", "hunter2",
+		"user", "alice",
+		slog.Group("headers", "Authorization", "Bearer abc", "X-Api-Key", "k1", "accept", "application/json"),
+		slog.Group("oidc", slog.Group("tokens", "ID_Token", "eyJ", "refresh_token", "r1")),
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"with-attrs", "hunter2", "Bearer abc", "k1", "eyJ", "r1"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains secret %q: %s", secret, out)
+		}
+	}
+
+	var entry struct {
+		APIKey  string `json:"API_Key"`
+		Request struct {
+			Password string `json:"Password"`
+			User     string `json:"user"`
+			Headers  struct {
+				Authorization string `json:"Authorization"`
+				Accept        string `json:"accept"`
+			} `json:"headers"`
+			OIDC struct {
+				Tokens struct {
+					IDToken string `json:"ID_Token"`
+				} `json:"tokens"`
+			} `json:"oidc"`
+		} `json:"request"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode log: %v\n%s", err, out)
+	}
+	for name, got := range map[string]string{
+		"API_Key":                       entry.APIKey,
+		"request.Password":              entry.Request.Password,
+		"request.headers.Authorization": entry.Request.Headers.Authorization,
+		"request.oidc.tokens.ID_Token":  entry.Request.OIDC.Tokens.IDToken,
+	} {
+		if got != redacted {
+			t.Errorf("%s = %q, want %q", name, got, redacted)
+		}
+	}
+	// ค่าอื่นยังอยู่ครบ
+	if entry.Request.User != "alice" || entry.Request.Headers.Accept != "application/json" {
+		t.Errorf("non-sensitive values changed: %s", out)
+	}
+}
+
+func TestTextFormatRedactsAndAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info", "text")
+
+	logger.InfoContext(WithRequestID(context.Background(), "req-1"), "callback", "Client_Secret", "s3cret")
+	logger.Debug("hidden below info", "token", "t1")
+
+	out := buf.String()
+	if strings.Contains(out, "s3cret") || strings.Contains(out, "t1") {
+		t.Errorf("log contains a secret or a debug line: %s", out)
+	}
+	if !strings.Contains(out, "Client_Secret="+redacted) || !strings.Contains(out, "request_id=req-1") {
+		t.Errorf("log = %q, want Client_Secret redacted and request_id=req-1", out)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"WARN":  slog.LevelWarn,
+		"error": slog.LevelError,
+		"":      slog.LevelInfo,
+		"loud":  slog.LevelInfo,
+	} {
+		if got := ParseLevel(in); got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"database/sql"
+	"io"
+)
+
+// Metrics ทุกตัวที่ระบบ Export (สร้างครั้งเดียวแล้วส่งต่อให้ Router / Handler)
+type Metrics struct {
+	registry *Registry
+
+	// HTTP
+	HTTPRequests *ValueVec     // method, route, status
+	HTTPDuration *HistogramVec // method, route
+
+	// การรับค่าจาก Sensor
+	// organization คือ ID ขององค์กร (ชื่อ Device ซ้ำกันได้ข้ามองค์กร)
+	ReadingsIngested *ValueVec // organization, device
+	ReadingsRejected *ValueVec // reason
+	LastIngested     *ValueVec // organization, device (Unix time ของค่าล่าสุด ใช้ดู Ingestion Lag)
+	Temperature      *ValueVec // organization, device
+	Humidity         *ValueVec // organization, device
+	Anomalies        *ValueVec // organization, device, metric, kind (ใช้ตั้ง Alert ใน Prometheus)
+
+	// การยืนยันตัวตนที่ไม่ผ่าน
+	AuthFailures *ValueVec // reason
+
+	// Request ที่ถูกปฏิเสธเพราะเกิน Rate Limit / Quota
+	RateLimited *ValueVec // rule
+}
+
+// MaxDeviceSeries จำนวนชุด Label สูงสุดของแต่ละ Metric ที่แยกตาม Device
+// ชื่อ Device มาจาก X-Device-ID ที่ Client ตั้งเองได้ Device ที่เกินจำนวนนี้รวมอยู่ใน device="other"
+const MaxDeviceSeries = 1000
+
+// OverflowDevice ค่า Label device ของ Device ที่เกิน MaxDeviceSeries
+const OverflowDevice = "other"
+
+// New สร้าง Metrics พร้อม Registry ของตัวเอง (แต่ละ Test สร้างแยกกันได้ไม่ชนกัน)
+func New() *Metrics {
+	r := NewRegistry()
+	return &Metrics{
+		registry: r,
+
+		HTTPRequests: r.NewCounterVec("worm_http_requests_total",
+			"HTTP requests by method, route and status code.", "method", "route", "status"),
+		HTTPDuration: r.NewHistogramVec("worm_http_request_duration_seconds",
+			"HTTP request latency by method and route.", DefaultBuckets, "method", "route"),
+
+		ReadingsIngested: r.NewCounterVec("worm_readings_ingested_total",
+			"Sensor readings stored, by organization and device.", "organization", "device").Limit(MaxDeviceSeries, "device", OverflowDevice),
+		ReadingsRejected: r.NewCounterVec("worm_readings_rejected_total",
+			"Sensor readings rejected, by reason.", "reason"),
+		LastIngested: r.NewGaugeVec("worm_reading_last_ingested_timestamp_seconds",
+			"Unix time of the latest stored reading, by organization and device.", "organization", "device").Limit(MaxDeviceSeries, "device", OverflowDevice),
+		Temperature: r.NewGaugeVec("worm_temperature_celsius",
+			"Latest temperature reading, by organization and device.", "organization", "device").Limit(MaxDeviceSeries, "device", OverflowDevice),
+		Humidity: r.NewGaugeVec("worm_humidity_percent",
+			"Latest relative humidity reading, by organization and device.", "organization", "device").Limit(MaxDeviceSeries, "device", OverflowDevice),
+		Anomalies: r.NewCounterVec("worm_reading_anomalies_total",
+			"Anomalies detected in stored sensor readings, by organization, device, metric and kind.", "organization", "device", "metric", "kind").
+			// 2 Metric x 3 ชนิดต่อ Device
+			Limit(6*MaxDeviceSeries, "device", OverflowDevice),
+
+		AuthFailures: r.NewCounterVec("worm_auth_failures_total",
+			"Failed authentication attempts, by reason.", "reason"),
+
+		RateLimited: r.NewCounterVec("worm_rate_limited_total",
+			"Requests rejected by a rate limit or daily quota, by rule.", "rule"),
+	}
+}
+
+// RegisterDBStats Export สถานะ Connection Pool ของฐานข้อมูล (อ่านตอนถูก Scrape)
+func (m *Metrics) RegisterDBStats(stats func() sql.DBStats) {
+	m.registry.NewGaugeFunc("worm_db_open_connections", "Open database connections (in use + idle).",
+		func() float64 { return float64(stats().OpenConnections) })
+	m.registry.NewGaugeFunc("worm_db_in_use_connections", "Database connections currently in use.",
+		func() float64 { return float64(stats().InUse) })
+	m.registry.NewGaugeFunc("worm_db_idle_connections", "Idle database connections.",
+		func() float64 { return float64(stats().Idle) })
+	m.registry.NewGaugeFunc("worm_db_max_open_connections", "Maximum number of open database connections.",
+		func() float64 { return float64(stats().MaxOpenConnections) })
+	m.registry.NewCounterFunc("worm_db_wait_count_total", "Total number of waits for a free database connection.",
+		func() float64 { return float64(stats().WaitCount) })
+	m.registry.NewCounterFunc("worm_db_wait_duration_seconds_total", "Total time spent waiting for a free database connection.",
+		func() float64 { return stats().WaitDuration.Seconds() })
+}
+
+// WriteText เขียน Metric ทั้งหมดในรูปแบบ Prometheus Text
+func (m *Metrics) WriteText(w io.Writer) {
+	m.registry.WriteText(w)
+}
@@ -0,0 +1,312 @@
+// Package metrics Metric แบบ Prometheus (Counter / Gauge / Histogram) ที่เขียนเองโดยไม่พึ่ง Library ภายนอก
+// และ Export เป็น Text Exposition Format สำหรับ GET /metrics
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector Metric หนึ่งชื่อ (พร้อมทุกชุด Label)
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry รวม Metric ทั้งหมดที่จะ Export
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry สร้าง Registry ว่าง
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText เขียน Metric ทั้งหมดในรูปแบบ Prometheus Text (text/plain; version=0.0.4)
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// --- Vec: ค่าตามชุด Label ---
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.kind)
+}
+
+// key รวมค่า Label เป็น Key ของ Map (คั่นด้วย \xff ซึ่งไม่มีใน UTF-8)
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelString สร้าง {a="1",b="2"} (extra ใช้กับ le ของ Histogram)
+func (d desc) labelString(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(values)+len(extra)/2)
+	for i, l := range d.labels {
+		parts = append(parts, l+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// --- Counter / Gauge ---
+
+// ValueVec Counter หรือ Gauge ตามชุด Label
+type ValueVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+	keys   map[string][]string
+
+	// ดู Limit (maxSeries 0 = ไม่จำกัด)
+	maxSeries int
+	fold      int
+	overflow  string
+}
+
+// NewCounterVec Counter (เพิ่มขึ้นอย่างเดียว)
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *ValueVec {
+	return r.newValueVec(desc{name: name, help: help, kind: "counter", labels: labels})
+}
+
+// NewGaugeVec Gauge (ค่าปัจจุบัน ขึ้นลงได้)
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *ValueVec {
+	return r.newValueVec(desc{name: name, help: help, kind: "gauge", labels: labels})
+}
+
+func (r *Registry) newValueVec(d desc) *ValueVec {
+	v := &ValueVec{desc: d, values: map[string]float64{}, keys: map[string][]string{}}
+	r.register(v)
+	return v
+}
+
+// Limit จำกัดจำนวนชุด Label ไว้ที่ max เมื่อเต็มแล้วชุดใหม่จะถูกรวมเข้าชุดที่ค่าของ label เป็น overflow
+// ใช้กับ Label ที่ Client กำหนดเองได้ (เช่น device จาก X-Device-ID) ไม่ให้ Series และหน่วยความจำโตไม่สิ้นสุด
+func (v *ValueVec) Limit(max int, label, overflow string) *ValueVec {
+	fold := -1
+	for i, l := range v.labels {
+		if l == label {
+			fold = i
+		}
+	}
+	if fold < 0 {
+		panic("metrics: " + v.name + " has no label " + label)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.maxSeries, v.fold, v.overflow = max, fold, overflow
+	return v
+}
+
+// Inc เพิ่ม 1
+func (v *ValueVec) Inc(labels ...string) {
+	v.Add(1, labels...)
+}
+
+// Add เพิ่มค่า (Counter ต้องไม่ติดลบ)
+func (v *ValueVec) Add(delta float64, labels ...string) {
+	if v.kind == "counter" && delta < 0 {
+		panic("metrics: counter " + v.name + " cannot decrease")
+	}
+	k := v.key(labels)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	k, labels = v.series(k, labels)
+	v.values[k] += delta
+	v.keys[k] = labels
+}
+
+// Set ตั้งค่า (ใช้กับ Gauge)
+func (v *ValueVec) Set(value float64, labels ...string) {
+	k := v.key(labels)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	k, labels = v.series(k, labels)
+	v.values[k] = value
+	v.keys[k] = labels
+}
+
+// series ชุด Label ที่จะบันทึกจริง: ชุดใหม่เมื่อถึง maxSeries แล้วถูกรวมเข้าชุด overflow (เรียกขณะถือ mu)
+func (v *ValueVec) series(k string, labels []string) (string, []string) {
+	if _, ok := v.values[k]; ok || v.maxSeries == 0 || len(v.values) < v.maxSeries {
+		return k, labels
+	}
+	folded := append([]string(nil), labels...)
+	folded[v.fold] = v.overflow
+	return v.key(folded), folded
+}
+
+// Value ค่าปัจจุบันของชุด Label (0 ถ้ายังไม่เคยบันทึก)
+func (v *ValueVec) Value(labels ...string) float64 {
+	k := v.key(labels)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.values[k]
+}
+
+func (v *ValueVec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.header(w)
+	for _, k := range sortedKeys(v.values) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, v.labelString(v.keys[k]), formatFloat(v.values[k]))
+	}
+}
+
+// --- Func: อ่านค่าตอน Export (เช่น Connection Pool) ---
+
+type valueFunc struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc Gauge ที่เรียก fn ทุกครั้งที่ถูก Scrape
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&valueFunc{desc: desc{name: name, help: help, kind: "gauge"}, fn: fn})
+}
+
+// NewCounterFunc Counter ที่นับอยู่แล้วที่อื่น (fn ต้องคืนค่าที่ไม่ลดลง)
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&valueFunc{desc: desc{name: name, help: help, kind: "counter"}, fn: fn})
+}
+
+func (g *valueFunc) write(w io.Writer) {
+	g.header(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+// --- Histogram ---
+
+// DefaultBuckets ช่วงเวลา (วินาที) สำหรับ Latency ของ HTTP
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// HistogramVec Histogram ตามชุด Label
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+}
+
+type histogram struct {
+	labels []string
+	counts []uint64 // นับแยกแต่ละ Bucket (ไม่สะสม) ตัวสุดท้ายคือ +Inf
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec Histogram ด้วย buckets ที่เรียงจากน้อยไปมาก
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: buckets,
+		series:  map[string]*histogram{},
+	}
+	r.register(h)
+	return h
+}
+
+// Observe บันทึกค่าหนึ่งครั้ง
+func (h *HistogramVec) Observe(value float64, labels ...string) {
+	k := h.key(labels)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogram{labels: labels, counts: make([]uint64, len(h.buckets)+1)}
+		h.series[k] = s
+	}
+	i := sort.SearchFloat64s(h.buckets, value)
+	s.counts[i]++
+	s.sum += value
+	s.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w)
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := h.series[k]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.labels, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(s.labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(s.labels), s.count)
+	}
+}
+
+// --- Formatting ---
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
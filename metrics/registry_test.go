@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// scrape ผลของ WriteText เป็น String
+func scrape(r *Registry) string {
+	var b strings.Builder
+	r.WriteText(&b)
+	return b.String()
+}
+
+func expectText(t *testing.T, got, want string) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("exposition mismatch\n--- got\n%s--- want\n%s", got, want)
+	}
+}
+
+func TestWriteTextEscapesHelpAndLabelValues(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("worm_test_total", "Line one\nline \"two\" with a \\ backslash.", "device")
+	c.Inc(`bin "7"` + "\n" + `C:\farm`)
+
+	// HELP: escape เฉพาะ \ และขึ้นบรรทัดใหม่ ค่า Label: escape \, ขึ้นบรรทัดใหม่ และ "
+	expectText(t, scrape(r), `# HELP worm_test_total Line one\nline "two" with a \\ backslash.
+# TYPE worm_test_total counter
+worm_test_total{device="bin \"7\"\nC:\\farm"} 1
+`)
+}
+
+func TestWriteTextOrdersLabelsAndSeries(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("worm_test_celsius", "Temperature.", "organization", "device")
+	g.Set(21.5, "2", "bin-1")
+	g.Set(19, "1", "bin-2")
+	g.Set(20.25, "1", "bin-1")
+	r.NewGaugeFunc("worm_test_up", "Always one.", func() float64 { return 1 })
+
+	// Label ตามลำดับที่ประกาศ ชุด Label เรียงตามค่า และ Metric ตามลำดับที่ลงทะเบียน
+	want := `# HELP worm_test_celsius Temperature.
+# TYPE worm_test_celsius gauge
+worm_test_celsius{organization="1",device="bin-1"} 20.25
+worm_test_celsius{organization="1",device="bin-2"} 19
+worm_test_celsius{organization="2",device="bin-1"} 21.5
+# HELP worm_test_up Always one.
+# TYPE worm_test_up gauge
+worm_test_up 1
+`
+	expectText(t, scrape(r), want)
+	// Scrape ซ้ำได้ผลเดิม (ไม่ขึ้นกับลำดับของ Map)
+	expectText(t, scrape(r), want)
+}
+
+func TestHistogramBucketsAreCumulativeAndInclusive(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("worm_test_seconds", "Latency.", []float64{0.1, 1}, "route")
+	h.Observe(0.05, "/a")
+	// ค่าที่เท่ากับขอบบนนับใน Bucket นั้น (le = less than or equal)
+	h.Observe(0.1, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(3, "/a")
+	h.Observe(2, "/b")
+
+	expectText(t, scrape(r), `# HELP worm_test_seconds Latency.
+# TYPE worm_test_seconds histogram
+worm_test_seconds_bucket{route="/a",le="0.1"} 2
+worm_test_seconds_bucket{route="/a",le="1"} 3
+worm_test_seconds_bucket{route="/a",le="+Inf"} 4
+worm_test_seconds_sum{route="/a"} 3.65
+worm_test_seconds_count{route="/a"} 4
+worm_test_seconds_bucket{route="/b",le="0.1"} 0
+worm_test_seconds_bucket{route="/b",le="1"} 0
+worm_test_seconds_bucket{route="/b",le="+Inf"} 1
+worm_test_seconds_sum{route="/b"} 2
+worm_test_seconds_count{route="/b"} 1
+`)
+}
+
+func TestWriteTextFormatsSpecialValues(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("worm_test_value", "Value.", "case")
+	g.Set(math.Inf(1), "a")
+	g.Set(math.Inf(-1), "b")
+	g.Set(math.NaN(), "c")
+	g.Set(1e21, "d")
+	g.Set(0.000001, "e")
+
+	expectText(t, scrape(r), `# HELP worm_test_value Value.
+# TYPE worm_test_value gauge
+worm_test_value{case="a"} +Inf
+worm_test_value{case="b"} -Inf
+worm_test_value{case="c"} NaN
+worm_test_value{case="d"} 1e+21
+worm_test_value{case="e"} 1e-06
+`)
+}
+
+func TestVecMisuse(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("worm_test_total", "Test.", "reason")
+
+	expectPanic(t, "decreasing a counter", func() { c.Add(-1, "x") })
+	expectPanic(t, "missing label value", func() { c.Inc() })
+	expectPanic(t, "extra label value", func() { c.Inc("x", "y") })
+}
+
+func expectPanic(t *testing.T, what string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("%s: want a panic", what)
+		}
+	}()
+	fn()
+}
+
+func TestLimitFoldsNewSeriesIntoOverflow(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("worm_test_total", "Test.", "organization", "device").Limit(2, "device", "other")
+	c.Inc("1", "bin-1")
+	c.Inc("1", "bin-2")
+	// เต็มแล้ว: Device ใหม่รวมเข้า other แต่ชุดเดิมยังนับต่อ
+	c.Inc("1", "bin-3")
+	c.Inc("1", "bin-4")
+	c.Inc("1", "bin-1")
+
+	expectText(t, scrape(r), `# HELP worm_test_total Test.
+# TYPE worm_test_total counter
+worm_test_total{organization="1",device="bin-1"} 2
+worm_test_total{organization="1",device="bin-2"} 1
+worm_test_total{organization="1",device="other"} 2
+`)
+	expectPanic(t, "unknown label", func() { r.NewGaugeVec("worm_test_value", "Test.", "device").Limit(1, "route", "other") })
+}
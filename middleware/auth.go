@@ -2,25 +2,78 @@ package middleware
 
 import (
 	"errors"
+	"net/http"
+	"strings"
+	"worm/apierror"
+	"worm/metrics"
 	"worm/models"
-	"gorm.io/gorm"
+	"worm/repository"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
 )
 
-// Authenticate ตรวจสอบ API Key และ Role
-func Authenticate(db *gorm.DB, apiKey string, requiredRole string) (*models.User, error) {
-	var user models.User
-	
-	// 1. เช็คว่ามี API Key นี้ในระบบไหม
-	result := db.Where("api_key = ?", apiKey).First(&user)
-	if result.Error != nil {
-		return nil, errors.New("Unauthorized: Invalid API Key")
+// RequireAPIKey ตรวจสอบ X-API-KEY แล้วเก็บ User ไว้ใน Context ("user")
+// และผูก Tenant ของ User กับ Context ของ Request (ทุก Query หลังจากนี้เห็นเฉพาะองค์กรของผู้เรียก)
+func RequireAPIKey(auth *services.AuthService, m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 1. เช็คว่ามี API Key นี้ในระบบไหม
+		user, err := auth.Authenticate(c.Request.Context(), c.GetHeader("X-API-KEY"))
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKey):
+			m.AuthFailures.Inc("invalid_api_key")
+			apierror.Abort(c, err)
+			return
+		case errors.Is(err, services.ErrUserDisabled):
+			// API Key ของบัญชีที่ถูกปิดถือว่าใช้ไม่ได้ (401 เหมือน Key ผิด)
+			m.AuthFailures.Inc("account_disabled")
+			apierror.Abort(c, apierror.New(http.StatusUnauthorized, apierror.CodeAccountDisabled, "Account is disabled"))
+			return
+		case err != nil:
+			apierror.Abort(c, err)
+			return
+		}
+		// เก็บ user ไว้ให้ Handler และ Access Log (แม้จะถูกปฏิเสธในขั้นถัดไป)
+		c.Set("user", user)
+		c.Request = c.Request.WithContext(repository.WithTenant(c.Request.Context(), services.TenantOf(user)))
+
+		// 2. ต้องเปลี่ยนรหัสผ่านก่อน -> ใช้ได้เฉพาะ /api/me/password
+		if user.MustChangePassword && c.FullPath() != "/api/me/password" {
+			apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodePasswordChangeRequired, "Password change required, use /api/me/password"))
+			return
+		}
+
+		// 3. ถ้าบังคับ 2FA สำหรับ Admin -> Admin ที่ยังไม่เปิด 2FA ใช้ได้เฉพาะ /api/2fa/* และ /api/me/password
+		// (Admin ที่ถูกบังคับเปลี่ยนรหัสผ่านด้วยต้องเปลี่ยนรหัสผ่านได้ก่อน ไม่งั้นติดทั้งสองขั้น)
+		if auth.TwoFactorSetupRequired(user) && !strings.HasPrefix(c.FullPath(), "/api/2fa/") && c.FullPath() != "/api/me/password" {
+			apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeTwoFactorSetupRequired, "Two-factor authentication is required for admin accounts, use /api/2fa/enroll"))
+			return
+		}
+
+		c.Next()
 	}
+}
 
-	// 2. เช็ค Role (ถ้าต้องการ admin แต่ user เป็นแค่ user ธรรมดา -> error)
-	if requiredRole == "admin" && user.Role != "admin" {
-		return nil, errors.New("Forbidden: Admin access required")
+// RequireAdmin ต้องเป็น Admin ขององค์กรหรือ Superadmin (ใช้ต่อจาก RequireAPIKey)
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requester := c.MustGet("user").(*models.User)
+		if !requester.IsAdmin() {
+			apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "Admin only"))
+			return
+		}
+		c.Next()
 	}
+}
 
-	// ผ่านทุกขั้นตอน Return user กลับไป
-	return &user, nil
-}
\ No newline at end of file
+// RequireSuperadmin ต้องเป็น Superadmin (ใช้ต่อจาก RequireAPIKey)
+func RequireSuperadmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requester := c.MustGet("user").(*models.User)
+		if !requester.IsSuperadmin() {
+			apierror.Abort(c, apierror.New(http.StatusForbidden, apierror.CodeForbidden, "Superadmin only"))
+			return
+		}
+		c.Next()
+	}
+}
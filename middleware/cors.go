@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+	"worm/config"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS สร้าง Middleware จาก cors ใน Config (ตรวจความถูกต้องแล้วตอน config.Load)
+// ถ้าไม่มี Origin ที่อนุญาตเลย จะไม่ใส่ CORS Header (Browser ใช้ได้เฉพาะ Same-Origin)
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	if len(cfg.AllowOrigins) == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	corsCfg := cors.Config{
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           time.Duration(cfg.MaxAge),
+	}
+	if len(cfg.AllowOrigins) == 1 && cfg.AllowOrigins[0] == "*" {
+		corsCfg.AllowAllOrigins = true
+	} else {
+		corsCfg.AllowOrigins = cfg.AllowOrigins
+		for _, origin := range cfg.AllowOrigins {
+			if strings.Contains(origin, "*") {
+				corsCfg.AllowWildcard = true
+			}
+		}
+	}
+	return cors.New(corsCfg)
+}
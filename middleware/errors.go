@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"worm/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Errors เขียน Response แบบ application/problem+json จาก Error ล่าสุดใน c.Errors
+// Handler / Middleware เพียงเรียก c.Error(err) (หรือ apierror.Abort) แล้ว return
+// สาเหตุภายในของ 5xx ไม่ถูกส่งให้ Client แต่ AccessLog จะเขียนลง Log
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		apierror.Respond(c, c.Errors.Last().Err)
+	}
+}
+
+// NotFound Route ที่ไม่มีอยู่จริง
+func NotFound() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_ = c.Error(apierror.New(http.StatusNotFound, apierror.CodeNotFound, "Route not found"))
+	}
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+	"worm/apierror"
+	"worm/logging"
+	"worm/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader Header ที่รับ/ส่ง Request ID (ใช้ตามรอยข้าม Proxy และ Log)
+const RequestIDHeader = "X-Request-ID"
+
+// Request ID จาก Client ต้องสั้นและมีแต่ตัวอักษรที่ปลอดภัย (กันการฉีดข้อความปลอมลง Log)
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID ใช้ X-Request-ID ที่ส่งมา (ถ้าถูกรูปแบบ) หรือสร้างใหม่
+// แล้วใส่กลับใน Response และใน Context ของ Request ให้ Log ทุกบรรทัดมี request_id
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.New().String()
+		}
+		c.Set("request_id", id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// AccessLog เขียน Log หนึ่งบรรทัดต่อ Request (ไม่รวม Query String ซึ่งอาจมี Code / Token)
+// skipPaths = Path ที่ไม่ต้อง Log (เช่น Health Check ที่ถูกเรียกทุกไม่กี่วินาที)
+func AccessLog(logger *slog.Logger, skipPaths ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, p := range skipPaths {
+		skip[p] = true
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		path := c.Request.URL.Path
+		if skip[path] {
+			return
+		}
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+		}
+		if user, ok := c.Get("user"); ok {
+			attrs = append(attrs, slog.Uint64("user_id", uint64(user.(*models.User).ID)))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
+
+// Recovery จับ Panic ใน Handler แล้วตอบ 500 พร้อมเขียน Stack Trace ลง Log (ไม่ส่งให้ Client)
+// ต้องอยู่หลัง Errors ใน Chain เพื่อให้ Errors เป็นผู้เขียน Response
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				logger.ErrorContext(c.Request.Context(), "panic in handler",
+					slog.Any("panic", rec),
+					slog.String("path", c.Request.URL.Path),
+					slog.String("stack", string(debug.Stack())),
+				)
+				apierror.Abort(c, apierror.Internal(fmt.Errorf("panic: %v", rec)))
+			}
+		}()
+		c.Next()
+	}
+}
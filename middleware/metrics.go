@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+	"worm/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics นับ Request และเวลาที่ใช้ ตาม Route (Path Template เช่น /api/users/:id ไม่ใช่ Path จริง)
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// Path ที่ไม่มี Route (404) รวมเป็นค่าเดียว กัน Label บวมจากการสุ่ม URL
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		m.HTTPRequests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		m.HTTPDuration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
@@ -0,0 +1,47 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"worm/metrics"
+	"worm/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsLabelsByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := metrics.New()
+	r := gin.New()
+	r.Use(middleware.Metrics(m))
+	r.GET("/api/users/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/api/users/1", "/api/users/2", "/no/such/path", "/another/random/path"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Path จริงไม่กลายเป็น Label (กัน Series บวม)
+	if got := m.HTTPRequests.Value("GET", "/api/users/:id", "204"); got != 2 {
+		t.Fatalf("requests for /api/users/:id = %v, want 2", got)
+	}
+	if got := m.HTTPRequests.Value("GET", "unmatched", "404"); got != 2 {
+		t.Fatalf("requests without a route = %v, want 2", got)
+	}
+
+	var b strings.Builder
+	m.WriteText(&b)
+	text := b.String()
+	for _, want := range []string{
+		`worm_http_request_duration_seconds_count{method="GET",route="/api/users/:id"} 2`,
+		`worm_http_request_duration_seconds_bucket{method="GET",route="unmatched",le="+Inf"} 2`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "/api/users/1") || strings.Contains(text, "/no/such/path") {
+		t.Fatalf("raw paths leaked into labels:\n%s", text)
+	}
+}
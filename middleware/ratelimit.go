@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+	"worm/apierror"
+	"worm/metrics"
+	"worm/models"
+	"worm/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceIDHeader Header ที่ Device ใช้ระบุตัวเอง (หลาย Device ใช้ API Key เดียวกันได้)
+const DeviceIDHeader = "X-Device-ID"
+
+var validDeviceID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// KeyFunc เลือก Key ที่ใช้นับของแต่ละ Request
+type KeyFunc func(c *gin.Context) string
+
+// ByClientIP นับต่อ IP ของ Client (ใช้กับ Route ที่ยังไม่มี API Key เช่น /login)
+func ByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser นับต่อเจ้าของ API Key (ใช้ต่อจาก RequireAPIKey ไม่เก็บ Key จริงลงฐานข้อมูล)
+func ByUser(c *gin.Context) string {
+	return "user:" + strconv.FormatUint(uint64(c.MustGet("user").(*models.User).ID), 10)
+}
+
+// ByDevice นับต่อ Device ขององค์กร (ชื่อ Device ซ้ำกันได้ระหว่างองค์กร ใช้ต่อจาก Device)
+func ByDevice(c *gin.Context) string {
+	org := "-"
+	if id := c.MustGet("user").(*models.User).OrganizationID; id != nil {
+		org = strconv.FormatUint(uint64(*id), 10)
+	}
+	return "device:" + org + ":" + c.GetString("device")
+}
+
+// Device เก็บ Device ที่ส่ง Request ไว้ใน Context ("device")
+// ใช้ X-Device-ID ถ้าส่งมา ไม่งั้นถือว่าเจ้าของ API Key คือ Device (ใช้ต่อจาก RequireAPIKey)
+func Device() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		device := c.GetHeader(DeviceIDHeader)
+		if device == "" {
+			device = c.MustGet("user").(*models.User).Username
+		} else if !validDeviceID.MatchString(device) {
+			apierror.Abort(c, apierror.New(http.StatusBadRequest, apierror.CodeBadRequest,
+				"X-Device-ID must be 1-64 characters: letters, digits, '.', '_', ':' or '-'"))
+			return
+		}
+		c.Set("device", device)
+		c.Next()
+	}
+}
+
+// RateLimit จำกัดจำนวน Request ตาม rule แยกตาม key
+// ถ้าที่เก็บสถานะใช้ไม่ได้ (เช่นฐานข้อมูลล่ม) จะปล่อยผ่านและเขียน Log แทนการปิดระบบทั้งหมด
+func RateLimit(l *ratelimit.Limiter, rule ratelimit.Rule, key KeyFunc, m *metrics.Metrics) gin.HandlerFunc {
+	if !rule.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		res, err := l.Allow(c.Request.Context(), rule, key(c))
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "rate limiter unavailable, allowing request", "rule", rule.Name, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(res.Reset/time.Second)))
+		if !res.Allowed {
+			m.RateLimited.Inc(rule.Name)
+			retry := int(res.RetryAfter / time.Second)
+			c.Header("Retry-After", strconv.Itoa(retry))
+			apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited,
+				fmt.Sprintf("Too many requests, retry after %d seconds", retry)))
+			return
+		}
+		c.Next()
+	}
+}
+
+// DailyQuota จำกัดจำนวน Request ต่อวัน (UTC) แยกตาม key (limit 0 = ไม่จำกัด)
+// นับทุก Request ที่ผ่านมาถึงจุดนี้ รวมถึงที่ถูกปฏิเสธภายหลังเพราะข้อมูลผิด
+func DailyQuota(l *ratelimit.Limiter, name string, limit int, key KeyFunc, m *metrics.Metrics) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		res, err := l.Quota(c.Request.Context(), name, key(c), limit)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "quota store unavailable, allowing request", "quota", name, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-Quota-Limit", strconv.Itoa(res.Limit))
+		c.Header("X-Quota-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-Quota-Reset", strconv.Itoa(int(res.Reset/time.Second)))
+		if !res.Allowed {
+			m.RateLimited.Inc(name + "_quota")
+			c.Header("Retry-After", strconv.Itoa(int(res.RetryAfter/time.Second)))
+			apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeQuotaExceeded,
+				fmt.Sprintf("Daily quota of %d requests exceeded, resets at 00:00 UTC", limit)))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"worm/config"
+	"worm/migrations"
+)
+
+const migrateUsage = `usage: worm migrate [-config file] <command>
+
+commands:
+  up             apply all pending migrations
+  down [n]       revert the last n migrations (default 1)
+  status         list migrations and whether they are applied
+  to <version>   migrate up or down to the given version (0 = revert all)
+`
+
+// runMigrate คำสั่ง `worm migrate ...` จัดการ Schema โดยไม่ต้องเปิด Server
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	configFile := fs.String("config", "", "path to a YAML or TOML config file (default: $CONFIG_FILE)")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrator, err := migrations.New(config.ConnectDB(cfg))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch fs.Arg(0) {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			if steps, err = strconv.Atoi(fs.Arg(1)); err != nil || steps < 1 {
+				log.Fatalf("down: invalid step count %q", fs.Arg(1))
+			}
+		}
+		err = migrator.Down(steps)
+	case "to":
+		if fs.NArg() < 2 {
+			log.Fatal("to: missing version")
+		}
+		version, convErr := strconv.Atoi(fs.Arg(1))
+		if convErr != nil {
+			log.Fatalf("to: invalid version %q", fs.Arg(1))
+		}
+		err = migrator.To(version)
+	case "status":
+		err = printMigrationStatus(migrator)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printMigrationStatus(migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d  %-30s  %s\n", s.Version, s.Name, applied)
+	}
+	return nil
+}
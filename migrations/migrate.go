@@ -0,0 +1,297 @@
+// Package migrations จัดการ Schema ของฐานข้อมูลด้วยไฟล์ SQL แบบมีเวอร์ชัน
+// ไฟล์อยู่ในโฟลเดอร์ตามชื่อ Driver เช่น postgres/0001_initial_schema.up.sql และ .down.sql
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Key ของ Advisory Lock (ค่าคงที่ใดก็ได้ที่ไม่ชนกับระบบอื่นใน DB เดียวกัน) เป็น bigint ตาม pg_advisory_lock
+// ต้องระบุ int64 ไม่งั้น Build บน 32-bit (เช่น Raspberry Pi แบบ armv7) ไม่ผ่าน
+const lockKey int64 = 7_362_519_004
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// ErrSchemaOutdated ฐานข้อมูลยังไม่ได้ Migrate ถึงเวอร์ชันล่าสุด
+var ErrSchemaOutdated = errors.New("database schema is not up to date, run `migrate up`")
+
+// Migration หนึ่งเวอร์ชันของ Schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status สถานะของแต่ละเวอร์ชัน
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// schemaMigration แถวในตาราง schema_migrations
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator รัน Migration กับฐานข้อมูลหนึ่งตัว
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New โหลด Migration ทั้งหมดของ Driver ที่ db ใช้อยู่
+func New(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	sub, err := fs.Sub(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q: %w", dialect, err)
+	}
+	migrations, err := load(sub)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest เวอร์ชันล่าสุดที่มีไฟล์
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up รันทุกเวอร์ชันที่ยังไม่ได้รัน
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down ย้อนกลับ steps เวอร์ชัน
+func (m *Migrator) Down(steps int) error {
+	return m.withLock(func(tx *gorm.DB) error {
+		applied, err := m.applied(tx)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if err := m.revert(tx, mig); err != nil {
+				return err
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// To รันขึ้นหรือลงจนถึง version (0 = ย้อนทั้งหมด)
+func (m *Migrator) To(version int) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(func(tx *gorm.DB) error {
+		applied, err := m.applied(tx)
+		if err != nil {
+			return err
+		}
+		// ย้อนเวอร์ชันที่สูงกว่าเป้าหมายก่อน (จากบนลงล่าง)
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; ok && mig.Version > version {
+				if err := m.revert(tx, mig); err != nil {
+					return err
+				}
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; !ok && mig.Version <= version {
+				if err := m.apply(tx, mig); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Status คืนสถานะของทุกเวอร์ชัน (AppliedAt = nil คือยังไม่ได้รัน)
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.ensureTable(m.db); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if row, ok := applied[mig.Version]; ok {
+			appliedAt := row.AppliedAt
+			s.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Check ใช้ตอนเริ่ม Server: คืน ErrSchemaOutdated ถ้ายังมีเวอร์ชันที่ไม่ได้รัน
+// หรือ Error ถ้าฐานข้อมูลมีเวอร์ชันที่ Binary นี้ไม่รู้จัก (Binary เก่ากว่า Schema)
+func (m *Migrator) Check() error {
+	if !m.db.Migrator().HasTable(&schemaMigration{}) {
+		return ErrSchemaOutdated
+	}
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return err
+	}
+	for version := range applied {
+		if m.find(version) == nil {
+			return fmt.Errorf("database has migration %d which this binary does not know, deploy a newer build", version)
+		}
+	}
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			return ErrSchemaOutdated
+		}
+	}
+	return nil
+}
+
+// --- Internal ---
+
+func (m *Migrator) apply(tx *gorm.DB, mig Migration) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(mig.Up).Error; err != nil {
+			return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+		}
+		return tx.Create(&schemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+	})
+}
+
+func (m *Migrator) revert(tx *gorm.DB, mig Migration) error {
+	if mig.Down == "" {
+		return fmt.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+	}
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(mig.Down).Error; err != nil {
+			return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+		}
+		return tx.Delete(&schemaMigration{Version: mig.Version}).Error
+	})
+}
+
+func (m *Migrator) applied(tx *gorm.DB) (map[int]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func (m *Migrator) ensureTable(tx *gorm.DB) error {
+	return tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`).Error
+}
+
+// withLock ถือ Lock ตลอดการ Migrate กันหลาย Replica รันพร้อมกัน
+// Advisory Lock ของ Postgres ผูกกับ Session จึงต้องใช้ Connection เดียวตลอด
+func (m *Migrator) withLock(fn func(tx *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		if conn.Dialector.Name() == "postgres" {
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+				return fmt.Errorf("acquire migration lock: %w", err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", lockKey)
+		}
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// load อ่านไฟล์ .up.sql / .down.sql แล้วเรียงตามเวอร์ชัน
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		} else if mig.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two different names: %s and %s", version, mig.Name, match[2])
+		}
+		if match[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
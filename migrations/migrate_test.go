@@ -0,0 +1,143 @@
+package migrations
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+	"worm/config"
+)
+
+func newSQLiteMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	db, err := config.OpenDB("sqlite://:memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = config.CloseDB(db) })
+	m, err := New(db)
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	return m
+}
+
+// ทุกไฟล์ .down.sql ต้องย้อนได้หมดจนรัน .up.sql ซ้ำได้อีกรอบ
+func TestUpDownUp(t *testing.T) {
+	m := newSQLiteMigrator(t)
+	if err := m.Check(); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("check on an empty database: %v, want ErrSchemaOutdated", err)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := m.Check(); err != nil {
+		t.Fatalf("check after up: %v", err)
+	}
+
+	if err := m.To(0); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if err := m.Check(); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("check after down: %v, want ErrSchemaOutdated", err)
+	}
+	for _, table := range []string{"users", "actuators", "schedules"} {
+		if m.db.Migrator().HasTable(table) {
+			t.Errorf("table %s left behind after migrating down", table)
+		}
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt != nil {
+			t.Errorf("migration %d_%s still applied after down", s.Version, s.Name)
+		}
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+	if err := m.Check(); err != nil {
+		t.Fatalf("check after up again: %v", err)
+	}
+}
+
+func TestDownStepsAndCheck(t *testing.T) {
+	m := newSQLiteMigrator(t)
+	if err := m.Up(); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := m.Down(1); err != nil {
+		t.Fatalf("down 1: %v", err)
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if last := statuses[len(statuses)-1]; last.AppliedAt != nil || statuses[len(statuses)-2].AppliedAt == nil {
+		t.Fatalf("down 1 should revert only version %d", last.Version)
+	}
+	if err := m.Check(); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("check after down 1: %v, want ErrSchemaOutdated", err)
+	}
+	if err := m.To(m.Latest() + 1); err == nil {
+		t.Fatal("migrating to an unknown version should fail")
+	}
+
+	// Schema ใหม่กว่า Binary
+	if err := m.Up(); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	future := schemaMigration{Version: m.Latest() + 1, Name: "future", AppliedAt: time.Now()}
+	if err := m.db.Create(&future).Error; err != nil {
+		t.Fatalf("insert future version: %v", err)
+	}
+	if err := m.Check(); err == nil || errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("check with an unknown version: %v, want an error other than ErrSchemaOutdated", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		files   fstest.MapFS
+		want    []int
+		wantErr bool
+	}{
+		{"sorted by version", fstest.MapFS{
+			"0002_b.up.sql":   {Data: []byte("B")},
+			"0001_a.up.sql":   {Data: []byte("A")},
+			"0001_a.down.sql": {Data: []byte("-A")},
+			"README.md":       {Data: []byte("ignored")},
+		}, []int{1, 2}, false},
+		{"down without up", fstest.MapFS{"0001_a.down.sql": {Data: []byte("-A")}}, nil, true},
+		{"two names for one version", fstest.MapFS{
+			"0001_a.up.sql":   {Data: []byte("A")},
+			"0001_b.down.sql": {Data: []byte("-B")},
+		}, nil, true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			migrations, err := load(c.files)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("load: want an error, got %+v", migrations)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if len(migrations) != len(c.want) {
+				t.Fatalf("load: got %d migrations, want %d", len(migrations), len(c.want))
+			}
+			for i, v := range c.want {
+				if migrations[i].Version != v {
+					t.Errorf("migration %d: version %d, want %d", i, migrations[i].Version, v)
+				}
+			}
+		})
+	}
+}
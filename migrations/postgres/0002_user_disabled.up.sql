@@ -0,0 +1,2 @@
+-- ปิดบัญชีโดยไม่ลบ (คำสั่ง `user disable`) บัญชีที่ถูกปิดใช้ API Key / Login / SSO ไม่ได้
+ALTER TABLE users ADD COLUMN disabled BOOLEAN DEFAULT FALSE;
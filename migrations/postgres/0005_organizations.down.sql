@@ -0,0 +1,16 @@
+UPDATE users SET role = 'admin' WHERE role = 'superadmin';
+
+DROP INDEX IF EXISTS idx_audit_logs_organization_id;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS organization_id;
+
+DROP INDEX IF EXISTS idx_sensor_data_device_id;
+DROP INDEX IF EXISTS idx_sensor_data_org_created_at;
+ALTER TABLE sensor_data DROP COLUMN IF EXISTS device_id;
+ALTER TABLE sensor_data DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS devices;
+
+DROP INDEX IF EXISTS idx_users_organization_id;
+ALTER TABLE users DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS device_assignments;
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,44 @@
+-- Relay ที่ Device ควบคุม (พัดลม เครื่องพ่นหมอก ฮีตเตอร์)
+CREATE TABLE actuators (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    organization_id BIGINT NOT NULL,
+    device_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    channel INTEGER NOT NULL,
+    state TEXT NOT NULL DEFAULT '',
+    state_changed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_actuators_organization_id ON actuators (organization_id);
+CREATE INDEX idx_actuators_device_id ON actuators (device_id);
+CREATE UNIQUE INDEX idx_actuators_device_name_lower ON actuators (device_id, LOWER(name));
+CREATE UNIQUE INDEX idx_actuators_device_channel ON actuators (device_id, channel);
+
+-- คำสั่งถึง Actuator และสถานะ (queued → delivered → acknowledged | failed, หรือ expired)
+CREATE TABLE actuator_commands (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    organization_id BIGINT NOT NULL,
+    actuator_id BIGINT NOT NULL,
+    device_id BIGINT NOT NULL,
+    channel INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    duty_cycle INTEGER,
+    duration_seconds INTEGER,
+    status TEXT NOT NULL,
+    source TEXT NOT NULL,
+    requested_by BIGINT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    delivered_at TIMESTAMPTZ,
+    acknowledged_at TIMESTAMPTZ,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_actuator_commands_organization_id ON actuator_commands (organization_id);
+CREATE INDEX idx_actuator_commands_actuator_id ON actuator_commands (actuator_id, created_at);
+-- Device Poll หาคำสั่งที่รออยู่ของตัวเอง
+CREATE INDEX idx_actuator_commands_device_status ON actuator_commands (device_id, status);
@@ -0,0 +1,49 @@
+-- Manual Override: Automation ไม่สั่ง Actuator จนถึง override_until (NULL = จนกว่าจะปิด)
+ALTER TABLE actuators ADD COLUMN manual_override BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE actuators ADD COLUMN override_until TIMESTAMPTZ;
+
+-- Rule ที่สั่ง Actuator อัตโนมัติจากค่า Sensor ของ Location
+CREATE TABLE automation_rules (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    organization_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    mode TEXT NOT NULL,
+    location_id BIGINT NOT NULL,
+    metric TEXT NOT NULL,
+    operator TEXT NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    hysteresis DOUBLE PRECISION NOT NULL,
+    for_seconds INTEGER NOT NULL,
+    cooldown_seconds INTEGER NOT NULL,
+    actuator_id BIGINT NOT NULL,
+    action TEXT NOT NULL,
+    duty_cycle INTEGER,
+    duration_seconds INTEGER,
+    condition_met BOOLEAN NOT NULL DEFAULT FALSE,
+    condition_since TIMESTAMPTZ,
+    last_value DOUBLE PRECISION,
+    last_evaluated_at TIMESTAMPTZ,
+    last_fired_at TIMESTAMPTZ,
+    fired_count BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_automation_rules_organization_id ON automation_rules (organization_id);
+CREATE INDEX idx_automation_rules_location_id ON automation_rules (location_id);
+CREATE INDEX idx_automation_rules_actuator_id ON automation_rules (actuator_id);
+
+-- ทุกครั้งที่ Rule ทำงาน (หรือจะทำงานในโหมด dry_run)
+CREATE TABLE automation_logs (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    organization_id BIGINT NOT NULL,
+    rule_id BIGINT NOT NULL,
+    outcome TEXT NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    command_id BIGINT,
+    details TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_automation_logs_organization_id ON automation_logs (organization_id);
+CREATE INDEX idx_automation_logs_rule_id ON automation_logs (rule_id, created_at);
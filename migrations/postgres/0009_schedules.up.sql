@@ -0,0 +1,39 @@
+-- งานที่ทำตามเวลาด้วย Cron Expression
+CREATE TABLE schedules (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    organization_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    cron TEXT NOT NULL,
+    time_zone TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    job TEXT NOT NULL,
+    actuator_id BIGINT,
+    action TEXT NOT NULL DEFAULT '',
+    duty_cycle INTEGER,
+    duration_seconds INTEGER,
+    retention_days INTEGER,
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ,
+    run_count BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_schedules_organization_id ON schedules (organization_id);
+CREATE INDEX idx_schedules_actuator_id ON schedules (actuator_id);
+CREATE INDEX idx_schedules_next_run_at ON schedules (next_run_at);
+
+-- ประวัติการทำงานของ Schedule
+CREATE TABLE schedule_runs (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    organization_id BIGINT NOT NULL,
+    schedule_id BIGINT NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    outcome TEXT NOT NULL,
+    command_id BIGINT,
+    details TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_schedule_runs_organization_id ON schedule_runs (organization_id);
+CREATE INDEX idx_schedule_runs_schedule_id ON schedule_runs (schedule_id, created_at);
@@ -0,0 +1,21 @@
+-- สิ่งที่ทำกับ Bin: ให้อาหาร เปลี่ยนวัสดุรองพื้น เติมน้ำ เก็บเกี่ยว ประมาณจำนวน
+CREATE TABLE husbandry_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    organization_id BIGINT NOT NULL,
+    location_id BIGINT NOT NULL,
+    kind TEXT NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    recorded_by BIGINT,
+    notes TEXT NOT NULL DEFAULT '',
+    feed_type TEXT NOT NULL DEFAULT '',
+    bedding_material TEXT NOT NULL DEFAULT '',
+    weight_kg DOUBLE PRECISION,
+    water_liters DOUBLE PRECISION,
+    castings_kg DOUBLE PRECISION,
+    worms_kg DOUBLE PRECISION,
+    worm_count BIGINT
+);
+
+CREATE INDEX idx_husbandry_events_organization_id ON husbandry_events (organization_id);
+CREATE INDEX idx_husbandry_events_location_occurred_at ON husbandry_events (location_id, occurred_at);
@@ -0,0 +1,5 @@
+-- ค่าหลังแก้ยังอยู่ใน temperature / humidity
+ALTER TABLE sensor_data DROP COLUMN raw_humidity;
+ALTER TABLE sensor_data DROP COLUMN raw_temperature;
+
+DROP TABLE IF EXISTS calibrations;
@@ -0,0 +1,23 @@
+-- การแก้ค่า Sensor ของแต่ละ Device / Metric ในช่วงเวลาที่ใช้ได้
+CREATE TABLE calibrations (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    organization_id BIGINT NOT NULL,
+    device_id BIGINT NOT NULL,
+    metric TEXT NOT NULL,
+    method TEXT NOT NULL,
+    "offset" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    gain DOUBLE PRECISION NOT NULL DEFAULT 1,
+    points TEXT,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_until TIMESTAMPTZ,
+    notes TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_calibrations_organization_id ON calibrations (organization_id);
+CREATE INDEX idx_calibrations_device_id ON calibrations (device_id);
+
+-- ค่าดิบจาก Device (temperature / humidity เป็นค่าหลังแก้) ข้อมูลเดิมยังไม่เคยถูกแก้
+ALTER TABLE sensor_data ADD COLUMN raw_temperature DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE sensor_data ADD COLUMN raw_humidity DOUBLE PRECISION NOT NULL DEFAULT 0;
+UPDATE sensor_data SET raw_temperature = temperature, raw_humidity = humidity;
@@ -0,0 +1 @@
+ALTER TABLE sensor_data DROP COLUMN derived;
@@ -0,0 +1,2 @@
+-- ค่าที่คำนวณจากอุณหภูมิ / ความชื้นตอนบันทึก (JSON ตามชื่อของสูตร) ข้อมูลเดิมเป็น NULL จนกว่าจะ Recompute
+ALTER TABLE sensor_data ADD COLUMN derived TEXT;
@@ -0,0 +1,3 @@
+DROP INDEX idx_sensor_data_anomalous;
+ALTER TABLE sensor_data DROP COLUMN anomalous;
+ALTER TABLE sensor_data DROP COLUMN anomalies;
@@ -0,0 +1,2 @@
+-- เพิ่มทุกครั้งที่สถานะการประเมินหรือค่าตั้งของ Rule เปลี่ยน (กันผู้ประเมินสองทางเขียนทับกัน)
+ALTER TABLE automation_rules ADD COLUMN state_version BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS sensor_data;
+DROP TABLE IF EXISTS users;
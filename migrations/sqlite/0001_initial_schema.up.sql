@@ -0,0 +1,50 @@
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME,
+    username TEXT NOT NULL,
+    password TEXT NOT NULL,
+    role TEXT DEFAULT 'user',
+    api_key TEXT,
+    must_change_password BOOLEAN DEFAULT FALSE,
+    totp_secret TEXT,
+    totp_enabled BOOLEAN DEFAULT FALSE,
+    oidc_subject TEXT,
+    password_login_disabled BOOLEAN DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX idx_users_username ON users (username);
+CREATE UNIQUE INDEX idx_users_api_key ON users (api_key);
+CREATE UNIQUE INDEX idx_users_oidc_subject ON users (oidc_subject);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE sensor_data (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    temperature REAL NOT NULL,
+    humidity REAL NOT NULL
+);
+
+CREATE INDEX idx_sensor_data_created_at ON sensor_data (created_at);
+
+CREATE TABLE recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    user_id INTEGER NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at DATETIME
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+CREATE TABLE audit_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    actor_id INTEGER,
+    action TEXT NOT NULL,
+    target_id INTEGER,
+    details TEXT
+);
+
+CREATE INDEX idx_audit_logs_actor_id ON audit_logs (actor_id);
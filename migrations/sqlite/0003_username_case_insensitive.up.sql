@@ -0,0 +1,4 @@
+-- Username ไม่ซ้ำแบบไม่สนตัวพิมพ์ (Admin กับ admin ถือเป็นชื่อเดียวกัน)
+-- ถ้ามีชื่อที่ต่างกันแค่ตัวพิมพ์อยู่แล้ว Migration นี้จะล้มเหลว ต้องเปลี่ยนชื่อบัญชีใดบัญชีหนึ่งก่อน
+DROP INDEX IF EXISTS idx_users_username;
+CREATE UNIQUE INDEX idx_users_username_lower ON users (LOWER(username));
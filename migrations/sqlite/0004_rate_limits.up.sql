@@ -0,0 +1,8 @@
+-- สถานะ Rate Limit / Quota ที่แชร์ระหว่างหลาย Instance (rate_limit.backend = database)
+CREATE TABLE rate_limit_buckets (
+    bucket_key TEXT PRIMARY KEY,
+    tokens REAL NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
@@ -0,0 +1,43 @@
+-- หลายองค์กร (Multi-tenant) ข้อมูลของแต่ละองค์กรแยกกันด้วย organization_id
+CREATE TABLE organizations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    name TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_organizations_name_lower ON organizations (LOWER(name));
+
+-- ข้อมูลเดิมทั้งหมดย้ายเข้าองค์กร Default
+INSERT INTO organizations (created_at, updated_at, name) VALUES (CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 'Default');
+
+-- User อยู่ในองค์กรเดียว ยกเว้น Superadmin ที่ไม่มีองค์กร (NULL) และเห็นทุกองค์กร
+ALTER TABLE users ADD COLUMN organization_id INTEGER;
+UPDATE users SET organization_id = (SELECT MIN(id) FROM organizations);
+-- Admin คนแรก (ที่ระบบสร้างตอนเริ่ม) กลายเป็น Superadmin ส่วน Admin อื่นเป็น Admin ขององค์กร Default
+UPDATE users SET role = 'superadmin', organization_id = NULL
+    WHERE id = (SELECT MIN(id) FROM users WHERE role = 'admin' AND deleted_at IS NULL);
+CREATE INDEX idx_users_organization_id ON users (organization_id);
+
+-- Device ลงทะเบียนอัตโนมัติเมื่อส่งค่าครั้งแรก ชื่อไม่ซ้ำภายในองค์กร (ไม่สนตัวพิมพ์)
+CREATE TABLE devices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    organization_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    last_seen_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_devices_org_name_lower ON devices (organization_id, LOWER(name));
+
+-- SQLite เพิ่ม Column NOT NULL ได้เฉพาะเมื่อมี DEFAULT (ทุกแถวถูกกำหนดองค์กรทันทีด้านล่าง)
+ALTER TABLE sensor_data ADD COLUMN organization_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sensor_data ADD COLUMN device_id INTEGER;
+UPDATE sensor_data SET organization_id = (SELECT MIN(id) FROM organizations);
+CREATE INDEX idx_sensor_data_org_created_at ON sensor_data (organization_id, created_at);
+CREATE INDEX idx_sensor_data_device_id ON sensor_data (device_id);
+
+-- NULL = การกระทำระดับระบบ (Superadmin / CLI) เห็นเฉพาะ Superadmin
+ALTER TABLE audit_logs ADD COLUMN organization_id INTEGER;
+UPDATE audit_logs SET organization_id = (SELECT MIN(id) FROM organizations);
+CREATE INDEX idx_audit_logs_organization_id ON audit_logs (organization_id);
@@ -0,0 +1,32 @@
+-- ตำแหน่งแบบลำดับชั้น (ฟาร์ม → โรงเรือน → แถว → Bin) ของแต่ละองค์กร
+CREATE TABLE locations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    organization_id INTEGER NOT NULL,
+    parent_id INTEGER,
+    kind TEXT NOT NULL,
+    name TEXT NOT NULL
+);
+
+CREATE INDEX idx_locations_organization_id ON locations (organization_id);
+CREATE INDEX idx_locations_parent_id ON locations (parent_id);
+-- ชื่อไม่ซ้ำกับพี่น้อง (ไม่สนตัวพิมพ์) ชั้นบนสุดใช้ parent 0 เพราะ NULL ไม่ถือว่าซ้ำกัน
+CREATE UNIQUE INDEX idx_locations_parent_name_lower ON locations (organization_id, COALESCE(parent_id, 0), LOWER(name));
+
+-- ประวัติการติดตั้ง Device ที่ Bin
+CREATE TABLE device_assignments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    organization_id INTEGER NOT NULL,
+    device_id INTEGER NOT NULL,
+    location_id INTEGER NOT NULL,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME
+);
+
+CREATE INDEX idx_device_assignments_organization_id ON device_assignments (organization_id);
+CREATE INDEX idx_device_assignments_device_id ON device_assignments (device_id, started_at);
+CREATE INDEX idx_device_assignments_location_id ON device_assignments (location_id);
+-- Device ติดตั้งอยู่ได้ที่เดียวในเวลาเดียวกัน
+CREATE UNIQUE INDEX idx_device_assignments_open ON device_assignments (device_id) WHERE ended_at IS NULL;
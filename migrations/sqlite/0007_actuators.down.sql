@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS actuator_commands;
+DROP TABLE IF EXISTS actuators;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS automation_logs;
+DROP TABLE IF EXISTS automation_rules;
+
+ALTER TABLE actuators DROP COLUMN override_until;
+ALTER TABLE actuators DROP COLUMN manual_override;
@@ -0,0 +1,49 @@
+-- Manual Override: Automation ไม่สั่ง Actuator จนถึง override_until (NULL = จนกว่าจะปิด)
+ALTER TABLE actuators ADD COLUMN manual_override BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE actuators ADD COLUMN override_until DATETIME;
+
+-- Rule ที่สั่ง Actuator อัตโนมัติจากค่า Sensor ของ Location
+CREATE TABLE automation_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    organization_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    mode TEXT NOT NULL,
+    location_id INTEGER NOT NULL,
+    metric TEXT NOT NULL,
+    operator TEXT NOT NULL,
+    threshold REAL NOT NULL,
+    hysteresis REAL NOT NULL,
+    for_seconds INTEGER NOT NULL,
+    cooldown_seconds INTEGER NOT NULL,
+    actuator_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    duty_cycle INTEGER,
+    duration_seconds INTEGER,
+    condition_met BOOLEAN NOT NULL DEFAULT 0,
+    condition_since DATETIME,
+    last_value REAL,
+    last_evaluated_at DATETIME,
+    last_fired_at DATETIME,
+    fired_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_automation_rules_organization_id ON automation_rules (organization_id);
+CREATE INDEX idx_automation_rules_location_id ON automation_rules (location_id);
+CREATE INDEX idx_automation_rules_actuator_id ON automation_rules (actuator_id);
+
+-- ทุกครั้งที่ Rule ทำงาน (หรือจะทำงานในโหมด dry_run)
+CREATE TABLE automation_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    organization_id INTEGER NOT NULL,
+    rule_id INTEGER NOT NULL,
+    outcome TEXT NOT NULL,
+    value REAL NOT NULL,
+    command_id INTEGER,
+    details TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_automation_logs_organization_id ON automation_logs (organization_id);
+CREATE INDEX idx_automation_logs_rule_id ON automation_logs (rule_id, created_at);
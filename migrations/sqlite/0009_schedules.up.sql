@@ -0,0 +1,39 @@
+-- งานที่ทำตามเวลาด้วย Cron Expression
+CREATE TABLE schedules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    organization_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    cron TEXT NOT NULL,
+    time_zone TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    job TEXT NOT NULL,
+    actuator_id INTEGER,
+    action TEXT NOT NULL DEFAULT '',
+    duty_cycle INTEGER,
+    duration_seconds INTEGER,
+    retention_days INTEGER,
+    next_run_at DATETIME,
+    last_run_at DATETIME,
+    run_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_schedules_organization_id ON schedules (organization_id);
+CREATE INDEX idx_schedules_actuator_id ON schedules (actuator_id);
+CREATE INDEX idx_schedules_next_run_at ON schedules (next_run_at);
+
+-- ประวัติการทำงานของ Schedule
+CREATE TABLE schedule_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    organization_id INTEGER NOT NULL,
+    schedule_id INTEGER NOT NULL,
+    scheduled_for DATETIME NOT NULL,
+    outcome TEXT NOT NULL,
+    command_id INTEGER,
+    details TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_schedule_runs_organization_id ON schedule_runs (organization_id);
+CREATE INDEX idx_schedule_runs_schedule_id ON schedule_runs (schedule_id, created_at);
@@ -0,0 +1,21 @@
+-- สิ่งที่ทำกับ Bin: ให้อาหาร เปลี่ยนวัสดุรองพื้น เติมน้ำ เก็บเกี่ยว ประมาณจำนวน
+CREATE TABLE husbandry_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME,
+    organization_id INTEGER NOT NULL,
+    location_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    occurred_at DATETIME NOT NULL,
+    recorded_by INTEGER,
+    notes TEXT NOT NULL DEFAULT '',
+    feed_type TEXT NOT NULL DEFAULT '',
+    bedding_material TEXT NOT NULL DEFAULT '',
+    weight_kg REAL,
+    water_liters REAL,
+    castings_kg REAL,
+    worms_kg REAL,
+    worm_count INTEGER
+);
+
+CREATE INDEX idx_husbandry_events_organization_id ON husbandry_events (organization_id);
+CREATE INDEX idx_husbandry_events_location_occurred_at ON husbandry_events (location_id, occurred_at);
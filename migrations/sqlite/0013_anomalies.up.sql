@@ -0,0 +1,6 @@
+-- ค่าผิดปกติที่ตรวจพบตอนบันทึก (JSON) ข้อมูลเดิมถือว่าไม่ได้ตรวจ
+ALTER TABLE sensor_data ADD COLUMN anomalies TEXT;
+ALTER TABLE sensor_data ADD COLUMN anomalous BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- ค่าที่ผิดปกติมีน้อย ทำ Index เฉพาะแถวเหล่านั้น
+CREATE INDEX idx_sensor_data_anomalous ON sensor_data (organization_id, created_at) WHERE anomalous;
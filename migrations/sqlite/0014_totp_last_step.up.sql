@@ -0,0 +1,2 @@
+-- ช่วงเวลาล่าสุดที่รหัส TOTP ถูกใช้ (กันการใช้รหัสเดิมซ้ำภายในช่วงเวลาเดียวกัน)
+ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE automation_rules DROP COLUMN state_version;
@@ -1,8 +1,17 @@
 package models
 
 import (
-	"time"
 	"gorm.io/gorm"
+	"time"
+)
+
+// Role ของ User
+const (
+	// RoleSuperadmin ผู้ดูแลทั้งระบบ เห็นและจัดการได้ทุกองค์กร (ไม่สังกัดองค์กรใด)
+	RoleSuperadmin = "superadmin"
+	// RoleAdmin ผู้ดูแลขององค์กรตัวเอง
+	RoleAdmin = "admin"
+	RoleUser  = "user"
 )
 
 // User: เก็บข้อมูลผู้ใช้
@@ -14,19 +23,496 @@ type User struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // json:"-" คือซ่อน field นี้ไม่ให้โชว์ใน Docs/Response
 
 	// Fields เดิมของคุณ
-	Username string `gorm:"unique;not null" json:"username" example:"staff01"`
-	Password string `gorm:"not null" json:"-"` 
-	Role     string `gorm:"default:user" json:"role" example:"user"`
-	APIKey   string `gorm:"unique;index" json:"api_key"`
+	// Unique แบบไม่สนตัวพิมพ์ (idx_users_username_lower ใน Migration 0003)
+	Username string `gorm:"not null" json:"username" example:"staff01"`
+	Password string `gorm:"not null" json:"-"`
+	// superadmin (ทุกองค์กร) | admin (Admin ขององค์กร) | user
+	Role string `gorm:"default:user" json:"role" example:"user"`
+	// ใช้แทนรหัสผ่านและ 2FA ได้ จึงไม่อยู่ใน JSON ของ User (คืนเฉพาะตอน Register / Login และ `worm user key create`)
+	APIKey string `gorm:"unique;index" json:"-"`
+
+	// องค์กรที่ User สังกัด (null = Superadmin ที่ไม่อยู่ในองค์กรใด)
+	OrganizationID *uint `gorm:"index" json:"organization_id" example:"1"`
+
+	// true = ต้องเปลี่ยนรหัสผ่านก่อนใช้ API อื่น (เช่น Admin คนแรกที่ได้รหัสสุ่ม)
+	MustChangePassword bool `gorm:"default:false" json:"must_change_password"`
+
+	// Two-Factor (TOTP) - Secret ห้ามหลุดออกไปใน Response
+	TOTPSecret  string `json:"-"`
+	TOTPEnabled bool   `gorm:"default:false" json:"totp_enabled"`
+	// ช่วงเวลา (Time-step) ล่าสุดที่รหัส TOTP ถูกใช้ รหัสของช่วงนี้หรือก่อนหน้าใช้ซ้ำไม่ได้
+	// เปลี่ยนผ่าน UserRepository.UseTOTPStep เท่านั้น
+	TOTPLastStep int64 `gorm:"not null;default:0" json:"-"`
+
+	// SSO (OpenID Connect) - Subject จาก IdP, null สำหรับบัญชี Password ปกติ
+	OIDCSubject           *string `gorm:"column:oidc_subject;unique" json:"-"`
+	PasswordLoginDisabled bool    `gorm:"default:false" json:"password_login_disabled"`
+
+	// true = ปิดบัญชี (ใช้ API Key / Login / SSO ไม่ได้) แต่ยังเก็บประวัติไว้
+	Disabled bool `gorm:"default:false" json:"disabled"`
+}
+
+// IsAdmin เป็น Admin ขององค์กรหรือ Superadmin
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin || u.Role == RoleSuperadmin
+}
+
+// IsSuperadmin เป็นผู้ดูแลทั้งระบบ
+func (u *User) IsSuperadmin() bool {
+	return u.Role == RoleSuperadmin
+}
+
+// Organization: องค์กร (ฟาร์ม) ที่เป็นเจ้าของ User, Device และข้อมูล Sensor
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Unique แบบไม่สนตัวพิมพ์ (idx_organizations_name_lower ใน Migration 0005)
+	Name string `gorm:"not null" json:"name" example:"Green Farm"`
+}
+
+// Device: อุปกรณ์ที่ส่งค่า Sensor (ลงทะเบียนอัตโนมัติเมื่อส่งค่าครั้งแรก)
+type Device struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedAt      time.Time `json:"created_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	// X-Device-ID หรือชื่อเจ้าของ API Key, Unique ภายในองค์กรแบบไม่สนตัวพิมพ์ (idx_devices_org_name_lower)
+	Name       string     `gorm:"not null" json:"name" example:"bin-01"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+}
+
+// LocationKindBin ชนิดของ Location ชั้นล่างสุด เป็นชนิดเดียวที่ติดตั้ง Device ได้และมี Location ลูกไม่ได้
+const LocationKindBin = "bin"
+
+// Location: ตำแหน่งแบบลำดับชั้นขององค์กร เช่น ฟาร์ม → โรงเรือน → แถว → Bin
+type Location struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"3"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	// null = ชั้นบนสุด (เช่นฟาร์ม)
+	ParentID *uint `gorm:"index" json:"parent_id" example:"2"`
+	// ชนิดตั้งชื่อเองได้ (farm, shed, row, ...) ยกเว้น bin ที่มีความหมายพิเศษ
+	Kind string `gorm:"not null" json:"kind" example:"shed"`
+	// ไม่ซ้ำกับพี่น้องภายใต้ Parent เดียวกัน (idx_locations_parent_name_lower)
+	Name string `gorm:"not null" json:"name" example:"Shed 2"`
+}
+
+// DeviceAssignment: ประวัติการติดตั้ง Device ที่ Bin (ย้าย Device แล้วข้อมูลเก่ายังนับเป็นของ Bin เดิม)
+// ค่าจาก Sensor เป็นของ Bin ที่ Device ติดตั้งอยู่ในช่วง [StartedAt, EndedAt)
+type DeviceAssignment struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedAt      time.Time `json:"created_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	DeviceID       uint      `gorm:"not null;index" json:"device_id" example:"1"`
+	LocationID     uint      `gorm:"not null;index" json:"location_id" example:"7"`
+	StartedAt      time.Time `gorm:"not null" json:"started_at"`
+	// null = ยังติดตั้งอยู่ (มีได้รายการเดียวต่อ Device, idx_device_assignments_open)
+	EndedAt *time.Time `json:"ended_at"`
+}
+
+// RecoveryCode: รหัสสำรองแบบใช้ครั้งเดียวสำหรับ 2FA (เก็บเฉพาะ Hash)
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// AuditLog: บันทึกการกระทำสำคัญของ Admin (เช่น Reset 2FA ของคนอื่น)
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ActorID   uint      `gorm:"index" json:"actor_id" example:"1"`
+	Action    string    `gorm:"not null" json:"action" example:"2fa.reset"`
+	TargetID  uint      `json:"target_id" example:"2"`
+	Details   string    `json:"details"`
+	// null = การกระทำระดับระบบ (Superadmin / CLI)
+	OrganizationID *uint `gorm:"index" json:"organization_id" example:"1"`
+}
+
+// คำสั่งของ Actuator
+const (
+	CommandOn  = "on"
+	CommandOff = "off"
+	// CommandDuty เปิด-ปิดเป็นรอบ โดยเปิด DutyCycle % ของแต่ละรอบ (ความยาวรอบเป็นของ Firmware)
+	CommandDuty = "duty"
+)
+
+// สถานะของ ActuatorCommand: queued → delivered (Device Poll ไปแล้ว) → acknowledged | failed
+// คำสั่งที่ไม่ถูก Poll ก่อน ExpiresAt เป็น expired และคำสั่งที่ส่งแล้วแต่ไม่ได้รับการยืนยันทันเวลาเป็น failed
+const (
+	CommandQueued       = "queued"
+	CommandDelivered    = "delivered"
+	CommandAcknowledged = "acknowledged"
+	CommandFailed       = "failed"
+	CommandExpired      = "expired"
+)
+
+// ผู้สั่งของ ActuatorCommand
+const (
+	// CommandSourceUser สั่งผ่าน API โดย User (RequestedBy)
+	CommandSourceUser = "user"
+	// CommandSourceRule สั่งโดย AutomationRule
+	CommandSourceRule = "rule"
+	// CommandSourceSchedule สั่งโดย Schedule
+	CommandSourceSchedule = "schedule"
+)
+
+// Actuator: Relay ที่ Device ควบคุม เช่น พัดลม เครื่องพ่นหมอก ฮีตเตอร์
+type Actuator struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	DeviceID       uint      `gorm:"not null;index" json:"device_id" example:"1"`
+	// ไม่ซ้ำภายใน Device เดียวกัน (idx_actuators_device_name_lower)
+	Name string `gorm:"not null" json:"name" example:"Mister 1"`
+	// ตั้งเองได้ เช่น fan, mister, heater
+	Kind string `gorm:"not null" json:"kind" example:"mister"`
+	// ช่อง Relay บน Device (ไม่ซ้ำภายใน Device, idx_actuators_device_channel)
+	Channel int `gorm:"not null" json:"channel" example:"0"`
+	// คำสั่งล่าสุดที่ Device ยืนยันแล้ว ("" = ยังไม่เคยได้รับคำสั่ง)
+	State          string     `json:"state" example:"on"`
+	StateChangedAt *time.Time `json:"state_changed_at"`
+	// Manual Override: Automation Rule / Schedule ไม่สั่ง Actuator นี้จนถึง OverrideUntil (null = จนกว่าจะปิด)
+	ManualOverride bool       `gorm:"not null;default:false" json:"manual_override" example:"false"`
+	OverrideUntil  *time.Time `json:"override_until"`
+}
+
+// OverrideActive อยู่ในโหมด Manual Override ณ เวลา now หรือไม่
+func (a *Actuator) OverrideActive(now time.Time) bool {
+	return a.ManualOverride && (a.OverrideUntil == nil || now.Before(*a.OverrideUntil))
+}
+
+// ActuatorCommand: คำสั่งหนึ่งครั้งถึง Actuator พร้อมสถานะ (เก็บไว้เป็นประวัติ)
+type ActuatorCommand struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"10"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	ActuatorID     uint      `gorm:"not null;index" json:"actuator_id" example:"1"`
+	// คัดลอกจาก Actuator ตอนสั่ง เพื่อให้ Device Poll ได้โดยไม่ต้อง Join
+	DeviceID uint `gorm:"not null;index" json:"device_id" example:"1"`
+	Channel  int  `gorm:"not null" json:"channel" example:"0"`
+
+	// on | off | duty
+	Action string `gorm:"not null" json:"action" example:"on"`
+	// 1-100 (เฉพาะ duty)
+	DutyCycle *int `json:"duty_cycle" example:"25"`
+	// ทำตามคำสั่งกี่วินาทีแล้วกลับเป็นปิด (null = จนกว่าจะมีคำสั่งใหม่)
+	DurationSeconds *int `json:"duration_seconds" example:"120"`
+
+	Status string `gorm:"not null;index" json:"status" example:"queued"`
+	// ผู้สั่ง (user = ผ่าน API)
+	Source string `gorm:"not null" json:"source" example:"user"`
+	// User ที่สั่ง (null = ระบบ)
+	RequestedBy *uint `json:"requested_by" example:"2"`
+	// Device ต้อง Poll ก่อนเวลานี้ ไม่งั้นคำสั่งหมดอายุ (actuators.command_ttl)
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	// เหตุผลเมื่อ failed (จาก Device หรือจากระบบเมื่อรอการยืนยันนานเกินไป)
+	Error string `json:"error,omitempty"`
+}
+
+// โหมดของ AutomationRule
+const (
+	RuleModeActive = "active"
+	// RuleModeDryRun ประเมินและบันทึกว่าจะทำอะไร แต่ไม่สั่ง Actuator จริง
+	RuleModeDryRun   = "dry_run"
+	RuleModeDisabled = "disabled"
+)
+
+// Metric ที่ใช้ในเงื่อนไขของ AutomationRule และ Calibration
+const (
+	MetricTemperature = "temperature"
+	MetricHumidity    = "humidity"
+)
+
+// การเปรียบเทียบในเงื่อนไขของ AutomationRule
+const (
+	OperatorBelow = "below"
+	OperatorAbove = "above"
+)
+
+// ผลของการทำงานของ Rule ใน AutomationLog
+const (
+	RuleOutcomeFired  = "fired"
+	RuleOutcomeDryRun = "dry_run"
+	// RuleOutcomeOverride ไม่ได้สั่งเพราะ Actuator อยู่ในโหมด Manual Override
+	RuleOutcomeOverride = "override"
+	RuleOutcomeFailed   = "failed"
+)
+
+// AutomationRule: สั่ง Actuator อัตโนมัติเมื่อค่า Sensor ของ Location เข้าเงื่อนไขต่อเนื่องนานพอ
+// เช่น ความชื้นใน Bin 4 ต่ำกว่า 65 % นาน 10 นาที → เปิดเครื่องพ่นหมอก 2 นาที แล้วเว้น 15 นาที
+type AutomationRule struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	Name           string    `gorm:"not null" json:"name" example:"Bin 4 humidity"`
+	// active | dry_run | disabled
+	Mode string `gorm:"not null" json:"mode" example:"dry_run"`
+
+	// เงื่อนไข: ค่าเฉลี่ยของ Metric ทุก Device ใต้ Location ในช่วง automation.reading_window ล่าสุด
+	LocationID uint    `gorm:"not null;index" json:"location_id" example:"4"`
+	Metric     string  `gorm:"not null" json:"metric" example:"humidity"`
+	Operator   string  `gorm:"not null" json:"operator" example:"below"`
+	Threshold  float64 `gorm:"not null" json:"threshold" example:"65"`
+	// เงื่อนไขที่เป็นจริงแล้วจะกลับเป็นเท็จเมื่อค่าเลย Threshold ไปอีก Hysteresis (กันการกระพริบรอบ Threshold)
+	Hysteresis float64 `gorm:"not null" json:"hysteresis" example:"2"`
+	// เงื่อนไขต้องเป็นจริงต่อเนื่องกี่วินาทีก่อนทำงาน
+	ForSeconds int `gorm:"not null" json:"for_seconds" example:"600"`
+	// เว้นอย่างน้อยกี่วินาทีระหว่างการทำงานแต่ละครั้ง
+	CooldownSeconds int `gorm:"not null" json:"cooldown_seconds" example:"900"`
+
+	// การกระทำ (เหมือน POST /api/actuators/{id}/commands)
+	ActuatorID      uint   `gorm:"not null;index" json:"actuator_id" example:"1"`
+	Action          string `gorm:"not null" json:"action" example:"on"`
+	DutyCycle       *int   `json:"duty_cycle"`
+	DurationSeconds *int   `json:"duration_seconds" example:"120"`
+
+	// สถานะจากการประเมินล่าสุด (ระบบเป็นผู้แก้)
+	ConditionMet    bool       `gorm:"not null;default:false" json:"condition_met" example:"false"`
+	ConditionSince  *time.Time `json:"condition_since"`
+	LastValue       *float64   `json:"last_value" example:"63.5"`
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at"`
+	LastFiredAt     *time.Time `json:"last_fired_at"`
+	// จำนวนครั้งที่ทำงาน (ใช้กันสอง Instance ทำงานซ้ำในรอบเดียวกัน)
+	FiredCount int64 `gorm:"not null;default:0" json:"fired_count" example:"3"`
+	// เพิ่มทุกครั้งที่บันทึกสถานะหรือแก้ค่าตั้ง (ใช้กัน Ingest กับ Worker เขียนสถานะทับกัน)
+	StateVersion int64 `gorm:"not null;default:0" json:"-"`
+}
+
+// AutomationLog: บันทึกทุกครั้งที่ Rule ทำงาน (หรือจะทำงานในโหมด dry_run)
+type AutomationLog struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedAt      time.Time `json:"created_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	RuleID         uint      `gorm:"not null;index" json:"rule_id" example:"1"`
+	// fired | dry_run | override | failed
+	Outcome string `gorm:"not null" json:"outcome" example:"dry_run"`
+	// ค่าที่ทำให้ Rule ทำงาน
+	Value float64 `json:"value" example:"63.5"`
+	// คำสั่งที่ส่ง (เฉพาะ fired)
+	CommandID *uint  `json:"command_id" example:"10"`
+	Details   string `json:"details" example:"would send on for 120s to Mister 1"`
+}
+
+// งานที่ Schedule ทำได้
+const (
+	// JobActuatorCommand สั่ง Actuator (เช่น เปิดพัดลมระบายอากาศทุกเช้า 06:00)
+	JobActuatorCommand = "actuator_command"
+	// JobPruneSensorData ลบค่า Sensor ขององค์กรที่เก่ากว่า RetentionDays วัน
+	JobPruneSensorData = "prune_sensor_data"
+)
+
+// ผลของการทำงานแต่ละครั้งใน ScheduleRun
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	// RunSkipped ไม่ได้ทำ (เลยเวลาเกิน schedule.misfire_grace หรือ Actuator อยู่ในโหมด Manual Override)
+	RunSkipped = "skipped"
+)
+
+// Schedule: งานที่ทำตามเวลาด้วย Cron Expression ตาม Time Zone ของ Schedule
+type Schedule struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	Name           string    `gorm:"not null" json:"name" example:"Morning ventilation"`
+	// Cron 5 ช่อง (minute hour day-of-month month day-of-week) หรือ @daily, @hourly, ...
+	Cron string `gorm:"not null" json:"cron" example:"0 6 * * *"`
+	// IANA Time Zone ที่ใช้อ่าน Cron
+	TimeZone string `gorm:"not null" json:"time_zone" example:"Asia/Bangkok"`
+	Enabled  bool   `gorm:"not null;default:true" json:"enabled" example:"true"`
+
+	// actuator_command | prune_sensor_data
+	Job string `gorm:"not null" json:"job" example:"actuator_command"`
+	// เฉพาะ actuator_command (เหมือน POST /api/actuators/{id}/commands)
+	ActuatorID      *uint  `gorm:"index" json:"actuator_id" example:"1"`
+	Action          string `gorm:"not null;default:''" json:"action,omitempty" example:"on"`
+	DutyCycle       *int   `json:"duty_cycle,omitempty"`
+	DurationSeconds *int   `json:"duration_seconds,omitempty" example:"900"`
+	// เฉพาะ prune_sensor_data
+	RetentionDays *int `json:"retention_days,omitempty"`
+
+	// เวลาทำงานครั้งถัดไป (null = ปิดอยู่) เก็บในฐานข้อมูลจึงไม่หายเมื่อ Restart
+	NextRunAt *time.Time `gorm:"index" json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	// จำนวนครั้งที่ถูกหยิบไปทำ (ใช้กันหลาย Instance ทำรอบเดียวกันซ้ำ)
+	RunCount int64 `gorm:"not null;default:0" json:"run_count" example:"12"`
+}
+
+// ScheduleRun: ประวัติการทำงานแต่ละครั้งของ Schedule
+type ScheduleRun struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedAt      time.Time `json:"created_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	ScheduleID     uint      `gorm:"not null;index" json:"schedule_id" example:"1"`
+	// เวลาที่ควรทำตาม Cron
+	ScheduledFor time.Time `gorm:"not null" json:"scheduled_for"`
+	// succeeded | failed | skipped
+	Outcome string `gorm:"not null" json:"outcome" example:"succeeded"`
+	// คำสั่งที่ส่ง (เฉพาะ actuator_command ที่สำเร็จ)
+	CommandID *uint  `json:"command_id" example:"10"`
+	Details   string `json:"details" example:"sent on for 900s to Fan 1"`
+}
+
+// ชนิดของ HusbandryEvent
+const (
+	// EventFeeding ให้อาหาร (FeedType, WeightKg)
+	EventFeeding = "feeding"
+	// EventBedding เปลี่ยน / เติมวัสดุรองพื้น (BeddingMaterial, WeightKg ไม่บังคับ)
+	EventBedding = "bedding"
+	// EventMoisture เติมน้ำ (WaterLiters)
+	EventMoisture = "moisture"
+	// EventHarvest เก็บเกี่ยว (CastingsKg, WormsKg ไม่บังคับ)
+	EventHarvest = "harvest"
+	// EventPopulation ประมาณจำนวนไส้เดือน (WormCount)
+	EventPopulation = "population"
+)
+
+// HusbandryEvent: สิ่งที่ทำกับ Bin (ให้อาหาร เปลี่ยนวัสดุรองพื้น เติมน้ำ เก็บเกี่ยว ประมาณจำนวน)
+// ใช้คู่กับค่า Sensor เพื่อดูว่าการดูแลส่งผลต่อ Bin อย่างไร
+type HusbandryEvent struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedAt      time.Time `json:"created_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	LocationID     uint      `gorm:"not null;index" json:"location_id" example:"7"`
+	// feeding | bedding | moisture | harvest | population
+	Kind       string    `gorm:"not null" json:"kind" example:"feeding"`
+	OccurredAt time.Time `gorm:"not null;index" json:"occurred_at"`
+	// User ที่บันทึก
+	RecordedBy *uint  `json:"recorded_by" example:"2"`
+	Notes      string `gorm:"not null;default:''" json:"notes" example:"coffee grounds from the cafe"`
+
+	FeedType        string   `gorm:"not null;default:''" json:"feed_type,omitempty" example:"vegetable scraps"`
+	BeddingMaterial string   `gorm:"not null;default:''" json:"bedding_material,omitempty" example:"shredded cardboard"`
+	WeightKg        *float64 `json:"weight_kg,omitempty" example:"2.5"`
+	WaterLiters     *float64 `json:"water_liters,omitempty" example:"1.5"`
+	CastingsKg      *float64 `json:"castings_kg,omitempty" example:"12"`
+	WormsKg         *float64 `json:"worms_kg,omitempty" example:"0.8"`
+	WormCount       *int64   `json:"worm_count,omitempty" example:"4000"`
+}
+
+// วิธีแก้ค่าของ Calibration
+const (
+	// CalibrationOffset ค่าจริง = ค่าดิบ + Offset
+	CalibrationOffset = "offset"
+	// CalibrationLinear ค่าจริง = ค่าดิบ × Gain + Offset
+	CalibrationLinear = "linear"
+	// CalibrationTable ประมาณเส้นตรงระหว่าง Points (นอกช่วงใช้เส้นของคู่แรก / คู่สุดท้าย)
+	CalibrationTable = "table"
+)
+
+// CalibrationPoint ค่าดิบจาก Sensor คู่กับค่าจริงจากเครื่องวัดอ้างอิง
+type CalibrationPoint struct {
+	Raw    float64 `json:"raw" example:"26.4"`
+	Actual float64 `json:"actual" example:"25"`
+}
+
+// Calibration: การแก้ค่า Metric หนึ่งของ Device ในช่วง [ValidFrom, ValidUntil)
+// ค่าใหม่ถูกแก้ตอนบันทึก (ค่าดิบเก็บไว้ใน SensorData) ช่วงของ Device / Metric เดียวกันไม่ซ้อนกัน
+type Calibration struct {
+	ID             uint      `gorm:"primaryKey" json:"id" example:"1"`
+	CreatedAt      time.Time `json:"created_at"`
+	OrganizationID uint      `gorm:"not null;index" json:"organization_id" example:"1"`
+	DeviceID       uint      `gorm:"not null;index" json:"device_id" example:"1"`
+	// temperature | humidity
+	Metric string `gorm:"not null" json:"metric" example:"temperature"`
+	// offset | linear | table
+	Method string  `gorm:"not null" json:"method" example:"offset"`
+	Offset float64 `gorm:"not null;default:0" json:"offset" example:"-1.5"`
+	// ใช้เฉพาะ linear
+	Gain float64 `gorm:"not null;default:1" json:"gain" example:"1"`
+	// ใช้เฉพาะ table (เรียงตาม Raw)
+	Points     []CalibrationPoint `gorm:"serializer:json" json:"points,omitempty"`
+	ValidFrom  time.Time          `gorm:"not null" json:"valid_from"`
+	ValidUntil *time.Time         `json:"valid_until"`
+	Notes      string             `gorm:"not null;default:''" json:"notes" example:"checked against a reference thermometer"`
+}
+
+// ValidAt ใช้กับค่าที่บันทึก ณ เวลา t หรือไม่
+func (c *Calibration) ValidAt(t time.Time) bool {
+	return !t.Before(c.ValidFrom) && (c.ValidUntil == nil || t.Before(*c.ValidUntil))
+}
+
+// Apply แก้ค่าดิบ raw ตาม Method
+func (c *Calibration) Apply(raw float64) float64 {
+	switch c.Method {
+	case CalibrationLinear:
+		return raw*c.Gain + c.Offset
+	case CalibrationTable:
+		if len(c.Points) < 2 {
+			return raw
+		}
+		// ช่วงที่ raw อยู่ (นอกช่วงใช้ช่วงแรก / ช่วงสุดท้าย)
+		i := 1
+		for i < len(c.Points)-1 && raw > c.Points[i].Raw {
+			i++
+		}
+		a, b := c.Points[i-1], c.Points[i]
+		return a.Actual + (raw-a.Raw)*(b.Actual-a.Actual)/(b.Raw-a.Raw)
+	default:
+		return raw + c.Offset
+	}
 }
 
 // SensorData: เก็บข้อมูลสภาพอากาศ
 type SensorData struct {
 	// ทำเหมือนกัน
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	CreatedAt time.Time      `json:"created_at"`
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
 	// SensorData อาจจะไม่จำเป็นต้องมี UpdatedAt/DeletedAt ก็ได้แล้วแต่ design
-	
+
+	// ค่าหลังแก้ตาม Calibration (ไม่มี Calibration = เท่าค่าดิบ)
 	Temperature float64 `gorm:"not null" json:"temp" example:"32.5"`
 	Humidity    float64 `gorm:"not null" json:"humidity" example:"60.0"`
-}
\ No newline at end of file
+	// ค่าดิบที่ Device ส่งมา (ใช้คำนวณใหม่เมื่อเพิ่ม Calibration ย้อนหลัง)
+	RawTemperature float64 `gorm:"not null;default:0" json:"raw_temp" example:"34"`
+	RawHumidity    float64 `gorm:"not null;default:0" json:"raw_humidity" example:"60.0"`
+	// ค่าที่คำนวณจาก Temperature / Humidity ตอนบันทึก ตามชื่อของสูตร (ดู package derived)
+	// null = ข้อมูลก่อนมีการคำนวณ (คำนวณได้ด้วย POST /api/devices/{id}/calibrations/recompute)
+	Derived map[string]float64 `gorm:"serializer:json" json:"derived" swaggertype:"object,number" example:"dew_point_c:23.73,absolute_humidity_gm3:20.78,vpd_kpa:1.954"`
+	// ค่าผิดปกติที่ตรวจพบตอนบันทึก (เทียบค่าดิบกับค่าล่าสุดของ Device เดียวกัน) ไม่มี = ปกติหรือไม่ได้ตรวจ
+	Anomalies []Anomaly `gorm:"serializer:json" json:"anomalies,omitempty"`
+	// มี Anomalies อย่างน้อยหนึ่งอย่าง (ใช้ค้นหา)
+	Anomalous bool `gorm:"not null;default:false" json:"-"`
+
+	OrganizationID uint `gorm:"not null;index" json:"organization_id" example:"1"`
+	// null = ข้อมูลก่อนมีการลงทะเบียน Device
+	DeviceID *uint `gorm:"index" json:"device_id" example:"1"`
+}
+
+// ชนิดของค่าผิดปกติ (Anomaly.Kind)
+const (
+	// ห่างจากค่าเฉลี่ยของค่าล่าสุดเกิน anomaly.z_score เท่าของส่วนเบี่ยงเบนมาตรฐาน
+	AnomalyZScore = "zscore"
+	// เปลี่ยนจากค่าก่อนหน้าเร็วเกิน anomaly.max_*_rate ต่อนาที
+	AnomalySpike = "spike"
+	// ค่าเดิมซ้ำติดกัน anomaly.stuck_count ค่า
+	AnomalyStuck = "stuck"
+)
+
+// Anomaly ค่าผิดปกติหนึ่งอย่างของ SensorData
+type Anomaly struct {
+	// temperature | humidity
+	Metric string `json:"metric" example:"temperature"`
+	// zscore | spike | stuck
+	Kind string `json:"kind" example:"spike"`
+	// zscore: z-score | spike: อัตราการเปลี่ยนต่อนาที | stuck: จำนวนค่าที่ซ้ำ
+	Score float64 `json:"score" example:"4.5"`
+}
+
+// RateLimitBucket: สถานะของ Token Bucket / Quota รายวัน (ใช้เมื่อ rate_limit.backend = database)
+// Tokens = Token ที่เหลือ (Rate Limit) หรือจำนวนที่ใช้ไปแล้ว (Quota)
+type RateLimitBucket struct {
+	Key       string    `gorm:"column:bucket_key;primaryKey"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"index;not null"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// SensorSummary: ค่าสรุปของข้อมูล Sensor ในช่วงเวลาหนึ่ง (ไม่ใช่ตาราง คำนวณจาก SensorData)
+// ค่าเฉลี่ย / ต่ำสุด / สูงสุดเป็น null เมื่อไม่มีข้อมูล (Count = 0)
+type SensorSummary struct {
+	// จุดเริ่มของช่วงเวลา (เฉพาะเมื่อขอแบบแบ่งช่วง)
+	BucketStart *time.Time `json:"bucket_start,omitempty"`
+	Count       int64      `json:"count" example:"288"`
+
+	TemperatureAvg *float64 `json:"temp_avg" example:"24.6"`
+	TemperatureMin *float64 `json:"temp_min" example:"21.2"`
+	TemperatureMax *float64 `json:"temp_max" example:"27.9"`
+	HumidityAvg    *float64 `json:"humidity_avg" example:"71.3"`
+	HumidityMin    *float64 `json:"humidity_min" example:"64.0"`
+	HumidityMax    *float64 `json:"humidity_max" example:"80.5"`
+	// ค่าสรุปของค่าที่คำนวณตอนบันทึก ตามชื่อของสูตร (นับเฉพาะค่าที่มี)
+	Derived map[string]MetricSummary `json:"derived,omitempty"`
+}
+
+// MetricSummary: ค่าเฉลี่ย / ต่ำสุด / สูงสุดของค่าหนึ่ง (null เมื่อไม่มีข้อมูล)
+type MetricSummary struct {
+	Avg *float64 `json:"avg" example:"18.2"`
+	Min *float64 `json:"min" example:"15.9"`
+	Max *float64 `json:"max" example:"20.7"`
+}
+
+// LocationStats: ค่าสรุปของ Location รวมทุกชั้นที่อยู่ใต้ Location นั้น
+type LocationStats struct {
+	Location Location      `json:"location"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Summary  SensorSummary `json:"summary"`
+	// ค่าสรุปของ Location ลูกโดยตรงแต่ละตัว (รวมทุกชั้นใต้ลูกตัวนั้น)
+	Children []ChildStats `json:"children"`
+	// เฉพาะเมื่อขอ interval: ค่าสรุปรายช่วงเวลา (เฉพาะช่วงที่มีข้อมูล)
+	Series []SensorSummary `json:"series,omitempty"`
+}
+
+// ChildStats: ค่าสรุปของ Location ลูกหนึ่งตัวใน LocationStats
+type ChildStats struct {
+	Location Location      `json:"location"`
+	Summary  SensorSummary `json:"summary"`
+}
+
+// ชนิดของ TimelineEntry
+const (
+	TimelineReading = "reading"
+	TimelineEvent   = "event"
+)
+
+// TimelineEntry: ค่า Sensor หรือ HusbandryEvent หนึ่งรายการใน Timeline ของ Location (มีอย่างใดอย่างหนึ่ง)
+type TimelineEntry struct {
+	Time time.Time `json:"time"`
+	// reading | event
+	Type    string          `json:"type" example:"event"`
+	Reading *SensorData     `json:"reading,omitempty"`
+	Event   *HusbandryEvent `json:"event,omitempty"`
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"worm/services"
+)
+
+const orgUsage = `usage: worm org <command> [arguments]
+
+commands:
+  create <name>   create an organization (tenant)
+  list            list all organizations
+
+Create the organization's first admin with "worm user create -org <name> -role admin <username>".
+`
+
+// runOrg คำสั่ง `worm org create|list`
+func runOrg(args []string) {
+	fs, configFile := subcommand("org", orgUsage)
+	if len(args) == 0 {
+		usageError(fs, "")
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+
+	need := map[string]int{"create": 1, "list": 0}
+	n, ok := need[command]
+	if !ok {
+		usageError(fs, "unknown org command %q", command)
+	}
+	if fs.NArg() != n {
+		usageError(fs, "org %s: expected %d argument(s), got %d", command, n, fs.NArg())
+	}
+
+	ctx := cliContext()
+	cfg, _, store := openStore(*configFile)
+	orgs := services.NewOrganizationService(store.Organizations)
+
+	switch command {
+	case "create":
+		org, err := orgs.CreateOrganization(ctx, fs.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		_, auth := cliServices(cfg, store)
+		if err := auth.RecordAudit(ctx, actorCLI, "organization.create", nil, "created organization "+org.Name+" (cli)"); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Created organization %s (id %d)\n", org.Name, org.ID)
+	case "list":
+		list, err := orgs.GetAllOrganizations(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tCREATED")
+		for _, o := range list {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", o.ID, o.Name, o.CreatedAt.Format("2006-01-02"))
+		}
+		w.Flush()
+	}
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+	"worm/models"
+)
+
+// MemoryStore เก็บ Bucket ใน Map ตาม Key (นับแยกต่อ Process ใช้เมื่อ rate_limit.backend = memory)
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]models.RateLimitBucket
+}
+
+// NewMemoryStore สร้าง MemoryStore ว่าง
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]models.RateLimitBucket{}}
+}
+
+func (s *MemoryStore) Update(ctx context.Context, key string, fn func(b *models.RateLimitBucket)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = models.RateLimitBucket{Key: key}
+	}
+	fn(&b)
+	s.buckets[key] = b
+	return nil
+}
+
+func (s *MemoryStore) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, b := range s.buckets {
+		if b.UpdatedAt.Before(before) {
+			delete(s.buckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
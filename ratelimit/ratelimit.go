@@ -0,0 +1,108 @@
+// Package ratelimit จำกัดจำนวน Request แบบ Token Bucket และ Quota รายวัน
+// สถานะเก็บผ่าน repository.RateLimitRepository (MemoryStore = ต่อ Instance, Database = แชร์ทุก Instance)
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+	"worm/models"
+	"worm/repository"
+)
+
+// Bucket ที่ไม่ถูกใช้นานกว่านี้ถือว่าเต็มแล้ว ลบทิ้งได้ (ต้องนานกว่า 1 วันเพื่อไม่ลบ Quota ของวันนี้)
+const staleAfter = 48 * time.Hour
+
+// Rule กติกาของกลุ่ม Route หนึ่ง
+type Rule struct {
+	// ชื่อกลุ่ม ใช้เป็นส่วนหนึ่งของ Key และ Label ของ Metric
+	Name string
+	// อัตราเติม Token ต่อนาที (0 = ไม่จำกัด)
+	PerMinute int
+	// จำนวน Token สูงสุด = จำนวน Request ที่ยิงติดกันได้
+	Burst int
+}
+
+// Enabled Rule นี้จำกัดจริงหรือไม่
+func (r Rule) Enabled() bool {
+	return r.PerMinute > 0 && r.Burst > 0
+}
+
+// Result ผลการขอใช้ 1 ครั้ง (ใช้ตั้ง Header X-RateLimit-*)
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// เวลาจนกว่าจะขอใหม่ได้ (เฉพาะเมื่อ Allowed = false)
+	RetryAfter time.Duration
+	// เวลาจนกว่า Bucket จะเต็ม / Quota จะเริ่มใหม่
+	Reset time.Duration
+}
+
+// Limiter ตัวจำกัดที่ใช้ร่วมกันทุก Rule
+type Limiter struct {
+	store repository.RateLimitRepository
+	now   func() time.Time
+}
+
+// New สร้าง Limiter บน store
+func New(store repository.RateLimitRepository) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Allow ขอใช้ 1 Token จาก Bucket ของ key ตาม rule
+func (l *Limiter) Allow(ctx context.Context, rule Rule, key string) (Result, error) {
+	now := l.now()
+	capacity := float64(rule.Burst)
+	perSecond := float64(rule.PerMinute) / 60
+	res := Result{Limit: rule.Burst}
+
+	err := l.store.Update(ctx, "rl:"+rule.Name+":"+key, func(b *models.RateLimitBucket) {
+		// เติม Token ตามเวลาที่ผ่านไป (Bucket ใหม่เริ่มเต็ม)
+		if b.UpdatedAt.IsZero() {
+			b.Tokens = capacity
+		} else if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+			b.Tokens = math.Min(capacity, b.Tokens+elapsed*perSecond)
+		}
+		b.UpdatedAt = now
+
+		if b.Tokens >= 1 {
+			b.Tokens--
+			res.Allowed = true
+		} else {
+			res.RetryAfter = seconds((1 - b.Tokens) / perSecond)
+		}
+		res.Remaining = int(b.Tokens)
+		res.Reset = seconds((capacity - b.Tokens) / perSecond)
+	})
+	return res, err
+}
+
+// Quota นับการใช้ของ key ในวันนี้ (UTC) ไม่ให้เกิน limit
+func (l *Limiter) Quota(ctx context.Context, name, key string, limit int) (Result, error) {
+	now := l.now().UTC()
+	tomorrow := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	res := Result{Limit: limit, Reset: tomorrow.Sub(now)}
+
+	err := l.store.Update(ctx, "quota:"+name+":"+key+":"+now.Format(time.DateOnly), func(b *models.RateLimitBucket) {
+		b.UpdatedAt = now
+		if b.Tokens < float64(limit) {
+			b.Tokens++
+			res.Allowed = true
+		} else {
+			res.RetryAfter = res.Reset
+		}
+		res.Remaining = limit - int(b.Tokens)
+	})
+	return res, err
+}
+
+// Prune ลบ Bucket ที่ไม่ถูกใช้แล้ว คืนจำนวนที่ลบ
+func (l *Limiter) Prune(ctx context.Context) (int64, error) {
+	return l.store.DeleteBefore(ctx, l.now().Add(-staleAfter))
+}
+
+// seconds แปลงวินาทีเป็น Duration ปัดขึ้นเป็นวินาทีเต็ม (Header เป็นจำนวนเต็ม ปัดลงจะทำให้ Client ยิงเร็วเกิน)
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s)) * time.Second
+}
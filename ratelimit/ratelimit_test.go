@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock เวลาที่ทดสอบเลื่อนเองได้
+type clock struct{ now time.Time }
+
+func (c *clock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter() (*Limiter, *clock) {
+	c := &clock{now: time.Date(2026, 10, 1, 23, 59, 0, 0, time.UTC)}
+	l := New(NewMemoryStore())
+	l.now = func() time.Time { return c.now }
+	return l, c
+}
+
+func allow(t *testing.T, l *Limiter, rule Rule, key string) Result {
+	t.Helper()
+	res, err := l.Allow(context.Background(), rule, key)
+	if err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	return res
+}
+
+func TestAllowBurstThenRefill(t *testing.T) {
+	l, c := newTestLimiter()
+	// 1 Token ทุก 10 วินาที ยิงติดกันได้ 3 ครั้ง
+	rule := Rule{Name: "login", PerMinute: 6, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		if res := allow(t, l, rule, "ip:1"); !res.Allowed || res.Remaining != i || res.Limit != 3 {
+			t.Fatalf("burst request: %+v, want allowed with %d remaining", res, i)
+		}
+	}
+	res := allow(t, l, rule, "ip:1")
+	if res.Allowed || res.RetryAfter != 10*time.Second {
+		t.Fatalf("over burst: %+v, want refused with retry after 10s", res)
+	}
+	// Key อื่นมี Bucket ของตัวเอง
+	if res := allow(t, l, rule, "ip:2"); !res.Allowed {
+		t.Fatalf("other key: %+v", res)
+	}
+
+	// ครึ่ง Token ยังไม่พอ (ปัด Retry-After ขึ้นเป็นวินาทีเต็ม)
+	c.advance(5 * time.Second)
+	if res := allow(t, l, rule, "ip:1"); res.Allowed || res.RetryAfter != 5*time.Second {
+		t.Fatalf("half refilled: %+v, want refused with retry after 5s", res)
+	}
+	c.advance(5 * time.Second)
+	if res := allow(t, l, rule, "ip:1"); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("refilled one token: %+v", res)
+	}
+
+	// เติมได้ไม่เกิน Burst แม้ไม่ได้ใช้นาน
+	c.advance(time.Hour)
+	if res := allow(t, l, rule, "ip:1"); !res.Allowed || res.Remaining != 2 || res.Reset != 10*time.Second {
+		t.Fatalf("after a long idle: %+v, want 2 remaining and full again in 10s", res)
+	}
+}
+
+func TestQuotaRollsOverAtMidnightUTC(t *testing.T) {
+	l, c := newTestLimiter()
+	ctx := context.Background()
+	quota := func() Result {
+		t.Helper()
+		res, err := l.Quota(ctx, "ingest", "device:1:bin-1", 2)
+		if err != nil {
+			t.Fatalf("quota: %v", err)
+		}
+		return res
+	}
+
+	for i := 1; i >= 0; i-- {
+		if res := quota(); !res.Allowed || res.Remaining != i || res.Reset != time.Minute {
+			t.Fatalf("within quota: %+v, want %d remaining, reset in 1m", res, i)
+		}
+	}
+	if res := quota(); res.Allowed || res.Remaining != 0 || res.RetryAfter != time.Minute {
+		t.Fatalf("over quota: %+v, want refused until midnight", res)
+	}
+
+	// วันใหม่ (UTC) เริ่มนับใหม่
+	c.advance(time.Minute)
+	if res := quota(); !res.Allowed || res.Remaining != 1 || res.Reset != 24*time.Hour {
+		t.Fatalf("next day: %+v, want a fresh quota", res)
+	}
+}
+
+func TestPruneDropsStaleBuckets(t *testing.T) {
+	l, c := newTestLimiter()
+	ctx := context.Background()
+	rule := Rule{Name: "api", PerMinute: 60, Burst: 10}
+	allow(t, l, rule, "user:1")
+	c.advance(staleAfter)
+	allow(t, l, rule, "user:2")
+	c.advance(time.Second)
+
+	if n, err := l.Prune(ctx); err != nil || n != 1 {
+		t.Fatalf("prune: deleted %d, err %v, want 1", n, err)
+	}
+	if n, _ := l.Prune(ctx); n != 0 {
+		t.Fatalf("second prune deleted %d, want 0", n)
+	}
+}
+
+func TestRuleEnabled(t *testing.T) {
+	for _, c := range []struct {
+		rule Rule
+		want bool
+	}{
+		{Rule{PerMinute: 10, Burst: 5}, true},
+		{Rule{PerMinute: 0, Burst: 5}, false},
+		{Rule{PerMinute: 10, Burst: 0}, false},
+	} {
+		if got := c.rule.Enabled(); got != c.want {
+			t.Errorf("%+v.Enabled() = %v, want %v", c.rule, got, c.want)
+		}
+	}
+}
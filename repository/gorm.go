@@ -0,0 +1,1216 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"worm/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewStore สร้าง Repository ทุกตัวบน GORM (ใช้ได้ทั้ง Postgres และ SQLite)
+func NewStore(db *gorm.DB) *Store {
+	return &Store{
+		Transactor:    gormTransactor{db: db},
+		Users:         &userRepository{db: db},
+		Organizations: &organizationRepository{db: db},
+		Devices:       &deviceRepository{db: db},
+		Locations:     &locationRepository{db: db},
+		Assignments:   &deviceAssignmentRepository{db: db},
+		Actuators:     &actuatorRepository{db: db},
+		Commands:      &actuatorCommandRepository{db: db},
+		Rules:         &automationRuleRepository{db: db},
+		RuleLogs:      &automationLogRepository{db: db},
+		Schedules:     &scheduleRepository{db: db},
+		ScheduleRuns:  &scheduleRunRepository{db: db},
+		Husbandry:     &husbandryRepository{db: db},
+		Calibrations:  &calibrationRepository{db: db},
+		Sensors:       &sensorRepository{db: db},
+		RecoveryCodes: &recoveryCodeRepository{db: db},
+		Audit:         &auditRepository{db: db},
+		RateLimits:    &rateLimitRepository{db: db},
+	}
+}
+
+// gormTransactor เปิด Transaction ของ gorm แล้วสร้าง Store ใหม่บน Transaction นั้น
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+func (t gormTransactor) Transaction(ctx context.Context, fn func(tx *Store) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewStore(tx))
+	})
+}
+
+// first แปลง gorm.ErrRecordNotFound เป็น ErrNotFound
+func first[T any](query *gorm.DB) (*T, error) {
+	var out T
+	if err := query.First(&out).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &out, nil
+}
+
+// scoped เริ่ม Query ที่จำกัดตาม Tenant ใน ctx (column = Column ที่เก็บ ID ขององค์กร)
+func scoped(ctx context.Context, db *gorm.DB, column string) (*gorm.DB, error) {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db = db.WithContext(ctx)
+	if t.All {
+		return db, nil
+	}
+	return db.Where(column+" = ?", t.OrganizationID), nil
+}
+
+// findScoped หาแถวแรกที่ตรง where ภายใน Tenant
+func findScoped[T any](ctx context.Context, db *gorm.DB, column string, where string, args ...interface{}) (*T, error) {
+	q, err := scoped(ctx, db, column)
+	if err != nil {
+		return nil, err
+	}
+	return first[T](q.Where(where, args...))
+}
+
+// listScoped ทุกแถวภายใน Tenant เรียงตาม order
+func listScoped[T any](ctx context.Context, db *gorm.DB, column, order string) ([]T, error) {
+	q, err := scoped(ctx, db, column)
+	if err != nil {
+		return nil, err
+	}
+	var out []T
+	err = q.Order(order).Find(&out).Error
+	return out, err
+}
+
+// --- Users ---
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.ClaimOptional(&user.OrganizationID); err != nil {
+		return err
+	}
+	return translate(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.ClaimOptional(&user.OrganizationID); err != nil {
+		return err
+	}
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	// ไม่ใช้ Save เพราะถ้าไม่พบแถว (เช่นอยู่องค์กรอื่น) GORM จะ Insert แทน
+	// totp_last_step เปลี่ยนผ่าน UseTOTPStep เท่านั้น (user ที่อ่านมาก่อนจะไม่ย้อนค่ากลับ)
+	result := q.Model(user).Select("*").Omit("totp_last_step").Updates(user)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return false, err
+	}
+	result := q.Model(&models.User{}).Where("id = ? AND totp_last_step < ?", userID, step).Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	return findScoped[models.User](ctx, r.db, "organization_id", "id = ?", id)
+}
+
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return findScoped[models.User](ctx, r.db, "organization_id", "LOWER(username) = LOWER(?)", username)
+}
+
+func (r *userRepository) FindByAPIKey(ctx context.Context, apiKey string) (*models.User, error) {
+	return findScoped[models.User](ctx, r.db, "organization_id", "api_key = ?", apiKey)
+}
+
+func (r *userRepository) FindByOIDCSubject(ctx context.Context, subject string) (*models.User, error) {
+	return findScoped[models.User](ctx, r.db, "organization_id", "oidc_subject = ?", subject)
+}
+
+func (r *userRepository) List(ctx context.Context) ([]models.User, error) {
+	return listScoped[models.User](ctx, r.db, "organization_id", "id")
+}
+
+func (r *userRepository) ListByRole(ctx context.Context, role string) ([]models.User, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	err = q.Where("role = ?", role).Order("id").Find(&users).Error
+	return users, err
+}
+
+// --- Organizations ---
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func (r *organizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if !t.All {
+		return ErrTenantMismatch
+	}
+	return translate(r.db.WithContext(ctx).Create(org).Error)
+}
+
+func (r *organizationRepository) FindByID(ctx context.Context, id uint) (*models.Organization, error) {
+	return findScoped[models.Organization](ctx, r.db, "id", "id = ?", id)
+}
+
+func (r *organizationRepository) FindByName(ctx context.Context, name string) (*models.Organization, error) {
+	return findScoped[models.Organization](ctx, r.db, "id", "LOWER(name) = LOWER(?)", name)
+}
+
+func (r *organizationRepository) List(ctx context.Context) ([]models.Organization, error) {
+	return listScoped[models.Organization](ctx, r.db, "id", "id")
+}
+
+// --- Devices ---
+
+type deviceRepository struct {
+	db *gorm.DB
+}
+
+func (r *deviceRepository) Create(ctx context.Context, device *models.Device) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&device.OrganizationID); err != nil {
+		return err
+	}
+	return translate(r.db.WithContext(ctx).Create(device).Error)
+}
+
+func (r *deviceRepository) FindByName(ctx context.Context, orgID uint, name string) (*models.Device, error) {
+	return findScoped[models.Device](ctx, r.db, "organization_id",
+		"organization_id = ? AND LOWER(name) = LOWER(?)", orgID, name)
+}
+
+func (r *deviceRepository) FindByID(ctx context.Context, id uint) (*models.Device, error) {
+	return findScoped[models.Device](ctx, r.db, "organization_id", "id = ?", id)
+}
+
+func (r *deviceRepository) Touch(ctx context.Context, id uint, seenAt time.Time) error {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	result := q.Model(&models.Device{}).Where("id = ?", id).Update("last_seen_at", seenAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *deviceRepository) List(ctx context.Context) ([]models.Device, error) {
+	return listScoped[models.Device](ctx, r.db, "organization_id", "organization_id, name")
+}
+
+// --- Locations ---
+
+type locationRepository struct {
+	db *gorm.DB
+}
+
+func (r *locationRepository) Create(ctx context.Context, location *models.Location) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&location.OrganizationID); err != nil {
+		return err
+	}
+	return translate(r.db.WithContext(ctx).Create(location).Error)
+}
+
+func (r *locationRepository) Update(ctx context.Context, location *models.Location) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&location.OrganizationID); err != nil {
+		return err
+	}
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	result := q.Model(location).Select("*").Updates(location)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *locationRepository) Delete(ctx context.Context, id uint) error {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	result := q.Where("id = ?", id).Delete(&models.Location{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *locationRepository) FindByID(ctx context.Context, id uint) (*models.Location, error) {
+	return findScoped[models.Location](ctx, r.db, "organization_id", "id = ?", id)
+}
+
+func (r *locationRepository) List(ctx context.Context) ([]models.Location, error) {
+	return listScoped[models.Location](ctx, r.db, "organization_id", "organization_id, parent_id, name")
+}
+
+// --- Device Assignments ---
+
+type deviceAssignmentRepository struct {
+	db *gorm.DB
+}
+
+func (r *deviceAssignmentRepository) Current(ctx context.Context, deviceID uint) (*models.DeviceAssignment, error) {
+	return findScoped[models.DeviceAssignment](ctx, r.db, "organization_id", "device_id = ? AND ended_at IS NULL", deviceID)
+}
+
+func (r *deviceAssignmentRepository) Move(ctx context.Context, device *models.Device, locationID *uint, at time.Time) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	orgID := device.OrganizationID
+	if err := t.Claim(&orgID); err != nil {
+		return err
+	}
+	// เก็บเวลาใน Time Zone เดียวกับ created_at (gorm ใช้ Local) เพราะ SQLite เทียบเวลาแบบข้อความ
+	at = at.Local()
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.DeviceAssignment{}).
+			Where("organization_id = ? AND device_id = ? AND ended_at IS NULL", orgID, device.ID).
+			Update("ended_at", at).Error
+		if err != nil || locationID == nil {
+			return err
+		}
+		return tx.Create(&models.DeviceAssignment{
+			OrganizationID: orgID,
+			DeviceID:       device.ID,
+			LocationID:     *locationID,
+			StartedAt:      at,
+		}).Error
+	})
+	// ย้าย Device เดียวกันพร้อมกันสองครั้ง: idx_device_assignments_open กันไม่ให้ติดตั้งสองที่
+	return translate(err)
+}
+
+func (r *deviceAssignmentRepository) ListForDevice(ctx context.Context, deviceID uint) ([]models.DeviceAssignment, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	var out []models.DeviceAssignment
+	err = q.Where("device_id = ?", deviceID).Order("started_at, id").Find(&out).Error
+	return out, err
+}
+
+func (r *deviceAssignmentRepository) CountForLocation(ctx context.Context, locationID uint) (int64, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = q.Model(&models.DeviceAssignment{}).Where("location_id = ?", locationID).Count(&n).Error
+	return n, err
+}
+
+// --- Actuators ---
+
+type actuatorRepository struct {
+	db *gorm.DB
+}
+
+func (r *actuatorRepository) Create(ctx context.Context, actuator *models.Actuator) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&actuator.OrganizationID); err != nil {
+		return err
+	}
+	return translate(r.db.WithContext(ctx).Create(actuator).Error)
+}
+
+func (r *actuatorRepository) Update(ctx context.Context, actuator *models.Actuator) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&actuator.OrganizationID); err != nil {
+		return err
+	}
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	result := q.Model(actuator).Select("*").Omit("state", "state_changed_at").Updates(actuator)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *actuatorRepository) Delete(ctx context.Context, id uint) error {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	result := q.Where("id = ?", id).Delete(&models.Actuator{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *actuatorRepository) FindByID(ctx context.Context, id uint) (*models.Actuator, error) {
+	return findScoped[models.Actuator](ctx, r.db, "organization_id", "id = ?", id)
+}
+
+func (r *actuatorRepository) List(ctx context.Context) ([]models.Actuator, error) {
+	return listScoped[models.Actuator](ctx, r.db, "organization_id", "organization_id, device_id, channel")
+}
+
+func (r *actuatorRepository) SetState(ctx context.Context, id uint, state string, at time.Time) error {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	result := q.Model(&models.Actuator{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"state": state, "state_changed_at": at})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// --- Actuator Commands ---
+
+type actuatorCommandRepository struct {
+	db *gorm.DB
+}
+
+func (r *actuatorCommandRepository) Create(ctx context.Context, cmd *models.ActuatorCommand) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&cmd.OrganizationID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(cmd).Error
+}
+
+func (r *actuatorCommandRepository) FindByID(ctx context.Context, id uint) (*models.ActuatorCommand, error) {
+	return findScoped[models.ActuatorCommand](ctx, r.db, "organization_id", "id = ?", id)
+}
+
+func (r *actuatorCommandRepository) ListForActuator(ctx context.Context, actuatorID uint) ([]models.ActuatorCommand, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	var out []models.ActuatorCommand
+	err = q.Where("actuator_id = ?", actuatorID).Order("created_at DESC, id DESC").Find(&out).Error
+	return out, err
+}
+
+func (r *actuatorCommandRepository) CountForActuator(ctx context.Context, actuatorID uint) (int64, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = q.Model(&models.ActuatorCommand{}).Where("actuator_id = ?", actuatorID).Count(&n).Error
+	return n, err
+}
+
+func (r *actuatorCommandRepository) Deliver(ctx context.Context, deviceID uint, now time.Time) ([]models.ActuatorCommand, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	// UPDATE ... RETURNING คืนเฉพาะแถวที่ Request นี้เปลี่ยนได้จริง (Poll ที่ซ้อนกันจะไม่ได้คำสั่งเดียวกัน)
+	var out []models.ActuatorCommand
+	err = q.Model(&out).Clauses(clause.Returning{}).
+		Where("device_id = ? AND status = ? AND expires_at > ?", deviceID, models.CommandQueued, now.Local()).
+		Updates(map[string]interface{}{"status": models.CommandDelivered, "delivered_at": now, "updated_at": now}).Error
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *actuatorCommandRepository) Finish(ctx context.Context, id uint, status, message string, at time.Time) error {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	result := q.Model(&models.ActuatorCommand{}).Where("id = ? AND status = ?", id, models.CommandDelivered).
+		Updates(map[string]interface{}{"status": status, "error": message, "acknowledged_at": at, "updated_at": at})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *actuatorCommandRepository) Expire(ctx context.Context, now, ackBefore time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q, err := scoped(ctx, tx, "organization_id")
+		if err != nil {
+			return err
+		}
+		expired := q.Session(&gorm.Session{}).Model(&models.ActuatorCommand{}).
+			Where("status = ? AND expires_at <= ?", models.CommandQueued, now.Local()).
+			Updates(map[string]interface{}{"status": models.CommandExpired, "updated_at": now})
+		if expired.Error != nil {
+			return expired.Error
+		}
+		failed := q.Session(&gorm.Session{}).Model(&models.ActuatorCommand{}).
+			Where("status = ? AND delivered_at <= ?", models.CommandDelivered, ackBefore.Local()).
+			Updates(map[string]interface{}{"status": models.CommandFailed, "error": "no acknowledgement from device", "updated_at": now})
+		if failed.Error != nil {
+			return failed.Error
+		}
+		total = expired.RowsAffected + failed.RowsAffected
+		return nil
+	})
+	return total, err
+}
+
+// --- Automation ---
+
+type automationRuleRepository struct {
+	db *gorm.DB
+}
+
+func (r *automationRuleRepository) Create(ctx context.Context, rule *models.AutomationRule) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&rule.OrganizationID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *automationRuleRepository) Update(ctx context.Context, rule *models.AutomationRule) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&rule.OrganizationID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q, err := scoped(ctx, tx, "organization_id")
+		if err != nil {
+			return err
+		}
+		// last_fired_at / fired_count เปลี่ยนผ่าน MarkFired เท่านั้น และ state_version เพิ่มด้านล่าง
+		result := q.Session(&gorm.Session{}).Model(rule).Select("*").Omit("last_fired_at", "fired_count", "state_version").Updates(rule)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		// การประเมินที่เริ่มก่อนแก้ไขจะบันทึกสถานะตามค่าตั้งเดิมไม่ได้
+		return q.Session(&gorm.Session{}).Model(&models.AutomationRule{}).Where("id = ?", rule.ID).
+			UpdateColumn("state_version", gorm.Expr("state_version + 1")).Error
+	})
+}
+
+func (r *automationRuleRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q, err := scoped(ctx, tx, "organization_id")
+		if err != nil {
+			return err
+		}
+		result := q.Session(&gorm.Session{}).Where("id = ?", id).Delete(&models.AutomationRule{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return q.Session(&gorm.Session{}).Where("rule_id = ?", id).Delete(&models.AutomationLog{}).Error
+	})
+}
+
+func (r *automationRuleRepository) FindByID(ctx context.Context, id uint) (*models.AutomationRule, error) {
+	return findScoped[models.AutomationRule](ctx, r.db, "organization_id", "id = ?", id)
+}
+
+func (r *automationRuleRepository) List(ctx context.Context) ([]models.AutomationRule, error) {
+	return listScoped[models.AutomationRule](ctx, r.db, "organization_id", "organization_id, id")
+}
+
+func (r *automationRuleRepository) SaveState(ctx context.Context, rule *models.AutomationRule) (bool, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return false, err
+	}
+	result := q.Model(&models.AutomationRule{}).Where("id = ? AND state_version = ?", rule.ID, rule.StateVersion).
+		Updates(map[string]interface{}{
+			"condition_met":     rule.ConditionMet,
+			"condition_since":   rule.ConditionSince,
+			"last_value":        rule.LastValue,
+			"last_evaluated_at": rule.LastEvaluatedAt,
+			"state_version":     gorm.Expr("state_version + 1"),
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	rule.StateVersion++
+	return true, nil
+}
+
+func (r *automationRuleRepository) MarkFired(ctx context.Context, id uint, prev int64, at time.Time) (bool, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return false, err
+	}
+	result := q.Model(&models.AutomationRule{}).Where("id = ? AND fired_count = ?", id, prev).
+		Updates(map[string]interface{}{"last_fired_at": at, "fired_count": gorm.Expr("fired_count + 1")})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+type automationLogRepository struct {
+	db *gorm.DB
+}
+
+func (r *automationLogRepository) Create(ctx context.Context, entry *models.AutomationLog) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&entry.OrganizationID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *automationLogRepository) ListForRule(ctx context.Context, ruleID uint, limit int) ([]models.AutomationLog, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	var out []models.AutomationLog
+	err = q.Where("rule_id = ?", ruleID).Order("created_at DESC, id DESC").Limit(limit).Find(&out).Error
+	return out, err
+}
+
+// --- Schedules ---
+
+type scheduleRepository struct {
+	db *gorm.DB
+}
+
+func (r *scheduleRepository) Create(ctx context.Context, schedule *models.Schedule) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&schedule.OrganizationID); err != nil {
+		return err
+	}
+	schedule.NextRunAt = localTime(schedule.NextRunAt)
+	return r.db.WithContext(ctx).Create(schedule).Error
+}
+
+func (r *scheduleRepository) Update(ctx context.Context, schedule *models.Schedule) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&schedule.OrganizationID); err != nil {
+		return err
+	}
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	schedule.NextRunAt = localTime(schedule.NextRunAt)
+	result := q.Model(schedule).Select("*").Omit("last_run_at", "run_count").Updates(schedule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *scheduleRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q, err := scoped(ctx, tx, "organization_id")
+		if err != nil {
+			return err
+		}
+		result := q.Session(&gorm.Session{}).Where("id = ?", id).Delete(&models.Schedule{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return q.Session(&gorm.Session{}).Where("schedule_id = ?", id).Delete(&models.ScheduleRun{}).Error
+	})
+}
+
+func (r *scheduleRepository) FindByID(ctx context.Context, id uint) (*models.Schedule, error) {
+	return findScoped[models.Schedule](ctx, r.db, "organization_id", "id = ?", id)
+}
+
+func (r *scheduleRepository) List(ctx context.Context) ([]models.Schedule, error) {
+	return listScoped[models.Schedule](ctx, r.db, "organization_id", "organization_id, id")
+}
+
+func (r *scheduleRepository) Due(ctx context.Context, now time.Time) ([]models.Schedule, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	var out []models.Schedule
+	err = q.Where("enabled = ? AND next_run_at <= ?", true, now.Local()).Order("next_run_at, id").Find(&out).Error
+	return out, err
+}
+
+func (r *scheduleRepository) Claim(ctx context.Context, id uint, prev int64, next *time.Time, at time.Time) (bool, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return false, err
+	}
+	result := q.Model(&models.Schedule{}).Where("id = ? AND run_count = ?", id, prev).
+		Updates(map[string]interface{}{"next_run_at": localTime(next), "last_run_at": at, "run_count": gorm.Expr("run_count + 1")})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+type scheduleRunRepository struct {
+	db *gorm.DB
+}
+
+func (r *scheduleRunRepository) Create(ctx context.Context, run *models.ScheduleRun) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&run.OrganizationID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *scheduleRunRepository) ListForSchedule(ctx context.Context, scheduleID uint, limit int) ([]models.ScheduleRun, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	var out []models.ScheduleRun
+	err = q.Where("schedule_id = ?", scheduleID).Order("created_at DESC, id DESC").Limit(limit).Find(&out).Error
+	return out, err
+}
+
+// localTime แปลงเวลาเป็น Time Zone ของเครื่องก่อนเก็บ
+// SQLite เปรียบเทียบเวลาเป็นข้อความ เวลาที่เก็บจึงต้องอยู่ใน Zone เดียวกับค่าที่ใช้เปรียบเทียบ (เหมือน NowFunc ของ GORM)
+func localTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.Local()
+	return &local
+}
+
+// --- Husbandry ---
+
+type husbandryRepository struct {
+	db *gorm.DB
+}
+
+func (r *husbandryRepository) Create(ctx context.Context, event *models.HusbandryEvent) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&event.OrganizationID); err != nil {
+		return err
+	}
+	event.OccurredAt = event.OccurredAt.Local()
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *husbandryRepository) Delete(ctx context.Context, id uint) error {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	result := q.Where("id = ?", id).Delete(&models.HusbandryEvent{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *husbandryRepository) FindByID(ctx context.Context, id uint) (*models.HusbandryEvent, error) {
+	return findScoped[models.HusbandryEvent](ctx, r.db, "organization_id", "id = ?", id)
+}
+
+func (r *husbandryRepository) List(ctx context.Context, q HusbandryQuery) ([]models.HusbandryEvent, error) {
+	db, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	if len(q.LocationIDs) == 0 {
+		return nil, nil
+	}
+	db = db.Where("location_id IN ?", q.LocationIDs)
+	if !q.From.IsZero() {
+		db = db.Where("occurred_at >= ?", q.From.Local())
+	}
+	if !q.To.IsZero() {
+		db = db.Where("occurred_at < ?", q.To.Local())
+	}
+	if q.Kind != "" {
+		db = db.Where("kind = ?", q.Kind)
+	}
+	var out []models.HusbandryEvent
+	err = db.Order("occurred_at, id").Find(&out).Error
+	return out, err
+}
+
+func (r *husbandryRepository) CountForLocation(ctx context.Context, locationID uint) (int64, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = q.Model(&models.HusbandryEvent{}).Where("location_id = ?", locationID).Count(&n).Error
+	return n, err
+}
+
+// --- Calibrations ---
+
+type calibrationRepository struct {
+	db *gorm.DB
+}
+
+func (r *calibrationRepository) Create(ctx context.Context, calibration *models.Calibration) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&calibration.OrganizationID); err != nil {
+		return err
+	}
+	calibration.ValidFrom = calibration.ValidFrom.Local()
+	calibration.ValidUntil = localTime(calibration.ValidUntil)
+	return r.db.WithContext(ctx).Create(calibration).Error
+}
+
+func (r *calibrationRepository) SetValidUntil(ctx context.Context, id uint, until time.Time) error {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	result := q.Model(&models.Calibration{}).Where("id = ?", id).Update("valid_until", until.Local())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *calibrationRepository) Delete(ctx context.Context, id uint) error {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return err
+	}
+	result := q.Where("id = ?", id).Delete(&models.Calibration{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *calibrationRepository) FindByID(ctx context.Context, id uint) (*models.Calibration, error) {
+	return findScoped[models.Calibration](ctx, r.db, "organization_id", "id = ?", id)
+}
+
+func (r *calibrationRepository) ListForDevice(ctx context.Context, deviceID uint) ([]models.Calibration, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	var out []models.Calibration
+	err = q.Where("device_id = ?", deviceID).Order("metric, valid_from, id").Find(&out).Error
+	return out, err
+}
+
+// --- Sensors ---
+
+type sensorRepository struct {
+	db *gorm.DB
+}
+
+func (r *sensorRepository) Create(ctx context.Context, data *models.SensorData) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&data.OrganizationID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(data).Error
+}
+
+func (r *sensorRepository) List(ctx context.Context) ([]models.SensorData, error) {
+	return listScoped[models.SensorData](ctx, r.db, "organization_id", "id")
+}
+
+func (r *sensorRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return 0, err
+	}
+	result := q.Where("created_at < ?", before).Delete(&models.SensorData{})
+	return result.RowsAffected, result.Error
+}
+
+// summaryColumns Column ของ models.SensorSummary (ลำดับเดียวกับที่ scanSummaries อ่าน)
+const summaryColumns = `COUNT(*) AS count,
+	AVG(sensor_data.temperature) AS temperature_avg, MIN(sensor_data.temperature) AS temperature_min, MAX(sensor_data.temperature) AS temperature_max,
+	AVG(sensor_data.humidity) AS humidity_avg, MIN(sensor_data.humidity) AS humidity_min, MAX(sensor_data.humidity) AS humidity_max`
+
+func (r *sensorRepository) Aggregate(ctx context.Context, q SensorAggregateQuery) ([]models.SensorSummary, error) {
+	db, err := scoped(ctx, r.db, "sensor_data.organization_id")
+	if err != nil {
+		return nil, err
+	}
+	if len(q.LocationIDs) == 0 {
+		if q.Interval > 0 {
+			return nil, nil
+		}
+		return []models.SensorSummary{{}}, nil
+	}
+	query := atLocations(db, q)
+	columns := summaryColumns
+	for _, name := range q.Derived {
+		e := derivedExpression(r.db, name)
+		columns += fmt.Sprintf(", AVG(%s), MIN(%s), MAX(%s)", e, e, e)
+	}
+
+	if q.Interval <= 0 {
+		return scanSummaries(query.Select(columns), false, q.Derived)
+	}
+	return scanSummaries(query.Select(bucketExpression(r.db, q.Interval)+" AS bucket, "+columns).Group("bucket").Order("bucket"), true, q.Derived)
+}
+
+// scanSummaries อ่านผลของ Aggregate (bucket ถ้ามี, summaryColumns แล้วตามด้วย avg / min / max ของแต่ละ derived)
+func scanSummaries(query *gorm.DB, bucketed bool, derived []string) ([]models.SensorSummary, error) {
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.SensorSummary
+	for rows.Next() {
+		var s models.SensorSummary
+		var bucket int64
+		dest := []interface{}{&s.Count, &s.TemperatureAvg, &s.TemperatureMin, &s.TemperatureMax, &s.HumidityAvg, &s.HumidityMin, &s.HumidityMax}
+		if bucketed {
+			dest = append([]interface{}{&bucket}, dest...)
+		}
+		values := make([]*float64, 3*len(derived))
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if bucketed {
+			start := time.Unix(bucket, 0).UTC()
+			s.BucketStart = &start
+		}
+		if len(derived) > 0 {
+			s.Derived = make(map[string]models.MetricSummary, len(derived))
+			for i, name := range derived {
+				s.Derived[name] = models.MetricSummary{Avg: values[3*i], Min: values[3*i+1], Max: values[3*i+2]}
+			}
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// derivedExpression SQL ที่อ่านค่า name จาก JSON ใน sensor_data.derived (NULL ถ้าไม่มี)
+// name มาจาก package derived (ตัวอักษรพิมพ์เล็ก ตัวเลข และ _ เท่านั้น)
+func derivedExpression(db *gorm.DB, name string) string {
+	if db.Dialector.Name() == "sqlite" {
+		return fmt.Sprintf("json_extract(sensor_data.derived, '$.%s')", name)
+	}
+	return fmt.Sprintf("CAST(CAST(sensor_data.derived AS JSONB) ->> '%s' AS DOUBLE PRECISION)", name)
+}
+
+// recomputeBatchSize จำนวนค่าที่อ่านต่อรอบใน Recompute
+const recomputeBatchSize = 500
+
+func (r *sensorRepository) Recompute(ctx context.Context, deviceID uint, from, to time.Time, fn func(d *models.SensorData) bool) (int64, error) {
+	var changed int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q, err := scoped(ctx, tx, "organization_id")
+		if err != nil {
+			return err
+		}
+		var batch []models.SensorData
+		result := q.Session(&gorm.Session{}).
+			Where("device_id = ? AND created_at >= ? AND created_at < ?", deviceID, from.Local(), to.Local()).
+			FindInBatches(&batch, recomputeBatchSize, func(b *gorm.DB, _ int) error {
+				for i := range batch {
+					if !fn(&batch[i]) {
+						continue
+					}
+					// Struct (ไม่ใช่ Map) เพื่อให้ derived ผ่าน JSON Serializer
+					err := tx.Model(&batch[i]).Select("temperature", "humidity", "derived").UpdateColumns(&batch[i]).Error
+					if err != nil {
+						return err
+					}
+					changed++
+				}
+				return nil
+			})
+		return result.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+func (r *sensorRepository) ListForLocations(ctx context.Context, q SensorAggregateQuery) ([]models.SensorData, error) {
+	db, err := scoped(ctx, r.db, "sensor_data.organization_id")
+	if err != nil {
+		return nil, err
+	}
+	if len(q.LocationIDs) == 0 {
+		return nil, nil
+	}
+	var out []models.SensorData
+	err = atLocations(db, q).Select("sensor_data.*").Order("sensor_data.created_at, sensor_data.id").Find(&out).Error
+	return out, err
+}
+
+func (r *sensorRepository) Recent(ctx context.Context, deviceID uint, since time.Time, limit int) ([]models.SensorData, error) {
+	q, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	var out []models.SensorData
+	err = q.Where("device_id = ? AND created_at >= ?", deviceID, since.Local()).
+		Order("created_at DESC, id DESC").Limit(limit).Find(&out).Error
+	return out, err
+}
+
+func (r *sensorRepository) ListAnomalies(ctx context.Context, q AnomalyQuery) ([]models.SensorData, error) {
+	db, err := scoped(ctx, r.db, "organization_id")
+	if err != nil {
+		return nil, err
+	}
+	// ใช้ Index เฉพาะแถวที่ผิดปกติ (idx_sensor_data_anomalous)
+	db = db.Where("anomalous AND created_at >= ? AND created_at < ?", q.From.Local(), q.To.Local())
+	if q.DeviceID != 0 {
+		db = db.Where("device_id = ?", q.DeviceID)
+	}
+	var out []models.SensorData
+	err = db.Order("created_at DESC, id DESC").Limit(q.Limit).Find(&out).Error
+	return out, err
+}
+
+// atLocations ค่า Sensor ในช่วง [q.From, q.To) ที่บันทึกขณะ Device ติดตั้งอยู่ที่ q.LocationIDs
+// ค่าเป็นของ Location ที่ Device ติดตั้งอยู่ ณ เวลาที่บันทึก (ย้าย Device แล้วข้อมูลเก่าไม่ย้ายตาม)
+func atLocations(db *gorm.DB, q SensorAggregateQuery) *gorm.DB {
+	return db.Table("sensor_data").
+		Joins("JOIN device_assignments da ON da.device_id = sensor_data.device_id"+
+			" AND sensor_data.created_at >= da.started_at"+
+			" AND (da.ended_at IS NULL OR sensor_data.created_at < da.ended_at)").
+		Where("da.location_id IN ?", q.LocationIDs).
+		Where("sensor_data.created_at >= ? AND sensor_data.created_at < ?", q.From.Local(), q.To.Local())
+}
+
+// bucketExpression SQL ที่ปัด created_at ลงเป็น Unix Time ของต้นช่วง (แต่ละ Driver ใช้ฟังก์ชันเวลาต่างกัน)
+func bucketExpression(db *gorm.DB, interval time.Duration) string {
+	secs := int64(interval / time.Second)
+	if db.Dialector.Name() == "sqlite" {
+		return fmt.Sprintf("(CAST(strftime('%%s', sensor_data.created_at) AS INTEGER) / %d) * %d", secs, secs)
+	}
+	return fmt.Sprintf("(FLOOR(EXTRACT(EPOCH FROM sensor_data.created_at) / %d) * %d)::BIGINT", secs, secs)
+}
+
+// --- Recovery Codes ---
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID uint, hashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			if err := tx.Create(&models.RecoveryCode{UserID: userID, CodeHash: hash}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *recoveryCodeRepository) DeleteForUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+func (r *recoveryCodeRepository) Use(ctx context.Context, userID uint, hash string) (bool, error) {
+	now := time.Now()
+	// อัปเดตแบบมีเงื่อนไข used_at IS NULL กันการใช้รหัสเดิมซ้ำพร้อมกัน
+	result := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", &now)
+	return result.RowsAffected > 0, result.Error
+}
+
+// --- Audit ---
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	t, err := TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.ClaimOptional(&entry.OrganizationID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *auditRepository) List(ctx context.Context) ([]models.AuditLog, error) {
+	return listScoped[models.AuditLog](ctx, r.db, "organization_id", "created_at desc")
+}
+
+// --- Rate Limits ---
+
+type rateLimitRepository struct {
+	db *gorm.DB
+}
+
+func (r *rateLimitRepository) Update(ctx context.Context, key string, fn func(b *models.RateLimitBucket)) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// ล็อกแถวไว้จนจบ Transaction (Postgres: FOR UPDATE, SQLite ล็อกทั้งไฟล์ตอนเขียนอยู่แล้ว)
+		b, err := first[models.RateLimitBucket](tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("bucket_key = ?", key))
+		if errors.Is(err, ErrNotFound) {
+			b = &models.RateLimitBucket{Key: key}
+			fn(b)
+			// Request แรกของ Key ที่มาพร้อมกันจะมีตัวเดียวที่ Insert ได้ ตัวอื่นถูกนับเพิ่มไม่เกิน 1 ครั้ง
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(b).Error
+		}
+		if err != nil {
+			return err
+		}
+		fn(b)
+		return tx.Model(&models.RateLimitBucket{}).Where("bucket_key = ?", key).
+			UpdateColumns(map[string]interface{}{"tokens": b.Tokens, "updated_at": b.UpdatedAt}).Error
+	})
+}
+
+func (r *rateLimitRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("updated_at < ?", before).Delete(&models.RateLimitBucket{})
+	return result.RowsAffected, result.Error
+}
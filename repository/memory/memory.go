@@ -0,0 +1,1872 @@
+// Package memory เป็น Repository ปลอมที่เก็บข้อมูลใน Map
+// ใช้ทดสอบ Handler / Service ได้โดยไม่ต้องมีฐานข้อมูล (ปลอดภัยต่อการเรียกพร้อมกัน)
+// แยกข้อมูลตาม Tenant ใน ctx เหมือนตัวจริง (ดู repository.WithTenant)
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"worm/models"
+	"worm/ratelimit"
+	"worm/repository"
+)
+
+// NewStore สร้าง Repository ปลอมครบทุกตัว
+func NewStore() *repository.Store {
+	assignments := NewDeviceAssignmentRepository()
+	sensors := NewSensorRepository()
+	sensors.assignments = assignments
+	rules := NewAutomationRuleRepository()
+	rules.logs = NewAutomationLogRepository()
+	schedules := NewScheduleRepository()
+	schedules.runs = NewScheduleRunRepository()
+
+	store := &repository.Store{
+		Users:         NewUserRepository(),
+		Organizations: NewOrganizationRepository(),
+		Devices:       NewDeviceRepository(),
+		Locations:     NewLocationRepository(),
+		Assignments:   assignments,
+		Actuators:     NewActuatorRepository(),
+		Commands:      NewActuatorCommandRepository(),
+		Rules:         rules,
+		RuleLogs:      rules.logs,
+		Schedules:     schedules,
+		ScheduleRuns:  schedules.runs,
+		Husbandry:     NewHusbandryRepository(),
+		Calibrations:  NewCalibrationRepository(),
+		Sensors:       sensors,
+		RecoveryCodes: NewRecoveryCodeRepository(),
+		Audit:         NewAuditRepository(),
+		RateLimits:    ratelimit.NewMemoryStore(),
+	}
+	store.Transactor = &Transactor{store: store}
+	return store
+}
+
+// Transactor รัน Transaction ทีละตัวบน Store เดิม (ไม่มี Rollback: สิ่งที่ fn เขียนก่อนคืน error ยังอยู่)
+type Transactor struct {
+	mu    sync.Mutex
+	store *repository.Store
+}
+
+func (t *Transactor) Transaction(ctx context.Context, fn func(tx *repository.Store) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return fn(t.store)
+}
+
+// --- Users ---
+
+// UserRepository เก็บ User ใน Map ตาม ID
+type UserRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	users  map[uint]models.User
+}
+
+// NewUserRepository สร้าง UserRepository ว่าง
+func NewUserRepository() *UserRepository {
+	return &UserRepository{users: map[uint]models.User{}}
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.ClaimOptional(&user.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	r.nextID++
+	user.ID = r.nextID
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.ClaimOptional(&user.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.users[user.ID]
+	if !ok || !t.Allows(current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	user.UpdatedAt = time.Now()
+	user.TOTPLastStep = current.TOTPLastStep
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *UserRepository) UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.users[userID]
+	if !ok || !t.Allows(current.OrganizationID) || current.TOTPLastStep >= step {
+		return false, nil
+	}
+	current.TOTPLastStep = step
+	r.users[userID] = current
+	return true, nil
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	return r.find(ctx, func(u *models.User) bool { return u.ID == id })
+}
+
+func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.find(ctx, func(u *models.User) bool { return strings.EqualFold(u.Username, username) })
+}
+
+func (r *UserRepository) FindByAPIKey(ctx context.Context, apiKey string) (*models.User, error) {
+	return r.find(ctx, func(u *models.User) bool { return u.APIKey == apiKey })
+}
+
+func (r *UserRepository) FindByOIDCSubject(ctx context.Context, subject string) (*models.User, error) {
+	return r.find(ctx, func(u *models.User) bool { return u.OIDCSubject != nil && *u.OIDCSubject == subject })
+}
+
+func (r *UserRepository) List(ctx context.Context) ([]models.User, error) {
+	return r.filter(ctx, func(*models.User) bool { return true })
+}
+
+func (r *UserRepository) ListByRole(ctx context.Context, role string) ([]models.User, error) {
+	return r.filter(ctx, func(u *models.User) bool { return u.Role == role })
+}
+
+func (r *UserRepository) find(ctx context.Context, match func(*models.User) bool) (*models.User, error) {
+	users, err := r.filter(ctx, match)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &users[0], nil
+}
+
+// filter คืนสำเนาที่ Tenant มองเห็นเรียงตาม ID (แก้ค่าที่ได้ไม่กระทบข้อมูลใน Map)
+func (r *UserRepository) filter(ctx context.Context, match func(*models.User) bool) ([]models.User, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.User
+	for _, u := range r.users {
+		if t.Allows(u.OrganizationID) && match(&u) {
+			out = append(out, u)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *UserRepository) checkUnique(user *models.User) error {
+	for _, u := range r.users {
+		if u.ID == user.ID {
+			continue
+		}
+		if strings.EqualFold(u.Username, user.Username) {
+			return &repository.ConflictError{Field: "username"}
+		}
+		if user.APIKey != "" && u.APIKey == user.APIKey {
+			return &repository.ConflictError{Field: "api_key"}
+		}
+	}
+	return nil
+}
+
+// --- Organizations ---
+
+// OrganizationRepository เก็บองค์กรตามลำดับที่สร้าง
+type OrganizationRepository struct {
+	mu   sync.Mutex
+	orgs []models.Organization
+}
+
+// NewOrganizationRepository สร้าง OrganizationRepository ว่าง
+func NewOrganizationRepository() *OrganizationRepository {
+	return &OrganizationRepository{}
+}
+
+func (r *OrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if !t.All {
+		return repository.ErrTenantMismatch
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, o := range r.orgs {
+		if strings.EqualFold(o.Name, org.Name) {
+			return &repository.ConflictError{Field: "name"}
+		}
+	}
+	org.ID = uint(len(r.orgs) + 1)
+	org.CreatedAt = time.Now()
+	org.UpdatedAt = org.CreatedAt
+	r.orgs = append(r.orgs, *org)
+	return nil
+}
+
+func (r *OrganizationRepository) FindByID(ctx context.Context, id uint) (*models.Organization, error) {
+	return r.find(ctx, func(o *models.Organization) bool { return o.ID == id })
+}
+
+func (r *OrganizationRepository) FindByName(ctx context.Context, name string) (*models.Organization, error) {
+	return r.find(ctx, func(o *models.Organization) bool { return strings.EqualFold(o.Name, name) })
+}
+
+func (r *OrganizationRepository) List(ctx context.Context) ([]models.Organization, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.Organization
+	for _, o := range r.orgs {
+		if t.Allows(&o.ID) {
+			out = append(out, o)
+		}
+	}
+	return out, nil
+}
+
+func (r *OrganizationRepository) find(ctx context.Context, match func(*models.Organization) bool) (*models.Organization, error) {
+	orgs, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orgs {
+		if match(&o) {
+			return &o, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// --- Devices ---
+
+// DeviceRepository เก็บ Device ตามลำดับที่ลงทะเบียน
+type DeviceRepository struct {
+	mu      sync.Mutex
+	devices []models.Device
+}
+
+// NewDeviceRepository สร้าง DeviceRepository ว่าง
+func NewDeviceRepository() *DeviceRepository {
+	return &DeviceRepository{}
+}
+
+func (r *DeviceRepository) Create(ctx context.Context, device *models.Device) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&device.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.devices {
+		if d.OrganizationID == device.OrganizationID && strings.EqualFold(d.Name, device.Name) {
+			return &repository.ConflictError{Field: "name"}
+		}
+	}
+	device.ID = uint(len(r.devices) + 1)
+	device.CreatedAt = time.Now()
+	r.devices = append(r.devices, *device)
+	return nil
+}
+
+func (r *DeviceRepository) FindByName(ctx context.Context, orgID uint, name string) (*models.Device, error) {
+	devices, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		if d.OrganizationID == orgID && strings.EqualFold(d.Name, name) {
+			return &d, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *DeviceRepository) FindByID(ctx context.Context, id uint) (*models.Device, error) {
+	devices, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		if d.ID == id {
+			return &d, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *DeviceRepository) Touch(ctx context.Context, id uint, seenAt time.Time) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, d := range r.devices {
+		if d.ID == id && t.Allows(&d.OrganizationID) {
+			r.devices[i].LastSeenAt = &seenAt
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *DeviceRepository) List(ctx context.Context) ([]models.Device, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.Device
+	for _, d := range r.devices {
+		if t.Allows(&d.OrganizationID) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].OrganizationID != out[j].OrganizationID {
+			return out[i].OrganizationID < out[j].OrganizationID
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// --- Locations ---
+
+// LocationRepository เก็บ Location ใน Map ตาม ID
+type LocationRepository struct {
+	mu        sync.Mutex
+	nextID    uint
+	locations map[uint]models.Location
+}
+
+// NewLocationRepository สร้าง LocationRepository ว่าง
+func NewLocationRepository() *LocationRepository {
+	return &LocationRepository{locations: map[uint]models.Location{}}
+}
+
+func (r *LocationRepository) Create(ctx context.Context, location *models.Location) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&location.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(location); err != nil {
+		return err
+	}
+	r.nextID++
+	location.ID = r.nextID
+	location.CreatedAt = time.Now()
+	location.UpdatedAt = location.CreatedAt
+	r.locations[location.ID] = *location
+	return nil
+}
+
+func (r *LocationRepository) Update(ctx context.Context, location *models.Location) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&location.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.locations[location.ID]; !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	if err := r.checkUnique(location); err != nil {
+		return err
+	}
+	location.UpdatedAt = time.Now()
+	r.locations[location.ID] = *location
+	return nil
+}
+
+func (r *LocationRepository) Delete(ctx context.Context, id uint) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.locations[id]; !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	delete(r.locations, id)
+	return nil
+}
+
+func (r *LocationRepository) FindByID(ctx context.Context, id uint) (*models.Location, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.locations[id]
+	if !ok || !t.Allows(&l.OrganizationID) {
+		return nil, repository.ErrNotFound
+	}
+	return &l, nil
+}
+
+func (r *LocationRepository) List(ctx context.Context) ([]models.Location, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.Location
+	for _, l := range r.locations {
+		if t.Allows(&l.OrganizationID) {
+			out = append(out, l)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *LocationRepository) checkUnique(location *models.Location) error {
+	parent := func(l *models.Location) uint {
+		if l.ParentID == nil {
+			return 0
+		}
+		return *l.ParentID
+	}
+	for _, l := range r.locations {
+		if l.ID != location.ID && l.OrganizationID == location.OrganizationID &&
+			parent(&l) == parent(location) && strings.EqualFold(l.Name, location.Name) {
+			return &repository.ConflictError{Field: "name"}
+		}
+	}
+	return nil
+}
+
+// --- Device Assignments ---
+
+// DeviceAssignmentRepository เก็บประวัติการติดตั้งตามลำดับที่บันทึก
+type DeviceAssignmentRepository struct {
+	mu          sync.Mutex
+	assignments []models.DeviceAssignment
+}
+
+// NewDeviceAssignmentRepository สร้าง DeviceAssignmentRepository ว่าง
+func NewDeviceAssignmentRepository() *DeviceAssignmentRepository {
+	return &DeviceAssignmentRepository{}
+}
+
+func (r *DeviceAssignmentRepository) Current(ctx context.Context, deviceID uint) (*models.DeviceAssignment, error) {
+	list, err := r.ListForDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range list {
+		if a.EndedAt == nil {
+			return &a, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *DeviceAssignmentRepository) Move(ctx context.Context, device *models.Device, locationID *uint, at time.Time) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	orgID := device.OrganizationID
+	if err := t.Claim(&orgID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, a := range r.assignments {
+		if a.OrganizationID == orgID && a.DeviceID == device.ID && a.EndedAt == nil {
+			ended := at
+			r.assignments[i].EndedAt = &ended
+		}
+	}
+	if locationID == nil {
+		return nil
+	}
+	r.assignments = append(r.assignments, models.DeviceAssignment{
+		ID:             uint(len(r.assignments) + 1),
+		CreatedAt:      time.Now(),
+		OrganizationID: orgID,
+		DeviceID:       device.ID,
+		LocationID:     *locationID,
+		StartedAt:      at,
+	})
+	return nil
+}
+
+func (r *DeviceAssignmentRepository) ListForDevice(ctx context.Context, deviceID uint) ([]models.DeviceAssignment, error) {
+	return r.filter(ctx, func(a *models.DeviceAssignment) bool { return a.DeviceID == deviceID })
+}
+
+func (r *DeviceAssignmentRepository) CountForLocation(ctx context.Context, locationID uint) (int64, error) {
+	list, err := r.filter(ctx, func(a *models.DeviceAssignment) bool { return a.LocationID == locationID })
+	return int64(len(list)), err
+}
+
+// filter คืนสำเนาที่ Tenant มองเห็นเรียงตาม StartedAt
+func (r *DeviceAssignmentRepository) filter(ctx context.Context, match func(*models.DeviceAssignment) bool) ([]models.DeviceAssignment, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.DeviceAssignment
+	for _, a := range r.assignments {
+		if t.Allows(&a.OrganizationID) && match(&a) {
+			out = append(out, a)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out, nil
+}
+
+// locationAt Location ที่ Device ติดตั้งอยู่ ณ เวลา at (false = ไม่ได้ติดตั้ง)
+func (r *DeviceAssignmentRepository) locationAt(deviceID uint, at time.Time) (uint, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range r.assignments {
+		if a.DeviceID == deviceID && !at.Before(a.StartedAt) && (a.EndedAt == nil || at.Before(*a.EndedAt)) {
+			return a.LocationID, true
+		}
+	}
+	return 0, false
+}
+
+// --- Actuators ---
+
+// ActuatorRepository เก็บ Actuator ใน Map ตาม ID
+type ActuatorRepository struct {
+	mu        sync.Mutex
+	nextID    uint
+	actuators map[uint]models.Actuator
+}
+
+// NewActuatorRepository สร้าง ActuatorRepository ว่าง
+func NewActuatorRepository() *ActuatorRepository {
+	return &ActuatorRepository{actuators: map[uint]models.Actuator{}}
+}
+
+func (r *ActuatorRepository) Create(ctx context.Context, actuator *models.Actuator) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&actuator.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(actuator); err != nil {
+		return err
+	}
+	r.nextID++
+	actuator.ID = r.nextID
+	actuator.CreatedAt = time.Now()
+	actuator.UpdatedAt = actuator.CreatedAt
+	r.actuators[actuator.ID] = *actuator
+	return nil
+}
+
+func (r *ActuatorRepository) Update(ctx context.Context, actuator *models.Actuator) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&actuator.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.actuators[actuator.ID]
+	if !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	if err := r.checkUnique(actuator); err != nil {
+		return err
+	}
+	actuator.State, actuator.StateChangedAt = current.State, current.StateChangedAt
+	actuator.UpdatedAt = time.Now()
+	r.actuators[actuator.ID] = *actuator
+	return nil
+}
+
+func (r *ActuatorRepository) Delete(ctx context.Context, id uint) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.actuators[id]; !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	delete(r.actuators, id)
+	return nil
+}
+
+func (r *ActuatorRepository) FindByID(ctx context.Context, id uint) (*models.Actuator, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.actuators[id]
+	if !ok || !t.Allows(&a.OrganizationID) {
+		return nil, repository.ErrNotFound
+	}
+	return &a, nil
+}
+
+func (r *ActuatorRepository) List(ctx context.Context) ([]models.Actuator, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.Actuator
+	for _, a := range r.actuators {
+		if t.Allows(&a.OrganizationID) {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].DeviceID != out[j].DeviceID {
+			return out[i].DeviceID < out[j].DeviceID
+		}
+		return out[i].Channel < out[j].Channel
+	})
+	return out, nil
+}
+
+func (r *ActuatorRepository) SetState(ctx context.Context, id uint, state string, at time.Time) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.actuators[id]
+	if !ok || !t.Allows(&a.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	a.State = state
+	a.StateChangedAt = &at
+	r.actuators[id] = a
+	return nil
+}
+
+func (r *ActuatorRepository) checkUnique(actuator *models.Actuator) error {
+	for _, a := range r.actuators {
+		if a.ID == actuator.ID || a.DeviceID != actuator.DeviceID {
+			continue
+		}
+		if strings.EqualFold(a.Name, actuator.Name) {
+			return &repository.ConflictError{Field: "name"}
+		}
+		if a.Channel == actuator.Channel {
+			return &repository.ConflictError{Field: "channel"}
+		}
+	}
+	return nil
+}
+
+// --- Actuator Commands ---
+
+// ActuatorCommandRepository เก็บคำสั่งตามลำดับที่สั่ง
+type ActuatorCommandRepository struct {
+	mu       sync.Mutex
+	commands []models.ActuatorCommand
+}
+
+// NewActuatorCommandRepository สร้าง ActuatorCommandRepository ว่าง
+func NewActuatorCommandRepository() *ActuatorCommandRepository {
+	return &ActuatorCommandRepository{}
+}
+
+func (r *ActuatorCommandRepository) Create(ctx context.Context, cmd *models.ActuatorCommand) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&cmd.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cmd.ID = uint(len(r.commands) + 1)
+	cmd.CreatedAt = time.Now()
+	cmd.UpdatedAt = cmd.CreatedAt
+	r.commands = append(r.commands, *cmd)
+	return nil
+}
+
+func (r *ActuatorCommandRepository) FindByID(ctx context.Context, id uint) (*models.ActuatorCommand, error) {
+	list, err := r.update(ctx, func(c *models.ActuatorCommand) bool { return c.ID == id })
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &list[0], nil
+}
+
+func (r *ActuatorCommandRepository) ListForActuator(ctx context.Context, actuatorID uint) ([]models.ActuatorCommand, error) {
+	list, err := r.update(ctx, func(c *models.ActuatorCommand) bool { return c.ActuatorID == actuatorID })
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list, err
+}
+
+func (r *ActuatorCommandRepository) CountForActuator(ctx context.Context, actuatorID uint) (int64, error) {
+	list, err := r.ListForActuator(ctx, actuatorID)
+	return int64(len(list)), err
+}
+
+func (r *ActuatorCommandRepository) Deliver(ctx context.Context, deviceID uint, now time.Time) ([]models.ActuatorCommand, error) {
+	return r.update(ctx, func(c *models.ActuatorCommand) bool {
+		if c.DeviceID != deviceID || c.Status != models.CommandQueued || !c.ExpiresAt.After(now) {
+			return false
+		}
+		c.Status = models.CommandDelivered
+		c.DeliveredAt = &now
+		c.UpdatedAt = now
+		return true
+	})
+}
+
+func (r *ActuatorCommandRepository) Finish(ctx context.Context, id uint, status, message string, at time.Time) error {
+	list, err := r.update(ctx, func(c *models.ActuatorCommand) bool {
+		if c.ID != id || c.Status != models.CommandDelivered {
+			return false
+		}
+		c.Status = status
+		c.Error = message
+		c.AcknowledgedAt = &at
+		c.UpdatedAt = at
+		return true
+	})
+	if err == nil && len(list) == 0 {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+func (r *ActuatorCommandRepository) Expire(ctx context.Context, now, ackBefore time.Time) (int64, error) {
+	list, err := r.update(ctx, func(c *models.ActuatorCommand) bool {
+		switch {
+		case c.Status == models.CommandQueued && !c.ExpiresAt.After(now):
+			c.Status = models.CommandExpired
+		case c.Status == models.CommandDelivered && !c.DeliveredAt.After(ackBefore):
+			c.Status = models.CommandFailed
+			c.Error = "no acknowledgement from device"
+		default:
+			return false
+		}
+		c.UpdatedAt = now
+		return true
+	})
+	return int64(len(list)), err
+}
+
+// update เรียก fn กับทุกคำสั่งที่ Tenant มองเห็น (fn แก้ค่าได้) แล้วคืนสำเนาของคำสั่งที่ fn คืน true ตามลำดับที่สั่ง
+func (r *ActuatorCommandRepository) update(ctx context.Context, fn func(*models.ActuatorCommand) bool) ([]models.ActuatorCommand, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.ActuatorCommand
+	for i := range r.commands {
+		c := &r.commands[i]
+		if t.Allows(&c.OrganizationID) && fn(c) {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+// --- Automation ---
+
+// AutomationRuleRepository เก็บ Rule ใน Map ตาม ID
+// logs ใช้ลบ Log ไปพร้อมกับ Rule (nil = ไม่มี Log)
+type AutomationRuleRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	rules  map[uint]models.AutomationRule
+	logs   *AutomationLogRepository
+}
+
+// NewAutomationRuleRepository สร้าง AutomationRuleRepository ว่าง
+func NewAutomationRuleRepository() *AutomationRuleRepository {
+	return &AutomationRuleRepository{rules: map[uint]models.AutomationRule{}}
+}
+
+func (r *AutomationRuleRepository) Create(ctx context.Context, rule *models.AutomationRule) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&rule.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	rule.ID = r.nextID
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = rule.CreatedAt
+	r.rules[rule.ID] = *rule
+	return nil
+}
+
+func (r *AutomationRuleRepository) Update(ctx context.Context, rule *models.AutomationRule) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&rule.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.rules[rule.ID]
+	if !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	rule.LastFiredAt, rule.FiredCount = current.LastFiredAt, current.FiredCount
+	rule.StateVersion = current.StateVersion + 1
+	rule.UpdatedAt = time.Now()
+	r.rules[rule.ID] = *rule
+	return nil
+}
+
+func (r *AutomationRuleRepository) Delete(ctx context.Context, id uint) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.rules[id]; !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	delete(r.rules, id)
+	if r.logs != nil {
+		r.logs.deleteForRule(id)
+	}
+	return nil
+}
+
+func (r *AutomationRuleRepository) FindByID(ctx context.Context, id uint) (*models.AutomationRule, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rule, ok := r.rules[id]
+	if !ok || !t.Allows(&rule.OrganizationID) {
+		return nil, repository.ErrNotFound
+	}
+	return &rule, nil
+}
+
+func (r *AutomationRuleRepository) List(ctx context.Context) ([]models.AutomationRule, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.AutomationRule
+	for _, rule := range r.rules {
+		if t.Allows(&rule.OrganizationID) {
+			out = append(out, rule)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].OrganizationID != out[j].OrganizationID {
+			return out[i].OrganizationID < out[j].OrganizationID
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (r *AutomationRuleRepository) SaveState(ctx context.Context, rule *models.AutomationRule) (bool, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.rules[rule.ID]
+	if !ok || !t.Allows(&current.OrganizationID) || current.StateVersion != rule.StateVersion {
+		return false, nil
+	}
+	current.ConditionMet = rule.ConditionMet
+	current.ConditionSince = rule.ConditionSince
+	current.LastValue = rule.LastValue
+	current.LastEvaluatedAt = rule.LastEvaluatedAt
+	current.StateVersion++
+	r.rules[rule.ID] = current
+	rule.StateVersion = current.StateVersion
+	return true, nil
+}
+
+func (r *AutomationRuleRepository) MarkFired(ctx context.Context, id uint, prev int64, at time.Time) (bool, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.rules[id]
+	if !ok || !t.Allows(&current.OrganizationID) || current.FiredCount != prev {
+		return false, nil
+	}
+	current.LastFiredAt = &at
+	current.FiredCount++
+	r.rules[id] = current
+	return true, nil
+}
+
+// AutomationLogRepository เก็บ Log ตามลำดับที่บันทึก
+type AutomationLogRepository struct {
+	mu      sync.Mutex
+	nextID  uint
+	entries []models.AutomationLog
+}
+
+// NewAutomationLogRepository สร้าง AutomationLogRepository ว่าง
+func NewAutomationLogRepository() *AutomationLogRepository {
+	return &AutomationLogRepository{}
+}
+
+func (r *AutomationLogRepository) Create(ctx context.Context, entry *models.AutomationLog) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&entry.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	entry.ID = r.nextID
+	entry.CreatedAt = time.Now()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *AutomationLogRepository) ListForRule(ctx context.Context, ruleID uint, limit int) ([]models.AutomationLog, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.AutomationLog
+	for i := len(r.entries) - 1; i >= 0 && len(out) < limit; i-- {
+		e := r.entries[i]
+		if e.RuleID == ruleID && t.Allows(&e.OrganizationID) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (r *AutomationLogRepository) deleteForRule(ruleID uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.entries[:0]
+	for _, e := range r.entries {
+		if e.RuleID != ruleID {
+			kept = append(kept, e)
+		}
+	}
+	r.entries = kept
+}
+
+// --- Schedules ---
+
+// ScheduleRepository เก็บ Schedule ใน Map ตาม ID
+// runs ใช้ลบประวัติไปพร้อมกับ Schedule (nil = ไม่มีประวัติ)
+type ScheduleRepository struct {
+	mu        sync.Mutex
+	nextID    uint
+	schedules map[uint]models.Schedule
+	runs      *ScheduleRunRepository
+}
+
+// NewScheduleRepository สร้าง ScheduleRepository ว่าง
+func NewScheduleRepository() *ScheduleRepository {
+	return &ScheduleRepository{schedules: map[uint]models.Schedule{}}
+}
+
+func (r *ScheduleRepository) Create(ctx context.Context, schedule *models.Schedule) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&schedule.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	schedule.ID = r.nextID
+	schedule.CreatedAt = time.Now()
+	schedule.UpdatedAt = schedule.CreatedAt
+	r.schedules[schedule.ID] = *schedule
+	return nil
+}
+
+func (r *ScheduleRepository) Update(ctx context.Context, schedule *models.Schedule) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&schedule.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.schedules[schedule.ID]
+	if !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	schedule.LastRunAt, schedule.RunCount = current.LastRunAt, current.RunCount
+	schedule.UpdatedAt = time.Now()
+	r.schedules[schedule.ID] = *schedule
+	return nil
+}
+
+func (r *ScheduleRepository) Delete(ctx context.Context, id uint) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.schedules[id]; !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	delete(r.schedules, id)
+	if r.runs != nil {
+		r.runs.deleteForSchedule(id)
+	}
+	return nil
+}
+
+func (r *ScheduleRepository) FindByID(ctx context.Context, id uint) (*models.Schedule, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedule, ok := r.schedules[id]
+	if !ok || !t.Allows(&schedule.OrganizationID) {
+		return nil, repository.ErrNotFound
+	}
+	return &schedule, nil
+}
+
+func (r *ScheduleRepository) List(ctx context.Context) ([]models.Schedule, error) {
+	return r.filter(ctx, func(*models.Schedule) bool { return true }, func(a, b *models.Schedule) bool {
+		if a.OrganizationID != b.OrganizationID {
+			return a.OrganizationID < b.OrganizationID
+		}
+		return a.ID < b.ID
+	})
+}
+
+func (r *ScheduleRepository) Due(ctx context.Context, now time.Time) ([]models.Schedule, error) {
+	return r.filter(ctx, func(s *models.Schedule) bool {
+		return s.Enabled && s.NextRunAt != nil && !s.NextRunAt.After(now)
+	}, func(a, b *models.Schedule) bool {
+		if !a.NextRunAt.Equal(*b.NextRunAt) {
+			return a.NextRunAt.Before(*b.NextRunAt)
+		}
+		return a.ID < b.ID
+	})
+}
+
+func (r *ScheduleRepository) Claim(ctx context.Context, id uint, prev int64, next *time.Time, at time.Time) (bool, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.schedules[id]
+	if !ok || !t.Allows(&current.OrganizationID) || current.RunCount != prev {
+		return false, nil
+	}
+	current.NextRunAt = next
+	current.LastRunAt = &at
+	current.RunCount++
+	r.schedules[id] = current
+	return true, nil
+}
+
+// filter Schedule ที่ Tenant มองเห็นและ keep คืน true เรียงตาม less
+func (r *ScheduleRepository) filter(ctx context.Context, keep func(*models.Schedule) bool, less func(a, b *models.Schedule) bool) ([]models.Schedule, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.Schedule
+	for _, s := range r.schedules {
+		if t.Allows(&s.OrganizationID) && keep(&s) {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return less(&out[i], &out[j]) })
+	return out, nil
+}
+
+// ScheduleRunRepository เก็บประวัติตามลำดับที่บันทึก
+type ScheduleRunRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	runs   []models.ScheduleRun
+}
+
+// NewScheduleRunRepository สร้าง ScheduleRunRepository ว่าง
+func NewScheduleRunRepository() *ScheduleRunRepository {
+	return &ScheduleRunRepository{}
+}
+
+func (r *ScheduleRunRepository) Create(ctx context.Context, run *models.ScheduleRun) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&run.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	run.ID = r.nextID
+	run.CreatedAt = time.Now()
+	r.runs = append(r.runs, *run)
+	return nil
+}
+
+func (r *ScheduleRunRepository) ListForSchedule(ctx context.Context, scheduleID uint, limit int) ([]models.ScheduleRun, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.ScheduleRun
+	for i := len(r.runs) - 1; i >= 0 && len(out) < limit; i-- {
+		run := r.runs[i]
+		if run.ScheduleID == scheduleID && t.Allows(&run.OrganizationID) {
+			out = append(out, run)
+		}
+	}
+	return out, nil
+}
+
+func (r *ScheduleRunRepository) deleteForSchedule(scheduleID uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.runs[:0]
+	for _, run := range r.runs {
+		if run.ScheduleID != scheduleID {
+			kept = append(kept, run)
+		}
+	}
+	r.runs = kept
+}
+
+// --- Husbandry ---
+
+// HusbandryRepository เก็บ HusbandryEvent ใน Map ตาม ID
+type HusbandryRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	events map[uint]models.HusbandryEvent
+}
+
+// NewHusbandryRepository สร้าง HusbandryRepository ว่าง
+func NewHusbandryRepository() *HusbandryRepository {
+	return &HusbandryRepository{events: map[uint]models.HusbandryEvent{}}
+}
+
+func (r *HusbandryRepository) Create(ctx context.Context, event *models.HusbandryEvent) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&event.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	event.ID = r.nextID
+	event.CreatedAt = time.Now()
+	r.events[event.ID] = *event
+	return nil
+}
+
+func (r *HusbandryRepository) Delete(ctx context.Context, id uint) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.events[id]; !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	delete(r.events, id)
+	return nil
+}
+
+func (r *HusbandryRepository) FindByID(ctx context.Context, id uint) (*models.HusbandryEvent, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, ok := r.events[id]
+	if !ok || !t.Allows(&event.OrganizationID) {
+		return nil, repository.ErrNotFound
+	}
+	return &event, nil
+}
+
+func (r *HusbandryRepository) List(ctx context.Context, q repository.HusbandryQuery) ([]models.HusbandryEvent, error) {
+	locations := make(map[uint]bool, len(q.LocationIDs))
+	for _, id := range q.LocationIDs {
+		locations[id] = true
+	}
+	return r.filter(ctx, func(e *models.HusbandryEvent) bool {
+		return locations[e.LocationID] &&
+			(q.From.IsZero() || !e.OccurredAt.Before(q.From)) &&
+			(q.To.IsZero() || e.OccurredAt.Before(q.To)) &&
+			(q.Kind == "" || e.Kind == q.Kind)
+	})
+}
+
+func (r *HusbandryRepository) CountForLocation(ctx context.Context, locationID uint) (int64, error) {
+	list, err := r.filter(ctx, func(e *models.HusbandryEvent) bool { return e.LocationID == locationID })
+	return int64(len(list)), err
+}
+
+// filter คืนสำเนาที่ Tenant มองเห็นเรียงตาม OccurredAt
+func (r *HusbandryRepository) filter(ctx context.Context, match func(*models.HusbandryEvent) bool) ([]models.HusbandryEvent, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.HusbandryEvent
+	for _, e := range r.events {
+		if t.Allows(&e.OrganizationID) && match(&e) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].OccurredAt.Equal(out[j].OccurredAt) {
+			return out[i].OccurredAt.Before(out[j].OccurredAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// --- Calibrations ---
+
+// CalibrationRepository เก็บ Calibration ใน Map ตาม ID
+type CalibrationRepository struct {
+	mu           sync.Mutex
+	nextID       uint
+	calibrations map[uint]models.Calibration
+}
+
+// NewCalibrationRepository สร้าง CalibrationRepository ว่าง
+func NewCalibrationRepository() *CalibrationRepository {
+	return &CalibrationRepository{calibrations: map[uint]models.Calibration{}}
+}
+
+func (r *CalibrationRepository) Create(ctx context.Context, calibration *models.Calibration) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&calibration.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	calibration.ID = r.nextID
+	calibration.CreatedAt = time.Now()
+	r.calibrations[calibration.ID] = *calibration
+	return nil
+}
+
+func (r *CalibrationRepository) SetValidUntil(ctx context.Context, id uint, until time.Time) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.calibrations[id]
+	if !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	current.ValidUntil = &until
+	r.calibrations[id] = current
+	return nil
+}
+
+func (r *CalibrationRepository) Delete(ctx context.Context, id uint) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.calibrations[id]; !ok || !t.Allows(&current.OrganizationID) {
+		return repository.ErrNotFound
+	}
+	delete(r.calibrations, id)
+	return nil
+}
+
+func (r *CalibrationRepository) FindByID(ctx context.Context, id uint) (*models.Calibration, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calibration, ok := r.calibrations[id]
+	if !ok || !t.Allows(&calibration.OrganizationID) {
+		return nil, repository.ErrNotFound
+	}
+	return &calibration, nil
+}
+
+func (r *CalibrationRepository) ListForDevice(ctx context.Context, deviceID uint) ([]models.Calibration, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.Calibration
+	for _, c := range r.calibrations {
+		if c.DeviceID == deviceID && t.Allows(&c.OrganizationID) {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Metric != out[j].Metric {
+			return out[i].Metric < out[j].Metric
+		}
+		if !out[i].ValidFrom.Equal(out[j].ValidFrom) {
+			return out[i].ValidFrom.Before(out[j].ValidFrom)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// --- Sensors ---
+
+// SensorRepository เก็บ SensorData ตามลำดับที่บันทึก
+// assignments ใช้หา Location ของค่าแต่ละค่าใน Aggregate / ListForLocations (nil = ไม่มี Device ติดตั้งที่ไหน)
+type SensorRepository struct {
+	mu          sync.Mutex
+	nextID      uint
+	data        []models.SensorData
+	assignments *DeviceAssignmentRepository
+}
+
+// NewSensorRepository สร้าง SensorRepository ว่าง
+func NewSensorRepository() *SensorRepository {
+	return &SensorRepository{}
+}
+
+func (r *SensorRepository) Create(ctx context.Context, data *models.SensorData) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.Claim(&data.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	data.ID = r.nextID
+	data.CreatedAt = time.Now()
+	r.data = append(r.data, *data)
+	return nil
+}
+
+func (r *SensorRepository) List(ctx context.Context) ([]models.SensorData, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []models.SensorData
+	for _, d := range r.data {
+		if t.Allows(&d.OrganizationID) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *SensorRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.data[:0]
+	for _, d := range r.data {
+		if !t.Allows(&d.OrganizationID) || !d.CreatedAt.Before(before) {
+			kept = append(kept, d)
+		}
+	}
+	deleted := int64(len(r.data) - len(kept))
+	r.data = kept
+	return deleted, nil
+}
+
+func (r *SensorRepository) Aggregate(ctx context.Context, q repository.SensorAggregateQuery) ([]models.SensorSummary, error) {
+	data, err := r.ListForLocations(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := map[int64][]models.SensorData{}
+	var keys []int64
+	for _, d := range data {
+		var key int64
+		if q.Interval > 0 {
+			secs := int64(q.Interval / time.Second)
+			key = d.CreatedAt.Unix() / secs * secs
+		}
+		if _, ok := buckets[key]; !ok {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], d)
+	}
+
+	if q.Interval <= 0 {
+		return []models.SensorSummary{summarize(buckets[0], q.Derived)}, nil
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	out := make([]models.SensorSummary, len(keys))
+	for i, key := range keys {
+		start := time.Unix(key, 0).UTC()
+		out[i] = summarize(buckets[key], q.Derived)
+		out[i].BucketStart = &start
+	}
+	return out, nil
+}
+
+func (r *SensorRepository) Recompute(ctx context.Context, deviceID uint, from, to time.Time, fn func(d *models.SensorData) bool) (int64, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var changed int64
+	for i := range r.data {
+		d := &r.data[i]
+		if d.DeviceID == nil || *d.DeviceID != deviceID || !t.Allows(&d.OrganizationID) ||
+			d.CreatedAt.Before(from) || !d.CreatedAt.Before(to) {
+			continue
+		}
+		if fn(d) {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (r *SensorRepository) ListForLocations(ctx context.Context, q repository.SensorAggregateQuery) ([]models.SensorData, error) {
+	data, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	locations := make(map[uint]bool, len(q.LocationIDs))
+	for _, id := range q.LocationIDs {
+		locations[id] = true
+	}
+
+	var out []models.SensorData
+	for _, d := range data {
+		if d.DeviceID == nil || r.assignments == nil || d.CreatedAt.Before(q.From) || !d.CreatedAt.Before(q.To) {
+			continue
+		}
+		if loc, ok := r.assignments.locationAt(*d.DeviceID, d.CreatedAt); ok && locations[loc] {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *SensorRepository) Recent(ctx context.Context, deviceID uint, since time.Time, limit int) ([]models.SensorData, error) {
+	data, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// data เรียงตามลำดับที่บันทึก อ่านจากท้าย
+	var out []models.SensorData
+	for i := len(data) - 1; i >= 0 && len(out) < limit; i-- {
+		d := data[i]
+		if d.DeviceID != nil && *d.DeviceID == deviceID && !d.CreatedAt.Before(since) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *SensorRepository) ListAnomalies(ctx context.Context, q repository.AnomalyQuery) ([]models.SensorData, error) {
+	data, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []models.SensorData
+	for i := len(data) - 1; i >= 0 && len(out) < q.Limit; i-- {
+		d := data[i]
+		if !d.Anomalous || d.CreatedAt.Before(q.From) || !d.CreatedAt.Before(q.To) {
+			continue
+		}
+		if q.DeviceID == 0 || (d.DeviceID != nil && *d.DeviceID == q.DeviceID) {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// summarize สรุปค่าเหมือน SQL (AVG / MIN / MAX ไม่นับค่าที่ไม่มี)
+func summarize(data []models.SensorData, derived []string) models.SensorSummary {
+	s := models.SensorSummary{Count: int64(len(data))}
+	temps := make([]float64, len(data))
+	humidities := make([]float64, len(data))
+	for i, d := range data {
+		temps[i], humidities[i] = d.Temperature, d.Humidity
+	}
+	t := summarizeValues(temps)
+	h := summarizeValues(humidities)
+	s.TemperatureAvg, s.TemperatureMin, s.TemperatureMax = t.Avg, t.Min, t.Max
+	s.HumidityAvg, s.HumidityMin, s.HumidityMax = h.Avg, h.Min, h.Max
+
+	if len(derived) > 0 {
+		s.Derived = make(map[string]models.MetricSummary, len(derived))
+		for _, name := range derived {
+			var values []float64
+			for _, d := range data {
+				if v, ok := d.Derived[name]; ok {
+					values = append(values, v)
+				}
+			}
+			s.Derived[name] = summarizeValues(values)
+		}
+	}
+	return s
+}
+
+func summarizeValues(values []float64) models.MetricSummary {
+	if len(values) == 0 {
+		return models.MetricSummary{}
+	}
+	sum, lo, hi := 0.0, values[0], values[0]
+	for _, v := range values {
+		sum += v
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	avg := sum / float64(len(values))
+	return models.MetricSummary{Avg: &avg, Min: &lo, Max: &hi}
+}
+
+// --- Recovery Codes ---
+
+// RecoveryCodeRepository เก็บ Recovery Code ตาม User
+type RecoveryCodeRepository struct {
+	mu    sync.Mutex
+	codes map[uint]map[string]bool // userID -> hash -> ใช้แล้วหรือยัง
+}
+
+// NewRecoveryCodeRepository สร้าง RecoveryCodeRepository ว่าง
+func NewRecoveryCodeRepository() *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{codes: map[uint]map[string]bool{}}
+}
+
+func (r *RecoveryCodeRepository) Replace(ctx context.Context, userID uint, hashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		codes[h] = false
+	}
+	r.codes[userID] = codes
+	return nil
+}
+
+func (r *RecoveryCodeRepository) DeleteForUser(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.codes, userID)
+	return nil
+}
+
+func (r *RecoveryCodeRepository) Use(ctx context.Context, userID uint, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	used, ok := r.codes[userID][hash]
+	if !ok || used {
+		return false, nil
+	}
+	r.codes[userID][hash] = true
+	return true, nil
+}
+
+// --- Audit ---
+
+// AuditRepository เก็บ Audit Log ตามลำดับที่บันทึก
+type AuditRepository struct {
+	mu   sync.Mutex
+	logs []models.AuditLog
+}
+
+// NewAuditRepository สร้าง AuditRepository ว่าง
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	if err := t.ClaimOptional(&entry.OrganizationID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.ID = uint(len(r.logs) + 1)
+	entry.CreatedAt = time.Now()
+	r.logs = append(r.logs, *entry)
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context) ([]models.AuditLog, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]models.AuditLog, 0, len(r.logs))
+	for i := len(r.logs) - 1; i >= 0; i-- {
+		if t.Allows(r.logs[i].OrganizationID) {
+			out = append(out, r.logs[i])
+		}
+	}
+	return out, nil
+}
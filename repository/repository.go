@@ -0,0 +1,320 @@
+// Package repository รวม Query ทั้งหมดไว้หลัง Interface เพื่อให้ Service ไม่ต้องรู้จัก *gorm.DB
+// ตัวจริงใช้ GORM (NewStore) ส่วน repository/memory เป็นตัวปลอมสำหรับทดสอบ Handler โดยไม่ต้องมีฐานข้อมูล
+//
+// ข้อมูลขององค์กร (User, Organization, Device, Location, Actuator, Sensor, Audit) แยกตาม Tenant ใน ctx (ดู WithTenant)
+// ทุก Method ของ Repository เหล่านี้คืน ErrNoTenant ถ้า ctx ไม่มี Tenant
+// และไม่มีทางอ่านหรือเขียนข้อมูลขององค์กรอื่นได้ ไม่ว่าผู้เรียกจะส่ง ID อะไรมา
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"worm/models"
+)
+
+// ErrNotFound ไม่พบข้อมูลที่ค้นหา
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict ค่าที่ต้องไม่ซ้ำ (Unique) มีอยู่แล้ว ใช้กับ errors.Is ส่วน Field ที่ชนอยู่ใน *ConflictError
+var ErrConflict = errors.New("already exists")
+
+// ConflictError Unique Constraint ถูกละเมิด
+// Field = ชื่อ Field ตาม JSON ที่ชน ("" = ระบุไม่ได้)
+type ConflictError struct {
+	Field string
+	Err   error
+}
+
+func (e *ConflictError) Error() string {
+	if e.Field == "" {
+		return "value already exists"
+	}
+	return e.Field + " already exists"
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// UserRepository ข้อมูลผู้ใช้ (แยกตาม Tenant, Superadmin เห็นได้เฉพาะ Tenant ที่เห็นทุกองค์กร)
+// Username และ API Key ไม่ซ้ำทั้งระบบ (ใช้ล็อกอินโดยไม่ต้องระบุองค์กร)
+type UserRepository interface {
+	// Create / Update คืน *ConflictError เมื่อ Username (ไม่สนตัวพิมพ์) หรือ API Key ซ้ำ
+	// และคืน ErrTenantMismatch ถ้า user อยู่คนละองค์กรกับ Tenant (ไม่ระบุ = องค์กรของ Tenant)
+	Create(ctx context.Context, user *models.User) error
+	// Update บันทึกทุก Field ของ user ยกเว้น TOTPLastStep (User ที่ Tenant มองไม่เห็นคืน ErrNotFound)
+	Update(ctx context.Context, user *models.User) error
+	// UseTOTPStep ตั้ง TOTPLastStep = step ถ้าค่าเดิมน้อยกว่า step คืน false ถ้ารหัสของช่วงเวลานี้ถูกใช้ไปแล้ว
+	// (รวมถึง Request ที่ใช้รหัสเดียวกันพร้อมกัน)
+	UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	// FindByUsername ไม่สนตัวพิมพ์เล็ก/ใหญ่ (Admin = admin)
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindByAPIKey(ctx context.Context, apiKey string) (*models.User, error)
+	FindByOIDCSubject(ctx context.Context, subject string) (*models.User, error)
+	List(ctx context.Context) ([]models.User, error)
+	ListByRole(ctx context.Context, role string) ([]models.User, error)
+}
+
+// OrganizationRepository องค์กร (Tenant ขององค์กรเดียวเห็นเฉพาะองค์กรของตัวเอง)
+type OrganizationRepository interface {
+	// Create ใช้ได้เฉพาะ Tenant ที่เห็นทุกองค์กร คืน *ConflictError เมื่อชื่อซ้ำ (ไม่สนตัวพิมพ์)
+	Create(ctx context.Context, org *models.Organization) error
+	FindByID(ctx context.Context, id uint) (*models.Organization, error)
+	// FindByName ไม่สนตัวพิมพ์เล็ก/ใหญ่
+	FindByName(ctx context.Context, name string) (*models.Organization, error)
+	List(ctx context.Context) ([]models.Organization, error)
+}
+
+// DeviceRepository Device ที่ส่งค่า Sensor (แยกตาม Tenant)
+type DeviceRepository interface {
+	// Create คืน *ConflictError เมื่อชื่อซ้ำภายในองค์กรเดียวกัน (ไม่สนตัวพิมพ์)
+	Create(ctx context.Context, device *models.Device) error
+	// FindByName หา Device ชื่อ name ขององค์กร orgID (ไม่สนตัวพิมพ์)
+	FindByName(ctx context.Context, orgID uint, name string) (*models.Device, error)
+	FindByID(ctx context.Context, id uint) (*models.Device, error)
+	// Touch บันทึกเวลาที่ Device ส่งค่าล่าสุด
+	Touch(ctx context.Context, id uint, seenAt time.Time) error
+	List(ctx context.Context) ([]models.Device, error)
+}
+
+// LocationRepository ตำแหน่งแบบลำดับชั้น (แยกตาม Tenant)
+// กฎของต้นไม้ (ห้ามวน, Bin ไม่มีลูก) ตรวจที่ services ส่วนที่นี่กันเฉพาะชื่อซ้ำกับพี่น้อง
+type LocationRepository interface {
+	// Create / Update คืน *ConflictError เมื่อชื่อซ้ำกับพี่น้อง (ไม่สนตัวพิมพ์)
+	Create(ctx context.Context, location *models.Location) error
+	Update(ctx context.Context, location *models.Location) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.Location, error)
+	List(ctx context.Context) ([]models.Location, error)
+}
+
+// DeviceAssignmentRepository ประวัติการติดตั้ง Device (แยกตาม Tenant)
+type DeviceAssignmentRepository interface {
+	// Current การติดตั้งที่ยังไม่สิ้นสุดของ Device (ErrNotFound = ไม่ได้ติดตั้งที่ไหน)
+	Current(ctx context.Context, deviceID uint) (*models.DeviceAssignment, error)
+	// Move ปิดการติดตั้งปัจจุบันของ device ที่เวลา at แล้วเริ่มติดตั้งที่ locationID (nil = ถอดออก) ใน Transaction เดียว
+	Move(ctx context.Context, device *models.Device, locationID *uint, at time.Time) error
+	// ListForDevice เรียงจากเก่าไปใหม่
+	ListForDevice(ctx context.Context, deviceID uint) ([]models.DeviceAssignment, error)
+	// CountForLocation จำนวนการติดตั้งทั้งหมด (รวมที่สิ้นสุดแล้ว) ที่ Location นี้
+	CountForLocation(ctx context.Context, locationID uint) (int64, error)
+}
+
+// ActuatorRepository Relay ที่ Device ควบคุม (แยกตาม Tenant)
+type ActuatorRepository interface {
+	// Create / Update คืน *ConflictError เมื่อชื่อหรือ Channel ซ้ำภายใน Device เดียวกัน
+	Create(ctx context.Context, actuator *models.Actuator) error
+	// Update ไม่แตะ state / state_changed_at (เปลี่ยนผ่าน SetState เท่านั้น)
+	Update(ctx context.Context, actuator *models.Actuator) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.Actuator, error)
+	List(ctx context.Context) ([]models.Actuator, error)
+	// SetState บันทึกคำสั่งล่าสุดที่ Device ยืนยันแล้ว
+	SetState(ctx context.Context, id uint, state string, at time.Time) error
+}
+
+// ActuatorCommandRepository คำสั่งถึง Actuator และสถานะ (แยกตาม Tenant)
+// การเปลี่ยนสถานะทุกแบบเป็น Atomic: เปลี่ยนได้เฉพาะจากสถานะที่คาดไว้ จึงปลอดภัยเมื่อรันหลาย Instance
+type ActuatorCommandRepository interface {
+	Create(ctx context.Context, cmd *models.ActuatorCommand) error
+	FindByID(ctx context.Context, id uint) (*models.ActuatorCommand, error)
+	// ListForActuator เรียงจากล่าสุด
+	ListForActuator(ctx context.Context, actuatorID uint) ([]models.ActuatorCommand, error)
+	CountForActuator(ctx context.Context, actuatorID uint) (int64, error)
+	// Deliver เปลี่ยนคำสั่ง queued ที่ยังไม่หมดอายุของ deviceID เป็น delivered แล้วคืนคำสั่งเหล่านั้นเรียงตามลำดับที่สั่ง
+	// คำสั่งหนึ่งถูกส่งได้ครั้งเดียวแม้ Device จะ Poll ซ้อนกัน
+	Deliver(ctx context.Context, deviceID uint, now time.Time) ([]models.ActuatorCommand, error)
+	// Finish เปลี่ยนคำสั่ง delivered เป็น status (acknowledged | failed) คืน ErrNotFound ถ้าคำสั่งไม่ได้อยู่ในสถานะ delivered
+	Finish(ctx context.Context, id uint, status, message string, at time.Time) error
+	// Expire เปลี่ยน queued ที่หมดอายุก่อน now เป็น expired และ delivered ที่ส่งก่อน ackBefore เป็น failed
+	// คืนจำนวนคำสั่งที่เปลี่ยน
+	Expire(ctx context.Context, now, ackBefore time.Time) (int64, error)
+}
+
+// AutomationRuleRepository Rule ของ Automation (แยกตาม Tenant)
+type AutomationRuleRepository interface {
+	Create(ctx context.Context, rule *models.AutomationRule) error
+	Update(ctx context.Context, rule *models.AutomationRule) error
+	// Delete ลบ Rule พร้อม Log ของ Rule
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.AutomationRule, error)
+	List(ctx context.Context) ([]models.AutomationRule, error)
+	// SaveState บันทึกสถานะจากการประเมิน (condition_*, last_value, last_evaluated_at) โดยไม่แตะค่าตั้ง
+	// ถ้า state_version ยังเท่ากับ rule.StateVersion แล้วเพิ่ม rule.StateVersion
+	// คืน false ถ้ามีการประเมินหรือแก้ไขอื่นบันทึกไปก่อน (หรือ Rule ถูกลบ)
+	SaveState(ctx context.Context, rule *models.AutomationRule) (bool, error)
+	// MarkFired ตั้ง last_fired_at = at และเพิ่ม fired_count ถ้า fired_count ยังเท่ากับ prev
+	// คืน false ถ้ามี Instance อื่นทำงานไปก่อนแล้ว
+	MarkFired(ctx context.Context, id uint, prev int64, at time.Time) (bool, error)
+}
+
+// AutomationLogRepository Log การทำงานของ Rule (แยกตาม Tenant)
+type AutomationLogRepository interface {
+	Create(ctx context.Context, entry *models.AutomationLog) error
+	// ListForRule เรียงจากล่าสุด ไม่เกิน limit รายการ
+	ListForRule(ctx context.Context, ruleID uint, limit int) ([]models.AutomationLog, error)
+}
+
+// ScheduleRepository Schedule ที่ทำตาม Cron (แยกตาม Tenant)
+type ScheduleRepository interface {
+	Create(ctx context.Context, schedule *models.Schedule) error
+	// Update ไม่แตะ last_run_at / run_count
+	Update(ctx context.Context, schedule *models.Schedule) error
+	// Delete ลบ Schedule พร้อมประวัติการทำงาน
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.Schedule, error)
+	List(ctx context.Context) ([]models.Schedule, error)
+	// Due Schedule ที่เปิดอยู่และถึงเวลาแล้ว (next_run_at <= now) เรียงตาม next_run_at
+	Due(ctx context.Context, now time.Time) ([]models.Schedule, error)
+	// Claim หยิบรอบที่ถึงเวลาไปทำ: ตั้ง next_run_at = next, last_run_at = at และเพิ่ม run_count ถ้า run_count ยังเท่ากับ prev
+	// คืน false ถ้ามี Instance อื่นหยิบไปก่อนแล้ว
+	Claim(ctx context.Context, id uint, prev int64, next *time.Time, at time.Time) (bool, error)
+}
+
+// ScheduleRunRepository ประวัติการทำงานของ Schedule (แยกตาม Tenant)
+type ScheduleRunRepository interface {
+	Create(ctx context.Context, run *models.ScheduleRun) error
+	// ListForSchedule เรียงจากล่าสุด ไม่เกิน limit รายการ
+	ListForSchedule(ctx context.Context, scheduleID uint, limit int) ([]models.ScheduleRun, error)
+}
+
+// CalibrationRepository การแก้ค่า Sensor ของ Device (แยกตาม Tenant)
+type CalibrationRepository interface {
+	Create(ctx context.Context, calibration *models.Calibration) error
+	// SetValidUntil ตั้ง valid_until ของ Calibration
+	SetValidUntil(ctx context.Context, id uint, until time.Time) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.Calibration, error)
+	// ListForDevice เรียงตาม metric แล้ว valid_from
+	ListForDevice(ctx context.Context, deviceID uint) ([]models.Calibration, error)
+}
+
+// HusbandryQuery เงื่อนไขการค้นหา HusbandryEvent
+type HusbandryQuery struct {
+	// ว่าง = ไม่คืนรายการใด
+	LocationIDs []uint
+	// ช่วง [From, To) ของ occurred_at (ค่าศูนย์ = ไม่จำกัดด้านนั้น)
+	From, To time.Time
+	// ว่าง = ทุกชนิด
+	Kind string
+}
+
+// HusbandryRepository บันทึกการดูแล Bin (แยกตาม Tenant)
+type HusbandryRepository interface {
+	Create(ctx context.Context, event *models.HusbandryEvent) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.HusbandryEvent, error)
+	// List เรียงตาม occurred_at
+	List(ctx context.Context, q HusbandryQuery) ([]models.HusbandryEvent, error)
+	// CountForLocation จำนวนรายการที่ Location นี้
+	CountForLocation(ctx context.Context, locationID uint) (int64, error)
+}
+
+// SensorAggregateQuery เงื่อนไขการสรุปข้อมูล Sensor ตาม Location
+type SensorAggregateQuery struct {
+	// นับเฉพาะค่าที่ Device ติดตั้งอยู่ที่ Location เหล่านี้ ณ เวลาที่บันทึก
+	LocationIDs []uint
+	// ช่วง [From, To)
+	From, To time.Time
+	// > 0 = แบ่งเป็นช่วงละ Interval (นับจาก Unix Epoch) คืนเฉพาะช่วงที่มีข้อมูล
+	Interval time.Duration
+	// ชื่อของค่าที่คำนวณตอนบันทึก (SensorData.Derived) ที่ต้องการสรุปด้วย
+	Derived []string
+}
+
+// AnomalyQuery เงื่อนไขการค้นหาค่าที่ผิดปกติ
+type AnomalyQuery struct {
+	// 0 = ทุก Device
+	DeviceID uint
+	// ช่วง [From, To)
+	From, To time.Time
+	// จำนวนสูงสุด (ล่าสุดก่อน)
+	Limit int
+}
+
+// SensorRepository ข้อมูลจาก Sensor (แยกตาม Tenant)
+type SensorRepository interface {
+	// Create ไม่ระบุองค์กร = องค์กรของ Tenant
+	Create(ctx context.Context, data *models.SensorData) error
+	List(ctx context.Context) ([]models.SensorData, error)
+	// DeleteBefore ลบข้อมูลที่บันทึกก่อน before คืนจำนวนแถวที่ลบ
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	// Aggregate สรุปค่าตาม q (ไม่แบ่งช่วง = คืน 1 รายการเสมอ แม้ไม่มีข้อมูล)
+	Aggregate(ctx context.Context, q SensorAggregateQuery) ([]models.SensorSummary, error)
+	// Recompute ให้ fn แก้ค่าที่ Device บันทึกในช่วง [from, to) ทีละค่า แล้วบันทึก temperature / humidity / derived ของค่าที่ fn คืน true
+	// คืนจำนวนค่าที่เปลี่ยน
+	Recompute(ctx context.Context, deviceID uint, from, to time.Time, fn func(d *models.SensorData) bool) (int64, error)
+	// ListForLocations ค่าแต่ละค่าตาม LocationIDs และ From / To ของ q เรียงตามเวลาที่บันทึก (ไม่สน Interval)
+	ListForLocations(ctx context.Context, q SensorAggregateQuery) ([]models.SensorData, error)
+	// Recent ค่าที่ Device บันทึกตั้งแต่ since ไม่เกิน limit ค่า เรียงจากล่าสุด
+	Recent(ctx context.Context, deviceID uint, since time.Time, limit int) ([]models.SensorData, error)
+	// ListAnomalies ค่าที่มี Anomalies ตาม q เรียงจากล่าสุด
+	ListAnomalies(ctx context.Context, q AnomalyQuery) ([]models.SensorData, error)
+}
+
+// RecoveryCodeRepository Recovery Code ของ 2FA (เก็บเฉพาะ Hash)
+// ไม่แยกตาม Tenant: ใช้กับ User ที่ผ่านการยืนยันตัวตน / ผ่าน UserRepository มาแล้วเท่านั้น
+type RecoveryCodeRepository interface {
+	// Replace ลบรหัสเดิมทั้งหมดของ User แล้วใส่ชุดใหม่
+	Replace(ctx context.Context, userID uint, hashes []string) error
+	DeleteForUser(ctx context.Context, userID uint) error
+	// Use ทำเครื่องหมายว่าใช้แล้ว คืน false ถ้าไม่พบหรือถูกใช้ไปแล้ว
+	Use(ctx context.Context, userID uint, hash string) (bool, error)
+}
+
+// AuditRepository Audit Log (แยกตาม Tenant, รายการระดับระบบเห็นเฉพาะ Tenant ที่เห็นทุกองค์กร)
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	// List เรียงจากล่าสุด
+	List(ctx context.Context) ([]models.AuditLog, error)
+}
+
+// RateLimitRepository สถานะของ Rate Limit / Quota ตาม Key (ไม่แยกตาม Tenant)
+type RateLimitRepository interface {
+	// Update อ่าน Bucket ของ key แล้วให้ fn แก้ค่าและบันทึกแบบ Atomic (Bucket ใหม่มี UpdatedAt เป็นค่าศูนย์)
+	Update(ctx context.Context, key string, fn func(b *models.RateLimitBucket)) error
+	// DeleteBefore ลบ Bucket ที่ไม่ถูกใช้ตั้งแต่ before คืนจำนวนที่ลบ
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Transactor เปิด Transaction ที่ Repository ทุกตัวใน tx ใช้ร่วมกัน (fn คืน error = Rollback)
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(tx *Store) error) error
+}
+
+// Store รวม Repository ทุกตัวที่ระบบใช้
+type Store struct {
+	Transactor    Transactor
+	Users         UserRepository
+	Organizations OrganizationRepository
+	Devices       DeviceRepository
+	Locations     LocationRepository
+	Assignments   DeviceAssignmentRepository
+	Actuators     ActuatorRepository
+	Commands      ActuatorCommandRepository
+	Rules         AutomationRuleRepository
+	RuleLogs      AutomationLogRepository
+	Schedules     ScheduleRepository
+	ScheduleRuns  ScheduleRunRepository
+	Husbandry     HusbandryRepository
+	Calibrations  CalibrationRepository
+	Sensors       SensorRepository
+	RecoveryCodes RecoveryCodeRepository
+	Audit         AuditRepository
+	RateLimits    RateLimitRepository
+}
+
+// Transaction รัน fn ด้วย Store ที่อยู่ใน Transaction เดียวกัน (ไม่มี Transactor = รัน fn กับ s ตรงๆ)
+// ภายใน fn ต้องใช้ Repository ของ tx เท่านั้น (SQLite มี Connection เดียว)
+func (s *Store) Transaction(ctx context.Context, fn func(tx *Store) error) error {
+	if s.Transactor == nil {
+		return fn(s)
+	}
+	return s.Transactor.Transaction(ctx, fn)
+}
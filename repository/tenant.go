@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoTenant เรียก Repository ที่แยกข้อมูลตามองค์กรโดยไม่ได้ระบุ Tenant ใน Context
+// เป็น Bug ของผู้เรียกเสมอ (ปฏิเสธไว้ก่อนดีกว่าคืนข้อมูลของทุกองค์กร)
+var ErrNoTenant = errors.New("no tenant in context")
+
+// ErrTenantMismatch พยายามเขียนข้อมูลของ / ย้ายข้อมูลไปองค์กรอื่น
+var ErrTenantMismatch = errors.New("record belongs to another organization")
+
+// ErrNoOrganization ข้อมูลที่ต้องอยู่ในองค์กรถูกเขียนโดยไม่ได้ระบุองค์กร
+var ErrNoOrganization = errors.New("organization is required")
+
+// Tenant ขอบเขตข้อมูลที่ผู้เรียกเห็นได้
+// All = ทุกองค์กร (Superadmin, CLI และงานของระบบ) ไม่งั้นเห็นเฉพาะ OrganizationID
+type Tenant struct {
+	OrganizationID uint
+	All            bool
+}
+
+type tenantKey struct{}
+
+// WithTenant ผูก Tenant ไว้กับ ctx ทุก Query ที่ใช้ ctx นี้จะถูกจำกัดตาม t
+func WithTenant(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// AllTenants ctx ที่เห็นข้อมูลทุกองค์กร (ใช้กับการยืนยันตัวตน, CLI และ Worker เท่านั้น)
+func AllTenants(ctx context.Context) context.Context {
+	return WithTenant(ctx, Tenant{All: true})
+}
+
+// OrganizationTenant ctx ที่เห็นเฉพาะองค์กร orgID
+func OrganizationTenant(ctx context.Context, orgID uint) context.Context {
+	return WithTenant(ctx, Tenant{OrganizationID: orgID})
+}
+
+// TenantFrom Tenant ใน ctx (คืน ErrNoTenant ถ้าไม่มี)
+func TenantFrom(ctx context.Context) (Tenant, error) {
+	t, ok := ctx.Value(tenantKey{}).(Tenant)
+	if !ok || (!t.All && t.OrganizationID == 0) {
+		return Tenant{}, ErrNoTenant
+	}
+	return t, nil
+}
+
+// Allows Tenant นี้เข้าถึงข้อมูลขององค์กร orgID ได้หรือไม่ (nil = ไม่มีองค์กร เช่น Superadmin)
+func (t Tenant) Allows(orgID *uint) bool {
+	return t.All || (orgID != nil && *orgID == t.OrganizationID)
+}
+
+// Claim ตรวจ / กำหนดองค์กรของข้อมูลที่กำลังจะเขียน (0 = ยังไม่กำหนด)
+// Tenant ที่จำกัดองค์กร: ค่าว่างถูกกำหนดเป็นองค์กรของตัวเอง ค่าขององค์กรอื่นคืน ErrTenantMismatch
+func (t Tenant) Claim(orgID *uint) error {
+	if t.All {
+		if *orgID == 0 {
+			return ErrNoOrganization
+		}
+		return nil
+	}
+	if *orgID == 0 {
+		*orgID = t.OrganizationID
+		return nil
+	}
+	if *orgID != t.OrganizationID {
+		return ErrTenantMismatch
+	}
+	return nil
+}
+
+// ClaimOptional เหมือน Claim สำหรับข้อมูลที่ไม่มีองค์กรได้ (nil ใช้ได้เฉพาะ Tenant ที่เห็นทุกองค์กร)
+func (t Tenant) ClaimOptional(orgID **uint) error {
+	if t.All {
+		return nil
+	}
+	if *orgID == nil {
+		id := t.OrganizationID
+		*orgID = &id
+		return nil
+	}
+	return t.Claim(*orgID)
+}
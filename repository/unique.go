@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// uniqueFields ชื่อ Unique Index / Column ในฐานข้อมูล -> ชื่อ Field ตาม JSON
+// เพิ่ม Unique Field ใหม่ต้องเพิ่มชื่อ Index ที่นี่ด้วย
+var uniqueFields = map[string]string{
+	"idx_users_username_lower": "username",
+	"idx_users_username":       "username",
+	"idx_users_api_key":        "api_key",
+	"idx_users_oidc_subject":   "oidc_subject",
+
+	"idx_organizations_name_lower": "name",
+	"idx_devices_org_name_lower":   "name",
+
+	"idx_locations_parent_name_lower": "name",
+	"idx_device_assignments_open":     "device_id",
+
+	"idx_actuators_device_name_lower": "name",
+	"idx_actuators_device_channel":    "channel",
+	// SQLite รายงาน Index ที่ไม่ใช่ Expression ด้วยรายชื่อ Column (ตัวแรกคือ device_id)
+	"actuators.device_id": "channel",
+}
+
+// SQLSTATE ของ Postgres สำหรับ unique_violation
+const pgUniqueViolation = "23505"
+
+var (
+	// Postgres: duplicate key value violates unique constraint "idx_users_username_lower"
+	pgConstraint = regexp.MustCompile(`unique constraint "([^"]+)"`)
+	// SQLite: UNIQUE constraint failed: users.username หรือ UNIQUE constraint failed: index 'idx_users_username_lower'
+	sqliteConstraint = regexp.MustCompile(`UNIQUE constraint failed: (?:index '([^']+)'|([\w.]+))`)
+)
+
+// translate แปลง Unique Violation ของทุก Driver ที่รองรับเป็น *ConflictError (Error อื่นคืนตามเดิม)
+func translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	name, ok := uniqueViolation(err)
+	if !ok {
+		return err
+	}
+	return &ConflictError{Field: fieldForConstraint(name), Err: err}
+}
+
+// uniqueViolation คืนชื่อ Constraint / Column ที่ชน
+func uniqueViolation(err error) (string, bool) {
+	// pgx (*pgconn.PgError) มี SQLState() ไม่ต้อง Import Driver
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		if pgErr.SQLState() != pgUniqueViolation {
+			return "", false
+		}
+		if m := pgConstraint.FindStringSubmatch(err.Error()); m != nil {
+			return m[1], true
+		}
+		return "", true
+	}
+
+	if m := sqliteConstraint.FindStringSubmatch(err.Error()); m != nil {
+		if m[1] != "" {
+			return m[1], true
+		}
+		return m[2], true
+	}
+	return "", false
+}
+
+// fieldForConstraint หาชื่อ Field จากตาราง ถ้าไม่รู้จักแต่เป็นรูป table.column ใช้ชื่อ column
+func fieldForConstraint(name string) string {
+	if field, ok := uniqueFields[name]; ok {
+		return field
+	}
+	if _, column, ok := strings.Cut(name, "."); ok {
+		return column
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"worm/derived"
+	"worm/models"
+	"worm/repository"
+	"worm/services"
+)
+
+const sensorUsage = `usage: worm sensor <command> [flags]
+
+commands:
+  export   write all sensor readings to stdout (or -o file) as CSV or JSON
+  prune    delete readings older than -older-than (e.g. 720h for 30 days)
+
+Both commands cover every organization unless -org is given.
+`
+
+// runSensor คำสั่ง `worm sensor export|prune`
+func runSensor(args []string) {
+	fs, configFile := subcommand("sensor", sensorUsage)
+	format := fs.String("format", "csv", "export format: csv or json (export)")
+	output := fs.String("o", "", "write to this file instead of stdout (export)")
+	olderThan := fs.Duration("older-than", 0, "delete readings older than this duration, e.g. 720h (prune)")
+	org := fs.String("org", "", "only readings of this organization (default: all organizations)")
+	if len(args) == 0 {
+		usageError(fs, "")
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+
+	switch command {
+	case "export":
+		if *format != "csv" && *format != "json" {
+			usageError(fs, "sensor export: unknown format %q", *format)
+		}
+	case "prune":
+		if *olderThan <= 0 {
+			usageError(fs, "sensor prune: -older-than is required")
+		}
+	default:
+		usageError(fs, "unknown sensor command %q", command)
+	}
+
+	ctx := cliContext()
+	_, _, store := openStore(*configFile)
+	orgID, err := organizationID(ctx, store, *org)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if orgID != nil {
+		ctx = repository.OrganizationTenant(ctx, *orgID)
+	}
+	sensors := services.NewSensorService(store, derived.Default, nil)
+
+	if command == "prune" {
+		before := time.Now().Add(-*olderThan)
+		deleted, err := sensors.PruneSensorData(ctx, before)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Deleted %d readings recorded before %s\n", deleted, before.Format(time.RFC3339))
+		return
+	}
+
+	data, err := sensors.GetAllSensorData(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := exportSensorData(w, *format, data, derived.Default.Names()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// exportSensorData เขียนข้อมูลเป็น JSON หรือ CSV (ค่าที่คำนวณตอนบันทึกเป็น Column ตาม derivedNames ว่าง = ไม่มีค่า)
+func exportSensorData(w io.Writer, format string, data []models.SensorData, derivedNames []string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"id", "created_at", "temp", "humidity", "organization_id", "device_id", "raw_temp", "raw_humidity"}, derivedNames...))
+	for _, d := range data {
+		deviceID := ""
+		if d.DeviceID != nil {
+			deviceID = strconv.FormatUint(uint64(*d.DeviceID), 10)
+		}
+		row := []string{
+			strconv.FormatUint(uint64(d.ID), 10),
+			d.CreatedAt.Format(time.RFC3339),
+			strconv.FormatFloat(d.Temperature, 'f', -1, 64),
+			strconv.FormatFloat(d.Humidity, 'f', -1, 64),
+			strconv.FormatUint(uint64(d.OrganizationID), 10),
+			deviceID,
+			strconv.FormatFloat(d.RawTemperature, 'f', -1, 64),
+			strconv.FormatFloat(d.RawHumidity, 'f', -1, 64),
+		}
+		for _, name := range derivedNames {
+			value := ""
+			if v, ok := d.Derived[name]; ok {
+				value = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+			row = append(row, value)
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"worm/config"
+	"worm/controllers"
+	"worm/logging"
+	"worm/metrics"
+	"worm/migrations"
+	"worm/ratelimit"
+	"worm/repository"
+	"worm/server"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runServe คำสั่ง `worm serve` (ค่าเริ่มต้นเมื่อไม่ระบุคำสั่ง) เปิด HTTP API
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+
+	// 0. โหลด Config (ค่าเริ่มต้น -> ไฟล์ -> .env -> Env) Flag ทับได้อีกชั้น
+	configFile := fs.String("config", "", "path to a YAML or TOML config file (default: $CONFIG_FILE)")
+	adminUser := fs.String("admin-user", "", "username of the first superadmin (created only if no superadmin exists)")
+	adminPassword := fs.String("admin-password", "", "password of the first superadmin (random one-time password if empty)")
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Log แบบมีโครงสร้างผ่าน log/slog (ค่าลับถูกซ่อนใน logging.New) ใช้เป็น Default ของทั้ง Process
+	logger := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	slog.SetDefault(logger)
+	gin.DebugPrintRouteFunc = func(method, path, handler string, _ int) {
+		slog.Debug("route", "method", method, "path", path, "handler", handler)
+	}
+	if logging.ParseLevel(cfg.Log.Level) != slog.LevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	if *adminUser != "" {
+		cfg.Admin.Username = *adminUser
+	}
+	if *adminPassword != "" {
+		cfg.Admin.Password = *adminPassword
+	}
+
+	// 1. เชื่อมต่อฐานข้อมูล และไม่ยอมเริ่มถ้า Schema ยังไม่ได้ Migrate
+	db := config.ConnectDB(cfg)
+	migrator, err := migrations.New(db)
+	if err != nil {
+		fatal("failed to load migrations", err)
+	}
+	if err := migrator.Check(); err != nil {
+		fatal("database schema check failed", err)
+	}
+	store := repository.NewStore(db)
+	// Rate Limit นับในหน่วยความจำของ Instance นี้ เว้นแต่เลือกให้แชร์ผ่านฐานข้อมูล
+	if cfg.RateLimit.Backend == "memory" {
+		store.RateLimits = ratelimit.NewMemoryStore()
+	}
+
+	// 2. ระบบ Auto Create First Superadmin (ถ้าไม่มี Superadmin เลย)
+	// ถ้าไม่กำหนดรหัสผ่านจะสุ่มให้และบังคับเปลี่ยนเมื่อล็อกอินครั้งแรก
+	users := services.NewUserService(store, cfg.Auth.BcryptCost)
+	generatedPw, err := users.BootstrapAdmin(repository.AllTenants(context.Background()), cfg.Admin, cfg.IsProduction())
+	if err != nil {
+		fatal("admin bootstrap failed", err)
+	}
+	if generatedPw != "" {
+		showBootstrapPassword(cfg.Admin.Username, generatedPw)
+	}
+
+	// 3. SSO Login (เปิดเมื่อตั้ง oidc.issuer)
+	oidcAuth, err := controllers.NewOIDCAuth(context.Background(), cfg.OIDC)
+	if err != nil {
+		fatal("failed to set up OIDC", err)
+	}
+
+	// 4. เริ่มต้น Server (/readyz ตรวจฐานข้อมูล, Migration และ Worker)
+	limiter := ratelimit.New(store.RateLimits)
+	actuators := services.NewActuatorService(store, cfg.Actuator)
+	automation := services.NewAutomationService(store, actuators, cfg.Automation)
+	schedules := services.NewScheduleService(store, actuators, cfg.Schedule)
+	alerts := services.NewAlertQueue(cfg.Anomaly)
+	workers := server.NewWorkerPool(
+		pruneRateLimits(limiter),
+		expireCommands(actuators),
+		evaluateRules(automation, time.Duration(cfg.Automation.Interval)),
+		runSchedules(schedules, time.Duration(cfg.Schedule.Interval)),
+		deliverAlerts(alerts),
+	)
+	m := metrics.New()
+	if sqlDB, err := db.DB(); err == nil {
+		m.RegisterDBStats(sqlDB.Stats)
+	}
+	srv := server.NewServer(cfg, server.Deps{
+		Store:      store,
+		OIDC:       oidcAuth,
+		Metrics:    m,
+		Logger:     logger,
+		Limiter:    limiter,
+		Alerts:     alerts,
+		Actuators:  actuators,
+		Automation: automation,
+		Schedules:  schedules,
+		ReadyChecks: []controllers.ReadyCheck{
+			{Name: "database", Check: func(ctx context.Context) error { return config.PingDB(ctx, db) }},
+			{Name: "migrations", Check: func(context.Context) error { return migrator.Check() }},
+			{Name: "workers", Check: workers.Check},
+		},
+	})
+
+	// Run Server จนกว่าจะได้รับ SIGINT / SIGTERM แล้วค่อยๆ ปิด (Request ที่ค้างอยู่ทำต่อจนเสร็จ)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := server.Run(ctx, srv, time.Duration(cfg.Server.ShutdownTimeout), workers); err != nil {
+		slog.Error("server error", "error", err)
+	}
+
+	// 5. ปิดการเชื่อมต่อฐานข้อมูลหลังไม่มี Request ค้างแล้ว
+	if err := config.CloseDB(db); err != nil {
+		slog.Error("failed to close database", "error", err)
+	}
+	slog.Info("server stopped")
+}
+
+// pruneRateLimits Worker ที่ลบ Bucket ของ Rate Limit / Quota ที่ไม่ถูกใช้แล้วทุกชั่วโมง
+func pruneRateLimits(limiter *ratelimit.Limiter) server.Worker {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := limiter.Prune(ctx); err != nil {
+					slog.Warn("failed to prune rate limit buckets", "error", err)
+				} else if n > 0 {
+					slog.Debug("pruned rate limit buckets", "deleted", n)
+				}
+			}
+		}
+	}
+}
+
+// expireCommands Worker ที่ปิดคำสั่งถึง Actuator ที่ Device ไม่ Poll / ไม่ยืนยันทันเวลาทุก 30 วินาที
+func expireCommands(actuators *services.ActuatorService) server.Worker {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := actuators.ExpireCommands(repository.AllTenants(ctx)); err != nil {
+					slog.Warn("failed to expire actuator commands", "error", err)
+				} else if n > 0 {
+					slog.Info("expired actuator commands", "count", n)
+				}
+			}
+		}
+	}
+}
+
+// evaluateRules Worker ที่ประเมิน Automation Rule ทุก interval (Rule ทำงานได้แม้ Device หยุดส่งค่าใหม่)
+func evaluateRules(automation *services.AutomationService, interval time.Duration) server.Worker {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := automation.EvaluateAll(repository.AllTenants(ctx)); err != nil {
+					slog.Warn("failed to evaluate automation rules", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// runSchedules Worker ที่ทำ Schedule ที่ถึงเวลาทุก interval
+// รอบที่ถึงเวลาระหว่าง Server ปิดจะถูกทำ (หรือข้าม) ในรอบแรกหลังเริ่มใหม่
+func runSchedules(schedules *services.ScheduleService, interval time.Duration) server.Worker {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := schedules.RunDue(repository.AllTenants(ctx)); err != nil {
+					slog.Warn("failed to run schedules", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// deliverAlerts Worker ที่ส่ง Anomaly Alert ในคิวไปที่ anomaly.alert_webhook ทีละตัว
+func deliverAlerts(alerts *services.AlertQueue) server.Worker {
+	return func(ctx context.Context) {
+		for {
+			alert, ok := alerts.Next(ctx)
+			if !ok {
+				return
+			}
+			if err := alerts.Deliver(ctx, alert); err != nil {
+				slog.Warn("failed to deliver anomaly alert", "device", alert.Device, "error", err)
+			}
+		}
+	}
+}
+
+// fatal เขียน Error ลง Log แล้วจบ Process
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// showBootstrapPassword แสดงรหัสผ่านของ Superadmin คนแรกบน Terminal เท่านั้น (ห้ามลง Log)
+// ถ้ารันแบบไม่มี Terminal (Container / systemd) ให้ผู้ดูแลตั้งรหัสใหม่ด้วยคำสั่ง CLI แทน
+func showBootstrapPassword(username, password string) {
+	if info, err := os.Stdout.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		slog.Warn("no superadmin found, created first superadmin with a random password that is not logged; "+
+			"set one with `worm user reset-password "+username+"`", "username", username)
+		return
+	}
+	fmt.Println("==================================================")
+	fmt.Println("⚠️  NO SUPERADMIN FOUND -> CREATED FIRST SUPERADMIN")
+	fmt.Println("One-time password (must be changed at first login):")
+	fmt.Println(password)
+	fmt.Println("==================================================")
+}
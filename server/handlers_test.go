@@ -0,0 +1,498 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"worm/config"
+	"worm/migrations"
+	"worm/models"
+	"worm/repository"
+	"worm/repository/memory"
+	"worm/services"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// storeFactories Store ที่ชุดทดสอบ Handler รันด้วย แต่ละ Test ได้ Store ใหม่
+var storeFactories = []struct {
+	name string
+	open func(t *testing.T) *repository.Store
+}{
+	{"sqlite", openSQLiteStore},
+	// ไม่ต้องมีฐานข้อมูล
+	{"memory", func(*testing.T) *repository.Store { return memory.NewStore() }},
+}
+
+// openSQLiteStore SQLite ในหน่วยความจำที่ผ่าน Migration ทั้งหมดแล้ว
+func openSQLiteStore(t *testing.T) *repository.Store {
+	t.Helper()
+	db, err := config.OpenDB("sqlite://:memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = config.CloseDB(db) })
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return repository.NewStore(db)
+}
+
+// eachStore รัน fn กับทุก Store ใน storeFactories
+func eachStore(t *testing.T, fn func(t *testing.T, f *fixture)) {
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			fn(t, newFixture(t, factory.open(t)))
+		})
+	}
+}
+
+// fixture Server ที่มี Superadmin, องค์กร "Farm A" และ Admin / User ขององค์กรนั้น
+type fixture struct {
+	*testServer
+	root, admin, user string
+	orgID             uint
+}
+
+func newFixture(t *testing.T, store *repository.Store) *fixture {
+	t.Helper()
+	s := newTestServer(t, store, nil)
+	users := services.NewUserService(store, bcrypt.MinCost)
+	if _, err := users.CreateUser(repository.AllTenants(context.Background()), "root", "root-password", models.RoleSuperadmin, nil); err != nil {
+		t.Fatalf("create superadmin: %v", err)
+	}
+	f := &fixture{testServer: s, root: s.login("root", "root-password")}
+	f.orgID = f.createOrganization("Farm A")
+	f.admin = f.register(f.root, "farm-a-admin", models.RoleAdmin, f.orgID)
+	f.user = f.register(f.admin, "farm-a-staff", models.RoleUser, 0)
+	return f
+}
+
+// createOrganization สร้างองค์กรผ่าน API (Superadmin) คืน ID
+func (f *fixture) createOrganization(name string) uint {
+	f.t.Helper()
+	w := f.do(http.MethodPost, "/api/organizations", f.root, map[string]string{"name": name})
+	expect(f.t, w, http.StatusOK, "create organization "+name)
+	var org models.Organization
+	decode(f.t, w, &org)
+	return org.ID
+}
+
+// register สร้าง User ผ่าน /api/register คืน API Key (orgID 0 = องค์กรของผู้สร้าง)
+func (f *fixture) register(apiKey, username, role string, orgID uint) string {
+	f.t.Helper()
+	body := map[string]interface{}{"username": username, "password": "password-" + username, "role": role}
+	if orgID != 0 {
+		body["organization_id"] = orgID
+	}
+	w := f.do(http.MethodPost, "/api/register", apiKey, body)
+	expect(f.t, w, http.StatusOK, "register "+username)
+	var resp struct {
+		APIKey string `json:"api_key"`
+	}
+	decode(f.t, w, &resp)
+	return resp.APIKey
+}
+
+// ingest ส่งค่า Sensor ในนามของ Device
+func (f *fixture) ingest(apiKey, device string, temp, humidity float64) {
+	f.t.Helper()
+	w := f.do(http.MethodPost, "/api/sensor", apiKey, map[string]float64{"temp": temp, "humidity": humidity}, "X-Device-ID", device)
+	expect(f.t, w, http.StatusOK, "ingest from "+device)
+}
+
+// deviceID หา ID ของ Device จาก GET /api/devices
+func (f *fixture) deviceID(apiKey, name string) uint {
+	f.t.Helper()
+	w := f.do(http.MethodGet, "/api/devices", apiKey, nil)
+	expect(f.t, w, http.StatusOK, "list devices")
+	var resp struct {
+		Devices []models.Device `json:"devices"`
+	}
+	decode(f.t, w, &resp)
+	for _, d := range resp.Devices {
+		if d.Name == name {
+			return d.ID
+		}
+	}
+	f.t.Fatalf("device %s not listed", name)
+	return 0
+}
+
+// createLocation สร้าง Location (parentID 0 = ชั้นบนสุด) คืน ID
+func (f *fixture) createLocation(name, kind string, parentID uint) uint {
+	f.t.Helper()
+	body := map[string]interface{}{"name": name, "kind": kind}
+	if parentID != 0 {
+		body["parent_id"] = parentID
+	}
+	w := f.do(http.MethodPost, "/api/locations", f.admin, body)
+	expect(f.t, w, http.StatusOK, "create location "+name)
+	var loc models.Location
+	decode(f.t, w, &loc)
+	return loc.ID
+}
+
+// createActuator เพิ่ม Actuator ให้ Device คืน ID
+func (f *fixture) createActuator(deviceID uint, name string) uint {
+	f.t.Helper()
+	w := f.do(http.MethodPost, "/api/actuators", f.admin, map[string]interface{}{"device_id": deviceID, "name": name, "kind": "mister", "channel": 0})
+	expect(f.t, w, http.StatusOK, "create actuator "+name)
+	var actuator models.Actuator
+	decode(f.t, w, &actuator)
+	return actuator.ID
+}
+
+func TestUserHandlers(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		// ชื่อซ้ำแบบไม่สนตัวพิมพ์
+		w := f.do(http.MethodPost, "/api/register", f.admin, map[string]string{"username": "FARM-A-STAFF", "password": "password-1", "role": "user"})
+		expectProblem(t, w, http.StatusConflict, "conflict", "duplicate username")
+		w = f.do(http.MethodPost, "/api/register", f.admin, map[string]string{"username": "x", "password": "password-1", "role": "owner"})
+		expect(t, w, http.StatusBadRequest, "invalid username and role")
+
+		expectProblem(t, f.do(http.MethodGet, "/api/users", f.user, nil), http.StatusForbidden, "forbidden", "user lists users")
+
+		w = f.do(http.MethodGet, "/api/users", f.admin, nil)
+		expect(t, w, http.StatusOK, "admin lists users")
+		// API Key ข้าม 2FA ได้ Admin จึงห้ามเห็น Key ของคนอื่น
+		if strings.Contains(w.Body.String(), "api_key") {
+			t.Fatalf("user list exposes API keys: %s", w.Body.String())
+		}
+		var list struct {
+			Users []models.User `json:"users"`
+		}
+		decode(t, w, &list)
+		if len(list.Users) != 2 {
+			t.Fatalf("admin sees %d users, want the 2 in Farm A", len(list.Users))
+		}
+
+		var staff models.User
+		for _, u := range list.Users {
+			if u.Username == "farm-a-staff" {
+				staff = u
+			}
+		}
+		w = f.do(http.MethodPut, fmt.Sprintf("/api/users/%d", staff.ID), f.admin, map[string]string{"role": "admin"})
+		expect(t, w, http.StatusOK, "promote user")
+		if strings.Contains(w.Body.String(), "api_key") {
+			t.Fatalf("updated user exposes the API key: %s", w.Body.String())
+		}
+		// Admin ของ Farm A ได้สิทธิ์ Admin ทันที
+		expect(t, f.do(http.MethodGet, "/api/users", f.user, nil), http.StatusOK, "promoted user lists users")
+
+		w = f.do(http.MethodPost, "/api/me/password", f.user, map[string]string{"current_password": "wrong", "new_password": "new-password-1"})
+		expect(t, w, http.StatusUnauthorized, "change password with the wrong current password")
+		w = f.do(http.MethodPost, "/api/me/password", f.user, map[string]string{"current_password": "password-farm-a-staff", "new_password": "new-password-1"})
+		expect(t, w, http.StatusOK, "change password")
+		f.login("farm-a-staff", "new-password-1")
+
+		w = f.do(http.MethodGet, "/api/audit", f.admin, nil)
+		expect(t, w, http.StatusOK, "audit log")
+		expectProblem(t, f.do(http.MethodGet, "/api/users", "not-a-key", nil), http.StatusUnauthorized, "unauthorized", "unknown API key")
+	})
+}
+
+func TestSensorHandlers(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		f.ingest(f.user, "bin-1", 24.5, 70)
+		f.ingest(f.user, "bin-1", 25.5, 72)
+
+		w := f.do(http.MethodGet, "/api/sensor", f.user, nil)
+		expect(t, w, http.StatusOK, "list readings")
+		var readings struct {
+			Data []models.SensorData `json:"data"`
+		}
+		decode(t, w, &readings)
+		if len(readings.Data) != 2 {
+			t.Fatalf("got %d readings, want 2", len(readings.Data))
+		}
+		if r := readings.Data[0]; r.OrganizationID != f.orgID || r.DeviceID == nil || r.Derived["dew_point_c"] == 0 {
+			t.Fatalf("reading not attributed to the device and organization or missing derived metrics: %+v", r)
+		}
+		if f.deviceID(f.user, "bin-1") == 0 {
+			t.Fatal("device bin-1 has no ID")
+		}
+
+		w = f.do(http.MethodPost, "/api/sensor", f.user, map[string]float64{"temp": 150, "humidity": 50}, "X-Device-ID", "bin-1")
+		expectProblem(t, w, http.StatusBadRequest, "reading_out_of_range", "temperature out of range")
+		w = f.do(http.MethodPost, "/api/sensor", f.user, "not an object", "X-Device-ID", "bin-1")
+		expect(t, w, http.StatusBadRequest, "invalid body")
+
+		expect(t, f.do(http.MethodGet, "/api/anomalies", f.user, nil), http.StatusOK, "list anomalies")
+		expectProblem(t, f.do(http.MethodGet, "/api/anomalies?device_id=9999", f.user, nil), http.StatusNotFound, "not_found", "anomalies of an unknown device")
+	})
+}
+
+func TestLocationHandlers(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		shed := f.createLocation("Shed 1", "shed", 0)
+		bin := f.createLocation("Bin 1", "bin", shed)
+		w := f.do(http.MethodPost, "/api/locations", f.admin, map[string]interface{}{"name": "bin 1", "kind": "bin", "parent_id": shed})
+		expectProblem(t, w, http.StatusConflict, "conflict", "duplicate sibling name")
+		expectProblem(t, f.do(http.MethodPost, "/api/locations", f.user, map[string]string{"name": "Shed 2", "kind": "shed"}), http.StatusForbidden, "forbidden", "user creates location")
+
+		f.ingest(f.user, "bin-1", 20, 60)
+		device := f.deviceID(f.user, "bin-1")
+		w = f.do(http.MethodPut, fmt.Sprintf("/api/devices/%d/location", device), f.admin, map[string]interface{}{"location_id": bin})
+		expect(t, w, http.StatusOK, "assign device")
+		f.ingest(f.user, "bin-1", 24, 70)
+		f.ingest(f.user, "bin-1", 26, 74)
+
+		w = f.do(http.MethodGet, fmt.Sprintf("/api/locations/%d/stats?interval=1h", shed), f.user, nil)
+		expect(t, w, http.StatusOK, "shed stats")
+		var stats models.LocationStats
+		decode(t, w, &stats)
+		if stats.Summary.Count != 2 || stats.Summary.TemperatureAvg == nil || *stats.Summary.TemperatureAvg != 25 {
+			t.Fatalf("shed summary counts readings from before the assignment or averages wrongly: %+v", stats.Summary)
+		}
+		if len(stats.Children) != 1 || stats.Children[0].Summary.Count != 2 || len(stats.Series) == 0 {
+			t.Fatalf("children / series: %+v", stats)
+		}
+		expect(t, f.do(http.MethodGet, fmt.Sprintf("/api/locations/%d/stats?interval=soon", shed), f.user, nil), http.StatusBadRequest, "invalid interval")
+		expect(t, f.do(http.MethodGet, fmt.Sprintf("/api/devices/%d/assignments", device), f.user, nil), http.StatusOK, "assignment history")
+
+		w = f.do(http.MethodPost, fmt.Sprintf("/api/locations/%d/events", bin), f.user, map[string]interface{}{"kind": "feeding", "feed_type": "vegetable scraps", "weight_kg": 2.5})
+		expect(t, w, http.StatusOK, "record feeding")
+		var event models.HusbandryEvent
+		decode(t, w, &event)
+		expect(t, f.do(http.MethodGet, fmt.Sprintf("/api/locations/%d/events", bin), f.user, nil), http.StatusOK, "list events")
+
+		w = f.do(http.MethodGet, fmt.Sprintf("/api/locations/%d/timeline", shed), f.user, nil)
+		expect(t, w, http.StatusOK, "timeline")
+		var timeline struct {
+			Timeline []models.TimelineEntry `json:"timeline"`
+		}
+		decode(t, w, &timeline)
+		if len(timeline.Timeline) != 3 {
+			t.Fatalf("timeline has %d entries, want 2 readings and 1 event", len(timeline.Timeline))
+		}
+
+		expect(t, f.do(http.MethodDelete, fmt.Sprintf("/api/events/%d", event.ID), f.admin, nil), http.StatusOK, "delete event")
+		w = f.do(http.MethodPut, fmt.Sprintf("/api/locations/%d", shed), f.admin, map[string]string{"name": "Shed 1B"})
+		expect(t, w, http.StatusOK, "rename location")
+		expect(t, f.do(http.MethodDelete, fmt.Sprintf("/api/locations/%d", shed), f.admin, nil), http.StatusConflict, "delete a location with children")
+		expect(t, f.do(http.MethodGet, "/api/locations", f.user, nil), http.StatusOK, "list locations")
+		expectProblem(t, f.do(http.MethodGet, "/api/locations/9999/stats", f.user, nil), http.StatusNotFound, "not_found", "unknown location")
+	})
+}
+
+func TestActuatorHandlers(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		f.ingest(f.user, "bin-1", 24, 70)
+		actuator := f.createActuator(f.deviceID(f.user, "bin-1"), "Mister 1")
+
+		w := f.do(http.MethodPost, fmt.Sprintf("/api/actuators/%d/commands", actuator), f.user, map[string]interface{}{"action": "on", "duration_seconds": 120})
+		expect(t, w, http.StatusOK, "send command")
+		w = f.do(http.MethodPost, fmt.Sprintf("/api/actuators/%d/commands", actuator), f.user, map[string]interface{}{"action": "off", "duration_seconds": 120})
+		expect(t, w, http.StatusBadRequest, "off with a duration")
+
+		// Device อื่นไม่ได้คำสั่งของ bin-1
+		w = f.do(http.MethodGet, "/api/device/commands", f.user, nil, "X-Device-ID", "bin-2")
+		expect(t, w, http.StatusOK, "poll from another device")
+		var polled struct {
+			Commands []models.ActuatorCommand `json:"commands"`
+		}
+		decode(t, w, &polled)
+		if len(polled.Commands) != 0 {
+			t.Fatalf("bin-2 received %d commands meant for bin-1", len(polled.Commands))
+		}
+
+		w = f.do(http.MethodGet, "/api/device/commands", f.user, nil, "X-Device-ID", "bin-1")
+		expect(t, w, http.StatusOK, "poll")
+		decode(t, w, &polled)
+		if len(polled.Commands) != 1 || polled.Commands[0].Action != "on" {
+			t.Fatalf("poll returned %+v, want the queued on command", polled.Commands)
+		}
+		cmd := polled.Commands[0].ID
+		w = f.do(http.MethodPost, fmt.Sprintf("/api/device/commands/%d/ack", cmd), f.user, map[string]string{"status": "acknowledged"}, "X-Device-ID", "bin-1")
+		expect(t, w, http.StatusOK, "acknowledge")
+		var acked models.ActuatorCommand
+		decode(t, w, &acked)
+		if acked.Status != models.CommandAcknowledged {
+			t.Fatalf("status after ack %q", acked.Status)
+		}
+
+		expect(t, f.do(http.MethodGet, fmt.Sprintf("/api/actuators/%d/commands", actuator), f.user, nil), http.StatusOK, "command history")
+		w = f.do(http.MethodPut, fmt.Sprintf("/api/actuators/%d/override", actuator), f.user, map[string]interface{}{"enabled": true, "duration_seconds": 3600})
+		expect(t, w, http.StatusOK, "override")
+		w = f.do(http.MethodPut, fmt.Sprintf("/api/actuators/%d", actuator), f.admin, map[string]interface{}{"channel": 1})
+		expect(t, w, http.StatusOK, "update actuator")
+		expect(t, f.do(http.MethodGet, "/api/actuators", f.user, nil), http.StatusOK, "list actuators")
+		// Actuator ที่มีประวัติคำสั่งลบไม่ได้ ตัวที่ยังไม่เคยสั่งลบได้
+		expectProblem(t, f.do(http.MethodDelete, fmt.Sprintf("/api/actuators/%d", actuator), f.admin, nil), http.StatusConflict, "conflict", "delete actuator with history")
+		unused := f.createActuator(f.deviceID(f.user, "bin-1"), "Fan 1")
+		expect(t, f.do(http.MethodDelete, fmt.Sprintf("/api/actuators/%d", unused), f.admin, nil), http.StatusOK, "delete actuator")
+		expectProblem(t, f.do(http.MethodGet, fmt.Sprintf("/api/actuators/%d/commands", unused), f.user, nil), http.StatusNotFound, "not_found", "deleted actuator")
+	})
+}
+
+func TestAutomationAndScheduleHandlers(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		shed := f.createLocation("Shed 1", "shed", 0)
+		f.ingest(f.user, "bin-1", 24, 70)
+		actuator := f.createActuator(f.deviceID(f.user, "bin-1"), "Mister 1")
+
+		rule := map[string]interface{}{
+			"name": "Shed humidity", "mode": "dry_run", "location_id": shed, "metric": "humidity", "operator": "below",
+			"threshold": 65, "hysteresis": 2, "cooldown_seconds": 900, "actuator_id": actuator,
+			"command": map[string]interface{}{"action": "on", "duration_seconds": 60},
+		}
+		w := f.do(http.MethodPost, "/api/automation/rules", f.admin, rule)
+		expect(t, w, http.StatusOK, "create rule")
+		var created models.AutomationRule
+		decode(t, w, &created)
+		rule["cooldown_seconds"] = 5
+		expect(t, f.do(http.MethodPost, "/api/automation/rules", f.admin, rule), http.StatusBadRequest, "cooldown too short")
+
+		w = f.do(http.MethodPut, fmt.Sprintf("/api/automation/rules/%d", created.ID), f.admin, map[string]interface{}{"mode": "active", "threshold": 60})
+		expect(t, w, http.StatusOK, "update rule")
+		expect(t, f.do(http.MethodGet, "/api/automation/rules", f.user, nil), http.StatusOK, "list rules")
+		expect(t, f.do(http.MethodGet, fmt.Sprintf("/api/automation/rules/%d/log", created.ID), f.user, nil), http.StatusOK, "rule log")
+		expect(t, f.do(http.MethodDelete, fmt.Sprintf("/api/automation/rules/%d", created.ID), f.admin, nil), http.StatusOK, "delete rule")
+
+		schedule := map[string]interface{}{
+			"name": "Morning mist", "cron": "0 6 * * *", "time_zone": "Asia/Bangkok", "job": "actuator_command",
+			"actuator_id": actuator, "command": map[string]interface{}{"action": "on", "duration_seconds": 300},
+		}
+		w = f.do(http.MethodPost, "/api/schedules", f.admin, schedule)
+		expect(t, w, http.StatusOK, "create schedule")
+		var sched models.Schedule
+		decode(t, w, &sched)
+		if sched.NextRunAt == nil {
+			t.Fatal("schedule has no next run")
+		}
+		schedule["cron"] = "61 * * * *"
+		expect(t, f.do(http.MethodPost, "/api/schedules", f.admin, schedule), http.StatusBadRequest, "invalid cron")
+
+		w = f.do(http.MethodPut, fmt.Sprintf("/api/schedules/%d", sched.ID), f.admin, map[string]interface{}{"enabled": false})
+		expect(t, w, http.StatusOK, "disable schedule")
+		expect(t, f.do(http.MethodGet, "/api/schedules", f.user, nil), http.StatusOK, "list schedules")
+		expect(t, f.do(http.MethodGet, fmt.Sprintf("/api/schedules/%d/runs", sched.ID), f.user, nil), http.StatusOK, "schedule runs")
+		expect(t, f.do(http.MethodDelete, fmt.Sprintf("/api/schedules/%d", sched.ID), f.admin, nil), http.StatusOK, "delete schedule")
+	})
+}
+
+func TestRuleStateSavesAreVersioned(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		ctx := repository.AllTenants(context.Background())
+		shed := f.createLocation("Shed 1", "shed", 0)
+		f.ingest(f.user, "bin-1", 24, 70)
+		actuator := f.createActuator(f.deviceID(f.user, "bin-1"), "Mister 1")
+		w := f.do(http.MethodPost, "/api/automation/rules", f.admin, map[string]interface{}{
+			"name": "Shed humidity", "mode": "active", "location_id": shed, "metric": "humidity", "operator": "below",
+			"threshold": 65, "for_seconds": 600, "cooldown_seconds": 900, "actuator_id": actuator,
+			"command": map[string]interface{}{"action": "on"},
+		})
+		expect(t, w, http.StatusOK, "create rule")
+		var created models.AutomationRule
+		decode(t, w, &created)
+		load := func() *models.AutomationRule {
+			rule, err := f.store.Rules.FindByID(ctx, created.ID)
+			if err != nil {
+				t.Fatalf("load rule: %v", err)
+			}
+			return rule
+		}
+
+		// Ingest และ Worker โหลด Rule เดียวกัน ฝั่งที่บันทึกทีหลังต้องไม่ทับ condition_since ของอีกฝั่ง
+		ingest, worker := load(), load()
+		since := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+		ingest.ConditionMet, ingest.ConditionSince = true, &since
+		if saved, err := f.store.Rules.SaveState(ctx, ingest); err != nil || !saved {
+			t.Fatalf("first save: saved %v, err %v", saved, err)
+		}
+		worker.ConditionMet, worker.ConditionSince = false, nil
+		if saved, err := f.store.Rules.SaveState(ctx, worker); err != nil || saved {
+			t.Fatalf("stale save: saved %v, err %v", saved, err)
+		}
+		got := load()
+		if !got.ConditionMet || got.ConditionSince == nil || !got.ConditionSince.Equal(since) {
+			t.Fatalf("stale save overwrote the state: %+v", got)
+		}
+
+		// แก้ค่าตั้งแล้ว การประเมินที่โหลดไว้ก่อนบันทึกไม่ได้
+		expect(t, f.do(http.MethodPut, fmt.Sprintf("/api/automation/rules/%d", created.ID), f.admin, map[string]interface{}{"threshold": 60}), http.StatusOK, "update rule")
+		if saved, err := f.store.Rules.SaveState(ctx, got); err != nil || saved {
+			t.Fatalf("save after update: saved %v, err %v", saved, err)
+		}
+		got = load()
+		if saved, err := f.store.Rules.SaveState(ctx, got); err != nil || !saved {
+			t.Fatalf("save after reload: saved %v, err %v", saved, err)
+		}
+	})
+}
+
+func TestCalibrationHandlers(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		f.ingest(f.user, "bin-1", 24, 70)
+		device := f.deviceID(f.user, "bin-1")
+
+		w := f.do(http.MethodPost, fmt.Sprintf("/api/devices/%d/calibrations", device), f.admin, map[string]interface{}{"metric": "temperature", "method": "offset", "offset": -1.5})
+		expect(t, w, http.StatusOK, "create calibration")
+		var cal models.Calibration
+		decode(t, w, &cal)
+		w = f.do(http.MethodPost, fmt.Sprintf("/api/devices/%d/calibrations", device), f.admin, map[string]interface{}{"metric": "temperature", "method": "offset", "offset": 80})
+		expect(t, w, http.StatusBadRequest, "offset out of range")
+
+		f.ingest(f.user, "bin-1", 30, 70)
+		w = f.do(http.MethodGet, "/api/sensor", f.user, nil)
+		var readings struct {
+			Data []models.SensorData `json:"data"`
+		}
+		decode(t, w, &readings)
+		var calibrated bool
+		for _, r := range readings.Data {
+			calibrated = calibrated || (r.RawTemperature == 30 && r.Temperature == 28.5)
+		}
+		if !calibrated {
+			t.Fatalf("calibration not applied at ingest: %+v", readings.Data)
+		}
+
+		expect(t, f.do(http.MethodGet, fmt.Sprintf("/api/devices/%d/calibrations", device), f.user, nil), http.StatusOK, "list calibrations")
+		w = f.do(http.MethodPost, fmt.Sprintf("/api/devices/%d/calibrations/recompute", device), f.admin, map[string]string{"from": "2020-01-01T00:00:00Z"})
+		expect(t, w, http.StatusOK, "recompute")
+		expect(t, f.do(http.MethodDelete, fmt.Sprintf("/api/calibrations/%d", cal.ID), f.admin, nil), http.StatusOK, "delete calibration")
+	})
+}
+
+func TestNewCalibrationClosesOpenEndedOne(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		f.ingest(f.user, "bin-1", 24, 70)
+		path := fmt.Sprintf("/api/devices/%d/calibrations", f.deviceID(f.user, "bin-1"))
+		create := func(from, until string, offset float64) *httptest.ResponseRecorder {
+			body := map[string]interface{}{"metric": "temperature", "method": "offset", "offset": offset, "valid_from": from}
+			if until != "" {
+				body["valid_until"] = until
+			}
+			return f.do(http.MethodPost, path, f.admin, body)
+		}
+
+		expect(t, create("2026-01-01T00:00:00Z", "", -1), http.StatusOK, "first calibration")
+		expect(t, create("2026-03-01T00:00:00Z", "", -2), http.StatusOK, "second calibration")
+		// ตัวแรกสิ้นสุดที่ valid_from ของตัวที่สองแล้ว จึงซ้อนกับช่วงนั้นไม่ได้
+		expectProblem(t, create("2026-02-01T00:00:00Z", "2026-02-15T00:00:00Z", -3), http.StatusConflict, "conflict", "calibration inside the closed range")
+
+		w := f.do(http.MethodGet, path, f.user, nil)
+		expect(t, w, http.StatusOK, "list calibrations")
+		var resp struct {
+			Calibrations []models.Calibration `json:"calibrations"`
+		}
+		decode(t, w, &resp)
+		if len(resp.Calibrations) != 2 {
+			t.Fatalf("want 2 calibrations, got %+v", resp.Calibrations)
+		}
+		first, second := resp.Calibrations[0], resp.Calibrations[1]
+		if first.ValidUntil == nil || !first.ValidUntil.Equal(second.ValidFrom) || second.ValidUntil != nil {
+			t.Fatalf("first calibration not closed at %v: %+v", second.ValidFrom, first)
+		}
+	})
+}
@@ -0,0 +1,252 @@
+// Package server ประกอบ Router ของ HTTP API จาก Config และ Dependency ที่ส่งเข้ามา
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"worm/apierror"
+	"worm/config"
+	"worm/controllers"
+	"worm/derived"
+	"worm/metrics"
+	"worm/middleware"
+	"worm/ratelimit"
+	"worm/repository"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+
+	// Import Swagger Files
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+
+	// Import Docs ที่ Gen แล้ว (สำคัญมาก)
+	_ "worm/docs"
+)
+
+// Deps สิ่งที่ Router ต้องใช้จากภายนอก (ทดสอบได้ด้วย memory.NewStore())
+type Deps struct {
+	Store *repository.Store
+	// nil = ปิด SSO
+	OIDC *controllers.OIDCAuth
+	// สิ่งที่ /readyz ตรวจ (ฐานข้อมูล, Migration, Worker)
+	ReadyChecks []controllers.ReadyCheck
+	// nil = สร้างใหม่ (ไม่มีสถิติ Connection Pool)
+	Metrics *metrics.Metrics
+	// nil = slog.Default()
+	Logger *slog.Logger
+	// nil = สร้างจาก Store.RateLimits
+	Limiter *ratelimit.Limiter
+	// คิวที่ Worker ส่ง Anomaly Alert ออกไป nil = ไม่ส่ง Alert
+	Alerts *services.AlertQueue
+	// Service ตัวเดียวกับที่ Worker ใช้ nil = สร้างใหม่จาก Store
+	Actuators  *services.ActuatorService
+	Automation *services.AutomationService
+	Schedules  *services.ScheduleService
+}
+
+// Path ที่ถูกเรียกถี่โดย Load Balancer / Orchestrator ไม่ต้อง Log ทุกครั้ง
+var quietPaths = []string{"/healthz", "/readyz", "/version", "/metrics"}
+
+// NewRouter สร้าง Service (ที่ Deps ไม่ได้ส่งมา) และ Handler ครั้งเดียว แล้วผูกกับ Route ทั้งหมด
+func NewRouter(cfg *config.Config, deps Deps) *gin.Engine {
+	m := deps.Metrics
+	if m == nil {
+		m = metrics.New()
+	}
+	logger := deps.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	limiter := deps.Limiter
+	if limiter == nil {
+		limiter = ratelimit.New(deps.Store.RateLimits)
+	}
+	limits := rateLimitRules(cfg.RateLimit)
+	auth := services.NewAuthService(deps.Store, cfg.Auth)
+	actuators := deps.Actuators
+	if actuators == nil {
+		actuators = services.NewActuatorService(deps.Store, cfg.Actuator)
+	}
+	automation := deps.Automation
+	if automation == nil {
+		automation = services.NewAutomationService(deps.Store, actuators, cfg.Automation)
+	}
+	schedules := deps.Schedules
+	if schedules == nil {
+		schedules = services.NewScheduleService(deps.Store, actuators, cfg.Schedule)
+	}
+	anomalies := services.NewAnomalyService(deps.Store, cfg.Anomaly, deps.Alerts)
+	h := &controllers.Handler{
+		Users:         services.NewUserService(deps.Store, cfg.Auth.BcryptCost),
+		Auth:          auth,
+		Organizations: services.NewOrganizationService(deps.Store.Organizations),
+		Sensors:       services.NewSensorService(deps.Store, derived.Default, anomalies, automation, anomalies),
+		Locations:     services.NewLocationService(deps.Store, derived.Default),
+		Actuators:     actuators,
+		Automation:    automation,
+		Schedules:     schedules,
+		Husbandry:     services.NewHusbandryService(deps.Store),
+		Calibrations:  services.NewCalibrationService(deps.Store, derived.Default),
+		Anomalies:     anomalies,
+		Metrics:       m,
+		OIDC:          deps.OIDC,
+		Config:        cfg,
+
+		ReadyChecks: deps.ReadyChecks,
+	}
+
+	// Error ของ Validator ใช้ชื่อ Field ตาม JSON
+	apierror.UseJSONFieldNames()
+
+	r := gin.New()
+	// ClientIP (Rate Limit ของ /login, Access Log) อ่าน X-Forwarded-For เฉพาะจาก Proxy ที่ตั้งไว้
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Error("invalid server.trusted_proxies, trusting no proxy", "error", err)
+		_ = r.SetTrustedProxies(nil)
+	}
+	// Errors ต้องอยู่ก่อน Recovery เพื่อเขียน Response ให้ทั้ง Error ปกติและ Panic
+	r.Use(middleware.RequestID(), middleware.AccessLog(logger, quietPaths...), middleware.Errors(), middleware.Recovery(logger))
+	r.Use(middleware.Metrics(m))
+
+	// CORS ตาม Config (Production ต้องระบุ Origin เอง)
+	r.Use(middleware.CORS(cfg.CORS))
+
+	// Route ที่ไม่มีอยู่จริงก็ตอบเป็น Problem เหมือนกัน
+	r.NoRoute(middleware.NotFound())
+
+	// --- Health (ไม่ต้องมี API Key) ---
+	r.GET("/healthz", h.HealthHandler)
+	r.GET("/readyz", h.ReadyHandler)
+	r.GET("/version", h.VersionHandler)
+	if cfg.Metrics.Enabled {
+		r.GET("/metrics", h.MetricsHandler)
+	}
+
+	// --- Public Routes ---
+
+	// Swagger Route
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	r.GET("/", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/swagger/index.html")
+	})
+
+	r.POST("/login", middleware.RateLimit(limiter, limits.login, middleware.ByClientIP, m), h.LoginHandler)
+
+	// SSO Login (เปิดเมื่อตั้ง oidc.issuer)
+	if h.OIDC != nil {
+		r.GET("/login/oidc", h.OIDCLoginHandler)
+		r.GET("/login/oidc/callback", h.OIDCCallbackHandler)
+	}
+
+	// --- Protected Routes (ต้องมี API Key, เห็นเฉพาะข้อมูลขององค์กรผู้เรียก) ---
+	protected := r.Group("/api")
+	protected.Use(middleware.RequireAPIKey(auth, m))
+	protected.Use(middleware.RateLimit(limiter, limits.api, middleware.ByUser, m))
+
+	// เปลี่ยนรหัสผ่านของตัวเอง (All Users)
+	protected.POST("/me/password", h.ChangePasswordHandler)
+
+	// 2FA (ทุก User จัดการของตัวเอง)
+	protected.POST("/2fa/enroll", h.EnrollTwoFactorHandler)
+	protected.POST("/2fa/confirm", h.ConfirmTwoFactorHandler)
+	protected.POST("/2fa/disable", h.DisableTwoFactorHandler)
+
+	// Sensor (All Users)
+	// จำกัดต่อ Device เพิ่มจากต่อ API Key (กัน Firmware ที่วนลูปส่งค่า)
+	protected.POST("/sensor",
+		middleware.Device(),
+		middleware.RateLimit(limiter, limits.ingest, middleware.ByDevice, m),
+		middleware.DailyQuota(limiter, "ingest", limits.dailyDeviceQuota, middleware.ByDevice, m),
+		h.AddSensorHandler,
+	)
+	protected.GET("/sensor", h.GetAllSensorHandler)
+	protected.GET("/devices", h.GetAllDevicesHandler)
+	protected.GET("/devices/:id/assignments", h.GetDeviceAssignmentsHandler)
+	protected.GET("/devices/:id/calibrations", h.GetCalibrationsHandler)
+	protected.GET("/anomalies", h.GetAnomaliesHandler)
+
+	// Location (ดูได้ทุก User แก้ไขเฉพาะ Admin)
+	protected.GET("/locations", h.GetAllLocationsHandler)
+	protected.GET("/locations/:id/stats", h.GetLocationStatsHandler)
+
+	// บันทึกการดูแล Bin (บันทึกได้ทุก User ลบเฉพาะ Admin)
+	protected.GET("/locations/:id/events", h.GetEventsHandler)
+	protected.POST("/locations/:id/events", h.RecordEventHandler)
+	protected.GET("/locations/:id/timeline", h.GetTimelineHandler)
+
+	// Actuator (ดูและสั่งได้ทุก User เพิ่ม/แก้ไขเฉพาะ Admin)
+	protected.GET("/actuators", h.GetAllActuatorsHandler)
+	protected.GET("/actuators/:id/commands", h.GetCommandHistoryHandler)
+	protected.POST("/actuators/:id/commands", h.SendCommandHandler)
+	protected.PUT("/actuators/:id/override", h.SetOverrideHandler)
+
+	// Automation Rule (ดูได้ทุก User แก้ไขเฉพาะ Admin)
+	protected.GET("/automation/rules", h.GetAllRulesHandler)
+	protected.GET("/automation/rules/:id/log", h.GetRuleLogHandler)
+
+	// Schedule (ดูได้ทุก User แก้ไขเฉพาะ Admin)
+	protected.GET("/schedules", h.GetAllSchedulesHandler)
+	protected.GET("/schedules/:id/runs", h.GetScheduleRunsHandler)
+
+	// ฝั่ง Device: Poll คำสั่งที่รออยู่แล้วรายงานผล (Device ระบุด้วย X-Device-ID เหมือนการส่งค่า)
+	protected.GET("/device/commands", middleware.Device(), h.PollCommandsHandler)
+	protected.POST("/device/commands/:id/ack", middleware.Device(), h.AcknowledgeCommandHandler)
+
+	// --- Admin Only (Admin ขององค์กร หรือ Superadmin) ---
+	admin := protected.Group("")
+	admin.Use(middleware.RequireAdmin())
+
+	admin.POST("/register", h.CreateUserHandler)
+	admin.GET("/users", h.GetAllUsersHandler)
+	admin.PUT("/users/:id", h.UpdateUserHandler)
+	// Reset 2FA ของ User อื่น
+	admin.DELETE("/users/:id/2fa", h.ResetTwoFactorHandler)
+	admin.GET("/audit", h.GetAuditLogsHandler)
+	admin.GET("/organizations", h.GetAllOrganizationsHandler)
+	admin.POST("/locations", h.CreateLocationHandler)
+	admin.PUT("/locations/:id", h.UpdateLocationHandler)
+	admin.DELETE("/locations/:id", h.DeleteLocationHandler)
+	admin.PUT("/devices/:id/location", h.AssignDeviceHandler)
+	admin.POST("/devices/:id/calibrations", h.CreateCalibrationHandler)
+	admin.POST("/devices/:id/calibrations/recompute", h.RecomputeCalibrationHandler)
+	admin.DELETE("/calibrations/:id", h.DeleteCalibrationHandler)
+	admin.POST("/actuators", h.CreateActuatorHandler)
+	admin.PUT("/actuators/:id", h.UpdateActuatorHandler)
+	admin.DELETE("/actuators/:id", h.DeleteActuatorHandler)
+	admin.POST("/automation/rules", h.CreateRuleHandler)
+	admin.PUT("/automation/rules/:id", h.UpdateRuleHandler)
+	admin.DELETE("/automation/rules/:id", h.DeleteRuleHandler)
+	admin.POST("/schedules", h.CreateScheduleHandler)
+	admin.PUT("/schedules/:id", h.UpdateScheduleHandler)
+	admin.DELETE("/schedules/:id", h.DeleteScheduleHandler)
+	admin.DELETE("/events/:id", h.DeleteEventHandler)
+
+	// --- Superadmin Only (ข้ามองค์กร) ---
+	superadmin := admin.Group("")
+	superadmin.Use(middleware.RequireSuperadmin())
+
+	superadmin.POST("/organizations", h.CreateOrganizationHandler)
+
+	return r
+}
+
+// rateLimits กติกาของแต่ละกลุ่ม Route
+type rateLimits struct {
+	login, api, ingest ratelimit.Rule
+	dailyDeviceQuota   int
+}
+
+// rateLimitRules แปลง rate_limit ใน Config เป็น Rule (ปิดทั้งหมด = ทุก Rule ไม่จำกัด)
+func rateLimitRules(cfg config.RateLimitConfig) rateLimits {
+	if !cfg.Enabled {
+		return rateLimits{}
+	}
+	return rateLimits{
+		login:  ratelimit.Rule{Name: "login", PerMinute: cfg.LoginPerMinute, Burst: cfg.LoginBurst},
+		api:    ratelimit.Rule{Name: "api", PerMinute: cfg.APIPerMinute, Burst: cfg.APIBurst},
+		ingest: ratelimit.Rule{Name: "ingest", PerMinute: cfg.IngestPerMinute, Burst: cfg.IngestBurst},
+
+		dailyDeviceQuota: cfg.DailyDeviceQuota,
+	}
+}
@@ -0,0 +1,164 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"worm/config"
+	"worm/repository"
+	"worm/repository/memory"
+	"worm/server"
+	"worm/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testServer Router เต็มชุดจาก server.NewRouter เรียกผ่าน httptest (ไม่เปิด Port)
+type testServer struct {
+	t      *testing.T
+	cfg    *config.Config
+	store  *repository.Store
+	router *gin.Engine
+}
+
+// newTestServer สร้าง Router บน store ด้วย Config เริ่มต้น (bcrypt ต่ำสุด, ปิด Rate Limit)
+// configure แก้ Config เพิ่มก่อนสร้าง Router (nil = ไม่แก้)
+func newTestServer(t *testing.T, store *repository.Store, configure func(*config.Config)) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Auth.BcryptCost = bcrypt.MinCost
+	cfg.RateLimit.Enabled = false
+	if configure != nil {
+		configure(cfg)
+	}
+	return &testServer{t: t, cfg: cfg, store: store, router: server.NewRouter(cfg, server.Deps{Store: store, Logger: slog.New(slog.DiscardHandler)})}
+}
+
+// do ส่ง Request (body nil = ไม่มี Body) headers เป็นคู่ชื่อ / ค่า
+func (s *testServer) do(method, path, apiKey string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if apiKey != "" {
+		req.Header.Set("X-API-KEY", apiKey)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// login ล็อกอินด้วยรหัสผ่านแล้วคืน API Key
+func (s *testServer) login(username, password string) string {
+	s.t.Helper()
+	w := s.do(http.MethodPost, "/login", "", map[string]string{"username": username, "password": password})
+	if w.Code != http.StatusOK {
+		s.t.Fatalf("login %s: status %d %s", username, w.Code, w.Body.String())
+	}
+	var resp struct {
+		APIKey string `json:"api_key"`
+	}
+	decode(s.t, w, &resp)
+	return resp.APIKey
+}
+
+// expect ตรวจ Status Code ของ Response
+func expect(t *testing.T, w *httptest.ResponseRecorder, status int, what string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("%s: status %d, want %d: %s", what, w.Code, status, w.Body.String())
+	}
+}
+
+// expectProblem ตรวจ Status และ code ของ Problem (application/problem+json)
+func expectProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code, what string) {
+	t.Helper()
+	expect(t, w, status, what)
+	var problem struct {
+		Code string `json:"code"`
+	}
+	decode(t, w, &problem)
+	if problem.Code != code {
+		t.Fatalf("%s: problem code %q, want %q", what, problem.Code, code)
+	}
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %s: %v", w.Body.String(), err)
+	}
+}
+
+func TestBootstrapAdminCanChangePasswordThenEnrollWhen2FARequired(t *testing.T) {
+	store := memory.NewStore()
+	s := newTestServer(t, store, func(cfg *config.Config) { cfg.Auth.RequireAdmin2FA = true })
+
+	// Superadmin คนแรกที่ไม่ได้ตั้งรหัสผ่าน = รหัสสุ่มที่ต้องเปลี่ยนก่อนใช้งาน
+	users := services.NewUserService(store, bcrypt.MinCost)
+	generated, err := users.BootstrapAdmin(repository.AllTenants(context.Background()), config.AdminConfig{Username: "admin"}, false)
+	if err != nil || generated == "" {
+		t.Fatalf("bootstrap: generated %q, err %v", generated, err)
+	}
+	key := s.login("admin", generated)
+
+	// ต้องเปลี่ยนรหัสผ่านก่อน แม้แต่ /api/2fa/*
+	expectProblem(t, s.do(http.MethodPost, "/api/2fa/enroll", key, nil), http.StatusForbidden, "password_change_required", "enroll before password change")
+
+	w := s.do(http.MethodPost, "/api/me/password", key, map[string]string{"current_password": generated, "new_password": "a-much-better-password"})
+	expect(t, w, http.StatusOK, "change password while 2FA setup is required")
+
+	// หลังเปลี่ยนรหัสผ่านเหลือขั้น 2FA: API อื่นยังใช้ไม่ได้ แต่ Enroll ได้
+	expectProblem(t, s.do(http.MethodGet, "/api/users", key, nil), http.StatusForbidden, "two_factor_setup_required", "api before 2FA setup")
+	expect(t, s.do(http.MethodPost, "/api/2fa/enroll", key, nil), http.StatusOK, "enroll after password change")
+}
+
+func TestSpoofedForwardedForDoesNotResetLoginLimit(t *testing.T) {
+	s := newTestServer(t, memory.NewStore(), func(cfg *config.Config) {
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.LoginBurst = 3
+	})
+	body := map[string]string{"username": "nobody", "password": "wrong-password"}
+
+	// ทุก Request มาจาก IP เดียวกัน (httptest) แต่เปลี่ยน X-Forwarded-For ทุกครั้ง
+	for i := 0; i < 3; i++ {
+		expect(t, s.do(http.MethodPost, "/login", "", body, "X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i)), http.StatusUnauthorized, "login attempt")
+	}
+	w := s.do(http.MethodPost, "/login", "", body, "X-Forwarded-For", "203.0.113.99")
+	expectProblem(t, w, http.StatusTooManyRequests, "rate_limited", "login with a new forged X-Forwarded-For")
+}
+
+func TestTrustedProxyForwardsClientIP(t *testing.T) {
+	s := newTestServer(t, memory.NewStore(), func(cfg *config.Config) {
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.LoginBurst = 1
+		// RemoteAddr ของ httptest
+		cfg.Server.TrustedProxies = []string{"192.0.2.0/24"}
+	})
+	body := map[string]string{"username": "nobody", "password": "wrong-password"}
+
+	// ผ่าน Proxy ที่เชื่อได้ Client แต่ละ IP มี Bucket ของตัวเอง
+	expect(t, s.do(http.MethodPost, "/login", "", body, "X-Forwarded-For", "203.0.113.1"), http.StatusUnauthorized, "first client")
+	expect(t, s.do(http.MethodPost, "/login", "", body, "X-Forwarded-For", "203.0.113.2"), http.StatusUnauthorized, "second client")
+	expect(t, s.do(http.MethodPost, "/login", "", body, "X-Forwarded-For", "203.0.113.1"), http.StatusTooManyRequests, "first client again")
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"worm/config"
+)
+
+// Worker งานเบื้องหลังที่ทำงานจนกว่า ctx จะถูกยกเลิก
+type Worker func(ctx context.Context)
+
+// WorkerPool ชุด Worker ที่ Run เปิดพร้อม Server และนับว่ายังทำงานอยู่กี่ตัว
+type WorkerPool struct {
+	workers []Worker
+	running atomic.Int64
+}
+
+// NewWorkerPool รวม Worker ที่จะเปิดตอน Run
+func NewWorkerPool(workers ...Worker) *WorkerPool {
+	return &WorkerPool{workers: workers}
+}
+
+// Check ใช้กับ /readyz: Error ถ้ามี Worker ที่หยุดไปแล้ว (หรือยังไม่ได้เริ่ม)
+func (p *WorkerPool) Check(ctx context.Context) error {
+	if running := p.running.Load(); running < int64(len(p.workers)) {
+		return fmt.Errorf("%d of %d background workers running", running, len(p.workers))
+	}
+	return nil
+}
+
+// start เปิด Worker ทุกตัว คืนฟังก์ชันที่หยุดและรอจนครบ
+func (p *WorkerPool) start() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, w := range p.workers {
+		wg.Add(1)
+		p.running.Add(1)
+		go func(w Worker) {
+			defer wg.Done()
+			defer p.running.Add(-1)
+			w(ctx)
+		}(w)
+	}
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// NewServer สร้าง http.Server พร้อม Router และ Timeout ตาม server ใน Config (ยังไม่เริ่มรับ Request)
+func NewServer(cfg *config.Config, deps Deps) *http.Server {
+	return &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           NewRouter(cfg, deps),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+	}
+}
+
+// Run เปิด srv และ Worker ใน pool จนกว่า ctx จะถูกยกเลิก (เช่นได้รับ SIGTERM)
+// จากนั้นหยุดรับ Request ใหม่ รอ Request ที่ค้างอยู่ไม่เกิน shutdownTimeout แล้วรอ Worker หยุดครบ
+func Run(ctx context.Context, srv *http.Server, shutdownTimeout time.Duration, pool *WorkerPool) error {
+	stopWorkers := pool.start()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "addr", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		// เปิด Port ไม่ได้ หรือ Server หยุดเอง
+	case <-ctx.Done():
+		slog.Info("shutting down, waiting for in-flight requests", "timeout", shutdownTimeout.String())
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err = srv.Shutdown(shutdownCtx)
+	}
+
+	stopWorkers()
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
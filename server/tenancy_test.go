@@ -0,0 +1,195 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"worm/config"
+	"worm/models"
+	"worm/repository/memory"
+)
+
+// tenant Admin / User ขององค์กรหนึ่ง
+type tenant struct {
+	orgID       uint
+	admin, user string
+}
+
+// addTenant สร้างองค์กรใหม่พร้อม Admin และ User
+func (f *fixture) addTenant(name, prefix string) tenant {
+	f.t.Helper()
+	org := f.createOrganization(name)
+	admin := f.register(f.root, prefix+"-admin", models.RoleAdmin, org)
+	return tenant{orgID: org, admin: admin, user: f.register(admin, prefix+"-staff", models.RoleUser, 0)}
+}
+
+// readings ค่า Sensor ที่ apiKey เห็น
+func (f *fixture) readings(apiKey string) []models.SensorData {
+	f.t.Helper()
+	w := f.do(http.MethodGet, "/api/sensor", apiKey, nil)
+	expect(f.t, w, http.StatusOK, "list readings")
+	var resp struct {
+		Data []models.SensorData `json:"data"`
+	}
+	decode(f.t, w, &resp)
+	return resp.Data
+}
+
+func TestTenantsCannotReadEachOther(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		b := f.addTenant("Farm B", "farm-b")
+
+		// ชื่อ Device เดียวกันในสององค์กรเป็นคนละ Device
+		f.ingest(f.user, "bin-1", 20, 60)
+		f.ingest(b.user, "bin-1", 30, 80)
+		for _, c := range []struct {
+			name  string
+			key   string
+			org   uint
+			temp  float64
+			count int
+		}{
+			{"Farm A", f.user, f.orgID, 20, 1},
+			{"Farm B", b.user, b.orgID, 30, 1},
+			{"superadmin", f.root, 0, 0, 2},
+		} {
+			data := f.readings(c.key)
+			if len(data) != c.count {
+				t.Fatalf("%s sees %d readings, want %d", c.name, len(data), c.count)
+			}
+			if c.org != 0 && (data[0].OrganizationID != c.org || data[0].Temperature != c.temp) {
+				t.Fatalf("%s sees another organization's reading: %+v", c.name, data[0])
+			}
+		}
+		deviceA, deviceB := f.deviceID(f.user, "bin-1"), f.deviceID(b.user, "bin-1")
+		if deviceA == deviceB {
+			t.Fatal("bin-1 of both organizations is the same device")
+		}
+
+		w := f.do(http.MethodGet, "/api/users", b.admin, nil)
+		expect(t, w, http.StatusOK, "list users")
+		var users struct {
+			Users []models.User `json:"users"`
+		}
+		decode(t, w, &users)
+		for _, u := range users.Users {
+			if u.OrganizationID == nil || *u.OrganizationID != b.orgID {
+				t.Fatalf("Farm B admin sees %s from another organization", u.Username)
+			}
+		}
+
+		shedA := f.createLocation("Shed A", "shed", 0)
+		for _, path := range []string{
+			fmt.Sprintf("/api/locations/%d/stats", shedA),
+			fmt.Sprintf("/api/locations/%d/events", shedA),
+			fmt.Sprintf("/api/locations/%d/timeline", shedA),
+			fmt.Sprintf("/api/devices/%d/assignments", deviceA),
+			fmt.Sprintf("/api/devices/%d/calibrations", deviceA),
+			fmt.Sprintf("/api/anomalies?device_id=%d", deviceA),
+		} {
+			expectProblem(t, f.do(http.MethodGet, path, b.admin, nil), http.StatusNotFound, "not_found", "Farm B reads "+path)
+		}
+
+		w = f.do(http.MethodGet, "/api/locations", b.user, nil)
+		if strings.Contains(w.Body.String(), "Shed A") {
+			t.Fatalf("Farm B lists Farm A locations: %s", w.Body.String())
+		}
+		w = f.do(http.MethodGet, "/api/audit", b.admin, nil)
+		if strings.Contains(w.Body.String(), "farm-a-") {
+			t.Fatalf("Farm B audit log shows Farm A users: %s", w.Body.String())
+		}
+	})
+}
+
+func TestTenantsCannotWriteEachOther(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		b := f.addTenant("Farm B", "farm-b")
+		f.ingest(f.user, "bin-1", 20, 60)
+		deviceA := f.deviceID(f.user, "bin-1")
+		shedA := f.createLocation("Shed A", "shed", 0)
+		binA := f.createLocation("Bin A", "bin", shedA)
+		actuatorA := f.createActuator(deviceA, "Mister A")
+
+		w := f.do(http.MethodGet, "/api/users", f.admin, nil)
+		var users struct {
+			Users []models.User `json:"users"`
+		}
+		decode(t, w, &users)
+		userA := users.Users[0].ID
+
+		writes := []struct {
+			method, path string
+			body         interface{}
+		}{
+			{http.MethodPut, fmt.Sprintf("/api/users/%d", userA), map[string]string{"role": "user"}},
+			{http.MethodDelete, fmt.Sprintf("/api/users/%d/2fa", userA), nil},
+			{http.MethodPut, fmt.Sprintf("/api/locations/%d", shedA), map[string]string{"name": "Taken"}},
+			{http.MethodDelete, fmt.Sprintf("/api/locations/%d", binA), nil},
+			{http.MethodPost, fmt.Sprintf("/api/locations/%d/events", binA), map[string]interface{}{"kind": "feeding", "feed_type": "scraps", "weight_kg": 1}},
+			{http.MethodPut, fmt.Sprintf("/api/devices/%d/location", deviceA), map[string]interface{}{"location_id": nil}},
+			{http.MethodPost, fmt.Sprintf("/api/devices/%d/calibrations", deviceA), map[string]interface{}{"metric": "temperature", "method": "offset", "offset": 1}},
+			{http.MethodPost, "/api/actuators", map[string]interface{}{"device_id": deviceA, "name": "Intruder", "kind": "fan"}},
+			{http.MethodPut, fmt.Sprintf("/api/actuators/%d", actuatorA), map[string]string{"name": "Taken"}},
+			{http.MethodDelete, fmt.Sprintf("/api/actuators/%d", actuatorA), nil},
+			{http.MethodPost, fmt.Sprintf("/api/actuators/%d/commands", actuatorA), map[string]string{"action": "on"}},
+			{http.MethodPut, fmt.Sprintf("/api/actuators/%d/override", actuatorA), map[string]bool{"enabled": true}},
+		}
+		for _, wr := range writes {
+			w := f.do(wr.method, wr.path, b.admin, wr.body)
+			expectProblem(t, w, http.StatusNotFound, "not_found", "Farm B "+wr.method+" "+wr.path)
+		}
+
+		// อ้างถึงของ Farm A ใน Body ก็ไม่ได้
+		w = f.do(http.MethodPost, "/api/locations", b.admin, map[string]interface{}{"name": "Inside A", "kind": "bin", "parent_id": shedA})
+		expect(t, w, http.StatusNotFound, "Farm B creates a location under Farm A")
+		w = f.do(http.MethodPost, "/api/register", b.admin, map[string]interface{}{"username": "mole", "password": "password-1", "role": "user", "organization_id": f.orgID})
+		expect(t, w, http.StatusForbidden, "Farm B admin registers a user in Farm A")
+		expect(t, f.do(http.MethodPost, "/api/organizations", b.admin, map[string]string{"name": "Farm C"}), http.StatusForbidden, "org admin creates an organization")
+
+		// Device ชื่อเดียวกันของ Farm B ไม่ได้คำสั่งของ Farm A
+		expect(t, f.do(http.MethodPost, fmt.Sprintf("/api/actuators/%d/commands", actuatorA), f.user, map[string]string{"action": "on"}), http.StatusOK, "Farm A command")
+		w = f.do(http.MethodGet, "/api/device/commands", b.user, nil, "X-Device-ID", "bin-1")
+		expect(t, w, http.StatusOK, "Farm B poll")
+		var polled struct {
+			Commands []models.ActuatorCommand `json:"commands"`
+		}
+		decode(t, w, &polled)
+		if len(polled.Commands) != 0 {
+			t.Fatalf("Farm B bin-1 received Farm A commands: %+v", polled.Commands)
+		}
+
+		// ของ Farm A ไม่เปลี่ยน
+		w = f.do(http.MethodGet, "/api/actuators", f.user, nil)
+		if !strings.Contains(w.Body.String(), "Mister A") {
+			t.Fatalf("Farm A actuator changed: %s", w.Body.String())
+		}
+		f.login("farm-a-staff", "password-farm-a-staff")
+	})
+}
+
+func TestDeviceMetricsAreLabelledByOrganization(t *testing.T) {
+	eachStore(t, func(t *testing.T, f *fixture) {
+		b := f.addTenant("Farm B", "farm-b")
+		f.ingest(f.user, "bin-1", 20, 60)
+		f.ingest(b.user, "bin-1", 30, 80)
+
+		w := f.do(http.MethodGet, "/metrics", "", nil)
+		expect(t, w, http.StatusOK, "scrape")
+		for _, want := range []string{
+			fmt.Sprintf(`worm_temperature_celsius{organization="%d",device="bin-1"} 20`, f.orgID),
+			fmt.Sprintf(`worm_temperature_celsius{organization="%d",device="bin-1"} 30`, b.orgID),
+			fmt.Sprintf(`worm_readings_ingested_total{organization="%d",device="bin-1"} 1`, b.orgID),
+		} {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("missing %q", want)
+			}
+		}
+	})
+}
+
+func TestMetricsTokenIsEnforced(t *testing.T) {
+	s := newTestServer(t, memory.NewStore(), func(cfg *config.Config) { cfg.Metrics.Token = "scrape-secret" })
+	expectProblem(t, s.do(http.MethodGet, "/metrics", "", nil), http.StatusUnauthorized, "unauthorized", "scrape without the token")
+	expect(t, s.do(http.MethodGet, "/metrics", "", nil, "Authorization", "Bearer scrape-secret"), http.StatusOK, "scrape with the token")
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"worm/config"
+	"worm/models"
+	"worm/repository"
+)
+
+const (
+	// ความยาวสูงสุดของชื่อ Actuator (ตัวอักษร)
+	maxActuatorNameLength = 100
+	// ช่อง Relay สูงสุด (บอร์ด Relay ทั่วไปมีไม่เกิน 16 ช่อง เผื่อไว้สำหรับ Expander)
+	maxActuatorChannel = 255
+	// ระยะเวลาสูงสุดของคำสั่งหนึ่งครั้ง
+	maxCommandDuration = 24 * 60 * 60
+	// ระยะเวลาสูงสุดของ Manual Override แบบมีกำหนด
+	maxOverrideDuration = 7 * 24 * 60 * 60
+)
+
+// ActuatorService จัดการ Actuator และวงจรของคำสั่ง: สั่ง (queued) → Device Poll (delivered) → Device ยืนยัน (acknowledged | failed)
+type ActuatorService struct {
+	actuators repository.ActuatorRepository
+	commands  repository.ActuatorCommandRepository
+	devices   repository.DeviceRepository
+	rules     repository.AutomationRuleRepository
+	schedules repository.ScheduleRepository
+	cfg       config.ActuatorConfig
+}
+
+// NewActuatorService สร้าง ActuatorService
+func NewActuatorService(store *repository.Store, cfg config.ActuatorConfig) *ActuatorService {
+	return &ActuatorService{
+		actuators: store.Actuators,
+		commands:  store.Commands,
+		devices:   store.Devices,
+		rules:     store.Rules,
+		schedules: store.Schedules,
+		cfg:       cfg,
+	}
+}
+
+// ActuatorInput ข้อมูลของ Actuator ใหม่
+type ActuatorInput struct {
+	DeviceID uint
+	Name     string
+	Kind     string
+	Channel  int
+}
+
+// ActuatorUpdate ค่าที่ต้องการแก้ (nil = ไม่แก้)
+type ActuatorUpdate struct {
+	Name    *string
+	Kind    *string
+	Channel *int
+}
+
+// CommandInput คำสั่งหนึ่งครั้ง
+type CommandInput struct {
+	// on | off | duty
+	Action string
+	// 1-100 (เฉพาะ duty)
+	DutyCycle *int
+	// วินาที (nil = จนกว่าจะมีคำสั่งใหม่)
+	DurationSeconds *int
+}
+
+// CreateActuator เพิ่ม Actuator ให้ Device (องค์กรตาม Device)
+func (s *ActuatorService) CreateActuator(ctx context.Context, in ActuatorInput) (*models.Actuator, error) {
+	name, kind, err := validActuator(in.Name, in.Kind, in.Channel)
+	if err != nil {
+		return nil, err
+	}
+	device, err := s.devices.FindByID(ctx, in.DeviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	actuator := models.Actuator{
+		OrganizationID: device.OrganizationID,
+		DeviceID:       device.ID,
+		Name:           name,
+		Kind:           kind,
+		Channel:        in.Channel,
+	}
+	if err := s.actuators.Create(ctx, &actuator); err != nil {
+		return nil, err
+	}
+	return &actuator, nil
+}
+
+// UpdateActuator เปลี่ยนชื่อ ชนิด หรือช่อง Relay (คำสั่งที่สั่งไปแล้วยังใช้ช่องเดิม)
+func (s *ActuatorService) UpdateActuator(ctx context.Context, id uint, upd ActuatorUpdate) (*models.Actuator, error) {
+	actuator, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, kind, channel := actuator.Name, actuator.Kind, actuator.Channel
+	if upd.Name != nil {
+		name = *upd.Name
+	}
+	if upd.Kind != nil {
+		kind = *upd.Kind
+	}
+	if upd.Channel != nil {
+		channel = *upd.Channel
+	}
+	if actuator.Name, actuator.Kind, err = validActuator(name, kind, channel); err != nil {
+		return nil, err
+	}
+	actuator.Channel = channel
+
+	if err := s.actuators.Update(ctx, actuator); err != nil {
+		return nil, err
+	}
+	return actuator, nil
+}
+
+// DeleteActuator ลบ Actuator ที่ยังไม่เคยได้รับคำสั่งและไม่มี Rule / Schedule ใช้อยู่
+func (s *ActuatorService) DeleteActuator(ctx context.Context, id uint) (*models.Actuator, error) {
+	actuator, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.commands.CountForActuator(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		return nil, ErrActuatorInUse
+	}
+	if err := usedByRule(ctx, s.rules, func(r *models.AutomationRule) bool { return r.ActuatorID == id }); err != nil {
+		return nil, err
+	}
+	if err := usedBySchedule(ctx, s.schedules, id); err != nil {
+		return nil, err
+	}
+	if err := s.actuators.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return actuator, nil
+}
+
+// FindByID หา Actuator (Actuator ขององค์กรอื่นถือว่าไม่พบ)
+func (s *ActuatorService) FindByID(ctx context.Context, id uint) (*models.Actuator, error) {
+	actuator, err := s.actuators.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrActuatorNotFound
+	}
+	return actuator, err
+}
+
+// GetAllActuators Actuator ทั้งหมดที่ผู้เรียกเห็นได้
+func (s *ActuatorService) GetAllActuators(ctx context.Context) ([]models.Actuator, error) {
+	return s.actuators.List(ctx)
+}
+
+// SetOverride เปิด / ปิดโหมด Manual Override (ระหว่างนี้ Automation Rule ไม่สั่ง Actuator นี้)
+// durationSeconds = nil คือจนกว่าจะปิดเอง
+func (s *ActuatorService) SetOverride(ctx context.Context, id uint, enabled bool, durationSeconds *int) (*models.Actuator, error) {
+	if durationSeconds != nil && (!enabled || *durationSeconds < 1 || *durationSeconds > maxOverrideDuration) {
+		return nil, ErrInvalidOverride
+	}
+	actuator, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	actuator.ManualOverride = enabled
+	actuator.OverrideUntil = nil
+	if durationSeconds != nil {
+		until := time.Now().Add(time.Duration(*durationSeconds) * time.Second)
+		actuator.OverrideUntil = &until
+	}
+	if err := s.actuators.Update(ctx, actuator); err != nil {
+		return nil, err
+	}
+	return actuator, nil
+}
+
+// SendCommand เข้าคิวคำสั่งถึง Actuator รอ Device มา Poll ภายใน actuator.command_ttl
+// source บอกว่าใครสั่ง (models.CommandSource*) requestedBy = User ที่สั่ง (nil = ระบบ)
+func (s *ActuatorService) SendCommand(ctx context.Context, actuatorID uint, in CommandInput, source string, requestedBy *uint) (*models.ActuatorCommand, error) {
+	if err := validCommand(in); err != nil {
+		return nil, err
+	}
+	actuator, err := s.FindByID(ctx, actuatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := models.ActuatorCommand{
+		OrganizationID:  actuator.OrganizationID,
+		ActuatorID:      actuator.ID,
+		DeviceID:        actuator.DeviceID,
+		Channel:         actuator.Channel,
+		Action:          in.Action,
+		DutyCycle:       in.DutyCycle,
+		DurationSeconds: in.DurationSeconds,
+		Status:          models.CommandQueued,
+		Source:          source,
+		RequestedBy:     requestedBy,
+		ExpiresAt:       time.Now().Add(time.Duration(s.cfg.CommandTTL)),
+	}
+	if err := s.commands.Create(ctx, &cmd); err != nil {
+		return nil, err
+	}
+	return &cmd, nil
+}
+
+// CommandHistory ประวัติคำสั่งทั้งหมดของ Actuator เรียงจากล่าสุด
+func (s *ActuatorService) CommandHistory(ctx context.Context, actuatorID uint) ([]models.ActuatorCommand, error) {
+	if _, err := s.FindByID(ctx, actuatorID); err != nil {
+		return nil, err
+	}
+	// ให้สถานะที่เห็นตรงกับเวลาจริงโดยไม่ต้องรอ Worker รอบถัดไป
+	if _, err := s.ExpireCommands(ctx); err != nil {
+		return nil, err
+	}
+	return s.commands.ListForActuator(ctx, actuatorID)
+}
+
+// PollCommands ส่งคำสั่งที่รออยู่ของ Device ชื่อ device ในองค์กรของผู้เรียก (ทุกคำสั่งถูกส่งครั้งเดียว)
+// Device ที่ยังไม่เคยส่งค่าไม่มีคำสั่ง จึงได้รายการว่าง
+func (s *ActuatorService) PollCommands(ctx context.Context, device string) ([]models.ActuatorCommand, error) {
+	d, err := s.callingDevice(ctx, device)
+	if errors.Is(err, ErrDeviceNotFound) {
+		return []models.ActuatorCommand{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cmds, err := s.commands.Deliver(ctx, d.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.devices.Touch(ctx, d.ID, now); err != nil {
+		return nil, err
+	}
+	if cmds == nil {
+		cmds = []models.ActuatorCommand{}
+	}
+	return cmds, nil
+}
+
+// AcknowledgeCommand Device ชื่อ device รายงานผลของคำสั่ง (ok = ทำสำเร็จ, ไม่งั้นเป็น failed พร้อม message)
+// ส่งผลเดิมซ้ำได้ (เช่น Device ไม่ได้รับ Response ครั้งแรก) โดยไม่ Error
+func (s *ActuatorService) AcknowledgeCommand(ctx context.Context, device string, commandID uint, ok bool, message string) (*models.ActuatorCommand, error) {
+	d, err := s.callingDevice(ctx, device)
+	if errors.Is(err, ErrDeviceNotFound) {
+		return nil, ErrCommandNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := s.commands.FindByID(ctx, commandID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && cmd.DeviceID != d.ID) {
+		return nil, ErrCommandNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := models.CommandAcknowledged
+	if !ok {
+		status = models.CommandFailed
+	} else {
+		message = ""
+	}
+	if cmd.Status == status && cmd.AcknowledgedAt != nil {
+		return cmd, nil
+	}
+
+	now := time.Now()
+	err = s.commands.Finish(ctx, cmd.ID, status, strings.TrimSpace(message), now)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCommandNotDelivered
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if err := s.actuators.SetState(ctx, cmd.ActuatorID, cmd.Action, now); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return s.commands.FindByID(ctx, cmd.ID)
+}
+
+// ExpireCommands ปิดคำสั่งที่ไม่ถูก Poll ทันเวลา (expired) และที่ไม่ได้รับการยืนยันทันเวลา (failed)
+func (s *ActuatorService) ExpireCommands(ctx context.Context) (int64, error) {
+	now := time.Now()
+	return s.commands.Expire(ctx, now, now.Add(-time.Duration(s.cfg.AckTimeout)))
+}
+
+// --- Internal Logic ---
+
+// callingDevice Device ของผู้เรียก (ชื่อจาก X-Device-ID) ในองค์กรของ Tenant
+func (s *ActuatorService) callingDevice(ctx context.Context, name string) (*models.Device, error) {
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t.All {
+		return nil, ErrNoOrganization
+	}
+	d, err := s.devices.FindByName(ctx, t.OrganizationID, name)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	return d, err
+}
+
+// validActuator ตัดช่องว่างและตรวจรูปแบบชื่อ / ชนิด / ช่อง (ชนิดเป็นตัวพิมพ์เล็กเสมอ)
+func validActuator(name, kind string, channel int) (string, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxActuatorNameLength {
+		return "", "", ErrInvalidActuatorName
+	}
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if !kindPattern.MatchString(kind) {
+		return "", "", ErrInvalidActuatorKind
+	}
+	if channel < 0 || channel > maxActuatorChannel {
+		return "", "", ErrInvalidChannel
+	}
+	return name, kind, nil
+}
+
+func validCommand(in CommandInput) error {
+	switch in.Action {
+	case models.CommandOn, models.CommandOff, models.CommandDuty:
+	default:
+		return ErrInvalidCommand
+	}
+	if (in.Action == models.CommandDuty) != (in.DutyCycle != nil) {
+		return ErrInvalidDutyCycle
+	}
+	if in.DutyCycle != nil && (*in.DutyCycle < 1 || *in.DutyCycle > 100) {
+		return ErrInvalidDutyCycle
+	}
+	if in.DurationSeconds != nil && (in.Action == models.CommandOff || *in.DurationSeconds < 1 || *in.DurationSeconds > maxCommandDuration) {
+		return ErrInvalidDuration
+	}
+	return nil
+}
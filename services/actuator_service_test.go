@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+	"worm/models"
+	"worm/repository"
+)
+
+// State ที่ Device ยืนยันระหว่างอ่านกับเขียน Override ต้องไม่ถูกค่าเก่าทับ
+func TestUpdateKeepsAcknowledgedState(t *testing.T) {
+	for _, s := range testStores {
+		t.Run(s.name, func(t *testing.T) {
+			store := s.open(t)
+			org := models.Organization{Name: "Farm A"}
+			if err := store.Organizations.Create(repository.AllTenants(context.Background()), &org); err != nil {
+				t.Fatalf("create organization: %v", err)
+			}
+			ctx := repository.OrganizationTenant(context.Background(), org.ID)
+			actuator := models.Actuator{DeviceID: 1, Name: "Mister 1", Kind: "mister", Channel: 0}
+			if err := store.Actuators.Create(ctx, &actuator); err != nil {
+				t.Fatalf("create actuator: %v", err)
+			}
+
+			stale, err := store.Actuators.FindByID(ctx, actuator.ID)
+			if err != nil {
+				t.Fatalf("find: %v", err)
+			}
+			at := time.Now().UTC().Truncate(time.Second)
+			if err := store.Actuators.SetState(ctx, actuator.ID, "on", at); err != nil {
+				t.Fatalf("set state: %v", err)
+			}
+			stale.ManualOverride = true
+			if err := store.Actuators.Update(ctx, stale); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			got, err := store.Actuators.FindByID(ctx, actuator.ID)
+			if err != nil {
+				t.Fatalf("find: %v", err)
+			}
+			if !got.ManualOverride {
+				t.Errorf("manual_override not saved")
+			}
+			if got.State != "on" || got.StateChangedAt == nil || !got.StateChangedAt.Equal(at) {
+				t.Errorf("state = %q at %v, want %q at %v", got.State, got.StateChangedAt, "on", at)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+	"worm/config"
+	"worm/models"
+	"worm/repository"
+)
+
+const (
+	// ช่วงเริ่มต้นของการดูค่าที่ผิดปกติ และจำนวนสูงสุดที่คืน (ล่าสุดก่อน)
+	defaultAnomalyRange = 24 * time.Hour
+	maxAnomalies        = 1000
+	// จำนวน Alert ที่รอส่งได้ (เต็มแล้ว Alert ใหม่ถูกทิ้ง) จำนวนครั้งที่ลองส่ง และเวลารอก่อนลองใหม่ครั้งแรก (เพิ่มเป็นเท่าตัว)
+	alertQueueSize  = 100
+	alertAttempts   = 4
+	alertRetryDelay = 2 * time.Second
+	// ส่วนเบี่ยงเบนมาตรฐานต่ำสุดที่ใช้คิด z-score (ความละเอียดของ DHT22 / SHT3x)
+	// ไม่งั้นค่าที่นิ่งมากจะทำให้ค่าที่ต่างไปเพียงหลักทศนิยมถูกนับเป็นค่าผิดปกติ
+	minAnomalyStddev = 0.1
+)
+
+// AnomalyService ตรวจค่าผิดปกติของแต่ละ Device ตอนรับค่า (SensorService เรียก Detect ก่อนบันทึก)
+// และเป็น ReadingObserver ที่ใส่ค่าที่ผิดปกติลง AlertQueue (Ingest ไม่รอ Webhook)
+type AnomalyService struct {
+	sensors repository.SensorRepository
+	devices repository.DeviceRepository
+	cfg     config.AnomalyConfig
+	alerts  *AlertQueue
+}
+
+// NewAnomalyService สร้าง AnomalyService (alerts nil = ไม่ส่ง Alert)
+func NewAnomalyService(store *repository.Store, cfg config.AnomalyConfig, alerts *AlertQueue) *AnomalyService {
+	return &AnomalyService{
+		sensors: store.Sensors,
+		devices: store.Devices,
+		cfg:     cfg,
+		alerts:  alerts,
+	}
+}
+
+// AnomalyAlert สิ่งที่ POST ไปที่ anomaly.alert_webhook
+type AnomalyAlert struct {
+	// reading.anomaly
+	Event string `json:"event"`
+	// ชื่อ Device (X-Device-ID)
+	Device  string             `json:"device"`
+	Reading *models.SensorData `json:"reading"`
+}
+
+// Detect ตั้ง Anomalies ของ data ที่ยังไม่บันทึก โดยเทียบค่าดิบกับค่าล่าสุดของ Device เดียวกัน
+// (ค่าดิบไม่ขึ้นกับ Calibration ที่เพิ่ม / ลบภายหลัง)
+func (s *AnomalyService) Detect(ctx context.Context, data *models.SensorData) error {
+	if !s.cfg.Enabled || data.DeviceID == nil {
+		return nil
+	}
+	now := time.Now()
+	history, err := s.sensors.Recent(ctx, *data.DeviceID, now.Add(-time.Duration(s.cfg.MaxAge)), max(s.cfg.Window, s.cfg.StuckCount-1))
+	if err != nil {
+		return err
+	}
+
+	anomalies := s.detect(models.MetricTemperature, data.RawTemperature, s.cfg.MaxTemperatureRate, history, now,
+		func(d *models.SensorData) float64 { return d.RawTemperature })
+	anomalies = append(anomalies, s.detect(models.MetricHumidity, data.RawHumidity, s.cfg.MaxHumidityRate, history, now,
+		func(d *models.SensorData) float64 { return d.RawHumidity })...)
+	data.Anomalies = anomalies
+	data.Anomalous = len(anomalies) > 0
+	return nil
+}
+
+// ObserveReading ใส่ค่าที่ผิดปกติลงคิวส่งไปที่ anomaly.alert_webhook (ไม่ได้ตั้ง = ไม่ทำอะไร) โดยไม่รอส่ง
+// คิวเต็มคืน ErrAlertQueueFull (ค่าถูกบันทึกไปแล้ว)
+func (s *AnomalyService) ObserveReading(ctx context.Context, data *models.SensorData) error {
+	if !data.Anomalous || s.alerts == nil || s.cfg.AlertWebhook == "" {
+		return nil
+	}
+	alert := AnomalyAlert{Event: "reading.anomaly", Reading: data}
+	if data.DeviceID != nil {
+		device, err := s.devices.FindByID(ctx, *data.DeviceID)
+		if err != nil {
+			return err
+		}
+		alert.Device = device.Name
+	}
+	return s.alerts.Enqueue(alert)
+}
+
+// Anomalies ค่าที่ผิดปกติในช่วง [from, to) ล่าสุดก่อน ไม่เกิน 1000 ค่า
+// deviceID 0 = ทุก Device, to nil = ตอนนี้, from nil = 24 ชั่วโมงก่อน to
+func (s *AnomalyService) Anomalies(ctx context.Context, deviceID uint, from, to *time.Time) ([]models.SensorData, error) {
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	start := end.Add(-defaultAnomalyRange)
+	if from != nil {
+		start = *from
+	}
+	if !start.Before(end) {
+		return nil, ErrInvalidTimeRange
+	}
+	if deviceID != 0 {
+		if _, err := s.devices.FindByID(ctx, deviceID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrDeviceNotFound
+			}
+			return nil, err
+		}
+	}
+	return s.sensors.ListAnomalies(ctx, repository.AnomalyQuery{DeviceID: deviceID, From: start, To: end, Limit: maxAnomalies})
+}
+
+// AlertQueue คิว Anomaly Alert ที่ Worker ส่งไปที่ anomaly.alert_webhook ทีละตัว
+// Alert ที่ยังรออยู่ตอน Server ปิดจะหายไป
+type AlertQueue struct {
+	webhook    string
+	client     *http.Client
+	queue      chan AnomalyAlert
+	attempts   int
+	retryDelay time.Duration
+}
+
+// NewAlertQueue สร้าง AlertQueue ว่าง (แต่ละครั้งที่ส่งรอไม่เกิน anomaly.alert_timeout)
+func NewAlertQueue(cfg config.AnomalyConfig) *AlertQueue {
+	return &AlertQueue{
+		webhook:    cfg.AlertWebhook,
+		client:     &http.Client{Timeout: time.Duration(cfg.AlertTimeout)},
+		queue:      make(chan AnomalyAlert, alertQueueSize),
+		attempts:   alertAttempts,
+		retryDelay: alertRetryDelay,
+	}
+}
+
+// Enqueue ใส่ alert ลงคิวโดยไม่รอ คิวเต็มคืน ErrAlertQueueFull
+func (q *AlertQueue) Enqueue(alert AnomalyAlert) error {
+	select {
+	case q.queue <- alert:
+		return nil
+	default:
+		return ErrAlertQueueFull
+	}
+}
+
+// Next รอ Alert ตัวถัดไปในคิว คืน false เมื่อ ctx ถูกยกเลิก
+func (q *AlertQueue) Next(ctx context.Context) (AnomalyAlert, bool) {
+	select {
+	case alert := <-q.queue:
+		return alert, true
+	case <-ctx.Done():
+		return AnomalyAlert{}, false
+	}
+}
+
+// Deliver POST alert ไปที่ Webhook ถ้าไม่สำเร็จลองใหม่จนครบจำนวนครั้ง (รอเพิ่มเป็นเท่าตัว)
+// คืน Error ของครั้งสุดท้าย
+func (q *AlertQueue) Deliver(ctx context.Context, alert AnomalyAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	delay := q.retryDelay
+	for attempt := 1; ; attempt++ {
+		err = q.post(ctx, body)
+		if err == nil || attempt == q.attempts {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// --- Internal Logic ---
+
+// post ส่ง body ไปที่ Webhook หนึ่งครั้ง
+func (q *AlertQueue) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("anomaly alert: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("anomaly alert: webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// detect ตรวจค่า value ของ metric เทียบกับ history (ล่าสุดก่อน) raw อ่านค่าเดียวกันจากแต่ละค่าใน history
+func (s *AnomalyService) detect(metric string, value, maxRate float64, history []models.SensorData, now time.Time, raw func(*models.SensorData) float64) []models.Anomaly {
+	if len(history) == 0 {
+		return nil
+	}
+	var out []models.Anomaly
+
+	// stuck: ค่าเดิมซ้ำติดกัน StuckCount ค่า (รวมค่านี้)
+	if n := s.cfg.StuckCount; n > 0 && len(history) >= n-1 {
+		stuck := true
+		for i := 0; i < n-1 && stuck; i++ {
+			stuck = raw(&history[i]) == value
+		}
+		if stuck {
+			out = append(out, models.Anomaly{Metric: metric, Kind: models.AnomalyStuck, Score: float64(n)})
+		}
+	}
+
+	// spike: ช่วงห่างน้อยกว่า 1 นาทีนับเป็น 1 นาที (Device ที่ส่งถี่ไม่ทำให้ค่าที่ต่างเล็กน้อยกลายเป็นอัตราสูง)
+	if maxRate > 0 {
+		minutes := math.Max(now.Sub(history[0].CreatedAt).Minutes(), 1)
+		if rate := math.Abs(value-raw(&history[0])) / minutes; rate > maxRate {
+			out = append(out, models.Anomaly{Metric: metric, Kind: models.AnomalySpike, Score: math.Round(rate*100) / 100})
+		}
+	}
+
+	// zscore: เทียบกับค่าเฉลี่ย / ส่วนเบี่ยงเบนมาตรฐานของ Window ค่าล่าสุด
+	window := history[:min(len(history), s.cfg.Window)]
+	if len(window) >= s.cfg.MinSamples {
+		var sum, squares float64
+		for i := range window {
+			sum += raw(&window[i])
+		}
+		mean := sum / float64(len(window))
+		for i := range window {
+			squares += math.Pow(raw(&window[i])-mean, 2)
+		}
+		stddev := math.Max(math.Sqrt(squares/float64(len(window))), minAnomalyStddev)
+		if z := (value - mean) / stddev; math.Abs(z) > s.cfg.ZScore {
+			out = append(out, models.Anomaly{Metric: metric, Kind: models.AnomalyZScore, Score: math.Round(z*100) / 100})
+		}
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"worm/config"
+	"worm/models"
+	"worm/repository"
+	"worm/repository/memory"
+)
+
+// newAlertQueue AlertQueue ที่ส่งไปที่ webhook และรอก่อนลองใหม่เพียงเล็กน้อย
+func newAlertQueue(webhook string) *AlertQueue {
+	q := NewAlertQueue(config.AnomalyConfig{AlertWebhook: webhook, AlertTimeout: config.Duration(time.Second)})
+	q.retryDelay = time.Millisecond
+	return q
+}
+
+func TestObserveReadingDoesNotWaitForTheWebhook(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan AnomalyAlert, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		var alert AnomalyAlert
+		_ = json.NewDecoder(r.Body).Decode(&alert)
+		received <- alert
+	}))
+	defer hook.Close()
+	defer close(release)
+
+	alerts := newAlertQueue(hook.URL)
+	anomalies := NewAnomalyService(memory.NewStore(), config.AnomalyConfig{AlertWebhook: hook.URL}, alerts)
+	ctx := repository.OrganizationTenant(context.Background(), 1)
+
+	// Webhook ยังไม่ตอบ แต่ Ingest ต้องกลับทันที
+	start := time.Now()
+	if err := anomalies.ObserveReading(ctx, &models.SensorData{ID: 7, Anomalous: true}); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("ObserveReading waited %v for the webhook", elapsed)
+	}
+
+	alert, ok := alerts.Next(ctx)
+	if !ok {
+		t.Fatal("alert not queued")
+	}
+	done := make(chan error, 1)
+	go func() { done <- alerts.Deliver(ctx, alert) }()
+	release <- struct{}{}
+	if err := <-done; err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if got := <-received; got.Event != "reading.anomaly" || got.Reading == nil || got.Reading.ID != 7 {
+		t.Fatalf("webhook received %+v", got)
+	}
+}
+
+func TestAlertDeliveryRetries(t *testing.T) {
+	var calls atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// สองครั้งแรกล้มเหลว
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer hook.Close()
+
+	alerts := newAlertQueue(hook.URL)
+	if err := alerts.Deliver(context.Background(), AnomalyAlert{Event: "reading.anomaly"}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("webhook called %d times, want 3", n)
+	}
+
+	// ล้มเหลวทุกครั้ง: หยุดเมื่อครบจำนวนครั้งและคืน Error ครั้งสุดท้าย
+	var failures atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failures.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	if err := newAlertQueue(down.URL).Deliver(context.Background(), AnomalyAlert{Event: "reading.anomaly"}); err == nil {
+		t.Fatal("want an error after the last attempt")
+	}
+	if n := failures.Load(); n != alertAttempts {
+		t.Fatalf("webhook called %d times, want %d", n, alertAttempts)
+	}
+}
+
+func TestFullAlertQueueDropsAlerts(t *testing.T) {
+	alerts := newAlertQueue("http://127.0.0.1:1")
+	anomalies := NewAnomalyService(memory.NewStore(), config.AnomalyConfig{AlertWebhook: "http://127.0.0.1:1"}, alerts)
+	ctx := repository.OrganizationTenant(context.Background(), 1)
+
+	for i := 0; i < alertQueueSize; i++ {
+		if err := anomalies.ObserveReading(ctx, &models.SensorData{Anomalous: true}); err != nil {
+			t.Fatalf("alert %d: %v", i, err)
+		}
+	}
+	if err := anomalies.ObserveReading(ctx, &models.SensorData{Anomalous: true}); !errors.Is(err, ErrAlertQueueFull) {
+		t.Fatalf("alert over capacity: %v, want ErrAlertQueueFull", err)
+	}
+	// ค่าที่ไม่ผิดปกติไม่เข้าคิว
+	if err := anomalies.ObserveReading(ctx, &models.SensorData{}); err != nil {
+		t.Fatalf("normal reading: %v", err)
+	}
+}
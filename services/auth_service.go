@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"worm/config"
+	"worm/models"
+	"worm/repository"
+	"worm/utils"
+
+	"github.com/google/uuid"
+)
+
+// จำนวน Recovery Code ที่ออกให้ต่อการเปิด 2FA หนึ่งครั้ง
+const recoveryCodeCount = 10
+
+// AuthService การยืนยันตัวตน (API Key, Password, 2FA, SSO) และ Audit Log
+type AuthService struct {
+	users         repository.UserRepository
+	orgs          repository.OrganizationRepository
+	recoveryCodes repository.RecoveryCodeRepository
+	audit         repository.AuditRepository
+	cfg           config.AuthConfig
+	// Hash ที่ใช้ตรวจรหัสผ่านเมื่อไม่พบ User (สร้างครั้งแรกที่ต้องใช้)
+	dummyHash func() string
+}
+
+// NewAuthService สร้าง AuthService
+func NewAuthService(store *repository.Store, cfg config.AuthConfig) *AuthService {
+	return &AuthService{
+		users:         store.Users,
+		orgs:          store.Organizations,
+		recoveryCodes: store.RecoveryCodes,
+		audit:         store.Audit,
+		cfg:           cfg,
+		dummyHash: sync.OnceValue(func() string {
+			hash, _ := utils.HashPassword("not-a-real-password", cfg.BcryptCost)
+			return hash
+		}),
+	}
+}
+
+// Authenticate หา User จาก API Key (ค้นทุกองค์กร เพราะยังไม่รู้ว่าผู้เรียกอยู่องค์กรไหน)
+// Request ที่ผ่านแล้วต้องใช้ Tenant จาก TenantOf(user) ต่อ
+func (s *AuthService) Authenticate(ctx context.Context, apiKey string) (*models.User, error) {
+	if apiKey == "" {
+		return nil, ErrInvalidAPIKey
+	}
+	user, err := s.users.FindByAPIKey(repository.AllTenants(ctx), apiKey)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	return user, nil
+}
+
+// Login ตรวจ Username/Password และรหัส 2FA (ถ้าเปิดไว้) Username ไม่ซ้ำทั้งระบบจึงค้นทุกองค์กร
+// ErrUserNotFound / ErrIncorrectPassword ต้องตอบผู้เรียกเหมือนกัน (ไม่ให้ไล่หา Username ที่มีอยู่)
+func (s *AuthService) Login(ctx context.Context, username, password, otpCode string) (*models.User, error) {
+	// ยังไม่รู้ว่าผู้ล็อกอินอยู่องค์กรไหน
+	ctx = repository.AllTenants(ctx)
+	user, err := s.users.FindByUsername(ctx, username)
+	if errors.Is(err, repository.ErrNotFound) {
+		// ใช้เวลาตรวจเท่ากับ User ที่มีอยู่
+		utils.CheckPasswordHash(password, s.dummyHash())
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// ตรวจรหัสผ่านก่อนสถานะของบัญชี ผู้ที่ไม่รู้รหัสผ่านจึงไม่รู้ว่าบัญชีถูกปิดอยู่
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return nil, ErrIncorrectPassword
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	// บัญชีที่ถูกปิด Password Login ต้องเข้าผ่าน SSO เท่านั้น
+	if user.PasswordLoginDisabled {
+		return nil, ErrPasswordLoginDisabled
+	}
+
+	// ขั้นที่ 2: ถ้าเปิด 2FA ไว้ ต้องส่ง otp_code มาด้วย (ยังไม่คืน API Key จนกว่าจะผ่าน)
+	if user.TOTPEnabled {
+		if otpCode == "" {
+			return nil, ErrTwoFactorRequired
+		}
+		ok, err := s.VerifySecondFactor(ctx, user, otpCode)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrInvalidTwoFactorCode
+		}
+	}
+	return user, nil
+}
+
+// TwoFactorSetupRequired Admin (รวม Superadmin) ที่ยังไม่เปิด 2FA ทั้งที่ระบบบังคับ
+func (s *AuthService) TwoFactorSetupRequired(user *models.User) bool {
+	return user.IsAdmin() && !user.TOTPEnabled && s.cfg.RequireAdmin2FA
+}
+
+// EnrollTwoFactor สร้าง TOTP Secret ใหม่ (ยังไม่เปิดใช้จนกว่าจะ Confirm) คืน Secret และ otpauth:// URI
+func (s *AuthService) EnrollTwoFactor(ctx context.Context, user *models.User) (string, string, error) {
+	if user.TOTPEnabled {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	user.TOTPSecret = secret
+	if err := s.users.Update(ctx, user); err != nil {
+		return "", "", err
+	}
+	return secret, utils.TOTPURI(s.cfg.TOTPIssuer, user.Username, secret), nil
+}
+
+// ConfirmTwoFactor ตรวจรหัสแรกจากแอป แล้วเปิดใช้ 2FA พร้อมออก Recovery Code ชุดใหม่ (คืนค่าแบบ Plain text ครั้งเดียว)
+func (s *AuthService) ConfirmTwoFactor(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	// รหัสที่ใช้ยืนยันแล้วนำไปล็อกอินซ้ำไม่ได้
+	used, err := s.users.UseTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(code)
+	}
+	if err := s.recoveryCodes.Replace(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	user.TOTPEnabled = true
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor ปิด 2FA ของตัวเอง ต้องยืนยันด้วยรหัสจากแอปหรือ Recovery Code
+func (s *AuthService) DisableTwoFactor(ctx context.Context, user *models.User, code string) error {
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if user.IsAdmin() && s.cfg.RequireAdmin2FA {
+		return ErrTwoFactorMandatory
+	}
+	ok, err := s.VerifySecondFactor(ctx, user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return s.clearTwoFactor(ctx, user)
+}
+
+// ResetTwoFactor Admin ลบ 2FA ของ User อื่น (กรณีทำมือถือหาย) และบันทึกลง Audit Log
+// User ขององค์กรอื่นถือว่าไม่พบ
+func (s *AuthService) ResetTwoFactor(ctx context.Context, actor *models.User, targetID uint) error {
+	target, err := s.users.FindByID(ctx, targetID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.clearTwoFactor(ctx, target); err != nil {
+		return err
+	}
+	return s.RecordAudit(ctx, actor.ID, "2fa.reset", target, "two-factor reset for "+target.Username)
+}
+
+// VerifySecondFactor ตรวจรหัส TOTP หรือ Recovery Code ทั้งสองแบบใช้ได้ครั้งเดียว
+// (รหัส TOTP ของช่วงเวลาที่ใช้ไปแล้วหรือก่อนหน้าถูกปฏิเสธ แม้ยังไม่หมดอายุ)
+func (s *AuthService) VerifySecondFactor(ctx context.Context, user *models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return false, nil
+	}
+	if step, ok := utils.ValidateTOTP(user.TOTPSecret, code); ok {
+		return s.users.UseTOTPStep(ctx, user.ID, step)
+	}
+	return s.recoveryCodes.Use(ctx, user.ID, utils.HashToken(strings.ToLower(code)))
+}
+
+// ProvisionOIDCUser หา User จาก Subject ของ IdP หรือสร้างใหม่ในองค์กร organization เมื่อเข้าครั้งแรก
+// Role จะถูก Sync ตามกลุ่มใน IdP ทุกครั้งที่ล็อกอิน (ยกเว้น Superadmin ที่จัดการผ่าน CLI เท่านั้น)
+func (s *AuthService) ProvisionOIDCUser(ctx context.Context, subject, username, role, organization string) (*models.User, error) {
+	// ยังไม่รู้ว่าผู้ล็อกอินอยู่องค์กรไหน
+	ctx = repository.AllTenants(ctx)
+
+	user, err := s.users.FindByOIDCSubject(ctx, subject)
+	if err == nil {
+		if user.Disabled {
+			return nil, ErrUserDisabled
+		}
+		if user.Role != role && !user.IsSuperadmin() {
+			user.Role = role
+			if err := s.users.Update(ctx, user); err != nil {
+				return nil, err
+			}
+		}
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	if _, err := s.users.FindByUsername(ctx, username); err == nil {
+		return nil, ErrOIDCUsernameTaken
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	org, err := s.orgs.FindByName(ctx, organization)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// บัญชี SSO ไม่มี Password (Hash ว่างจะไม่มีวันตรง) และปิด Password Login ไว้
+	user = &models.User{
+		Username:              username,
+		Role:                  role,
+		APIKey:                uuid.New().String(),
+		OrganizationID:        &org.ID,
+		OIDCSubject:           &subject,
+		PasswordLoginDisabled: true,
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RecordAudit บันทึกการกระทำลง Audit Log
+// รายการอยู่ในองค์กรของ target (Admin ขององค์กรนั้นเห็นได้) target nil = การกระทำระดับระบบ
+func (s *AuthService) RecordAudit(ctx context.Context, actorID uint, action string, target *models.User, details string) error {
+	entry := models.AuditLog{
+		ActorID: actorID,
+		Action:  action,
+		Details: details,
+	}
+	if target != nil {
+		entry.TargetID = target.ID
+		entry.OrganizationID = target.OrganizationID
+	}
+	return s.audit.Create(ctx, &entry)
+}
+
+// AuditLogs ประวัติใน Audit Log ที่ผู้เรียกเห็นได้ เรียงจากล่าสุด
+func (s *AuthService) AuditLogs(ctx context.Context) ([]models.AuditLog, error) {
+	return s.audit.List(ctx)
+}
+
+func (s *AuthService) clearTwoFactor(ctx context.Context, user *models.User) error {
+	if err := s.recoveryCodes.DeleteForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	return s.users.Update(ctx, user)
+}
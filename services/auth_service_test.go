@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"worm/config"
+	"worm/models"
+	"worm/repository"
+	"worm/repository/memory"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// totpAt รหัส TOTP ของ secret ณ เวลา at (RFC 6238 แยกจาก utils เพื่อไม่ทดสอบโค้ดด้วยตัวมันเอง)
+func totpAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+// newTwoFactorAdmin Admin ที่เปิด 2FA แล้ว (ยืนยันด้วยรหัสของช่วงเวลาปัจจุบัน) คืน AuthService และ Secret
+func newTwoFactorAdmin(t *testing.T) (*AuthService, *models.User, string) {
+	t.Helper()
+	ctx := repository.AllTenants(context.Background())
+	store := memory.NewStore()
+	auth := NewAuthService(store, config.AuthConfig{BcryptCost: bcrypt.MinCost, TOTPIssuer: "Worm"})
+	users := NewUserService(store, bcrypt.MinCost)
+	if _, err := users.CreateUser(ctx, "root", "correct-horse", models.RoleSuperadmin, nil); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	user, err := store.Users.FindByUsername(ctx, "root")
+	if err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+
+	secret, _, err := auth.EnrollTwoFactor(ctx, user)
+	if err != nil {
+		t.Fatalf("enroll: %v", err)
+	}
+	if _, err := auth.ConfirmTwoFactor(ctx, user, totpAt(t, secret, time.Now())); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	return auth, user, secret
+}
+
+func TestLoginRejectsReplayedTOTPCode(t *testing.T) {
+	auth, _, secret := newTwoFactorAdmin(t)
+	ctx := context.Background()
+
+	// รหัสที่ใช้ยืนยันตอนเปิด 2FA ใช้ล็อกอินไม่ได้
+	if _, err := auth.Login(ctx, "root", "correct-horse", totpAt(t, secret, time.Now())); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("login with the confirmation code: got %v, want ErrInvalidTwoFactorCode", err)
+	}
+
+	// ช่วงเวลาถัดไป (อยู่ในช่วงคลาดเคลื่อนที่ยอมรับ) ใช้ได้ครั้งเดียว
+	next := totpAt(t, secret, time.Now().Add(30*time.Second))
+	if _, err := auth.Login(ctx, "root", "correct-horse", next); err != nil {
+		t.Fatalf("first login with a fresh code: %v", err)
+	}
+	if _, err := auth.Login(ctx, "root", "correct-horse", next); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("replayed code: got %v, want ErrInvalidTwoFactorCode", err)
+	}
+}
+
+func TestUpdateDoesNotRewindTOTPStep(t *testing.T) {
+	auth, user, secret := newTwoFactorAdmin(t)
+	ctx := repository.AllTenants(context.Background())
+
+	next := totpAt(t, secret, time.Now().Add(30*time.Second))
+	if ok, err := auth.VerifySecondFactor(ctx, user, next); err != nil || !ok {
+		t.Fatalf("verify fresh code: ok=%v err=%v", ok, err)
+	}
+	// user ถูกอ่านมาก่อนใช้รหัส Update จาก Struct เก่าต้องไม่ทำให้รหัสเดิมใช้ได้อีก
+	if err := auth.users.Update(ctx, user); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if ok, err := auth.VerifySecondFactor(ctx, user, next); err != nil || ok {
+		t.Fatalf("replay after update: ok=%v err=%v, want rejected", ok, err)
+	}
+}
+
+func TestLoginChecksPasswordBeforeAccountState(t *testing.T) {
+	ctx := repository.AllTenants(context.Background())
+	store := memory.NewStore()
+	auth := NewAuthService(store, config.AuthConfig{BcryptCost: bcrypt.MinCost})
+	users := NewUserService(store, bcrypt.MinCost)
+	if _, err := users.CreateUser(ctx, "root", "correct-horse", models.RoleSuperadmin, nil); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := users.SetDisabled(ctx, "root", true); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+
+	// ผู้ที่ไม่รู้รหัสผ่านต้องไม่รู้ว่าบัญชีถูกปิด
+	if _, err := auth.Login(ctx, "root", "wrong", ""); !errors.Is(err, ErrIncorrectPassword) {
+		t.Fatalf("wrong password on a disabled account: got %v, want ErrIncorrectPassword", err)
+	}
+	if _, err := auth.Login(ctx, "root", "correct-horse", ""); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("correct password on a disabled account: got %v, want ErrUserDisabled", err)
+	}
+	if _, err := auth.Login(ctx, "nobody", "correct-horse", ""); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("unknown user: got %v, want ErrUserNotFound", err)
+	}
+}
@@ -0,0 +1,454 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"worm/config"
+	"worm/models"
+	"worm/repository"
+)
+
+const (
+	// ความยาวสูงสุดของชื่อ Rule (ตัวอักษร)
+	maxRuleNameLength = 100
+	// เงื่อนไขต้องเป็นจริงต่อเนื่องได้นานสุด 1 วัน
+	maxRuleForSeconds = 24 * 60 * 60
+	// Cooldown ต่ำสุด / สูงสุด (กัน Rule สั่ง Relay รัวทุกครั้งที่มีค่าใหม่)
+	minRuleCooldown = 60
+	maxRuleCooldown = 7 * 24 * 60 * 60
+	// จำนวน Log ล่าสุดที่คืนต่อ Rule
+	ruleLogLimit = 200
+	// จำนวนครั้งที่ลองบันทึกสถานะเมื่อการประเมินอื่นบันทึกตัดหน้า
+	stateSaveAttempts = 3
+)
+
+// AutomationService ประเมิน Automation Rule เมื่อมีค่า Sensor ใหม่ (ReadingObserver) และตามรอบเวลา (EvaluateAll)
+// เงื่อนไขเป็นจริงต่อเนื่อง ForSeconds → ทำงาน (สั่ง Actuator, หรือบันทึกว่าจะสั่งในโหมด dry_run) → รอ Cooldown
+type AutomationService struct {
+	rules       repository.AutomationRuleRepository
+	logs        repository.AutomationLogRepository
+	locations   repository.LocationRepository
+	assignments repository.DeviceAssignmentRepository
+	sensors     repository.SensorRepository
+	actuators   *ActuatorService
+	cfg         config.AutomationConfig
+}
+
+// NewAutomationService สร้าง AutomationService (สั่ง Actuator ผ่าน actuators)
+func NewAutomationService(store *repository.Store, actuators *ActuatorService, cfg config.AutomationConfig) *AutomationService {
+	return &AutomationService{
+		rules:       store.Rules,
+		logs:        store.RuleLogs,
+		locations:   store.Locations,
+		assignments: store.Assignments,
+		sensors:     store.Sensors,
+		actuators:   actuators,
+		cfg:         cfg,
+	}
+}
+
+// RuleInput ข้อมูลของ Rule ใหม่
+type RuleInput struct {
+	Name string
+	// ไม่ระบุ = dry_run
+	Mode       string
+	LocationID uint
+	Metric     string
+	Operator   string
+	Threshold  float64
+	Hysteresis float64
+	// วินาที
+	ForSeconds      int
+	CooldownSeconds int
+	ActuatorID      uint
+	Command         CommandInput
+}
+
+// RuleUpdate ค่าที่ต้องการแก้ (nil = ไม่แก้)
+type RuleUpdate struct {
+	Name            *string
+	Mode            *string
+	LocationID      *uint
+	Metric          *string
+	Operator        *string
+	Threshold       *float64
+	Hysteresis      *float64
+	ForSeconds      *int
+	CooldownSeconds *int
+	ActuatorID      *uint
+	// ไม่ nil = แทนคำสั่งเดิมทั้งชุด
+	Command *CommandInput
+}
+
+// CreateRule สร้าง Rule (องค์กรตาม Location, Actuator ต้องอยู่องค์กรเดียวกัน)
+func (s *AutomationService) CreateRule(ctx context.Context, in RuleInput) (*models.AutomationRule, error) {
+	rule := models.AutomationRule{
+		Name:            in.Name,
+		Mode:            in.Mode,
+		LocationID:      in.LocationID,
+		Metric:          in.Metric,
+		Operator:        in.Operator,
+		Threshold:       in.Threshold,
+		Hysteresis:      in.Hysteresis,
+		ForSeconds:      in.ForSeconds,
+		CooldownSeconds: in.CooldownSeconds,
+		ActuatorID:      in.ActuatorID,
+		Action:          in.Command.Action,
+		DutyCycle:       in.Command.DutyCycle,
+		DurationSeconds: in.Command.DurationSeconds,
+	}
+	if rule.Mode == "" {
+		rule.Mode = models.RuleModeDryRun
+	}
+	if err := s.validRule(ctx, &rule); err != nil {
+		return nil, err
+	}
+	if err := s.rules.Create(ctx, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateRule แก้ค่าตั้งของ Rule แล้วเริ่มนับเงื่อนไขใหม่ (Cooldown จากการทำงานครั้งล่าสุดยังมีผล)
+func (s *AutomationService) UpdateRule(ctx context.Context, id uint, upd RuleUpdate) (*models.AutomationRule, error) {
+	rule, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upd.Name != nil {
+		rule.Name = *upd.Name
+	}
+	if upd.Mode != nil {
+		rule.Mode = *upd.Mode
+	}
+	if upd.LocationID != nil {
+		rule.LocationID = *upd.LocationID
+	}
+	if upd.Metric != nil {
+		rule.Metric = *upd.Metric
+	}
+	if upd.Operator != nil {
+		rule.Operator = *upd.Operator
+	}
+	if upd.Threshold != nil {
+		rule.Threshold = *upd.Threshold
+	}
+	if upd.Hysteresis != nil {
+		rule.Hysteresis = *upd.Hysteresis
+	}
+	if upd.ForSeconds != nil {
+		rule.ForSeconds = *upd.ForSeconds
+	}
+	if upd.CooldownSeconds != nil {
+		rule.CooldownSeconds = *upd.CooldownSeconds
+	}
+	if upd.ActuatorID != nil {
+		rule.ActuatorID = *upd.ActuatorID
+	}
+	if upd.Command != nil {
+		rule.Action, rule.DutyCycle, rule.DurationSeconds = upd.Command.Action, upd.Command.DutyCycle, upd.Command.DurationSeconds
+	}
+	if err := s.validRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	rule.ConditionMet = false
+	rule.ConditionSince = nil
+	if err := s.rules.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteRule ลบ Rule พร้อม Log
+func (s *AutomationService) DeleteRule(ctx context.Context, id uint) (*models.AutomationRule, error) {
+	rule, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.rules.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// FindByID หา Rule (Rule ขององค์กรอื่นถือว่าไม่พบ)
+func (s *AutomationService) FindByID(ctx context.Context, id uint) (*models.AutomationRule, error) {
+	rule, err := s.rules.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRuleNotFound
+	}
+	return rule, err
+}
+
+// GetAllRules Rule ทั้งหมดที่ผู้เรียกเห็นได้
+func (s *AutomationService) GetAllRules(ctx context.Context) ([]models.AutomationRule, error) {
+	return s.rules.List(ctx)
+}
+
+// RuleLog การทำงานล่าสุดของ Rule เรียงจากล่าสุด (ไม่เกิน 200 รายการ)
+func (s *AutomationService) RuleLog(ctx context.Context, id uint) ([]models.AutomationLog, error) {
+	if _, err := s.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+	entries, err := s.logs.ListForRule(ctx, id, ruleLogLimit)
+	if entries == nil {
+		entries = []models.AutomationLog{}
+	}
+	return entries, err
+}
+
+// ObserveReading ประเมิน Rule ของ Location ที่ Device ของค่านี้ติดตั้งอยู่ (รวม Location ชั้นบน)
+func (s *AutomationService) ObserveReading(ctx context.Context, data *models.SensorData) error {
+	if data.DeviceID == nil {
+		return nil
+	}
+	assignment, err := s.assignments.Current(ctx, *data.DeviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rules, err := s.rules.List(ctx)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	all, err := s.locations.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Mode == models.RuleModeDisabled || !isDescendant(all, assignment.LocationID, rule.LocationID) {
+			continue
+		}
+		if err := s.evaluate(ctx, rule, all, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", rule.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EvaluateAll ประเมินทุก Rule ที่เปิดอยู่ที่ ctx มองเห็น (Worker เรียกด้วย repository.AllTenants)
+// Rule ที่ประเมินไม่สำเร็จไม่หยุด Rule อื่น
+func (s *AutomationService) EvaluateAll(ctx context.Context) error {
+	rules, err := s.rules.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Location แยกตามองค์กร และประเมินแต่ละ Rule ใน Tenant ขององค์กรนั้น (คำสั่งจะเป็นขององค์กรเดียวกับ Rule)
+	locations := map[uint][]models.Location{}
+	var errs []error
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Mode == models.RuleModeDisabled {
+			continue
+		}
+		orgCtx := repository.OrganizationTenant(ctx, rule.OrganizationID)
+		all, ok := locations[rule.OrganizationID]
+		if !ok {
+			if all, err = s.locations.List(orgCtx); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			locations[rule.OrganizationID] = all
+		}
+		if err := s.evaluate(orgCtx, rule, all, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", rule.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// --- Internal Logic ---
+
+// evaluate ปรับสถานะเงื่อนไขของ rule ตามค่าล่าสุด แล้วทำงานถ้าเงื่อนไขเป็นจริงนานพอและพ้น Cooldown
+// Ingest กับ Worker ประเมิน Rule เดียวกันพร้อมกันได้ ถ้าอีกทางบันทึกสถานะไปก่อนจะโหลด Rule ใหม่แล้วประเมินซ้ำ
+func (s *AutomationService) evaluate(ctx context.Context, rule *models.AutomationRule, all []models.Location, now time.Time) error {
+	var value float64
+	var met bool
+	for attempt := 1; ; attempt++ {
+		v, ok, err := s.currentValue(ctx, rule, all, now)
+		if err != nil {
+			return err
+		}
+		value, met = v, ok && conditionHolds(rule, v)
+		switch {
+		case !met:
+			rule.ConditionSince = nil
+		case !rule.ConditionMet:
+			rule.ConditionSince = &now
+		}
+		rule.ConditionMet = met
+		rule.LastValue = nil
+		if ok {
+			rule.LastValue = &value
+		}
+		rule.LastEvaluatedAt = &now
+		saved, err := s.rules.SaveState(ctx, rule)
+		if err != nil {
+			return err
+		}
+		if saved {
+			break
+		}
+		// อีกทางเพิ่งบันทึกสถานะที่ใหม่กว่า ถ้ายังแย่งกันไม่จบก็ปล่อยรอบนี้ไป
+		if attempt == stateSaveAttempts {
+			return nil
+		}
+		fresh, err := s.rules.FindByID(ctx, rule.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fresh.Mode == models.RuleModeDisabled {
+			return nil
+		}
+		*rule = *fresh
+	}
+
+	if !met || now.Sub(*rule.ConditionSince) < time.Duration(rule.ForSeconds)*time.Second {
+		return nil
+	}
+	if rule.LastFiredAt != nil && now.Sub(*rule.LastFiredAt) < time.Duration(rule.CooldownSeconds)*time.Second {
+		return nil
+	}
+	// Instance อื่น (หรือค่าที่เข้ามาพร้อมกัน) ทำงานรอบนี้ไปแล้ว
+	fired, err := s.rules.MarkFired(ctx, rule.ID, rule.FiredCount, now)
+	if err != nil || !fired {
+		return err
+	}
+	return s.act(ctx, rule, value, now)
+}
+
+// act สั่ง Actuator ตาม rule แล้วบันทึกผล (Cooldown เริ่มนับแล้วแม้สั่งไม่สำเร็จหรือถูก Override)
+func (s *AutomationService) act(ctx context.Context, rule *models.AutomationRule, value float64, now time.Time) error {
+	entry := models.AutomationLog{OrganizationID: rule.OrganizationID, RuleID: rule.ID, Value: value}
+	desc := describeAction(rule.Action, rule.DutyCycle, rule.DurationSeconds)
+	actuator, err := s.actuators.FindByID(ctx, rule.ActuatorID)
+	switch {
+	case err != nil:
+		entry.Outcome = models.RuleOutcomeFailed
+		entry.Details = err.Error()
+	case actuator.OverrideActive(now):
+		entry.Outcome = models.RuleOutcomeOverride
+		entry.Details = fmt.Sprintf("skipped %s: %s is in manual override", desc, actuator.Name)
+	case rule.Mode == models.RuleModeDryRun:
+		entry.Outcome = models.RuleOutcomeDryRun
+		entry.Details = fmt.Sprintf("would send %s to %s", desc, actuator.Name)
+	default:
+		in := CommandInput{Action: rule.Action, DutyCycle: rule.DutyCycle, DurationSeconds: rule.DurationSeconds}
+		cmd, err := s.actuators.SendCommand(ctx, actuator.ID, in, models.CommandSourceRule, nil)
+		if err != nil {
+			entry.Outcome = models.RuleOutcomeFailed
+			entry.Details = err.Error()
+		} else {
+			entry.Outcome = models.RuleOutcomeFired
+			entry.CommandID = &cmd.ID
+			entry.Details = fmt.Sprintf("sent %s to %s", desc, actuator.Name)
+		}
+	}
+	return s.logs.Create(ctx, &entry)
+}
+
+// currentValue ค่าเฉลี่ยของ Metric ใต้ Location ของ rule ในช่วง automation.reading_window ล่าสุด (ok = false เมื่อไม่มีค่า)
+func (s *AutomationService) currentValue(ctx context.Context, rule *models.AutomationRule, all []models.Location, now time.Time) (float64, bool, error) {
+	out, err := s.sensors.Aggregate(ctx, repository.SensorAggregateQuery{
+		LocationIDs: subtree(all, rule.LocationID),
+		From:        now.Add(-time.Duration(s.cfg.ReadingWindow)),
+		To:          now,
+	})
+	if err != nil || len(out) == 0 {
+		return 0, false, err
+	}
+	avg := out[0].TemperatureAvg
+	if rule.Metric == models.MetricHumidity {
+		avg = out[0].HumidityAvg
+	}
+	if avg == nil {
+		return 0, false, nil
+	}
+	return *avg, true, nil
+}
+
+// conditionHolds เงื่อนไขเป็นจริงหรือไม่ (Schmitt Trigger: เมื่อเป็นจริงแล้วค่าต้องเลย Threshold ไปอีก Hysteresis จึงกลับเป็นเท็จ)
+func conditionHolds(rule *models.AutomationRule, value float64) bool {
+	threshold := rule.Threshold
+	if rule.Operator == models.OperatorBelow {
+		if rule.ConditionMet {
+			threshold += rule.Hysteresis
+		}
+		return value < threshold
+	}
+	if rule.ConditionMet {
+		threshold -= rule.Hysteresis
+	}
+	return value > threshold
+}
+
+// validRule ตรวจค่าตั้งของ rule, หา Location / Actuator และกำหนดองค์กรของ rule ตาม Location
+func (s *AutomationService) validRule(ctx context.Context, rule *models.AutomationRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" || utf8.RuneCountInString(rule.Name) > maxRuleNameLength {
+		return ErrInvalidRuleName
+	}
+	switch rule.Mode {
+	case models.RuleModeActive, models.RuleModeDryRun, models.RuleModeDisabled:
+	default:
+		return ErrInvalidRuleMode
+	}
+	if (rule.Metric != models.MetricTemperature && rule.Metric != models.MetricHumidity) ||
+		(rule.Operator != models.OperatorBelow && rule.Operator != models.OperatorAbove) ||
+		rule.Hysteresis < 0 {
+		return ErrInvalidRuleCondition
+	}
+	if rule.ForSeconds < 0 || rule.ForSeconds > maxRuleForSeconds ||
+		rule.CooldownSeconds < minRuleCooldown || rule.CooldownSeconds > maxRuleCooldown {
+		return ErrInvalidRuleTiming
+	}
+	if err := validCommand(CommandInput{Action: rule.Action, DutyCycle: rule.DutyCycle, DurationSeconds: rule.DurationSeconds}); err != nil {
+		return err
+	}
+
+	location, err := s.locations.FindByID(ctx, rule.LocationID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrLocationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	actuator, err := s.actuators.FindByID(ctx, rule.ActuatorID)
+	if err != nil {
+		return err
+	}
+	if actuator.OrganizationID != location.OrganizationID {
+		return ErrOrganizationMismatch
+	}
+	rule.OrganizationID = location.OrganizationID
+	return nil
+}
+
+// usedByRule คืน ErrUsedByRule ถ้ามี Rule ที่ uses คืน true
+func usedByRule(ctx context.Context, rules repository.AutomationRuleRepository, uses func(*models.AutomationRule) bool) error {
+	all, err := rules.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range all {
+		if uses(&all[i]) {
+			return ErrUsedByRule
+		}
+	}
+	return nil
+}
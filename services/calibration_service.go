@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"worm/derived"
+	"worm/models"
+	"worm/repository"
+)
+
+const (
+	// Offset สูงสุด (°C หรือ %RH) และช่วงของ Gain
+	maxCalibrationOffset = 50.0
+	minCalibrationGain   = 0.5
+	maxCalibrationGain   = 2.0
+	// จำนวนจุดของ table
+	minCalibrationPoints = 2
+	maxCalibrationPoints = 20
+)
+
+// CalibrationService จัดการ Calibration ของ Device และคำนวณค่าที่บันทึกไปแล้วใหม่
+// ค่าใหม่ถูกแก้ตอนบันทึกโดย SensorService
+type CalibrationService struct {
+	store        *repository.Store
+	calibrations repository.CalibrationRepository
+	devices      repository.DeviceRepository
+	sensors      repository.SensorRepository
+	pipeline     derived.Pipeline
+}
+
+// NewCalibrationService สร้าง CalibrationService (pipeline เดียวกับของ SensorService)
+func NewCalibrationService(store *repository.Store, pipeline derived.Pipeline) *CalibrationService {
+	return &CalibrationService{
+		store:        store,
+		calibrations: store.Calibrations,
+		devices:      store.Devices,
+		sensors:      store.Sensors,
+		pipeline:     pipeline,
+	}
+}
+
+// CalibrationInput ข้อมูลของ Calibration ใหม่ (ใช้เฉพาะค่าของ Method นั้น ค่าอื่นต้องว่าง)
+type CalibrationInput struct {
+	Metric string
+	Method string
+	// offset / linear
+	Offset *float64
+	// เฉพาะ linear
+	Gain *float64
+	// เฉพาะ table
+	Points []models.CalibrationPoint
+	// nil = ตอนนี้
+	ValidFrom *time.Time
+	// nil = ไม่สิ้นสุด (จนกว่าจะเพิ่ม Calibration ที่เริ่มทีหลัง)
+	ValidUntil *time.Time
+	Notes      string
+}
+
+// CreateCalibration เพิ่ม Calibration ให้ Device (องค์กรตาม Device)
+// Calibration เดิมที่ยังไม่สิ้นสุดและเริ่มก่อนจะสิ้นสุดที่ ValidFrom ของตัวใหม่ ค่าที่บันทึกไปแล้วไม่เปลี่ยนจนกว่าจะเรียก Recompute
+func (s *CalibrationService) CreateCalibration(ctx context.Context, deviceID uint, in CalibrationInput) (*models.Calibration, error) {
+	calibration, err := validCalibration(in)
+	if err != nil {
+		return nil, err
+	}
+	device, err := s.findDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	calibration.OrganizationID = device.OrganizationID
+	calibration.DeviceID = device.ID
+	// ตรวจช่วงเวลา ปิดตัวเดิม และเพิ่มตัวใหม่ใน Transaction เดียว
+	err = s.store.Transaction(ctx, func(tx *repository.Store) error {
+		closing, err := openBefore(ctx, tx.Calibrations, calibration)
+		if err != nil {
+			return err
+		}
+		for _, id := range closing {
+			if err := tx.Calibrations.SetValidUntil(ctx, id, calibration.ValidFrom); err != nil {
+				return err
+			}
+		}
+		return tx.Calibrations.Create(ctx, calibration)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return calibration, nil
+}
+
+// DeleteCalibration ลบ Calibration (ค่าที่บันทึกไปแล้วไม่เปลี่ยนจนกว่าจะเรียก Recompute)
+func (s *CalibrationService) DeleteCalibration(ctx context.Context, id uint) (*models.Calibration, error) {
+	calibration, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.calibrations.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCalibrationNotFound
+		}
+		return nil, err
+	}
+	return calibration, nil
+}
+
+// FindByID หา Calibration (ของ Device องค์กรอื่นถือว่าไม่พบ)
+func (s *CalibrationService) FindByID(ctx context.Context, id uint) (*models.Calibration, error) {
+	calibration, err := s.calibrations.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCalibrationNotFound
+	}
+	return calibration, err
+}
+
+// Calibrations Calibration ทั้งหมดของ Device (รวมที่สิ้นสุดแล้ว)
+func (s *CalibrationService) Calibrations(ctx context.Context, deviceID uint) ([]models.Calibration, error) {
+	if _, err := s.findDevice(ctx, deviceID); err != nil {
+		return nil, err
+	}
+	return s.calibrations.ListForDevice(ctx, deviceID)
+}
+
+// Recompute แก้ค่าที่ Device บันทึกในช่วง [from, to) ใหม่จากค่าดิบตาม Calibration ปัจจุบัน (to nil = ตอนนี้)
+// และคำนวณค่าเพิ่มตาม pipeline ใหม่ คืนจำนวนค่าที่เปลี่ยน Automation Rule ไม่ถูกประเมินซ้ำ
+func (s *CalibrationService) Recompute(ctx context.Context, deviceID uint, from time.Time, to *time.Time) (int64, error) {
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	if !from.Before(end) {
+		return 0, ErrInvalidTimeRange
+	}
+	if _, err := s.findDevice(ctx, deviceID); err != nil {
+		return 0, err
+	}
+	list, err := s.calibrations.ListForDevice(ctx, deviceID)
+	if err != nil {
+		return 0, err
+	}
+	return s.sensors.Recompute(ctx, deviceID, from, end, func(d *models.SensorData) bool {
+		changed := calibrate(d, list, d.CreatedAt)
+		values := s.pipeline.Apply(d.Temperature, d.Humidity)
+		if !maps.Equal(values, d.Derived) {
+			d.Derived = values
+			changed = true
+		}
+		return changed
+	})
+}
+
+// --- Internal Logic ---
+
+func (s *CalibrationService) findDevice(ctx context.Context, id uint) (*models.Device, error) {
+	device, err := s.devices.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	return device, err
+}
+
+// calibrate ตั้ง Temperature / Humidity ของ d จากค่าดิบตาม Calibration ที่ใช้ได้ ณ เวลา at
+// (ไม่มี = ค่าดิบ) คืน true ถ้าค่าเปลี่ยน
+func calibrate(d *models.SensorData, list []models.Calibration, at time.Time) bool {
+	temp, humidity := d.RawTemperature, d.RawHumidity
+	for i := range list {
+		c := &list[i]
+		if !c.ValidAt(at) {
+			continue
+		}
+		switch c.Metric {
+		case models.MetricTemperature:
+			temp = math.Round(c.Apply(d.RawTemperature)*100) / 100
+		case models.MetricHumidity:
+			// ความชื้นสัมพัทธ์เกิน 0-100 ไม่ได้
+			humidity = math.Min(maxHumidity, math.Max(minHumidity, math.Round(c.Apply(d.RawHumidity)*100)/100))
+		}
+	}
+	changed := temp != d.Temperature || humidity != d.Humidity
+	d.Temperature, d.Humidity = temp, humidity
+	return changed
+}
+
+// openBefore ID ของ Calibration ที่ต้องปิดที่ ValidFrom ของ calibration
+// (ยังไม่สิ้นสุด Metric เดียวกัน และเริ่มก่อน) คืน ErrCalibrationOverlap ถ้าซ้อนกับตัวอื่น
+func openBefore(ctx context.Context, calibrations repository.CalibrationRepository, calibration *models.Calibration) ([]uint, error) {
+	existing, err := calibrations.ListForDevice(ctx, calibration.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	var closing []uint
+	for _, e := range existing {
+		if e.Metric != calibration.Metric {
+			continue
+		}
+		if e.ValidUntil == nil && e.ValidFrom.Before(calibration.ValidFrom) {
+			closing = append(closing, e.ID)
+			continue
+		}
+		if overlaps(e.ValidFrom, e.ValidUntil, calibration.ValidFrom, calibration.ValidUntil) {
+			return nil, ErrCalibrationOverlap
+		}
+	}
+	return closing, nil
+}
+
+// overlaps ช่วง [aFrom, aUntil) กับ [bFrom, bUntil) ซ้อนกันหรือไม่ (nil = ไม่สิ้นสุด)
+func overlaps(aFrom time.Time, aUntil *time.Time, bFrom time.Time, bUntil *time.Time) bool {
+	return (aUntil == nil || bFrom.Before(*aUntil)) && (bUntil == nil || aFrom.Before(*bUntil))
+}
+
+// validCalibration ตรวจค่าตาม Method แล้วสร้าง Calibration (ยังไม่มี Device / องค์กร)
+func validCalibration(in CalibrationInput) (*models.Calibration, error) {
+	metric := strings.ToLower(strings.TrimSpace(in.Metric))
+	var lo, hi float64
+	switch metric {
+	case models.MetricTemperature:
+		lo, hi = minTemperature, maxTemperature
+	case models.MetricHumidity:
+		lo, hi = minHumidity, maxHumidity
+	default:
+		return nil, ErrInvalidCalibrationMetric
+	}
+
+	calibration := &models.Calibration{
+		Metric:     metric,
+		Method:     strings.ToLower(strings.TrimSpace(in.Method)),
+		Gain:       1,
+		ValidFrom:  time.Now(),
+		ValidUntil: in.ValidUntil,
+		Notes:      strings.TrimSpace(in.Notes),
+	}
+	switch calibration.Method {
+	case models.CalibrationOffset, models.CalibrationLinear:
+		if len(in.Points) > 0 {
+			return nil, fmt.Errorf("%w: points are only allowed with method 'table'", ErrInvalidCalibration)
+		}
+		if in.Offset != nil {
+			calibration.Offset = *in.Offset
+		}
+		if math.Abs(calibration.Offset) > maxCalibrationOffset {
+			return nil, fmt.Errorf("%w: offset must be between -%g and %g", ErrInvalidCalibration, maxCalibrationOffset, maxCalibrationOffset)
+		}
+		if calibration.Method == models.CalibrationOffset {
+			if in.Offset == nil || in.Gain != nil {
+				return nil, fmt.Errorf("%w: method 'offset' requires offset and does not allow gain", ErrInvalidCalibration)
+			}
+			break
+		}
+		if in.Gain == nil || *in.Gain < minCalibrationGain || *in.Gain > maxCalibrationGain {
+			return nil, fmt.Errorf("%w: method 'linear' requires gain between %g and %g", ErrInvalidCalibration, minCalibrationGain, maxCalibrationGain)
+		}
+		calibration.Gain = *in.Gain
+	case models.CalibrationTable:
+		if in.Offset != nil || in.Gain != nil {
+			return nil, fmt.Errorf("%w: method 'table' does not allow offset or gain", ErrInvalidCalibration)
+		}
+		if len(in.Points) < minCalibrationPoints || len(in.Points) > maxCalibrationPoints {
+			return nil, fmt.Errorf("%w: method 'table' requires %d-%d points", ErrInvalidCalibration, minCalibrationPoints, maxCalibrationPoints)
+		}
+		for i, p := range in.Points {
+			if p.Raw < lo || p.Raw > hi || p.Actual < lo || p.Actual > hi {
+				return nil, fmt.Errorf("%w: points must be between %g and %g", ErrInvalidCalibration, lo, hi)
+			}
+			if i > 0 && p.Raw <= in.Points[i-1].Raw {
+				return nil, fmt.Errorf("%w: points must be sorted by raw without duplicates", ErrInvalidCalibration)
+			}
+		}
+		calibration.Points = in.Points
+	default:
+		return nil, ErrInvalidCalibrationMethod
+	}
+
+	if in.ValidFrom != nil {
+		calibration.ValidFrom = *in.ValidFrom
+	}
+	if calibration.ValidUntil != nil && !calibration.ValidFrom.Before(*calibration.ValidUntil) {
+		return nil, ErrInvalidValidity
+	}
+	if utf8.RuneCountInString(calibration.Notes) > maxNotesLength {
+		return nil, ErrInvalidNotes
+	}
+	return calibration, nil
+}
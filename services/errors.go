@@ -0,0 +1,170 @@
+// Package services เก็บกฎทางธุรกิจ (Business Rules) ของระบบ
+// รับ Repository ผ่าน Constructor และไม่รู้จัก HTTP / Gin
+package services
+
+import "errors"
+
+var (
+	// ErrUserNotFound ไม่พบ User
+	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidUsername Username ผิดรูปแบบ
+	ErrInvalidUsername = errors.New("username must be 3-64 characters: letters, digits, '.', '_', '-' or '@', starting with a letter or digit")
+	// ErrInvalidRole Role ต้องเป็น superadmin, admin หรือ user
+	ErrInvalidRole = errors.New("role must be 'superadmin', 'admin' or 'user'")
+	// ErrIncorrectPassword รหัสผ่านไม่ถูกต้อง
+	ErrIncorrectPassword = errors.New("incorrect password")
+	// ErrSamePassword รหัสผ่านใหม่ต้องไม่ซ้ำกับรหัสเดิม
+	ErrSamePassword = errors.New("new password must be different from the current one")
+	// ErrPasswordLoginDisabled บัญชีนี้ต้องเข้าผ่าน SSO เท่านั้น
+	ErrPasswordLoginDisabled = errors.New("password login is disabled for this account, use SSO")
+	// ErrUserDisabled บัญชีถูกปิดใช้งาน
+	ErrUserDisabled = errors.New("account is disabled")
+	// ErrInvalidAPIKey API Key ไม่ถูกต้อง
+	ErrInvalidAPIKey = errors.New("invalid API key")
+
+	// ErrTwoFactorRequired ต้องส่งรหัส 2FA มาด้วย
+	ErrTwoFactorRequired = errors.New("two-factor code required")
+	// ErrInvalidTwoFactorCode รหัส 2FA ไม่ถูกต้อง
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrTwoFactorAlreadyEnabled เปิด 2FA ไว้แล้ว
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled ยังไม่ได้เปิด 2FA
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrTwoFactorNotEnrolled ยังไม่ได้เรียก Enroll
+	ErrTwoFactorNotEnrolled = errors.New("call /api/2fa/enroll first")
+	// ErrTwoFactorMandatory Admin ปิด 2FA ไม่ได้เมื่อระบบบังคับ
+	ErrTwoFactorMandatory = errors.New("two-factor authentication is required for admin accounts")
+
+	// ErrOrganizationNotFound ไม่พบองค์กร (รวมถึงองค์กรที่ผู้เรียกมองไม่เห็น)
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrInvalidOrganizationName ชื่อองค์กรผิดรูปแบบ
+	ErrInvalidOrganizationName = errors.New("organization name must be 1-100 characters")
+	// ErrOrganizationRequired User ที่ไม่ใช่ Superadmin ต้องอยู่ในองค์กร
+	ErrOrganizationRequired = errors.New("admin and user accounts must belong to an organization, set organization_id")
+	// ErrSuperadminOrganization Superadmin ไม่สังกัดองค์กรใด
+	ErrSuperadminOrganization = errors.New("superadmin accounts do not belong to an organization")
+	// ErrSuperadminOnly การกระทำข้ามองค์กรทำได้เฉพาะ Superadmin
+	ErrSuperadminOnly = errors.New("only a superadmin can grant or revoke the superadmin role or move users between organizations")
+	// ErrNoOrganization บัญชีที่ไม่สังกัดองค์กร (Superadmin) ส่งค่า Sensor ไม่ได้
+	ErrNoOrganization = errors.New("this account does not belong to an organization, use an account of the organization that owns the device")
+
+	// ErrLocationNotFound ไม่พบ Location (รวมถึง Location ขององค์กรอื่น)
+	ErrLocationNotFound = errors.New("location not found")
+	// ErrInvalidLocationName ชื่อ Location ผิดรูปแบบ
+	ErrInvalidLocationName = errors.New("location name must be 1-100 characters")
+	// ErrInvalidLocationKind ชนิดของ Location ผิดรูปแบบ
+	ErrInvalidLocationKind = errors.New("location kind must be 1-32 characters: lowercase letters, digits, '_' or '-'")
+	// ErrLocationParent Bin มี Location ลูกไม่ได้
+	ErrLocationParent = errors.New("a bin cannot contain other locations")
+	// ErrLocationCycle ย้าย Location ไปไว้ใต้ตัวเองหรือลูกหลานของตัวเอง
+	ErrLocationCycle = errors.New("a location cannot be moved under itself or one of its descendants")
+	// ErrLocationHasChildren Location ยังมี Location ลูก
+	ErrLocationHasChildren = errors.New("location still contains other locations")
+	// ErrLocationInUse Location มีประวัติการติดตั้ง Device (ลบแล้วข้อมูลย้อนหลังจะหาที่อยู่ไม่ได้)
+	ErrLocationInUse = errors.New("devices are or were assigned to this location, it cannot be deleted or changed from a bin")
+	// ErrNotABin ติดตั้ง Device ได้เฉพาะ Location ชนิด bin
+	ErrNotABin = errors.New("devices can only be assigned to a location of kind 'bin'")
+	// ErrOrganizationMismatch Location แม่, Device และ Location ต้องอยู่องค์กรเดียวกัน (เกิดได้เฉพาะ Superadmin)
+	ErrOrganizationMismatch = errors.New("parent location, device and location must belong to the same organization")
+	// ErrDeviceNotFound ไม่พบ Device (รวมถึง Device ขององค์กรอื่น)
+	ErrDeviceNotFound = errors.New("device not found")
+	// ErrInvalidAssignmentTime เวลาเริ่มติดตั้งต้องไม่ซ้อนกับประวัติเดิมและไม่อยู่ในอนาคต
+	ErrInvalidAssignmentTime = errors.New("started_at must not be in the future or overlap the device's previous assignment")
+	// ErrInvalidTimeRange ช่วงเวลาที่ขอผิด
+	ErrInvalidTimeRange = errors.New("from must be before to")
+	// ErrInvalidInterval ช่วงย่อยสั้นเกินไปหรือแบ่งได้มากเกินไป
+	ErrInvalidInterval = errors.New("interval must be at least 1m and split the range into at most 1000 buckets")
+
+	// ErrActuatorNotFound ไม่พบ Actuator (รวมถึง Actuator ขององค์กรอื่น)
+	ErrActuatorNotFound = errors.New("actuator not found")
+	// ErrInvalidActuatorName ชื่อ Actuator ผิดรูปแบบ
+	ErrInvalidActuatorName = errors.New("actuator name must be 1-100 characters")
+	// ErrInvalidActuatorKind ชนิดของ Actuator ผิดรูปแบบ
+	ErrInvalidActuatorKind = errors.New("actuator kind must be 1-32 characters: lowercase letters, digits, '_' or '-'")
+	// ErrInvalidChannel ช่อง Relay อยู่นอกช่วง
+	ErrInvalidChannel = errors.New("channel must be between 0 and 255")
+	// ErrActuatorInUse Actuator มีประวัติคำสั่ง (ลบแล้วประวัติจะหาเจ้าของไม่ได้)
+	ErrActuatorInUse = errors.New("actuator has a command history, it cannot be deleted")
+	// ErrInvalidCommand คำสั่งไม่รู้จัก
+	ErrInvalidCommand = errors.New("action must be 'on', 'off' or 'duty'")
+	// ErrInvalidDutyCycle duty_cycle ผิด (ต้องมีเฉพาะคำสั่ง duty)
+	ErrInvalidDutyCycle = errors.New("duty_cycle must be 1-100 and is required for, and only allowed with, action 'duty'")
+	// ErrInvalidDuration duration_seconds ผิด (คำสั่ง off ไม่มีระยะเวลา)
+	ErrInvalidDuration = errors.New("duration_seconds must be 1-86400 and is not allowed with action 'off'")
+	// ErrCommandNotFound ไม่พบคำสั่ง (รวมถึงคำสั่งของ Device อื่น)
+	ErrCommandNotFound = errors.New("command not found")
+	// ErrCommandNotDelivered คำสั่งไม่ได้รอการยืนยัน (ยังไม่ถูก Poll, ยืนยันไปแล้ว หรือหมดเวลาแล้ว)
+	ErrCommandNotDelivered = errors.New("command is not waiting for acknowledgement")
+	// ErrInvalidOverride duration_seconds ของ Manual Override ผิด
+	ErrInvalidOverride = errors.New("duration_seconds must be 1-604800, or omitted to override until turned off")
+
+	// ErrRuleNotFound ไม่พบ Automation Rule (รวมถึง Rule ขององค์กรอื่น)
+	ErrRuleNotFound = errors.New("automation rule not found")
+	// ErrInvalidRuleName ชื่อ Rule ผิดรูปแบบ
+	ErrInvalidRuleName = errors.New("rule name must be 1-100 characters")
+	// ErrInvalidRuleMode โหมดไม่รู้จัก
+	ErrInvalidRuleMode = errors.New("mode must be 'active', 'dry_run' or 'disabled'")
+	// ErrInvalidRuleCondition เงื่อนไขผิด
+	ErrInvalidRuleCondition = errors.New("metric must be 'temperature' or 'humidity', operator 'below' or 'above', and hysteresis must not be negative")
+	// ErrInvalidRuleTiming ระยะเวลาของ Rule ผิด
+	ErrInvalidRuleTiming = errors.New("for_seconds must be 0-86400 and cooldown_seconds 60-604800")
+	// ErrUsedByRule Location / Actuator ถูกใช้ใน Automation Rule
+	ErrUsedByRule = errors.New("used by an automation rule, delete or change the rule first")
+
+	// ErrScheduleNotFound ไม่พบ Schedule (รวมถึง Schedule ขององค์กรอื่น)
+	ErrScheduleNotFound = errors.New("schedule not found")
+	// ErrInvalidScheduleName ชื่อ Schedule ผิดรูปแบบ
+	ErrInvalidScheduleName = errors.New("schedule name must be 1-100 characters")
+	// ErrInvalidCron Cron Expression ผิดหรือไม่มีวันตรง
+	ErrInvalidCron = errors.New("cron must have 5 fields (minute hour day-of-month month day-of-week) or be a macro such as @daily")
+	// ErrInvalidTimeZone ไม่รู้จัก Time Zone
+	ErrInvalidTimeZone = errors.New("time_zone must be an IANA time zone such as Asia/Bangkok")
+	// ErrInvalidJob งานไม่รู้จัก
+	ErrInvalidJob = errors.New("job must be 'actuator_command' or 'prune_sensor_data'")
+	// ErrScheduleActuatorRequired งาน actuator_command ต้องระบุ Actuator
+	ErrScheduleActuatorRequired = errors.New("actuator_id is required for job 'actuator_command'")
+	// ErrInvalidRetention retention_days ผิด
+	ErrInvalidRetention = errors.New("retention_days must be 1-3650 and is required for job 'prune_sensor_data'")
+	// ErrUsedBySchedule Actuator ถูกใช้ใน Schedule
+	ErrUsedBySchedule = errors.New("used by a schedule, delete or change the schedule first")
+
+	// ErrEventNotFound ไม่พบบันทึกการดูแล (รวมถึงของ Location ที่ไม่ได้ขอ หรือขององค์กรอื่น)
+	ErrEventNotFound = errors.New("husbandry event not found")
+	// ErrInvalidEventKind ชนิดของบันทึกไม่รู้จัก
+	ErrInvalidEventKind = errors.New("kind must be 'feeding', 'bedding', 'moisture', 'harvest' or 'population'")
+	// ErrInvalidEventFields ค่าไม่ตรงกับชนิดของบันทึก (รายละเอียดต่อท้าย)
+	ErrInvalidEventFields = errors.New("fields do not match the event kind")
+	// ErrInvalidOccurredAt เวลาที่เกิดอยู่ในอนาคต
+	ErrInvalidOccurredAt = errors.New("occurred_at must not be in the future")
+	// ErrInvalidNotes บันทึกยาวเกินไป
+	ErrInvalidNotes = errors.New("notes must be at most 1000 characters")
+	// ErrEventNotABin บันทึกการดูแลได้เฉพาะ Location ชนิด bin
+	ErrEventNotABin = errors.New("husbandry events can only be recorded for a location of kind 'bin'")
+	// ErrLocationHasEvents Location มีบันทึกการดูแล (ลบแล้วบันทึกจะหาที่อยู่ไม่ได้)
+	ErrLocationHasEvents = errors.New("location has husbandry events, it cannot be deleted or changed from a bin")
+	// ErrTimelineRange ช่วงของ Timeline ยาวเกินไป
+	ErrTimelineRange = errors.New("timeline range must be at most 7 days")
+
+	// ErrCalibrationNotFound ไม่พบ Calibration (รวมถึงของ Device ขององค์กรอื่น)
+	ErrCalibrationNotFound = errors.New("calibration not found")
+	// ErrInvalidCalibrationMetric Metric ไม่รู้จัก
+	ErrInvalidCalibrationMetric = errors.New("metric must be 'temperature' or 'humidity'")
+	// ErrInvalidCalibrationMethod วิธีแก้ค่าไม่รู้จัก
+	ErrInvalidCalibrationMethod = errors.New("method must be 'offset', 'linear' or 'table'")
+	// ErrInvalidCalibration ค่าไม่ตรงกับวิธีแก้ค่าหรืออยู่นอกช่วง (รายละเอียดต่อท้าย)
+	ErrInvalidCalibration = errors.New("calibration values are invalid")
+	// ErrInvalidValidity ช่วงเวลาที่ใช้ได้ผิด
+	ErrInvalidValidity = errors.New("valid_until must be after valid_from")
+	// ErrCalibrationOverlap ช่วงเวลาซ้อนกับ Calibration อื่นของ Device / Metric เดียวกัน
+	ErrCalibrationOverlap = errors.New("calibration overlaps another calibration of the same device and metric")
+
+	// ErrReadingOutOfRange ค่าจาก Sensor อยู่นอกช่วงที่เป็นไปได้ (Sensor เสียหรือ Firmware ส่งค่าผิด)
+	ErrReadingOutOfRange = errors.New("reading is outside the physically possible range")
+	// ErrObserverFailed ค่าถูกบันทึกแล้ว แต่ ReadingObserver ทำงานไม่สำเร็จ (ไม่ควรให้ Device ส่งค่าเดิมซ้ำ)
+	ErrObserverFailed = errors.New("reading saved, but processing it failed")
+	// ErrAlertQueueFull คิว Anomaly Alert เต็ม (Webhook ช้าหรือล่ม) Alert นี้ถูกทิ้ง
+	ErrAlertQueueFull = errors.New("anomaly alert queue is full, alert dropped")
+
+	// ErrOIDCUsernameTaken ชื่อจาก IdP ไปชนกับบัญชี Password เดิม (ไม่ผูกให้อัตโนมัติ กันการยึดบัญชี)
+	ErrOIDCUsernameTaken = errors.New("username already belongs to a non-SSO account")
+)
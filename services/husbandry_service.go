@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"worm/models"
+	"worm/repository"
+)
+
+const (
+	// ความยาวสูงสุดของ notes (รวมถึงของ Calibration) และชื่ออาหาร / วัสดุรองพื้น (ตัวอักษร)
+	maxNotesLength         = 1000
+	maxEventMaterialLength = 100
+	// น้ำหนัก (kg) และปริมาณน้ำ (ลิตร) สูงสุดต่อรายการ
+	maxEventQuantity = 1000
+	// จำนวนไส้เดือนที่ประมาณได้สูงสุดต่อ Bin
+	maxWormCount = 100_000_000
+	// ช่วงยาวสุดของ Timeline (ค่า Sensor ทุกค่า ไม่สรุปเป็นช่วง)
+	maxTimelineRange = 7 * 24 * time.Hour
+)
+
+// HusbandryService บันทึกการดูแล Bin และรวมกับค่า Sensor เป็น Timeline
+type HusbandryService struct {
+	events    repository.HusbandryRepository
+	locations repository.LocationRepository
+	sensors   repository.SensorRepository
+}
+
+// NewHusbandryService สร้าง HusbandryService
+func NewHusbandryService(store *repository.Store) *HusbandryService {
+	return &HusbandryService{
+		events:    store.Husbandry,
+		locations: store.Locations,
+		sensors:   store.Sensors,
+	}
+}
+
+// EventInput ข้อมูลของบันทึกใหม่ (ใช้เฉพาะค่าของ Kind นั้น ค่าของชนิดอื่นต้องว่าง)
+type EventInput struct {
+	Kind string
+	// nil = ตอนนี้
+	OccurredAt      *time.Time
+	Notes           string
+	FeedType        string
+	BeddingMaterial string
+	WeightKg        *float64
+	WaterLiters     *float64
+	CastingsKg      *float64
+	WormsKg         *float64
+	WormCount       *int64
+}
+
+// RecordEvent บันทึกการดูแล Bin locationID (องค์กรตาม Bin) recordedBy = User ที่บันทึก
+func (s *HusbandryService) RecordEvent(ctx context.Context, locationID uint, in EventInput, recordedBy *uint) (*models.HusbandryEvent, error) {
+	event, err := validEvent(in)
+	if err != nil {
+		return nil, err
+	}
+	location, err := s.findLocation(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+	if location.Kind != models.LocationKindBin {
+		return nil, ErrEventNotABin
+	}
+
+	event.OrganizationID = location.OrganizationID
+	event.LocationID = location.ID
+	event.RecordedBy = recordedBy
+	if err := s.events.Create(ctx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// DeleteEvent ลบบันทึก (เช่น บันทึกผิด Bin)
+func (s *HusbandryService) DeleteEvent(ctx context.Context, id uint) (*models.HusbandryEvent, error) {
+	event, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.events.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+	return event, nil
+}
+
+// FindByID หาบันทึก (บันทึกขององค์กรอื่นถือว่าไม่พบ)
+func (s *HusbandryService) FindByID(ctx context.Context, id uint) (*models.HusbandryEvent, error) {
+	event, err := s.events.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrEventNotFound
+	}
+	return event, err
+}
+
+// Events บันทึกของ Location id รวมทุก Bin ที่อยู่ใต้ ในช่วง [from, to) (nil = ไม่จำกัด) kind ว่าง = ทุกชนิด
+func (s *HusbandryService) Events(ctx context.Context, id uint, from, to *time.Time, kind string) ([]models.HusbandryEvent, error) {
+	if kind != "" && !validEventKind(kind) {
+		return nil, ErrInvalidEventKind
+	}
+	q := repository.HusbandryQuery{Kind: kind}
+	if from != nil {
+		q.From = *from
+	}
+	if to != nil {
+		q.To = *to
+	}
+	if from != nil && to != nil && !q.From.Before(q.To) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	var err error
+	if q.LocationIDs, err = s.subtree(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.events.List(ctx, q)
+}
+
+// Timeline ค่า Sensor และบันทึกการดูแลของ Location id (รวมทุกชั้นที่อยู่ใต้) ในช่วง [from, to) เรียงตามเวลา
+// nil = 24 ชั่วโมงล่าสุด ช่วงยาวได้ไม่เกิน 7 วัน ค่าแต่ละค่านับให้ Bin ที่ Device ติดตั้งอยู่ ณ เวลาที่บันทึก
+func (s *HusbandryService) Timeline(ctx context.Context, id uint, from, to *time.Time) ([]models.TimelineEntry, error) {
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	start := end.Add(-defaultStatsRange)
+	if from != nil {
+		start = *from
+	}
+	if !start.Before(end) {
+		return nil, ErrInvalidTimeRange
+	}
+	if end.Sub(start) > maxTimelineRange {
+		return nil, ErrTimelineRange
+	}
+
+	ids, err := s.subtree(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	readings, err := s.sensors.ListForLocations(ctx, repository.SensorAggregateQuery{LocationIDs: ids, From: start, To: end})
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.events.List(ctx, repository.HusbandryQuery{LocationIDs: ids, From: start, To: end})
+	if err != nil {
+		return nil, err
+	}
+	return mergeTimeline(readings, events), nil
+}
+
+// --- Internal Logic ---
+
+func (s *HusbandryService) findLocation(ctx context.Context, id uint) (*models.Location, error) {
+	location, err := s.locations.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrLocationNotFound
+	}
+	return location, err
+}
+
+// subtree id และ ID ของลูกหลานทุกชั้น (ErrLocationNotFound ถ้าไม่พบ id)
+func (s *HusbandryService) subtree(ctx context.Context, id uint) ([]uint, error) {
+	if _, err := s.findLocation(ctx, id); err != nil {
+		return nil, err
+	}
+	all, err := s.locations.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return subtree(all, id), nil
+}
+
+// mergeTimeline รวมสองรายการที่เรียงตามเวลาแล้ว (เวลาเท่ากัน = ค่า Sensor ก่อน)
+func mergeTimeline(readings []models.SensorData, events []models.HusbandryEvent) []models.TimelineEntry {
+	out := make([]models.TimelineEntry, 0, len(readings)+len(events))
+	i, j := 0, 0
+	for i < len(readings) || j < len(events) {
+		if j == len(events) || (i < len(readings) && !readings[i].CreatedAt.After(events[j].OccurredAt)) {
+			out = append(out, models.TimelineEntry{Time: readings[i].CreatedAt, Type: models.TimelineReading, Reading: &readings[i]})
+			i++
+			continue
+		}
+		out = append(out, models.TimelineEntry{Time: events[j].OccurredAt, Type: models.TimelineEvent, Event: &events[j]})
+		j++
+	}
+	return out
+}
+
+func validEventKind(kind string) bool {
+	switch kind {
+	case models.EventFeeding, models.EventBedding, models.EventMoisture, models.EventHarvest, models.EventPopulation:
+		return true
+	}
+	return false
+}
+
+// validEvent ตรวจค่าตามชนิดแล้วสร้าง HusbandryEvent (ยังไม่มี Location / องค์กร)
+func validEvent(in EventInput) (*models.HusbandryEvent, error) {
+	kind := strings.ToLower(strings.TrimSpace(in.Kind))
+	if !validEventKind(kind) {
+		return nil, ErrInvalidEventKind
+	}
+	now := time.Now()
+	occurredAt := now
+	if in.OccurredAt != nil {
+		if in.OccurredAt.After(now) {
+			return nil, ErrInvalidOccurredAt
+		}
+		occurredAt = *in.OccurredAt
+	}
+	notes := strings.TrimSpace(in.Notes)
+	if utf8.RuneCountInString(notes) > maxNotesLength {
+		return nil, ErrInvalidNotes
+	}
+
+	event := &models.HusbandryEvent{
+		Kind:            kind,
+		OccurredAt:      occurredAt,
+		Notes:           notes,
+		FeedType:        strings.TrimSpace(in.FeedType),
+		BeddingMaterial: strings.TrimSpace(in.BeddingMaterial),
+		WeightKg:        in.WeightKg,
+		WaterLiters:     in.WaterLiters,
+		CastingsKg:      in.CastingsKg,
+		WormsKg:         in.WormsKg,
+		WormCount:       in.WormCount,
+	}
+
+	// ค่าที่ชนิดนี้ต้องมี / มีได้ ที่เหลือต้องว่าง
+	var required, optional []string
+	switch kind {
+	case models.EventFeeding:
+		required = []string{"feed_type", "weight_kg"}
+	case models.EventBedding:
+		required, optional = []string{"bedding_material"}, []string{"weight_kg"}
+	case models.EventMoisture:
+		required = []string{"water_liters"}
+	case models.EventHarvest:
+		required, optional = []string{"castings_kg"}, []string{"worms_kg"}
+	case models.EventPopulation:
+		required = []string{"worm_count"}
+	}
+	present := map[string]bool{
+		"feed_type":        event.FeedType != "",
+		"bedding_material": event.BeddingMaterial != "",
+		"weight_kg":        event.WeightKg != nil,
+		"water_liters":     event.WaterLiters != nil,
+		"castings_kg":      event.CastingsKg != nil,
+		"worms_kg":         event.WormsKg != nil,
+		"worm_count":       event.WormCount != nil,
+	}
+	allowed := map[string]bool{}
+	for _, name := range required {
+		if !present[name] {
+			return nil, fmt.Errorf("%w: %s requires %s", ErrInvalidEventFields, kind, strings.Join(required, " and "))
+		}
+		allowed[name] = true
+	}
+	for _, name := range optional {
+		allowed[name] = true
+	}
+	for _, name := range []string{"feed_type", "bedding_material", "weight_kg", "water_liters", "castings_kg", "worms_kg", "worm_count"} {
+		if present[name] && !allowed[name] {
+			return nil, fmt.Errorf("%w: %s is not allowed for %s", ErrInvalidEventFields, name, kind)
+		}
+	}
+
+	if utf8.RuneCountInString(event.FeedType) > maxEventMaterialLength || utf8.RuneCountInString(event.BeddingMaterial) > maxEventMaterialLength {
+		return nil, fmt.Errorf("%w: feed_type and bedding_material must be at most %d characters", ErrInvalidEventFields, maxEventMaterialLength)
+	}
+	quantities := []struct {
+		name  string
+		value *float64
+	}{{"weight_kg", event.WeightKg}, {"water_liters", event.WaterLiters}, {"castings_kg", event.CastingsKg}, {"worms_kg", event.WormsKg}}
+	for _, q := range quantities {
+		if q.value != nil && (*q.value <= 0 || *q.value > maxEventQuantity) {
+			return nil, fmt.Errorf("%w: %s must be greater than 0 and at most %d", ErrInvalidEventFields, q.name, maxEventQuantity)
+		}
+	}
+	if event.WormCount != nil && (*event.WormCount < 0 || *event.WormCount > maxWormCount) {
+		return nil, fmt.Errorf("%w: worm_count must be 0-%d", ErrInvalidEventFields, maxWormCount)
+	}
+	return event, nil
+}
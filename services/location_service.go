@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"worm/derived"
+	"worm/models"
+	"worm/repository"
+)
+
+const (
+	// ความยาวสูงสุดของชื่อ Location (ตัวอักษร)
+	maxLocationNameLength = 100
+	// ช่วงเวลาเริ่มต้นของ Stats เมื่อไม่ระบุ from
+	defaultStatsRange = 24 * time.Hour
+	// ช่วงย่อยที่สั้นที่สุดและจำนวนช่วงสูงสุดต่อ Request (กัน Query ที่คืนข้อมูลมหาศาล)
+	minStatsInterval = time.Minute
+	maxStatsBuckets  = 1000
+)
+
+// kindPattern รูปแบบของชนิดที่ผู้ใช้ตั้งเอง (Location, Actuator)
+var kindPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// LocationService จัดการต้นไม้ของ Location, การติดตั้ง Device และการสรุปค่า Sensor ตาม Location
+type LocationService struct {
+	locations   repository.LocationRepository
+	devices     repository.DeviceRepository
+	assignments repository.DeviceAssignmentRepository
+	sensors     repository.SensorRepository
+	rules       repository.AutomationRuleRepository
+	events      repository.HusbandryRepository
+	// ชื่อของค่าที่คำนวณตอนบันทึกที่สรุปใน Stats
+	derived []string
+}
+
+// NewLocationService สร้าง LocationService (Stats สรุปค่าของทุกสูตรใน pipeline ด้วย)
+func NewLocationService(store *repository.Store, pipeline derived.Pipeline) *LocationService {
+	return &LocationService{
+		locations:   store.Locations,
+		devices:     store.Devices,
+		assignments: store.Assignments,
+		sensors:     store.Sensors,
+		rules:       store.Rules,
+		events:      store.Husbandry,
+		derived:     pipeline.Names(),
+	}
+}
+
+// LocationInput ข้อมูลของ Location ใหม่
+type LocationInput struct {
+	Name string
+	Kind string
+	// nil = ชั้นบนสุด
+	ParentID *uint
+	// 0 = องค์กรของผู้เรียก หรือขององค์กรของ Parent (Superadmin ต้องระบุเมื่อสร้างชั้นบนสุด)
+	OrganizationID uint
+}
+
+// LocationUpdate ค่าที่ต้องการแก้ (nil = ไม่แก้)
+type LocationUpdate struct {
+	Name *string
+	Kind *string
+	// ย้ายไปไว้ใต้ Location อื่น (0 = ย้ายเป็นชั้นบนสุด)
+	ParentID *uint
+}
+
+// CreateLocation สร้าง Location ใต้ in.ParentID (องค์กรตาม Parent)
+func (s *LocationService) CreateLocation(ctx context.Context, in LocationInput) (*models.Location, error) {
+	name, kind, err := validLocation(in.Name, in.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	location := models.Location{OrganizationID: in.OrganizationID, Name: name, Kind: kind}
+	if in.ParentID != nil {
+		parent, err := s.parent(ctx, *in.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if location.OrganizationID != 0 && location.OrganizationID != parent.OrganizationID {
+			return nil, ErrOrganizationMismatch
+		}
+		location.OrganizationID = parent.OrganizationID
+		location.ParentID = &parent.ID
+	}
+
+	if err := s.locations.Create(ctx, &location); err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+// UpdateLocation เปลี่ยนชื่อ ชนิด หรือย้าย Location (ลูกหลานย้ายตามไปด้วย ข้อมูล Sensor เดิมไม่เปลี่ยน)
+func (s *LocationService) UpdateLocation(ctx context.Context, id uint, upd LocationUpdate) (*models.Location, error) {
+	location, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.locations.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wasBin := location.Kind == models.LocationKindBin
+	name, kind := location.Name, location.Kind
+	if upd.Name != nil {
+		name = *upd.Name
+	}
+	if upd.Kind != nil {
+		kind = *upd.Kind
+	}
+	if location.Name, location.Kind, err = validLocation(name, kind); err != nil {
+		return nil, err
+	}
+
+	if isBin := location.Kind == models.LocationKindBin; isBin != wasBin {
+		if isBin && len(children(all, id)) > 0 {
+			return nil, ErrLocationHasChildren
+		}
+		if wasBin {
+			// Device ที่ติดตั้งอยู่ต้องอยู่ใน Bin เสมอ
+			n, err := s.assignments.CountForLocation(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				return nil, ErrLocationInUse
+			}
+			if err := s.checkNoEvents(ctx, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if upd.ParentID != nil {
+		location.ParentID = nil
+		if *upd.ParentID != 0 {
+			parent, err := s.parent(ctx, *upd.ParentID)
+			if err != nil {
+				return nil, err
+			}
+			if parent.OrganizationID != location.OrganizationID {
+				return nil, ErrOrganizationMismatch
+			}
+			if isDescendant(all, parent.ID, id) {
+				return nil, ErrLocationCycle
+			}
+			location.ParentID = &parent.ID
+		}
+	}
+
+	if err := s.locations.Update(ctx, location); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// DeleteLocation ลบ Location ที่ไม่มีลูก ไม่มีบันทึกการดูแล และไม่เคยติดตั้ง Device (ประวัติการติดตั้งต้องชี้ไปที่ Location ที่มีอยู่จริง)
+func (s *LocationService) DeleteLocation(ctx context.Context, id uint) (*models.Location, error) {
+	location, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.locations.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(children(all, id)) > 0 {
+		return nil, ErrLocationHasChildren
+	}
+	n, err := s.assignments.CountForLocation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		return nil, ErrLocationInUse
+	}
+	if err := s.checkNoEvents(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := usedByRule(ctx, s.rules, func(r *models.AutomationRule) bool { return r.LocationID == id }); err != nil {
+		return nil, err
+	}
+	if err := s.locations.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// FindByID หา Location (Location ขององค์กรอื่นถือว่าไม่พบ)
+func (s *LocationService) FindByID(ctx context.Context, id uint) (*models.Location, error) {
+	location, err := s.locations.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrLocationNotFound
+	}
+	return location, err
+}
+
+// GetAllLocations Location ทั้งหมดที่ผู้เรียกเห็นได้
+func (s *LocationService) GetAllLocations(ctx context.Context) ([]models.Location, error) {
+	return s.locations.List(ctx)
+}
+
+// AssignDevice ติดตั้ง Device ที่ Bin locationID ตั้งแต่ startedAt (nil = ตอนนี้) หรือถอดออกเมื่อ locationID เป็น nil
+// การติดตั้งเดิมสิ้นสุดที่ startedAt ค่าที่บันทึกไปแล้วยังเป็นของ Location เดิม
+// คืนการติดตั้งปัจจุบัน (nil = ไม่ได้ติดตั้งที่ไหน)
+func (s *LocationService) AssignDevice(ctx context.Context, deviceID uint, locationID *uint, startedAt *time.Time) (*models.DeviceAssignment, error) {
+	device, err := s.findDevice(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	at := now
+	if startedAt != nil {
+		at = *startedAt
+	}
+	if at.After(now) {
+		return nil, ErrInvalidAssignmentTime
+	}
+	// ประวัติห้ามซ้อนกัน: เริ่มได้ไม่ก่อนการติดตั้งล่าสุด (ที่ยังอยู่หรือสิ้นสุดแล้ว)
+	history, err := s.assignments.ListForDevice(ctx, device.ID)
+	if err != nil {
+		return nil, err
+	}
+	var current *models.DeviceAssignment
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		bound := last.StartedAt
+		if last.EndedAt != nil {
+			bound = *last.EndedAt
+		} else {
+			current = &last
+		}
+		if at.Before(bound) {
+			return nil, ErrInvalidAssignmentTime
+		}
+	}
+
+	if locationID != nil {
+		location, err := s.FindByID(ctx, *locationID)
+		if err != nil {
+			return nil, err
+		}
+		if location.Kind != models.LocationKindBin {
+			return nil, ErrNotABin
+		}
+		if location.OrganizationID != device.OrganizationID {
+			return nil, ErrOrganizationMismatch
+		}
+		if current != nil && current.LocationID == location.ID {
+			// ติดตั้งอยู่ที่นี่แล้ว ไม่เริ่มประวัติใหม่
+			return current, nil
+		}
+	} else if current == nil {
+		return nil, nil
+	}
+
+	if err := s.assignments.Move(ctx, device, locationID, at); err != nil {
+		return nil, err
+	}
+	if locationID == nil {
+		return nil, nil
+	}
+	return s.assignments.Current(ctx, device.ID)
+}
+
+// DeviceAssignments ประวัติการติดตั้งของ Device เรียงจากเก่าไปใหม่
+func (s *LocationService) DeviceAssignments(ctx context.Context, deviceID uint) ([]models.DeviceAssignment, error) {
+	if _, err := s.findDevice(ctx, deviceID); err != nil {
+		return nil, err
+	}
+	return s.assignments.ListForDevice(ctx, deviceID)
+}
+
+// Stats สรุปค่า Sensor ในช่วง [from, to) ของ Location id รวมทุกชั้นที่อยู่ใต้ (nil = 24 ชั่วโมงล่าสุด)
+// ค่าแต่ละค่านับให้ Bin ที่ Device ติดตั้งอยู่ ณ เวลาที่บันทึก interval > 0 = คืนค่ารายช่วงใน Series ด้วย
+func (s *LocationService) Stats(ctx context.Context, id uint, from, to *time.Time, interval time.Duration) (*models.LocationStats, error) {
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	start := end.Add(-defaultStatsRange)
+	if from != nil {
+		start = *from
+	}
+	if !start.Before(end) {
+		return nil, ErrInvalidTimeRange
+	}
+	if interval != 0 {
+		interval = interval.Truncate(time.Second)
+		if interval < minStatsInterval || end.Sub(start)/interval > maxStatsBuckets {
+			return nil, ErrInvalidInterval
+		}
+	}
+
+	location, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.locations.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.LocationStats{Location: *location, From: start, To: end, Children: []models.ChildStats{}}
+	query := repository.SensorAggregateQuery{LocationIDs: subtree(all, id), From: start, To: end, Derived: s.derived}
+	if stats.Summary, err = s.summary(ctx, query); err != nil {
+		return nil, err
+	}
+	for _, child := range children(all, id) {
+		summary, err := s.summary(ctx, repository.SensorAggregateQuery{LocationIDs: subtree(all, child.ID), From: start, To: end, Derived: s.derived})
+		if err != nil {
+			return nil, err
+		}
+		stats.Children = append(stats.Children, models.ChildStats{Location: child, Summary: summary})
+	}
+	if interval > 0 {
+		query.Interval = interval
+		if stats.Series, err = s.sensors.Aggregate(ctx, query); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// --- Internal Logic ---
+
+func (s *LocationService) summary(ctx context.Context, q repository.SensorAggregateQuery) (models.SensorSummary, error) {
+	out, err := s.sensors.Aggregate(ctx, q)
+	if err != nil || len(out) == 0 {
+		return models.SensorSummary{}, err
+	}
+	return out[0], nil
+}
+
+// checkNoEvents บันทึกการดูแลต้องชี้ไปที่ Bin ที่มีอยู่จริง
+func (s *LocationService) checkNoEvents(ctx context.Context, id uint) error {
+	n, err := s.events.CountForLocation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrLocationHasEvents
+	}
+	return nil
+}
+
+// parent หา Location ที่จะเป็น Parent (Bin มีลูกไม่ได้)
+func (s *LocationService) parent(ctx context.Context, id uint) (*models.Location, error) {
+	parent, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if parent.Kind == models.LocationKindBin {
+		return nil, ErrLocationParent
+	}
+	return parent, nil
+}
+
+func (s *LocationService) findDevice(ctx context.Context, id uint) (*models.Device, error) {
+	device, err := s.devices.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	return device, err
+}
+
+// validLocation ตัดช่องว่างและตรวจรูปแบบชื่อ / ชนิด (ชนิดเป็นตัวพิมพ์เล็กเสมอ)
+func validLocation(name, kind string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxLocationNameLength {
+		return "", "", ErrInvalidLocationName
+	}
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if !kindPattern.MatchString(kind) {
+		return "", "", ErrInvalidLocationKind
+	}
+	return name, kind, nil
+}
+
+// children Location ลูกโดยตรงของ id
+func children(all []models.Location, id uint) []models.Location {
+	var out []models.Location
+	for _, l := range all {
+		if l.ParentID != nil && *l.ParentID == id {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// subtree id และ ID ของลูกหลานทุกชั้น
+func subtree(all []models.Location, id uint) []uint {
+	out := []uint{id}
+	for i := 0; i < len(out); i++ {
+		for _, child := range children(all, out[i]) {
+			out = append(out, child.ID)
+		}
+	}
+	return out
+}
+
+// isDescendant id เป็น ancestor เองหรืออยู่ใต้ ancestor หรือไม่ (ไล่ขึ้นตาม ParentID)
+func isDescendant(all []models.Location, id, ancestor uint) bool {
+	parents := make(map[uint]*uint, len(all))
+	for _, l := range all {
+		parents[l.ID] = l.ParentID
+	}
+	for seen := 0; seen <= len(all); seen++ {
+		if id == ancestor {
+			return true
+		}
+		parent := parents[id]
+		if parent == nil {
+			return false
+		}
+		id = *parent
+	}
+	return false
+}
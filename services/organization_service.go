@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+	"worm/models"
+	"worm/repository"
+)
+
+// ความยาวสูงสุดของชื่อองค์กร (ตัวอักษร)
+const maxOrganizationNameLength = 100
+
+// OrganizationService จัดการองค์กร (สร้างได้เฉพาะ Superadmin / CLI)
+type OrganizationService struct {
+	orgs repository.OrganizationRepository
+}
+
+// NewOrganizationService สร้าง OrganizationService
+func NewOrganizationService(orgs repository.OrganizationRepository) *OrganizationService {
+	return &OrganizationService{orgs: orgs}
+}
+
+// CreateOrganization สร้างองค์กรใหม่ (ชื่อไม่ซ้ำแบบไม่สนตัวพิมพ์)
+func (s *OrganizationService) CreateOrganization(ctx context.Context, name string) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxOrganizationNameLength {
+		return nil, ErrInvalidOrganizationName
+	}
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !t.All {
+		return nil, ErrSuperadminOnly
+	}
+
+	org := models.Organization{Name: name}
+	if err := s.orgs.Create(ctx, &org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// FindByName หาองค์กรจากชื่อ (ไม่สนตัวพิมพ์)
+func (s *OrganizationService) FindByName(ctx context.Context, name string) (*models.Organization, error) {
+	org, err := s.orgs.FindByName(ctx, strings.TrimSpace(name))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	return org, err
+}
+
+// GetAllOrganizations องค์กรที่ผู้เรียกเห็นได้ (Admin ขององค์กรเห็นเฉพาะองค์กรตัวเอง)
+func (s *OrganizationService) GetAllOrganizations(ctx context.Context) ([]models.Organization, error) {
+	return s.orgs.List(ctx)
+}
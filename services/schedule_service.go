@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"worm/config"
+	"worm/cron"
+	"worm/models"
+	"worm/repository"
+)
+
+const (
+	// ความยาวสูงสุดของชื่อ Schedule (ตัวอักษร)
+	maxScheduleNameLength = 100
+	// เก็บค่า Sensor ได้นานสุด 10 ปี
+	maxRetentionDays = 3650
+	// จำนวนประวัติล่าสุดที่คืนต่อ Schedule
+	scheduleRunLimit = 200
+)
+
+// ScheduleService จัดการ Schedule และทำงานที่ถึงเวลา (RunDue)
+// เวลาทำงานครั้งถัดไปเก็บในฐานข้อมูล จึงไม่หายเมื่อ Restart และหลาย Instance ไม่ทำรอบเดียวกันซ้ำ
+type ScheduleService struct {
+	schedules repository.ScheduleRepository
+	runs      repository.ScheduleRunRepository
+	sensors   repository.SensorRepository
+	actuators *ActuatorService
+	cfg       config.ScheduleConfig
+}
+
+// NewScheduleService สร้าง ScheduleService (สั่ง Actuator ผ่าน actuators)
+func NewScheduleService(store *repository.Store, actuators *ActuatorService, cfg config.ScheduleConfig) *ScheduleService {
+	return &ScheduleService{
+		schedules: store.Schedules,
+		runs:      store.ScheduleRuns,
+		sensors:   store.Sensors,
+		actuators: actuators,
+		cfg:       cfg,
+	}
+}
+
+// ScheduleInput ข้อมูลของ Schedule ใหม่
+type ScheduleInput struct {
+	Name string
+	Cron string
+	// ไม่ระบุ = UTC
+	TimeZone string
+	// nil = เปิด
+	Enabled *bool
+	Job     string
+	// เฉพาะ actuator_command
+	ActuatorID *uint
+	Command    CommandInput
+	// เฉพาะ prune_sensor_data
+	RetentionDays *int
+	// 0 = องค์กรของ Actuator หรือของผู้เรียก (Superadmin ต้องระบุสำหรับ prune_sensor_data)
+	OrganizationID uint
+}
+
+// ScheduleUpdate ค่าที่ต้องการแก้ (nil = ไม่แก้)
+type ScheduleUpdate struct {
+	Name       *string
+	Cron       *string
+	TimeZone   *string
+	Enabled    *bool
+	Job        *string
+	ActuatorID *uint
+	// ไม่ nil = แทนคำสั่งเดิมทั้งชุด
+	Command       *CommandInput
+	RetentionDays *int
+}
+
+// CreateSchedule สร้าง Schedule และคำนวณเวลาทำงานครั้งแรก
+func (s *ScheduleService) CreateSchedule(ctx context.Context, in ScheduleInput) (*models.Schedule, error) {
+	schedule := models.Schedule{
+		OrganizationID:  in.OrganizationID,
+		Name:            in.Name,
+		Cron:            in.Cron,
+		TimeZone:        in.TimeZone,
+		Enabled:         in.Enabled == nil || *in.Enabled,
+		Job:             in.Job,
+		ActuatorID:      in.ActuatorID,
+		Action:          in.Command.Action,
+		DutyCycle:       in.Command.DutyCycle,
+		DurationSeconds: in.Command.DurationSeconds,
+		RetentionDays:   in.RetentionDays,
+	}
+	if schedule.TimeZone == "" {
+		schedule.TimeZone = "UTC"
+	}
+	if err := s.validSchedule(ctx, &schedule, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.schedules.Create(ctx, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// UpdateSchedule แก้ Schedule แล้วคำนวณเวลาทำงานครั้งถัดไปใหม่
+func (s *ScheduleService) UpdateSchedule(ctx context.Context, id uint, upd ScheduleUpdate) (*models.Schedule, error) {
+	schedule, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upd.Name != nil {
+		schedule.Name = *upd.Name
+	}
+	if upd.Cron != nil {
+		schedule.Cron = *upd.Cron
+	}
+	if upd.TimeZone != nil {
+		schedule.TimeZone = *upd.TimeZone
+	}
+	if upd.Enabled != nil {
+		schedule.Enabled = *upd.Enabled
+	}
+	if upd.Job != nil {
+		schedule.Job = *upd.Job
+	}
+	if upd.ActuatorID != nil {
+		schedule.ActuatorID = upd.ActuatorID
+	}
+	if upd.Command != nil {
+		schedule.Action, schedule.DutyCycle, schedule.DurationSeconds = upd.Command.Action, upd.Command.DutyCycle, upd.Command.DurationSeconds
+	}
+	if upd.RetentionDays != nil {
+		schedule.RetentionDays = upd.RetentionDays
+	}
+	if err := s.validSchedule(ctx, schedule, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.schedules.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteSchedule ลบ Schedule พร้อมประวัติ
+func (s *ScheduleService) DeleteSchedule(ctx context.Context, id uint) (*models.Schedule, error) {
+	schedule, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.schedules.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// FindByID หา Schedule (Schedule ขององค์กรอื่นถือว่าไม่พบ)
+func (s *ScheduleService) FindByID(ctx context.Context, id uint) (*models.Schedule, error) {
+	schedule, err := s.schedules.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, err
+}
+
+// GetAllSchedules Schedule ทั้งหมดที่ผู้เรียกเห็นได้
+func (s *ScheduleService) GetAllSchedules(ctx context.Context) ([]models.Schedule, error) {
+	return s.schedules.List(ctx)
+}
+
+// Runs ประวัติการทำงานล่าสุดของ Schedule เรียงจากล่าสุด (ไม่เกิน 200 รายการ)
+func (s *ScheduleService) Runs(ctx context.Context, id uint) ([]models.ScheduleRun, error) {
+	if _, err := s.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+	runs, err := s.runs.ListForSchedule(ctx, id, scheduleRunLimit)
+	if runs == nil {
+		runs = []models.ScheduleRun{}
+	}
+	return runs, err
+}
+
+// RunDue ทำทุก Schedule ที่ถึงเวลาแล้วที่ ctx มองเห็น (Worker เรียกด้วย repository.AllTenants)
+// Schedule ที่ทำไม่สำเร็จไม่หยุด Schedule อื่น
+func (s *ScheduleService) RunDue(ctx context.Context) error {
+	now := time.Now()
+	due, err := s.schedules.Due(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range due {
+		schedule := &due[i]
+		if err := s.run(repository.OrganizationTenant(ctx, schedule.OrganizationID), schedule, now); err != nil {
+			errs = append(errs, fmt.Errorf("schedule %d: %w", schedule.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// --- Internal Logic ---
+
+// run หยิบรอบที่ถึงเวลาของ schedule (ถ้า Instance อื่นยังไม่หยิบ) แล้วทำงานและบันทึกประวัติ
+// เวลาครั้งถัดไปนับจาก now: รอบที่พลาดไประหว่าง Server ปิดจะไม่ถูกทำย้อนหลังทีละรอบ
+func (s *ScheduleService) run(ctx context.Context, schedule *models.Schedule, now time.Time) error {
+	scheduledFor := *schedule.NextRunAt
+	next, nextErr := nextRun(schedule, now)
+	claimed, err := s.schedules.Claim(ctx, schedule.ID, schedule.RunCount, next, now)
+	if err != nil || !claimed {
+		return err
+	}
+
+	run := models.ScheduleRun{OrganizationID: schedule.OrganizationID, ScheduleID: schedule.ID, ScheduledFor: scheduledFor}
+	if late := now.Sub(scheduledFor); late > time.Duration(s.cfg.MisfireGrace) {
+		run.Outcome = models.RunSkipped
+		run.Details = fmt.Sprintf("missed by %s", late.Truncate(time.Second))
+	} else {
+		s.execute(ctx, schedule, &run, now)
+	}
+	if nextErr != nil {
+		run.Details += fmt.Sprintf(" (schedule stopped: %v)", nextErr)
+	}
+	return s.runs.Create(ctx, &run)
+}
+
+// execute ทำงานของ schedule แล้วใส่ผลใน run
+func (s *ScheduleService) execute(ctx context.Context, schedule *models.Schedule, run *models.ScheduleRun, now time.Time) {
+	switch schedule.Job {
+	case models.JobActuatorCommand:
+		actuator, err := s.actuators.FindByID(ctx, *schedule.ActuatorID)
+		if err != nil {
+			run.Outcome, run.Details = models.RunFailed, err.Error()
+			return
+		}
+		desc := describeAction(schedule.Action, schedule.DutyCycle, schedule.DurationSeconds)
+		if actuator.OverrideActive(now) {
+			run.Outcome = models.RunSkipped
+			run.Details = fmt.Sprintf("skipped %s: %s is in manual override", desc, actuator.Name)
+			return
+		}
+		in := CommandInput{Action: schedule.Action, DutyCycle: schedule.DutyCycle, DurationSeconds: schedule.DurationSeconds}
+		cmd, err := s.actuators.SendCommand(ctx, actuator.ID, in, models.CommandSourceSchedule, nil)
+		if err != nil {
+			run.Outcome, run.Details = models.RunFailed, err.Error()
+			return
+		}
+		run.Outcome = models.RunSucceeded
+		run.CommandID = &cmd.ID
+		run.Details = fmt.Sprintf("sent %s to %s", desc, actuator.Name)
+
+	case models.JobPruneSensorData:
+		days := *schedule.RetentionDays
+		n, err := s.sensors.DeleteBefore(ctx, now.AddDate(0, 0, -days))
+		if err != nil {
+			run.Outcome, run.Details = models.RunFailed, err.Error()
+			return
+		}
+		run.Outcome = models.RunSucceeded
+		run.Details = fmt.Sprintf("deleted %d readings older than %d days", n, days)
+
+	default:
+		run.Outcome, run.Details = models.RunFailed, ErrInvalidJob.Error()
+	}
+}
+
+// validSchedule ตรวจค่าของ schedule, ล้างค่าที่งานนั้นไม่ใช้, กำหนดองค์กร และคำนวณ next_run_at จาก now
+func (s *ScheduleService) validSchedule(ctx context.Context, schedule *models.Schedule, now time.Time) error {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	if schedule.Name == "" || utf8.RuneCountInString(schedule.Name) > maxScheduleNameLength {
+		return ErrInvalidScheduleName
+	}
+	schedule.Cron = strings.Join(strings.Fields(schedule.Cron), " ")
+	schedule.TimeZone = strings.TrimSpace(schedule.TimeZone)
+	if _, err := nextRun(schedule, now); err != nil {
+		return err
+	}
+
+	switch schedule.Job {
+	case models.JobActuatorCommand:
+		schedule.RetentionDays = nil
+		if schedule.ActuatorID == nil {
+			return ErrScheduleActuatorRequired
+		}
+		if err := validCommand(CommandInput{Action: schedule.Action, DutyCycle: schedule.DutyCycle, DurationSeconds: schedule.DurationSeconds}); err != nil {
+			return err
+		}
+		actuator, err := s.actuators.FindByID(ctx, *schedule.ActuatorID)
+		if err != nil {
+			return err
+		}
+		if schedule.OrganizationID != 0 && schedule.OrganizationID != actuator.OrganizationID {
+			return ErrOrganizationMismatch
+		}
+		schedule.OrganizationID = actuator.OrganizationID
+	case models.JobPruneSensorData:
+		schedule.ActuatorID, schedule.Action, schedule.DutyCycle, schedule.DurationSeconds = nil, "", nil, nil
+		if schedule.RetentionDays == nil || *schedule.RetentionDays < 1 || *schedule.RetentionDays > maxRetentionDays {
+			return ErrInvalidRetention
+		}
+	default:
+		return ErrInvalidJob
+	}
+
+	schedule.NextRunAt = nil
+	if schedule.Enabled {
+		next, _ := nextRun(schedule, now)
+		schedule.NextRunAt = next
+	}
+	return nil
+}
+
+// nextRun เวลาทำงานครั้งถัดไปหลัง after ตาม Cron และ Time Zone ของ schedule
+func nextRun(schedule *models.Schedule, after time.Time) (*time.Time, error) {
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil || schedule.TimeZone == "" || strings.EqualFold(schedule.TimeZone, "local") {
+		return nil, ErrInvalidTimeZone
+	}
+	spec, err := cron.Parse(schedule.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCron, err)
+	}
+	next := spec.Next(after, loc)
+	if next.IsZero() {
+		return nil, fmt.Errorf("%w: expression never matches", ErrInvalidCron)
+	}
+	return &next, nil
+}
+
+// describeAction คำสั่งแบบอ่านง่าย เช่น "on for 120s", "duty 40%"
+func describeAction(action string, dutyCycle, durationSeconds *int) string {
+	out := action
+	if dutyCycle != nil {
+		out += fmt.Sprintf(" %d%%", *dutyCycle)
+	}
+	if durationSeconds != nil {
+		out += fmt.Sprintf(" for %ds", *durationSeconds)
+	}
+	return out
+}
+
+// usedBySchedule คืน ErrUsedBySchedule ถ้ามี Schedule ที่สั่ง Actuator actuatorID
+func usedBySchedule(ctx context.Context, schedules repository.ScheduleRepository, actuatorID uint) error {
+	all, err := schedules.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sc := range all {
+		if sc.ActuatorID != nil && *sc.ActuatorID == actuatorID {
+			return ErrUsedBySchedule
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"worm/config"
+	"worm/migrations"
+	"worm/models"
+	"worm/repository"
+	"worm/repository/memory"
+)
+
+// testStores Store ที่ทดสอบ Repository ผ่าน Service (SQLite ทดสอบ SQL จริง เช่น Claim)
+var testStores = []struct {
+	name string
+	open func(t *testing.T) *repository.Store
+}{
+	{"sqlite", func(t *testing.T) *repository.Store {
+		db, err := config.OpenDB("sqlite://:memory:")
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		t.Cleanup(func() { _ = config.CloseDB(db) })
+		migrator, err := migrations.New(db)
+		if err != nil {
+			t.Fatalf("migrator: %v", err)
+		}
+		if err := migrator.Up(); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+		return repository.NewStore(db)
+	}},
+	{"memory", func(*testing.T) *repository.Store { return memory.NewStore() }},
+}
+
+// dueSchedule สร้าง Schedule ลบค่าเก่าทุกนาทีขององค์กรใหม่ แล้วเลื่อน next_run_at ไปเป็น late ก่อนตอนนี้
+func dueSchedule(t *testing.T, store *repository.Store, late time.Duration) (*ScheduleService, context.Context, *models.Schedule) {
+	t.Helper()
+	org := models.Organization{Name: "Farm A"}
+	if err := store.Organizations.Create(repository.AllTenants(context.Background()), &org); err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	ctx := repository.OrganizationTenant(context.Background(), org.ID)
+	schedules := NewScheduleService(store, NewActuatorService(store, config.Default().Actuator), config.Default().Schedule)
+	days := 30
+	schedule, err := schedules.CreateSchedule(ctx, ScheduleInput{Name: "Prune", Cron: "* * * * *", Job: models.JobPruneSensorData, RetentionDays: &days})
+	if err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	due := time.Now().Add(-late).Truncate(time.Minute)
+	schedule.NextRunAt = &due
+	if err := store.Schedules.Update(ctx, schedule); err != nil {
+		t.Fatalf("make schedule due: %v", err)
+	}
+	return schedules, ctx, schedule
+}
+
+func runsOf(t *testing.T, schedules *ScheduleService, ctx context.Context, id uint) []models.ScheduleRun {
+	t.Helper()
+	runs, err := schedules.Runs(ctx, id)
+	if err != nil {
+		t.Fatalf("runs: %v", err)
+	}
+	return runs
+}
+
+func TestConcurrentRunDueRunsOnce(t *testing.T) {
+	for _, s := range testStores {
+		t.Run(s.name, func(t *testing.T) {
+			store := s.open(t)
+			first, ctx, schedule := dueSchedule(t, store, time.Minute)
+			// Instance ที่สองบน Store เดียวกัน (เหมือนสอง Server หลัง Load Balancer)
+			second := NewScheduleService(store, NewActuatorService(store, config.Default().Actuator), config.Default().Schedule)
+
+			var wg sync.WaitGroup
+			for _, svc := range []*ScheduleService{first, second, first, second} {
+				wg.Add(1)
+				go func(svc *ScheduleService) {
+					defer wg.Done()
+					if err := svc.RunDue(repository.AllTenants(context.Background())); err != nil {
+						t.Errorf("run due: %v", err)
+					}
+				}(svc)
+			}
+			wg.Wait()
+
+			runs := runsOf(t, first, ctx, schedule.ID)
+			if len(runs) != 1 || runs[0].Outcome != models.RunSucceeded {
+				t.Fatalf("want exactly one succeeded run, got %+v", runs)
+			}
+			got, err := first.FindByID(ctx, schedule.ID)
+			if err != nil {
+				t.Fatalf("find: %v", err)
+			}
+			if got.RunCount != 1 || got.NextRunAt == nil || !got.NextRunAt.After(time.Now()) {
+				t.Fatalf("schedule not advanced: run_count %d, next_run_at %v", got.RunCount, got.NextRunAt)
+			}
+
+			// Instance ที่อ่าน run_count เดิม (0) ไว้หยิบรอบเดียวกันซ้ำไม่ได้
+			if claimed, err := store.Schedules.Claim(ctx, schedule.ID, 0, got.NextRunAt, time.Now()); err != nil || claimed {
+				t.Fatalf("stale claim: claimed %v, err %v", claimed, err)
+			}
+
+			// หลัง Restart (Service ใหม่บน Store เดิม) รอบที่ทำแล้วไม่ถูกทำซ้ำ
+			restarted := NewScheduleService(store, NewActuatorService(store, config.Default().Actuator), config.Default().Schedule)
+			if err := restarted.RunDue(repository.AllTenants(context.Background())); err != nil {
+				t.Fatalf("run due after restart: %v", err)
+			}
+			if runs := runsOf(t, restarted, ctx, schedule.ID); len(runs) != 1 {
+				t.Fatalf("restart ran the schedule again: %+v", runs)
+			}
+		})
+	}
+}
+
+func TestRunDueSkipsMisfiredRuns(t *testing.T) {
+	for _, s := range testStores {
+		t.Run(s.name, func(t *testing.T) {
+			// เลยเวลามานานกว่า misfire_grace (5 นาที) เช่น Server ปิดอยู่
+			schedules, ctx, schedule := dueSchedule(t, s.open(t), time.Hour)
+			if err := schedules.RunDue(repository.AllTenants(context.Background())); err != nil {
+				t.Fatalf("run due: %v", err)
+			}
+			runs := runsOf(t, schedules, ctx, schedule.ID)
+			if len(runs) != 1 || runs[0].Outcome != models.RunSkipped {
+				t.Fatalf("want one skipped run, got %+v", runs)
+			}
+		})
+	}
+}
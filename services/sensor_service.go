@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"worm/derived"
+	"worm/models"
+	"worm/repository"
+)
+
+// ช่วงค่าที่ยอมรับ (ช่วงวัดของ DHT22 / SHT3x) ค่านอกช่วงนี้ถือว่า Sensor เสีย
+// ไม่บันทึกและตอบ 400 reading_out_of_range (รวมค่าที่ Firmware ใช้แทนความผิดพลาด เช่น -127)
+const (
+	minTemperature = -40.0
+	maxTemperature = 80.0
+	minHumidity    = 0.0
+	maxHumidity    = 100.0
+)
+
+// SensorService บันทึกและอ่านค่าจาก Sensor และ Device ที่ส่งค่า (ภายใน Tenant ของ ctx)
+type SensorService struct {
+	sensors      repository.SensorRepository
+	devices      repository.DeviceRepository
+	calibrations repository.CalibrationRepository
+	pipeline     derived.Pipeline
+	anomalies    *AnomalyService
+	observers    []ReadingObserver
+}
+
+// ReadingObserver ถูกเรียกหลังบันทึกค่าจาก Sensor แต่ละค่า (เช่น AutomationService)
+type ReadingObserver interface {
+	ObserveReading(ctx context.Context, data *models.SensorData) error
+}
+
+// NewSensorService สร้าง SensorService (pipeline คำนวณค่าเพิ่มจากทุกค่าที่บันทึก anomalies ตรวจค่าผิดปกติก่อนบันทึก
+// nil = ไม่ตรวจ observers ถูกเรียกตามลำดับหลังบันทึกค่า)
+func NewSensorService(store *repository.Store, pipeline derived.Pipeline, anomalies *AnomalyService, observers ...ReadingObserver) *SensorService {
+	return &SensorService{
+		sensors:      store.Sensors,
+		devices:      store.Devices,
+		calibrations: store.Calibrations,
+		pipeline:     pipeline,
+		anomalies:    anomalies,
+		observers:    observers,
+	}
+}
+
+// AddSensorData บันทึกค่าอุณหภูมิและความชื้นจาก Device ชื่อ device ในองค์กรของผู้เรียก
+// Device ที่ยังไม่เคยส่งค่าจะถูกลงทะเบียนให้อัตโนมัติ ค่าถูกแก้ตาม Calibration ของ Device (ค่าดิบเก็บไว้ด้วย)
+// แล้วคำนวณค่าเพิ่มตาม pipeline จากค่าที่แก้แล้ว และเก็บค่าผิดปกติที่ตรวจพบไว้กับค่า
+// คืนค่าที่บันทึก (รวมถึงเมื่อคืน ErrObserverFailed)
+func (s *SensorService) AddSensorData(ctx context.Context, device string, temp float64, humidity float64) (*models.SensorData, error) {
+	if temp < minTemperature || temp > maxTemperature || humidity < minHumidity || humidity > maxHumidity {
+		return nil, ErrReadingOutOfRange
+	}
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t.All {
+		return nil, ErrNoOrganization
+	}
+
+	d, err := s.registerDevice(ctx, t.OrganizationID, device)
+	if err != nil {
+		return nil, err
+	}
+	calibrations, err := s.calibrations.ListForDevice(ctx, d.ID)
+	if err != nil {
+		return nil, err
+	}
+	data := models.SensorData{
+		RawTemperature: temp,
+		RawHumidity:    humidity,
+		OrganizationID: t.OrganizationID,
+		DeviceID:       &d.ID,
+	}
+	calibrate(&data, calibrations, time.Now())
+	data.Derived = s.pipeline.Apply(data.Temperature, data.Humidity)
+	if s.anomalies != nil {
+		if err := s.anomalies.Detect(ctx, &data); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.sensors.Create(ctx, &data); err != nil {
+		return nil, err
+	}
+	if err := s.devices.Touch(ctx, d.ID, data.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	// ค่าถูกบันทึกแล้ว Observer ที่ล้มเหลวไม่หยุดตัวอื่น และคืนเป็น ErrObserverFailed ให้ผู้เรียกแยกออกจากการบันทึกไม่สำเร็จ
+	var errs []error
+	for _, o := range s.observers {
+		if err := o.ObserveReading(ctx, &data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return &data, fmt.Errorf("%w: %w", ErrObserverFailed, errors.Join(errs...))
+	}
+	return &data, nil
+}
+
+// GetAllDevices Device ทั้งหมดที่ผู้เรียกเห็นได้
+func (s *SensorService) GetAllDevices(ctx context.Context) ([]models.Device, error) {
+	return s.devices.List(ctx)
+}
+
+// GetAllSensorData ดึงข้อมูลทั้งหมด
+func (s *SensorService) GetAllSensorData(ctx context.Context) ([]models.SensorData, error) {
+	return s.sensors.List(ctx)
+}
+
+// PruneSensorData ลบข้อมูลที่เก่ากว่า before และคืนจำนวนแถวที่ลบ
+func (s *SensorService) PruneSensorData(ctx context.Context, before time.Time) (int64, error) {
+	return s.sensors.DeleteBefore(ctx, before)
+}
+
+// registerDevice หา Device ขององค์กร หรือลงทะเบียนใหม่ถ้ายังไม่มี
+func (s *SensorService) registerDevice(ctx context.Context, orgID uint, name string) (*models.Device, error) {
+	d, err := s.devices.FindByName(ctx, orgID, name)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return d, err
+	}
+	d = &models.Device{OrganizationID: orgID, Name: name}
+	err = s.devices.Create(ctx, d)
+	if errors.Is(err, repository.ErrConflict) {
+		// Request แรกของ Device ที่มาพร้อมกัน อีกตัวลงทะเบียนไปก่อนแล้ว
+		return s.devices.FindByName(ctx, orgID, name)
+	}
+	return d, err
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"worm/config"
+	"worm/models"
+	"worm/repository"
+	"worm/utils"
+
+	"github.com/google/uuid"
+)
+
+// รหัสผ่านที่เคย Hardcode ไว้ในเวอร์ชันก่อน ห้ามใช้ใน Production เด็ดขาด
+var defaultPasswords = []string{"admin1234", "changeme"}
+
+// ความยาวขั้นต่ำของรหัสผ่าน Admin คนแรกที่กำหนดเอง
+const minAdminPasswordLength = 8
+
+// รูปแบบ Username: 3-64 ตัว ขึ้นต้นด้วยตัวอักษรหรือตัวเลข (รองรับ Email สำหรับบัญชีที่ย้ายมาจาก SSO)
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]{2,63}$`)
+
+// UserService จัดการบัญชีผู้ใช้
+// ทุก Method ทำงานภายใน Tenant ของ ctx (Admin ขององค์กรเห็นและแก้ได้เฉพาะ User ในองค์กรตัวเอง)
+type UserService struct {
+	users      repository.UserRepository
+	orgs       repository.OrganizationRepository
+	bcryptCost int
+}
+
+// UserUpdate ค่าที่ต้องการแก้ (nil = ไม่แก้)
+type UserUpdate struct {
+	Username              *string
+	Password              *string
+	Role                  *string
+	PasswordLoginDisabled *bool
+	// ย้ายไปองค์กรอื่น (Superadmin เท่านั้น)
+	OrganizationID *uint
+}
+
+// NewUserService สร้าง UserService (bcryptCost มาจาก auth.bcrypt_cost)
+func NewUserService(store *repository.Store, bcryptCost int) *UserService {
+	return &UserService{users: store.Users, orgs: store.Organizations, bcryptCost: bcryptCost}
+}
+
+// TenantOf ขอบเขตข้อมูลของ user: Superadmin เห็นทุกองค์กร คนอื่นเห็นเฉพาะองค์กรของตัวเอง
+func TenantOf(user *models.User) repository.Tenant {
+	if user.IsSuperadmin() {
+		return repository.Tenant{All: true}
+	}
+	if user.OrganizationID == nil {
+		// ข้อมูลผิดปกติ: Tenant ว่างทำให้ทุก Query คืน ErrNoTenant แทนการเห็นข้อมูลเกินสิทธิ์
+		return repository.Tenant{}
+	}
+	return repository.Tenant{OrganizationID: *user.OrganizationID}
+}
+
+// CreateUser สร้าง User ใหม่และคืน API Key
+// orgID = องค์กรของ User ใหม่ (nil = องค์กรของผู้เรียก, Superadmin ต้องระบุเสมอยกเว้นสร้าง Superadmin)
+func (s *UserService) CreateUser(ctx context.Context, newUsername, newPassword, role string, orgID *uint) (string, error) {
+	user, err := s.create(ctx, newUsername, newPassword, role, orgID, false)
+	if err != nil {
+		return "", err
+	}
+	return user.APIKey, nil
+}
+
+// CreateUserWithTemporaryPassword สร้าง User ด้วยรหัสผ่านสุ่มที่ต้องเปลี่ยนเมื่อล็อกอินครั้งแรก
+// คืนรหัสผ่านนั้น (แสดงได้ครั้งเดียว) และ API Key
+func (s *UserService) CreateUserWithTemporaryPassword(ctx context.Context, newUsername, role string, orgID *uint) (string, string, error) {
+	password, err := generatePassword()
+	if err != nil {
+		return "", "", err
+	}
+	user, err := s.create(ctx, newUsername, password, role, orgID, true)
+	if err != nil {
+		return "", "", err
+	}
+	return password, user.APIKey, nil
+}
+
+func (s *UserService) create(ctx context.Context, newUsername, newPassword, role string, orgID *uint, mustChange bool) (*models.User, error) {
+	if !ValidUsername(newUsername) {
+		return nil, ErrInvalidUsername
+	}
+	newUser := models.User{
+		Username:           newUsername,
+		APIKey:             uuid.New().String(),
+		MustChangePassword: mustChange,
+	}
+	if err := s.assignRole(ctx, &newUser, role, orgID); err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(newPassword, s.bcryptCost)
+	if err != nil {
+		return nil, err
+	}
+	newUser.Password = hashedPassword
+
+	if err := s.users.Create(ctx, &newUser); err != nil {
+		return nil, err
+	}
+	return &newUser, nil
+}
+
+// UpdateUser แก้เฉพาะค่าที่ส่งมา
+func (s *UserService) UpdateUser(ctx context.Context, id uint, upd UserUpdate) (*models.User, error) {
+	user, err := s.findByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if upd.Username != nil {
+		if !ValidUsername(*upd.Username) {
+			return nil, ErrInvalidUsername
+		}
+		user.Username = *upd.Username
+	}
+	if upd.Role != nil || upd.OrganizationID != nil {
+		role := user.Role
+		if upd.Role != nil {
+			role = *upd.Role
+		}
+		if err := s.assignRole(ctx, user, role, upd.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+	if upd.Password != nil {
+		hashed, err := utils.HashPassword(*upd.Password, s.bcryptCost)
+		if err != nil {
+			return nil, err
+		}
+		user.Password = hashed
+	}
+	if upd.PasswordLoginDisabled != nil {
+		user.PasswordLoginDisabled = *upd.PasswordLoginDisabled
+	}
+
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangePassword เปลี่ยนรหัสผ่านของตัวเอง และปลดสถานะ must_change_password
+func (s *UserService) ChangePassword(ctx context.Context, user *models.User, currentPassword, newPassword string) error {
+	if !utils.CheckPasswordHash(currentPassword, user.Password) {
+		return ErrIncorrectPassword
+	}
+	if newPassword == currentPassword {
+		return ErrSamePassword
+	}
+
+	hashed, err := utils.HashPassword(newPassword, s.bcryptCost)
+	if err != nil {
+		return err
+	}
+	user.Password = hashed
+	user.MustChangePassword = false
+	return s.users.Update(ctx, user)
+}
+
+// ResetPassword ตั้งรหัสผ่านใหม่ให้ User (ว่าง = สุ่มให้) และบังคับเปลี่ยนเมื่อล็อกอินครั้งถัดไป
+// คืน User และรหัสผ่านที่ตั้ง เพื่อส่งให้เจ้าของบัญชี
+func (s *UserService) ResetPassword(ctx context.Context, username, newPassword string) (*models.User, string, error) {
+	user, err := s.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, "", err
+	}
+	if newPassword == "" {
+		if newPassword, err = generatePassword(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	hashed, err := utils.HashPassword(newPassword, s.bcryptCost)
+	if err != nil {
+		return nil, "", err
+	}
+	user.Password = hashed
+	user.MustChangePassword = true
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, "", err
+	}
+	return user, newPassword, nil
+}
+
+// SetRole เปลี่ยน Role ของ User (orgID != nil = ย้ายไปองค์กรนั้นด้วย)
+func (s *UserService) SetRole(ctx context.Context, username, role string, orgID *uint) (*models.User, error) {
+	user, err := s.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.assignRole(ctx, user, role, orgID); err != nil {
+		return nil, err
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetDisabled ปิด/เปิดบัญชี (บัญชีที่ปิดใช้ API Key / Login / SSO ไม่ได้)
+func (s *UserService) SetDisabled(ctx context.Context, username string, disabled bool) (*models.User, error) {
+	user, err := s.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	user.Disabled = disabled
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RotateAPIKey ออก API Key ใหม่ให้ User (Key เดิมใช้ไม่ได้ทันที) Key ใหม่อยู่ใน user.APIKey
+func (s *UserService) RotateAPIKey(ctx context.Context, username string) (*models.User, error) {
+	user, err := s.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	user.APIKey = uuid.New().String()
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// FindByUsername หา User จากชื่อ
+func (s *UserService) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	user, err := s.users.FindByUsername(ctx, username)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// GetAllUsers รายชื่อ User ทั้งหมด
+func (s *UserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	return s.users.List(ctx)
+}
+
+// BootstrapAdmin สร้าง Superadmin คนแรกจาก admin ใน Config ถ้ายังไม่มี Superadmin ในระบบ
+// ถ้าไม่กำหนดรหัสผ่านจะสุ่มให้ และคืนรหัสนั้นเพื่อให้ผู้ดูแลนำไปล็อกอินครั้งแรก
+// ctx ต้องเห็นทุกองค์กร (repository.AllTenants)
+func (s *UserService) BootstrapAdmin(ctx context.Context, admin config.AdminConfig, production bool) (string, error) {
+	if production && admin.Password != "" && isDefaultPassword(admin.Password) {
+		return "", errors.New("refusing to start in production with a default admin password")
+	}
+
+	superadmins, err := s.users.ListByRole(ctx, models.RoleSuperadmin)
+	if err != nil {
+		return "", err
+	}
+
+	if len(superadmins) > 0 {
+		if production {
+			admins, err := s.users.ListByRole(ctx, models.RoleAdmin)
+			if err != nil {
+				return "", err
+			}
+			return "", checkNoDefaultAdmins(append(superadmins, admins...))
+		}
+		return "", nil
+	}
+
+	if !ValidUsername(admin.Username) {
+		return "", fmt.Errorf("admin username %q: %w", admin.Username, ErrInvalidUsername)
+	}
+
+	generated := ""
+	password := admin.Password
+	if password == "" {
+		if generated, err = generatePassword(); err != nil {
+			return "", err
+		}
+		password = generated
+	} else if len(password) < minAdminPasswordLength {
+		return "", fmt.Errorf("admin password must be at least %d characters", minAdminPasswordLength)
+	}
+
+	hashedPw, err := utils.HashPassword(password, s.bcryptCost)
+	if err != nil {
+		return "", err
+	}
+
+	firstAdmin := models.User{
+		Username: admin.Username,
+		Password: hashedPw,
+		Role:     models.RoleSuperadmin,
+		APIKey:   uuid.New().String(),
+		// รหัสที่สุ่มให้ต้องเปลี่ยนทันทีที่ล็อกอินครั้งแรก
+		MustChangePassword: generated != "",
+	}
+	if err := s.users.Create(ctx, &firstAdmin); err != nil {
+		return "", err
+	}
+	return generated, nil
+}
+
+func (s *UserService) findByID(ctx context.Context, id uint) (*models.User, error) {
+	user, err := s.users.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// ValidUsername Username ถูกรูปแบบหรือไม่ (ความซ้ำตรวจที่ฐานข้อมูล ไม่สนตัวพิมพ์)
+func ValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+func validRole(role string) bool {
+	return role == models.RoleSuperadmin || role == models.RoleAdmin || role == models.RoleUser
+}
+
+// assignRole ตั้ง Role และองค์กรของ user (orgID nil = ไม่ย้ายองค์กร)
+// กฎ: Superadmin ไม่มีองค์กร, Role อื่นต้องมีองค์กร และเฉพาะ Superadmin ที่ให้/ถอน Role superadmin หรือย้ายองค์กรได้
+func (s *UserService) assignRole(ctx context.Context, user *models.User, role string, orgID *uint) error {
+	if !validRole(role) {
+		return ErrInvalidRole
+	}
+	t, err := repository.TenantFrom(ctx)
+	if err != nil {
+		return err
+	}
+	moving := orgID != nil && (user.OrganizationID == nil || *orgID != *user.OrganizationID)
+	if !t.All && (role == models.RoleSuperadmin || user.Role == models.RoleSuperadmin || moving) {
+		return ErrSuperadminOnly
+	}
+
+	if role == models.RoleSuperadmin {
+		if orgID != nil {
+			return ErrSuperadminOrganization
+		}
+		user.Role = role
+		user.OrganizationID = nil
+		return nil
+	}
+
+	if orgID != nil {
+		if _, err := s.orgs.FindByID(ctx, *orgID); errors.Is(err, repository.ErrNotFound) {
+			return ErrOrganizationNotFound
+		} else if err != nil {
+			return err
+		}
+		id := *orgID
+		user.OrganizationID = &id
+	}
+	if user.OrganizationID == nil {
+		if t.All {
+			return ErrOrganizationRequired
+		}
+		id := t.OrganizationID
+		user.OrganizationID = &id
+	}
+	user.Role = role
+	return nil
+}
+
+// checkNoDefaultAdmins ไม่ยอมให้ Production รันถ้ายังมี Admin ที่ใช้รหัสผ่านเริ่มต้นอยู่
+func checkNoDefaultAdmins(admins []models.User) error {
+	for _, admin := range admins {
+		for _, pw := range defaultPasswords {
+			if utils.CheckPasswordHash(pw, admin.Password) {
+				return fmt.Errorf("refusing to start in production: admin %q still uses a default password", admin.Username)
+			}
+		}
+	}
+	return nil
+}
+
+func isDefaultPassword(password string) bool {
+	for _, pw := range defaultPasswords {
+		if password == pw {
+			return true
+		}
+	}
+	return false
+}
+
+func generatePassword() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
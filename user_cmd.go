@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"worm/config"
+	"worm/models"
+	"worm/repository"
+	"worm/services"
+)
+
+const userUsage = `usage: worm user <command> [flags] <username>
+
+commands:
+  create <username>           create a user in -org (random one-time password if -password is empty)
+  list                        list all users of all organizations
+  set-role <username> <role>  change the role (superadmin, admin or user), -org also moves the user
+  reset-password <username>   set a new password (random if -password is empty), must be changed at next login
+  disable <username>          block API key, password and SSO login of the account
+  enable <username>           re-enable a disabled account
+
+Every account except a superadmin belongs to one organization (see "worm org").
+Flags go before the arguments, e.g. "worm user create -org 'Green Farm' -role admin alice".
+`
+
+const keyUsage = `usage: worm key <command> [flags] <username>
+
+commands:
+  create <username>   issue a new API key (the old key stops working) and print it
+  revoke <username>   invalidate the current API key without printing a new one
+                      (the user gets a fresh key from the next successful login)
+`
+
+// actorCLI ใช้เป็น actor_id ใน Audit Log สำหรับการกระทำผ่าน CLI (ไม่มี User ที่ล็อกอิน)
+const actorCLI = 0
+
+// runUser คำสั่ง `worm user ...` ใช้กู้ระบบได้โดยไม่ต้องมี API Key
+func runUser(args []string) {
+	fs, configFile := subcommand("user", userUsage)
+	role := fs.String("role", "user", "role of the new user: superadmin, admin or user (create)")
+	org := fs.String("org", "", "organization name (create: required unless -role superadmin, set-role: move the user)")
+	password := fs.String("password", "", "password to set (create, reset-password), random one-time password if empty")
+	if len(args) == 0 {
+		usageError(fs, "")
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+
+	need := map[string]int{"create": 1, "list": 0, "set-role": 2, "reset-password": 1, "disable": 1, "enable": 1}
+	n, ok := need[command]
+	if !ok {
+		usageError(fs, "unknown user command %q", command)
+	}
+	if fs.NArg() != n {
+		usageError(fs, "user %s: expected %d argument(s), got %d", command, n, fs.NArg())
+	}
+
+	ctx := cliContext()
+	cfg, _, store := openStore(*configFile)
+	users, auth := cliServices(cfg, store)
+	orgID, err := organizationID(ctx, store, *org)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch command {
+	case "create":
+		err = createUser(ctx, users, auth, fs.Arg(0), *password, *role, orgID)
+	case "list":
+		err = listUsers(ctx, users, store)
+	case "set-role":
+		var user *models.User
+		if user, err = users.SetRole(ctx, fs.Arg(0), fs.Arg(1), orgID); err == nil {
+			err = auth.RecordAudit(ctx, actorCLI, "user.set_role", user, "role of "+user.Username+" set to "+user.Role+" (cli)")
+			fmt.Printf("%s is now %s\n", user.Username, user.Role)
+		}
+	case "reset-password":
+		var user *models.User
+		var newPassword string
+		if user, newPassword, err = users.ResetPassword(ctx, fs.Arg(0), *password); err == nil {
+			err = auth.RecordAudit(ctx, actorCLI, "user.reset_password", user, "password reset for "+user.Username+" (cli)")
+			fmt.Println("Password reset, must be changed at next login.")
+			if *password == "" {
+				fmt.Println("One-time password:", newPassword)
+			}
+		}
+	case "disable", "enable":
+		var user *models.User
+		if user, err = users.SetDisabled(ctx, fs.Arg(0), command == "disable"); err == nil {
+			err = auth.RecordAudit(ctx, actorCLI, "user."+command, user, user.Username+" "+command+"d (cli)")
+			fmt.Printf("%s %sd\n", user.Username, command)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runKey คำสั่ง `worm key create|revoke <username>`
+func runKey(args []string) {
+	fs, configFile := subcommand("key", keyUsage)
+	if len(args) == 0 {
+		usageError(fs, "")
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+	if command != "create" && command != "revoke" {
+		usageError(fs, "unknown key command %q", command)
+	}
+	if fs.NArg() != 1 {
+		usageError(fs, "key %s: expected a username", command)
+	}
+
+	ctx := cliContext()
+	cfg, _, store := openStore(*configFile)
+	users, auth := cliServices(cfg, store)
+
+	user, err := users.RotateAPIKey(ctx, fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := auth.RecordAudit(ctx, actorCLI, "key."+command, user, "API key "+command+"d for "+user.Username+" (cli)"); err != nil {
+		log.Fatal(err)
+	}
+
+	if command == "create" {
+		fmt.Println(user.APIKey)
+	} else {
+		fmt.Printf("API key of %s revoked\n", user.Username)
+	}
+}
+
+func cliServices(cfg *config.Config, store *repository.Store) (*services.UserService, *services.AuthService) {
+	return services.NewUserService(store, cfg.Auth.BcryptCost), services.NewAuthService(store, cfg.Auth)
+}
+
+func createUser(ctx context.Context, users *services.UserService, auth *services.AuthService, username, password, role string, orgID *uint) error {
+	var apiKey, generated string
+	var err error
+	if password == "" {
+		generated, apiKey, err = users.CreateUserWithTemporaryPassword(ctx, username, role, orgID)
+	} else {
+		apiKey, err = users.CreateUser(ctx, username, password, role, orgID)
+	}
+	if err != nil {
+		return err
+	}
+
+	user, err := users.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if err := auth.RecordAudit(ctx, actorCLI, "user.create", user, "created "+role+" "+username+" (cli)"); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %s %s\n", role, username)
+	fmt.Println("API key:", apiKey)
+	if generated != "" {
+		fmt.Println("One-time password (must be changed at first login):", generated)
+	}
+	return nil
+}
+
+func listUsers(ctx context.Context, users *services.UserService, store *repository.Store) error {
+	list, err := users.GetAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+	orgs, err := services.NewOrganizationService(store.Organizations).GetAllOrganizations(ctx)
+	if err != nil {
+		return err
+	}
+	orgNames := make(map[uint]string, len(orgs))
+	for _, o := range orgs {
+		orgNames[o.ID] = o.Name
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tORGANIZATION\t2FA\tSSO\tSTATUS")
+	for _, u := range list {
+		status := "active"
+		if u.Disabled {
+			status = "disabled"
+		} else if u.MustChangePassword {
+			status = "must change password"
+		}
+		org := "-"
+		if u.OrganizationID != nil {
+			org = orgNames[*u.OrganizationID]
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\t%t\t%s\n", u.ID, u.Username, u.Role, org, u.TOTPEnabled, u.OIDCSubject != nil, status)
+	}
+	return w.Flush()
+}
@@ -1,12 +1,15 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword แปลงรหัสผ่านเป็น Hash
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+// HashPassword แปลงรหัสผ่านเป็น Hash (cost มาจาก auth.bcrypt_cost ใน Config)
+func HashPassword(password string, cost int) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	return string(bytes), err
 }
 
@@ -14,4 +17,11 @@ func HashPassword(password string) (string, error) {
 func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
-}
\ No newline at end of file
+}
+
+// HashToken แปลง Token ที่สุ่มมาแล้ว (เช่น Recovery Code) เป็น SHA-256
+// ใช้แทน bcrypt ได้เพราะค่าต้นทางสุ่มยาวพอ ไม่ต้องกันการเดารหัสแบบ Password
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,14 @@
+package utils
+
+import "strings"
+
+// SplitList แยกค่าที่คั่นด้วย comma และตัดช่องว่าง (ค่าว่างจะถูกข้าม)
+func SplitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}